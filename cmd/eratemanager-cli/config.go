@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// cliConfig is the subset of storage.Config the CLI resolves from flags, a
+// config file, and the environment.
+type cliConfig struct {
+	Driver string `json:"driver"`
+	DSN    string `json:"dsn"`
+}
+
+// configGetter returns a closure that lazily resolves and caches the CLI's
+// storage config the first time it's called, so subcommands that never
+// touch storage (e.g. the cobra-generated completion command) don't pay the
+// cost of reading a config file. Precedence, highest first: --driver/--dsn
+// flags, --config file, ERATEMANAGER_DB_DRIVER/ERATEMANAGER_DB_DSN env vars.
+func configGetter() func() (cliConfig, error) {
+	var (
+		once sync.Once
+		cfg  cliConfig
+		err  error
+	)
+	return func() (cliConfig, error) {
+		once.Do(func() { cfg, err = loadConfig() })
+		return cfg, err
+	}
+}
+
+func loadConfig() (cliConfig, error) {
+	cfg := cliConfig{
+		Driver: os.Getenv("ERATEMANAGER_DB_DRIVER"),
+		DSN:    os.Getenv("ERATEMANAGER_DB_DSN"),
+	}
+
+	if flagConfigFile != "" {
+		raw, err := os.ReadFile(flagConfigFile)
+		if err != nil {
+			return cliConfig{}, fmt.Errorf("read config %s: %w", flagConfigFile, err)
+		}
+		var fileCfg cliConfig
+		if err := json.Unmarshal(raw, &fileCfg); err != nil {
+			return cliConfig{}, fmt.Errorf("parse config %s: %w", flagConfigFile, err)
+		}
+		if fileCfg.Driver != "" {
+			cfg.Driver = fileCfg.Driver
+		}
+		if fileCfg.DSN != "" {
+			cfg.DSN = fileCfg.DSN
+		}
+	}
+
+	if flagDriver != "" {
+		cfg.Driver = flagDriver
+	}
+	if flagDSN != "" {
+		cfg.DSN = flagDSN
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = "sqlite"
+	}
+	if cfg.DSN == "" {
+		cfg.DSN = "eratemanager.db"
+	}
+	return cfg, nil
+}
+
+// getConfig is the configGetter shared by every subcommand; they all resolve
+// the same flags/config file/env vars, so one cached closure is enough.
+var getConfig = configGetter()
+
+// openStorage resolves the CLI's storage config and opens the backend it
+// names. Callers must Close() the result.
+func openStorage(ctx context.Context) (storage.Storage, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	st, err := storage.Open(ctx, storage.Config{Driver: cfg.Driver, DSN: cfg.DSN})
+	if err != nil {
+		return nil, fmt.Errorf("open storage (driver=%s): %w", cfg.Driver, err)
+	}
+	return st, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	dbmigrate "github.com/bher20/eratemanager/internal/migrate"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database migrations and connectivity checks",
+}
+
+func init() {
+	dbCmd.AddCommand(dbMigrateCmd, dbPingCmd)
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply all up migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		return dbmigrate.Up(ctx, cfg.Driver, cfg.DSN)
+	},
+}
+
+// pinger is implemented by backends that can check connectivity; not every
+// storage.Storage implementation needs one (e.g. MemoryStorage's Ping is a
+// no-op), so the CLI asserts for it rather than requiring it on Storage.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+var dbPingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check connectivity to the configured storage backend",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		p, ok := st.(pinger)
+		if !ok {
+			fmt.Fprintln(cmd.OutOrStdout(), "backend does not support ping; storage.Open succeeded")
+			return nil
+		}
+		if err := p.Ping(ctx); err != nil {
+			return fmt.Errorf("ping: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "ok")
+		return nil
+	},
+}
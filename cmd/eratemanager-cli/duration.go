@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var dayDurationRe = regexp.MustCompile(`^(\d+)d$`)
+
+// parseDuration parses a Go duration string (e.g. "24h", "90m") or a
+// day-suffixed shorthand (e.g. "30d") that time.ParseDuration doesn't
+// support on its own.
+func parseDuration(s string) (time.Duration, error) {
+	if m := dayDurationRe.FindStringSubmatch(s); m != nil {
+		days, _ := strconv.Atoi(m[1])
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use a Go duration like \"24h\" or a day count like \"30d\")", s)
+	}
+	return dur, nil
+}
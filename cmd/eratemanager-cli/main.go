@@ -0,0 +1,39 @@
+// Command eratemanager-cli is an operator CLI for managing providers and
+// inspecting/pruning rate-snapshot history directly against a
+// storage.Storage backend, mirroring the cscli machines/bouncers command
+// layout: one cobra.Command per resource, each with list/add/update/delete
+// subcommands where the resource supports them.
+package main
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+}
+
+var (
+	flagDriver     string
+	flagDSN        string
+	flagConfigFile string
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "eratemanager-cli",
+	Short: "Operator CLI for eRateManager providers and rate snapshots",
+	Long: "eratemanager-cli talks to the storage.Storage backend directly (no HTTP\n" +
+		"round-trip) to manage providers and inspect or prune rate snapshot history.",
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&flagDriver, "driver", "", "storage driver (sqlite, postgres, postgrespool, memory, etcd); overrides --config and ERATEMANAGER_DB_DRIVER")
+	rootCmd.PersistentFlags().StringVar(&flagDSN, "dsn", "", "storage DSN; overrides --config and ERATEMANAGER_DB_DSN")
+	rootCmd.PersistentFlags().StringVar(&flagConfigFile, "config", "", "path to a JSON config file with \"driver\"/\"dsn\" fields")
+
+	rootCmd.AddCommand(providersCmd, snapshotsCmd, dbCmd, ratesCmd)
+}
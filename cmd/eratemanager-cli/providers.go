@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "Manage utility providers",
+}
+
+var (
+	providerKey            string
+	providerName           string
+	providerLandingURL     string
+	providerDefaultPDFPath string
+	providerNotes          string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{providersAddCmd, providersUpdateCmd} {
+		c.Flags().StringVar(&providerKey, "key", "", "provider key (required)")
+		c.Flags().StringVar(&providerName, "name", "", "provider display name")
+		c.Flags().StringVar(&providerLandingURL, "landing-url", "", "provider landing page URL")
+		c.Flags().StringVar(&providerDefaultPDFPath, "default-pdf-path", "", "default local path to the provider's tariff PDF")
+		c.Flags().StringVar(&providerNotes, "notes", "", "free-form notes")
+		_ = c.MarkFlagRequired("key")
+	}
+
+	providersCmd.AddCommand(providersListCmd, providersAddCmd, providersUpdateCmd, providersDeleteCmd, providersShowCmd)
+}
+
+var providersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all providers",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		list, err := st.ListProviders(ctx)
+		if err != nil {
+			return fmt.Errorf("list providers: %w", err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	},
+}
+
+var providersShowCmd = &cobra.Command{
+	Use:   "show <key>",
+	Short: "Show a single provider",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		p, err := st.GetProvider(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("get provider %s: %w", args[0], err)
+		}
+		if p == nil {
+			return fmt.Errorf("provider %q not found", args[0])
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	},
+}
+
+var providersAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a new provider",
+	RunE:  runProviderUpsert,
+}
+
+var providersUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update an existing provider (same fields as add; UpsertProvider is idempotent either way)",
+	RunE:  runProviderUpsert,
+}
+
+func runProviderUpsert(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	st, err := openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	p := storage.Provider{
+		Key:            providerKey,
+		Name:           providerName,
+		LandingURL:     providerLandingURL,
+		DefaultPDFPath: providerDefaultPDFPath,
+		Notes:          providerNotes,
+	}
+	if err := st.UpsertProvider(ctx, p); err != nil {
+		return fmt.Errorf("upsert provider %s: %w", p.Key, err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "provider %s saved\n", p.Key)
+	return nil
+}
+
+var providersDeleteCmd = &cobra.Command{
+	Use:   "delete <key>",
+	Short: "Delete a provider",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		deleter, ok := st.(storage.ProviderDeleter)
+		if !ok {
+			return fmt.Errorf("backend does not support deleting providers")
+		}
+		if err := deleter.DeleteProvider(ctx, args[0]); err != nil {
+			return fmt.Errorf("delete provider %s: %w", args[0], err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "provider %s deleted\n", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bher20/eratemanager/internal/rates"
+	"github.com/bher20/eratemanager/internal/rates/urdb"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// ratesCmd groups commands that convert a provider's stored RatesResponse to
+// and from external tariff schemas, living here rather than under the
+// eratemanager server binary's cobra tree because export/import need the
+// same direct storage.Storage access providersCmd and snapshotsCmd already
+// have, not an HTTP round-trip.
+var ratesCmd = &cobra.Command{
+	Use:   "rates",
+	Short: "Export and import provider rate data in external tariff schemas",
+}
+
+var (
+	ratesExportProvider string
+	ratesExportFormat   string
+	ratesExportOutput   string
+	ratesImportFormat   string
+	ratesImportProvider string
+	ratesImportInput    string
+)
+
+func init() {
+	ratesExportCmd.Flags().StringVar(&ratesExportProvider, "provider", "", "provider key to export (required)")
+	ratesExportCmd.Flags().StringVar(&ratesExportFormat, "format", "urdb", "export format (only \"urdb\" is supported)")
+	ratesExportCmd.Flags().StringVarP(&ratesExportOutput, "output", "o", "", "file to write to (default stdout)")
+	_ = ratesExportCmd.MarkFlagRequired("provider")
+
+	ratesImportCmd.Flags().StringVar(&ratesImportFormat, "format", "urdb", "import format (only \"urdb\" is supported)")
+	ratesImportCmd.Flags().StringVar(&ratesImportProvider, "provider", "", "provider key to save the imported rates under (required)")
+	ratesImportCmd.Flags().StringVarP(&ratesImportInput, "file", "f", "", "file to read from (default stdin)")
+	_ = ratesImportCmd.MarkFlagRequired("provider")
+
+	ratesCmd.AddCommand(ratesExportCmd, ratesImportCmd)
+}
+
+var ratesExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a provider's latest rates snapshot as --format",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ratesExportFormat != "urdb" {
+			return fmt.Errorf("unsupported export format %q (want urdb)", ratesExportFormat)
+		}
+
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		snap, err := st.GetRatesSnapshot(ctx, ratesExportProvider)
+		if err != nil {
+			return fmt.Errorf("get rates for %s: %w", ratesExportProvider, err)
+		}
+		if snap == nil {
+			return fmt.Errorf("no rates snapshot for provider %q", ratesExportProvider)
+		}
+
+		var resp rates.RatesResponse
+		if err := json.Unmarshal(snap.Payload, &resp); err != nil {
+			return fmt.Errorf("decode rates snapshot for %s: %w", ratesExportProvider, err)
+		}
+
+		tariff, err := urdb.ToURDB(resp)
+		if err != nil {
+			return fmt.Errorf("convert %s to urdb: %w", ratesExportProvider, err)
+		}
+
+		out := cmd.OutOrStdout()
+		if ratesExportOutput != "" {
+			f, err := os.Create(ratesExportOutput)
+			if err != nil {
+				return fmt.Errorf("create %s: %w", ratesExportOutput, err)
+			}
+			defer f.Close()
+			out = f
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tariff)
+	},
+}
+
+var ratesImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a tariff in --format and save it as the named provider's rates",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ratesImportFormat != "urdb" {
+			return fmt.Errorf("unsupported import format %q (want urdb)", ratesImportFormat)
+		}
+
+		in := cmd.InOrStdin()
+		if ratesImportInput != "" {
+			f, err := os.Open(ratesImportInput)
+			if err != nil {
+				return fmt.Errorf("open %s: %w", ratesImportInput, err)
+			}
+			defer f.Close()
+			in = f
+		}
+		raw, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("read urdb tariff: %w", err)
+		}
+
+		var tariff urdb.URDBTariff
+		if err := json.Unmarshal(raw, &tariff); err != nil {
+			return fmt.Errorf("decode urdb tariff: %w", err)
+		}
+
+		resp, err := urdb.FromURDB(tariff)
+		if err != nil {
+			return fmt.Errorf("convert urdb tariff: %w", err)
+		}
+
+		payload, err := json.Marshal(resp)
+		if err != nil {
+			return fmt.Errorf("encode rates: %w", err)
+		}
+
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		snap := storage.RatesSnapshot{Provider: ratesImportProvider, Payload: payload}
+		if err := st.SaveRatesSnapshot(ctx, snap); err != nil {
+			return fmt.Errorf("save rates for %s: %w", ratesImportProvider, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "provider %s rates imported\n", ratesImportProvider)
+		return nil
+	},
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "Inspect and prune rate-snapshot history",
+}
+
+var (
+	snapshotsSince     string
+	snapshotsUntil     string
+	snapshotsLimit     int
+	snapshotsOutFormat string
+	pruneKeepLast      int
+	pruneOlderThan     string
+	atDate             string
+)
+
+func init() {
+	snapshotsListCmd.Flags().StringVar(&snapshotsSince, "since", "", "only show snapshots fetched within this long of now (e.g. \"24h\", \"7d\"); empty means no lower bound")
+	snapshotsListCmd.Flags().StringVar(&snapshotsUntil, "until", "", "only show snapshots fetched at least this long ago (e.g. \"24h\", \"7d\"); empty means no upper bound")
+	snapshotsListCmd.Flags().IntVar(&snapshotsLimit, "limit", 0, "max rows to return (0 means no limit)")
+
+	snapshotsShowCmd.Flags().StringVarP(&snapshotsOutFormat, "output", "o", "json", "output format: json, yaml, or pdf (raw payload bytes)")
+
+	snapshotsPruneCmd.Flags().IntVar(&pruneKeepLast, "keep-last", 10, "always keep at least this many most-recent snapshots per provider")
+	snapshotsPruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "30d", "delete eligible snapshots fetched longer ago than this (e.g. \"30d\", \"720h\")")
+
+	snapshotsAtCmd.Flags().StringVar(&atDate, "date", "", "point in time to look up, RFC3339 (required)")
+	_ = snapshotsAtCmd.MarkFlagRequired("date")
+
+	snapshotsCmd.AddCommand(snapshotsListCmd, snapshotsShowCmd, snapshotsPruneCmd, snapshotsAtCmd, snapshotsDiffCmd)
+}
+
+func snapshotHistory(st storage.Storage) (storage.SnapshotHistory, error) {
+	hist, ok := st.(storage.SnapshotHistory)
+	if !ok {
+		return nil, fmt.Errorf("backend does not retain snapshot history")
+	}
+	return hist, nil
+}
+
+var snapshotsListCmd = &cobra.Command{
+	Use:   "list <provider>",
+	Short: "List a provider's snapshot history, newest first",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		hist, err := snapshotHistory(st)
+		if err != nil {
+			return err
+		}
+
+		var since, until time.Time
+		if snapshotsSince != "" {
+			ago, err := parseDuration(snapshotsSince)
+			if err != nil {
+				return err
+			}
+			since = time.Now().Add(-ago)
+		}
+		if snapshotsUntil != "" {
+			ago, err := parseDuration(snapshotsUntil)
+			if err != nil {
+				return err
+			}
+			until = time.Now().Add(-ago)
+		}
+
+		snaps, err := hist.ListRatesSnapshots(ctx, args[0], since, until, snapshotsLimit)
+		if err != nil {
+			return fmt.Errorf("list snapshots for %s: %w", args[0], err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snaps)
+	},
+}
+
+var snapshotsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a single snapshot by ID",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot id %q: %w", args[0], err)
+		}
+
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		hist, err := snapshotHistory(st)
+		if err != nil {
+			return err
+		}
+
+		snap, err := hist.GetRatesSnapshotByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get snapshot %d: %w", id, err)
+		}
+		if snap == nil {
+			return fmt.Errorf("snapshot %d not found", id)
+		}
+		return writeSnapshot(os.Stdout, snap, snapshotsOutFormat)
+	},
+}
+
+var snapshotsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old snapshot history, keeping a minimum number of rows per provider",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, err := parseDuration(pruneOlderThan)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		hist, err := snapshotHistory(st)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := hist.PruneRatesSnapshots(ctx, pruneKeepLast, olderThan)
+		if err != nil {
+			return fmt.Errorf("prune snapshots: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "deleted %d snapshot(s)\n", deleted)
+		return nil
+	},
+}
+
+var snapshotsAtCmd = &cobra.Command{
+	Use:   "at <provider>",
+	Short: "Show the snapshot in effect at a point in time (--date), for point-in-time audits",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		at, err := time.Parse(time.RFC3339, atDate)
+		if err != nil {
+			return fmt.Errorf("invalid --date %q: %w", atDate, err)
+		}
+
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		hist, err := snapshotHistory(st)
+		if err != nil {
+			return err
+		}
+
+		snap, err := hist.GetRatesSnapshotAt(ctx, args[0], at)
+		if err != nil {
+			return fmt.Errorf("get snapshot for %s at %s: %w", args[0], atDate, err)
+		}
+		if snap == nil {
+			return fmt.Errorf("no snapshot for %s existed yet at %s", args[0], atDate)
+		}
+		return writeSnapshot(os.Stdout, snap, snapshotsOutFormat)
+	},
+}
+
+var snapshotsDiffCmd = &cobra.Command{
+	Use:   "diff <provider> <from-id> <to-id>",
+	Short: "Show what changed between two snapshots of a provider",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid from-id %q: %w", args[1], err)
+		}
+		b, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid to-id %q: %w", args[2], err)
+		}
+
+		ctx := context.Background()
+		st, err := openStorage(ctx)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		hist, err := snapshotHistory(st)
+		if err != nil {
+			return err
+		}
+
+		diff, err := storage.DiffRatesSnapshots(ctx, hist, args[0], a, b)
+		if err != nil {
+			return fmt.Errorf("diff snapshots %d..%d: %w", a, b, err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	},
+}
+
+// writeSnapshot renders snap to w in the requested format: "json" (indented,
+// the default), "yaml" (a minimal flat key/value dump, since this module
+// takes no YAML dependency), or "pdf" (the raw payload bytes, unmodified).
+func writeSnapshot(w *os.File, snap *storage.RatesSnapshot, format string) error {
+	switch format {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	case "yaml":
+		fmt.Fprintf(w, "id: %d\n", snap.ID)
+		fmt.Fprintf(w, "provider: %s\n", snap.Provider)
+		fmt.Fprintf(w, "fetched_at: %s\n", snap.FetchedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "content_hash: %s\n", snap.ContentHash)
+		fmt.Fprintf(w, "version: %d\n", snap.Version)
+		fmt.Fprintf(w, "size: %d\n", snap.Size)
+		fmt.Fprintf(w, "payload: |\n")
+		for _, line := range splitLines(snap.Payload) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+		return nil
+	case "pdf":
+		_, err := w.Write(snap.Payload)
+		return err
+	default:
+		return fmt.Errorf("unknown output format %q (want json, yaml, or pdf)", format)
+	}
+}
+
+func splitLines(payload []byte) []string {
+	var lines []string
+	start := 0
+	for i, b := range payload {
+		if b == '\n' {
+			lines = append(lines, string(payload[start:i]))
+			start = i + 1
+		}
+	}
+	if start < len(payload) {
+		lines = append(lines, string(payload[start:]))
+	}
+	return lines
+}
@@ -2,9 +2,13 @@ package main
 
 import (
     "context"
+    "errors"
     "log"
     "net/http"
     "os"
+    "os/signal"
+    "syscall"
+    "time"
 
     "github.com/spf13/cobra"
 
@@ -80,10 +84,15 @@ var cronCmd = &cobra.Command{
     Use:   "cron",
     Short: "Run background cron worker that refreshes rates on a schedule",
     RunE: func(cmd *cobra.Command, args []string) error {
-        ctx := context.Background()
+        ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+        defer stop()
         driver, dsn := getDBEnv()
         log.Printf("starting cron worker with driver=%s dsn=%s", driver, dsn)
-        return cron.Run(ctx, driver, dsn)
+        err := cron.Run(ctx, driver, dsn)
+        if errors.Is(err, context.Canceled) {
+            return nil
+        }
+        return err
     },
 }
 
@@ -108,10 +117,30 @@ func serve() error {
     if port == "" {
         port = "8000"
     }
-    mux := api.NewMux()
     addr := ":" + port
-    log.Printf("eRateManager listening on %s", addr)
-    return http.ListenAndServe(addr, mux)
+    srv := api.NewServer(addr)
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    errCh := make(chan error, 1)
+    go func() {
+        log.Printf("eRateManager listening on %s", addr)
+        errCh <- srv.ListenAndServe()
+    }()
+
+    select {
+    case err := <-errCh:
+        if errors.Is(err, http.ErrServerClosed) {
+            return nil
+        }
+        return err
+    case <-ctx.Done():
+        log.Printf("shutdown signal received, draining in-flight requests")
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+        return srv.Shutdown(shutdownCtx)
+    }
 }
 
 func getDBEnv() (driver, dsn string) {
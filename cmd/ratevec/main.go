@@ -0,0 +1,120 @@
+// Command ratevec records conformance vectors for the rates package's
+// parsers (see internal/rates/conformance), the fixture corpus
+// TestConformance replays.
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bher20/eratemanager/internal/rates"
+)
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+}
+
+var flagTolerance float64
+
+var rootCmd = &cobra.Command{
+	Use:   "ratevec",
+	Short: "Record conformance vectors for eRateManager's rate parsers",
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record <provider> <input-file>",
+	Short: "Parse input-file with provider's registered parser and save the result as a new golden vector",
+	Long: "record drives provider's registered ParserConfig.ParseText/ParsePDF over\n" +
+		"input-file and writes the result as a new vector JSON under\n" +
+		"internal/rates/conformance/testdata/vectors/<provider>/, for\n" +
+		"TestConformance to replay. input-file's extension selects input_kind:\n" +
+		"\".pdf\" drives ParsePDF, anything else drives ParseText.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return record(args[0], args[1], flagTolerance)
+	},
+}
+
+func init() {
+	recordCmd.Flags().Float64Var(&flagTolerance, "tolerance", 1e-6, "numeric tolerance to record into the vector")
+	rootCmd.AddCommand(recordCmd)
+}
+
+type vectorFile struct {
+	Parser    string          `json:"parser"`
+	InputKind string          `json:"input_kind"`
+	Input     string          `json:"input"`
+	Expected  json.RawMessage `json:"expected"`
+	Tolerance float64         `json:"tolerance"`
+}
+
+func record(providerKey, inputPath string, tolerance float64) error {
+	cfg, ok := rates.GetParser(providerKey)
+	if !ok {
+		return fmt.Errorf("no parser registered for %q", providerKey)
+	}
+
+	raw, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", inputPath, err)
+	}
+
+	inputKind := "text"
+	var got *rates.RatesResponse
+	if strings.EqualFold(filepath.Ext(inputPath), ".pdf") {
+		inputKind = "pdf"
+		if cfg.ParsePDF == nil {
+			return fmt.Errorf("parser %q has no ParsePDF", providerKey)
+		}
+		got, err = cfg.ParsePDF(inputPath)
+	} else {
+		if cfg.ParseText == nil {
+			return fmt.Errorf("parser %q has no ParseText", providerKey)
+		}
+		got, err = cfg.ParseText(string(raw))
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", inputPath, err)
+	}
+
+	expected, err := json.Marshal(got)
+	if err != nil {
+		return fmt.Errorf("marshal parsed output: %w", err)
+	}
+
+	vector := vectorFile{
+		Parser:    providerKey,
+		InputKind: inputKind,
+		Input:     base64.StdEncoding.EncodeToString(raw),
+		Expected:  expected,
+		Tolerance: tolerance,
+	}
+
+	outDir := filepath.Join("internal", "rates", "conformance", "testdata", "vectors", providerKey)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)) + ".json"
+	outPath := filepath.Join(outDir, name)
+
+	b, err := json.MarshalIndent(vector, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %s\n", outPath)
+	return nil
+}
@@ -1,15 +1,23 @@
+// Package alerting delivers Event values to administrator-registered
+// webhook Subscriptions (Slack, Discord, or a generic signed JSON POST),
+// retrying transient failures on a bounded worker pool before giving up
+// and recording a dead letter. SendBatchAlert and SendCircuitAlert are thin
+// wrappers that turn the batch/circuit-breaker conditions the cron package
+// detects into Events, kept for backward compatibility with existing
+// callers.
 package alerting
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
 )
 
 // AlertConfig holds alerting configuration.
@@ -58,20 +66,74 @@ func DefaultAlertConfig() AlertConfig {
 	return cfg
 }
 
-// Alerter sends alerts to configured webhooks.
+// Alerter delivers Events to every registered Subscription that matches
+// them. Broadcast queues the delivery and returns immediately; a pool of
+// background workers fans it out, retrying with backoff and recording a
+// dead letter if every attempt fails.
 type Alerter struct {
 	cfg    AlertConfig
 	client *http.Client
+	// store persists subscriptions and dead letters. Left nil on backends
+	// that don't implement storage.AlertingStore, in which case
+	// subscriptions don't survive a restart and failed deliveries are only
+	// logged.
+	store storage.AlertingStore
+
+	mu   sync.Mutex
+	subs map[string]Subscription
+
+	queue chan delivery
+	wg    sync.WaitGroup
+	stop  chan struct{}
+	once  sync.Once
 }
 
-// NewAlerter creates a new alerter instance.
-func NewAlerter(cfg AlertConfig) *Alerter {
-	return &Alerter{
-		cfg: cfg,
-		client: &http.Client{
-			Timeout: cfg.Timeout,
-		},
+// NewAlerter starts Alerter's delivery worker pool, loading any
+// subscriptions st has persisted (if it implements storage.AlertingStore),
+// and - for backward compatibility - registering cfg.WebhookURL as an
+// implicit "env-default" subscription when cfg.Enabled, matching every
+// event the same way SendBatchAlert/SendCircuitAlert's single webhook did
+// before the Subscription registry existed.
+func NewAlerter(cfg AlertConfig, st storage.Storage) *Alerter {
+	store, _ := st.(storage.AlertingStore)
+	a := &Alerter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		store:  store,
+		subs:   make(map[string]Subscription),
+		queue:  make(chan delivery, deliveryQueueCapacity),
+		stop:   make(chan struct{}),
+	}
+
+	if store != nil {
+		stored, err := store.ListAlertWebhookSubscriptions(context.Background())
+		if err != nil {
+			log.Printf("alerting: failed to load webhook subscriptions: %v", err)
+		}
+		for _, s := range stored {
+			a.subs[s.ID] = subscriptionFromStorage(s)
+		}
+	}
+
+	if cfg.Enabled {
+		a.subs["env-default"] = Subscription{ID: "env-default", URL: cfg.WebhookURL, WebhookType: cfg.WebhookType}
+		log.Printf("alerting: alerts enabled (webhook type: %s)", cfg.WebhookType)
+	}
+
+	a.wg.Add(alertingWorkerCount)
+	for i := 0; i < alertingWorkerCount; i++ {
+		go a.run()
 	}
+	return a
+}
+
+// Close stops Alerter's worker pool, letting in-flight deliveries finish
+// but dropping anything still queued.
+func (a *Alerter) Close() {
+	a.once.Do(func() {
+		close(a.stop)
+		a.wg.Wait()
+	})
 }
 
 // BatchAlert represents an alert about batch job results.
@@ -92,141 +154,48 @@ type ProviderFailure struct {
 	Attempts int
 }
 
-// SendBatchAlert sends an alert about batch job failures.
+// SendBatchAlert broadcasts a rates.batch_failed Event built from alert,
+// once FailedCount crosses MinFailuresBeforeAlert.
 func (a *Alerter) SendBatchAlert(ctx context.Context, alert BatchAlert) error {
-	if !a.cfg.Enabled {
-		log.Printf("alerting: alerts disabled, skipping")
-		return nil
-	}
-
 	if alert.FailedCount < a.cfg.MinFailuresBeforeAlert {
 		log.Printf("alerting: %d failures below threshold (%d), skipping",
 			alert.FailedCount, a.cfg.MinFailuresBeforeAlert)
 		return nil
 	}
 
-	var payload []byte
-	var err error
-
-	switch a.cfg.WebhookType {
-	case "slack":
-		payload, err = a.buildSlackPayload(alert)
-	case "discord":
-		payload, err = a.buildDiscordPayload(alert)
-	default:
-		payload, err = a.buildGenericPayload(alert)
-	}
-
-	if err != nil {
-		return fmt.Errorf("build payload: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", a.cfg.WebhookURL, bytes.NewReader(payload))
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
-	}
-
-	log.Printf("alerting: sent alert for %d failed providers", alert.FailedCount)
-	return nil
-}
-
-func (a *Alerter) buildSlackPayload(alert BatchAlert) ([]byte, error) {
-	// Build failure list
-	var failedList strings.Builder
-	for _, f := range alert.FailedDetails {
-		failedList.WriteString(fmt.Sprintf("• *%s*: %s (attempts: %d)\n", f.Provider, f.Error, f.Attempts))
-	}
-
-	emoji := ":warning:"
-	if alert.FailedCount == alert.TotalCount {
-		emoji = ":x:"
-	}
-
-	payload := map[string]interface{}{
-		"blocks": []map[string]interface{}{
-			{
-				"type": "header",
-				"text": map[string]string{
-					"type": "plain_text",
-					"text": fmt.Sprintf("%s Batch Job Alert: %s", emoji, alert.JobName),
-				},
-			},
-			{
-				"type": "section",
-				"fields": []map[string]string{
-					{"type": "mrkdwn", "text": fmt.Sprintf("*Status:*\n%d/%d failed", alert.FailedCount, alert.TotalCount)},
-					{"type": "mrkdwn", "text": fmt.Sprintf("*Duration:*\n%s", alert.Duration.Round(time.Millisecond))},
-					{"type": "mrkdwn", "text": fmt.Sprintf("*Success:*\n%d", alert.SuccessCount)},
-					{"type": "mrkdwn", "text": fmt.Sprintf("*Timestamp:*\n%s", alert.Timestamp.Format(time.RFC3339))},
-				},
-			},
-			{
-				"type": "section",
-				"text": map[string]string{
-					"type": "mrkdwn",
-					"text": fmt.Sprintf("*Failed Providers:*\n%s", failedList.String()),
-				},
-			},
-		},
-	}
-
-	return json.Marshal(payload)
-}
-
-func (a *Alerter) buildDiscordPayload(alert BatchAlert) ([]byte, error) {
-	// Build failure list
-	var failedList strings.Builder
-	for _, f := range alert.FailedDetails {
-		failedList.WriteString(fmt.Sprintf("• **%s**: %s (attempts: %d)\n", f.Provider, f.Error, f.Attempts))
-	}
-
-	color := 16776960 // Yellow
-	if alert.FailedCount == alert.TotalCount {
-		color = 16711680 // Red
-	}
-
-	payload := map[string]interface{}{
-		"embeds": []map[string]interface{}{
-			{
-				"title":       fmt.Sprintf("Batch Job Alert: %s", alert.JobName),
-				"description": fmt.Sprintf("%d/%d providers failed", alert.FailedCount, alert.TotalCount),
-				"color":       color,
-				"fields": []map[string]interface{}{
-					{"name": "Success", "value": fmt.Sprintf("%d", alert.SuccessCount), "inline": true},
-					{"name": "Failed", "value": fmt.Sprintf("%d", alert.FailedCount), "inline": true},
-					{"name": "Duration", "value": alert.Duration.Round(time.Millisecond).String(), "inline": true},
-					{"name": "Failed Providers", "value": failedList.String(), "inline": false},
-				},
-				"timestamp": alert.Timestamp.Format(time.RFC3339),
-			},
-		},
-	}
-
-	return json.Marshal(payload)
-}
-
-func (a *Alerter) buildGenericPayload(alert BatchAlert) ([]byte, error) {
-	payload := map[string]interface{}{
-		"alert_type":     "batch_job_failure",
+	a.Broadcast(ctx, NewEvent("rates", "batch_failed", map[string]interface{}{
 		"job_name":       alert.JobName,
 		"total_count":    alert.TotalCount,
 		"success_count":  alert.SuccessCount,
 		"failed_count":   alert.FailedCount,
 		"duration_ms":    alert.Duration.Milliseconds(),
-		"timestamp":      alert.Timestamp.Format(time.RFC3339),
 		"failed_details": alert.FailedDetails,
-	}
+	}))
+	return nil
+}
 
-	return json.Marshal(payload)
+// CircuitAlert represents an alert about a provider's circuit breaker
+// transitioning state (e.g. closed -> open after repeated failures, or
+// open -> closed once it recovers).
+type CircuitAlert struct {
+	Provider            string
+	FromState           string
+	ToState             string
+	ConsecutiveFailures int
+	LastError           string
+	Timestamp           time.Time
+}
+
+// SendCircuitAlert broadcasts a provider.circuit_state_changed Event built
+// from alert. Unlike SendBatchAlert, it is not gated by
+// MinFailuresBeforeAlert - a single state transition is always notable.
+func (a *Alerter) SendCircuitAlert(ctx context.Context, alert CircuitAlert) error {
+	a.Broadcast(ctx, NewEvent("provider", "circuit_state_changed", map[string]interface{}{
+		"provider":             alert.Provider,
+		"from_state":           alert.FromState,
+		"to_state":             alert.ToState,
+		"consecutive_failures": alert.ConsecutiveFailures,
+		"last_error":           alert.LastError,
+	}))
+	return nil
 }
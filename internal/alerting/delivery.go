@@ -0,0 +1,213 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+	"github.com/bher20/eratemanager/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// deliveryQueueCapacity bounds Alerter's in-memory delivery queue, the same
+// role queueCapacity plays in notification.Service.
+const deliveryQueueCapacity = 256
+
+// alertingWorkerCount is the number of goroutines draining the delivery
+// queue concurrently.
+const alertingWorkerCount = 4
+
+// webhookMaxAttempts, webhookBackoffBase and webhookBackoffCap bound a
+// delivery's retry loop - the same doubling-up-to-a-cap shape
+// auth/lockout.go uses for login backoff.
+const (
+	webhookMaxAttempts = 5
+	webhookBackoffBase = 500 * time.Millisecond
+	webhookBackoffCap  = 30 * time.Second
+)
+
+func webhookBackoff(attempt int) time.Duration {
+	return webhooks.Backoff(attempt, webhookBackoffBase, webhookBackoffCap)
+}
+
+type delivery struct {
+	sub   Subscription
+	event Event
+}
+
+// Broadcast queues event for delivery to every subscription whose
+// EventFilter matches it. Delivery happens asynchronously on Alerter's
+// worker pool; Broadcast only blocks if the queue itself is full.
+func (a *Alerter) Broadcast(ctx context.Context, event Event) {
+	a.mu.Lock()
+	matched := make([]Subscription, 0, len(a.subs))
+	for _, sub := range a.subs {
+		if sub.matches(event) {
+			matched = append(matched, sub)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, sub := range matched {
+		select {
+		case a.queue <- delivery{sub: sub, event: event}:
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+func (a *Alerter) run() {
+	defer a.wg.Done()
+	for {
+		select {
+		case d, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			a.deliver(d)
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// deliver attempts d up to webhookMaxAttempts times with exponential
+// backoff between attempts, recording a dead letter if every attempt
+// fails.
+func (a *Alerter) deliver(d delivery) {
+	body, err := buildPayload(d.sub.WebhookType, d.event)
+	if err != nil {
+		log.Printf("alerting: failed to build payload for %s: %v", d.event.Key(), err)
+		return
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(webhookBackoff(attempt - 1)):
+			case <-a.stop:
+				return
+			}
+		}
+		if lastErr = a.post(d.sub, body); lastErr == nil {
+			return
+		}
+		log.Printf("alerting: delivery of %s to %s failed (attempt %d/%d): %v", d.event.Key(), d.sub.URL, attempt, webhookMaxAttempts, lastErr)
+	}
+
+	a.recordDeadLetter(d, body, lastErr)
+}
+
+// post sends body to sub.URL, setting sub's extra header and HMAC
+// signature.
+func (a *Alerter) post(sub Subscription, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sub.HeaderKey != "" {
+		req.Header.Set(sub.HeaderKey, sub.HeaderValue)
+	}
+	if sub.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", webhooks.SignHex(sub.Secret, body))
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// buildPayload renders event for delivery to a subscription of the given
+// webhookType: a short formatted message for "slack"/"discord", or the
+// event itself JSON-encoded for anything else ("generic").
+func buildPayload(webhookType string, event Event) ([]byte, error) {
+	switch webhookType {
+	case "slack":
+		return json.Marshal(map[string]interface{}{
+			"text": fmt.Sprintf("*%s*\n```%s```", event.Key(), formatEventData(event.Data)),
+		})
+	case "discord":
+		return json.Marshal(map[string]interface{}{
+			"embeds": []map[string]interface{}{{
+				"title":       event.Key(),
+				"description": formatEventData(event.Data),
+				"timestamp":   event.Timestamp.Format(time.RFC3339),
+			}},
+		})
+	default:
+		return json.Marshal(event)
+	}
+}
+
+func formatEventData(data map[string]interface{}) string {
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", data)
+	}
+	return string(b)
+}
+
+// recordDeadLetter logs a delivery that exhausted its retry budget and, on
+// a backend that implements storage.AlertingStore, persists it for later
+// inspection via /internal/alerts/webhooks/dead.
+func (a *Alerter) recordDeadLetter(d delivery, body []byte, deliverErr error) {
+	log.Printf("alerting: giving up on delivery of %s to %s after %d attempts: %v", d.event.Key(), d.sub.URL, webhookMaxAttempts, deliverErr)
+	if a.store == nil {
+		return
+	}
+	dl := storage.AlertWebhookDeadLetter{
+		ID:             uuid.New().String(),
+		SubscriptionID: d.sub.ID,
+		EventModule:    d.event.Module,
+		EventID:        d.event.EventID,
+		Payload:        body,
+		Error:          deliverErr.Error(),
+		Attempts:       webhookMaxAttempts,
+		CreatedAt:      time.Now(),
+	}
+	if err := a.store.SaveAlertWebhookDeadLetter(context.Background(), dl); err != nil {
+		log.Printf("alerting: failed to persist dead letter for %s: %v", d.sub.URL, err)
+	}
+}
+
+// DeadLetters returns every delivery that exhausted its retry budget, for
+// /internal/alerts/webhooks/dead. Returns an empty slice (not an error) on
+// a backend that doesn't implement storage.AlertingStore.
+func (a *Alerter) DeadLetters(ctx context.Context) ([]storage.AlertWebhookDeadLetter, error) {
+	if a.store == nil {
+		return nil, nil
+	}
+	return a.store.ListAlertWebhookDeadLetters(ctx)
+}
+
+// Ping sends a synthetic alerting.ping event directly to subscription id,
+// bypassing the event filter and worker queue, so a caller gets an
+// immediate pass/fail result instead of waiting on async delivery.
+func (a *Alerter) Ping(ctx context.Context, id string) error {
+	sub := a.Get(id)
+	if sub == nil {
+		return fmt.Errorf("alerting: unknown subscription %q", id)
+	}
+
+	event := NewEvent("alerting", "ping", map[string]interface{}{"subscription_id": id})
+	body, err := buildPayload(sub.WebhookType, event)
+	if err != nil {
+		return err
+	}
+	return a.post(*sub, body)
+}
@@ -0,0 +1,27 @@
+package alerting
+
+import "time"
+
+// Event is a single notable occurrence broadcast to every matching
+// Subscription, identified by a Module ("rates", "provider", "auth", ...)
+// and an EventID scoped to that module ("batch_failed",
+// "health_degraded", "token_expiring", ...). Data carries whatever fields
+// are specific to this event; it's serialized as-is in a "generic"
+// delivery and summarized for "slack"/"discord" subscriptions.
+type Event struct {
+	Module    string                 `json:"module"`
+	EventID   string                 `json:"event_id"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// NewEvent builds an Event stamped with the current time.
+func NewEvent(module, eventID string, data map[string]interface{}) Event {
+	return Event{Module: module, EventID: eventID, Data: data, Timestamp: time.Now()}
+}
+
+// Key returns "module.event_id", the form used by a Subscription's
+// EventFilter.
+func (e Event) Key() string {
+	return e.Module + "." + e.EventID
+}
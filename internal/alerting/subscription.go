@@ -0,0 +1,163 @@
+package alerting
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Subscription is one webhook registered to receive Broadcast events.
+type Subscription struct {
+	ID string `json:"id"`
+	// URL is the endpoint Alerter POSTs the event payload to.
+	URL string `json:"url"`
+	// HeaderKey/HeaderValue, if set, are sent as an extra request header on
+	// every delivery - e.g. an endpoint-specific API key distinct from the
+	// HMAC signature.
+	HeaderKey   string `json:"header_key,omitempty"`
+	HeaderValue string `json:"-"`
+	// EventFilter narrows which events this subscription receives: each
+	// entry is a "module.event_id" key, "module.*" for every event in a
+	// module, or "*" for everything. An empty filter matches everything.
+	EventFilter []string `json:"event_filter,omitempty"`
+	// WebhookType selects the payload shape: "slack", "discord", or
+	// "generic" (the default, a raw JSON-encoded Event).
+	WebhookType string `json:"webhook_type,omitempty"`
+	// Secret HMAC-SHA256-signs every delivery's body (X-Webhook-Signature),
+	// generated automatically by Register if left blank.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// matches reports whether sub wants to receive event, per EventFilter.
+func (sub Subscription) matches(event Event) bool {
+	if len(sub.EventFilter) == 0 {
+		return true
+	}
+	key := event.Key()
+	for _, f := range sub.EventFilter {
+		if f == "*" || f == key || f == event.Module+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// toStorage converts sub to its persisted form.
+func (sub Subscription) toStorage() (storage.AlertWebhookSubscription, error) {
+	filter, err := json.Marshal(sub.EventFilter)
+	if err != nil {
+		return storage.AlertWebhookSubscription{}, err
+	}
+	return storage.AlertWebhookSubscription{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		HeaderKey:   sub.HeaderKey,
+		HeaderValue: sub.HeaderValue,
+		EventFilter: string(filter),
+		WebhookType: sub.WebhookType,
+		Secret:      sub.Secret,
+		CreatedAt:   sub.CreatedAt,
+	}, nil
+}
+
+// subscriptionFromStorage is toStorage's inverse, used when NewAlerter
+// loads previously-registered subscriptions back out of storage.
+func subscriptionFromStorage(s storage.AlertWebhookSubscription) Subscription {
+	var filter []string
+	_ = json.Unmarshal([]byte(s.EventFilter), &filter)
+	return Subscription{
+		ID:          s.ID,
+		URL:         s.URL,
+		HeaderKey:   s.HeaderKey,
+		HeaderValue: s.HeaderValue,
+		EventFilter: filter,
+		WebhookType: s.WebhookType,
+		Secret:      s.Secret,
+		CreatedAt:   s.CreatedAt,
+	}
+}
+
+// newWebhookSecret generates a random hex-encoded HMAC secret for a new
+// Subscription.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Register adds sub to the subscription registry and returns its ID.
+// Secret is generated automatically when left blank.
+func (a *Alerter) Register(ctx context.Context, sub Subscription) (string, error) {
+	if sub.URL == "" {
+		return "", errors.New("alerting: subscription URL is required")
+	}
+	sub.ID = uuid.New().String()
+	sub.CreatedAt = time.Now()
+	if sub.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return "", err
+		}
+		sub.Secret = secret
+	}
+
+	if a.store != nil {
+		stored, err := sub.toStorage()
+		if err != nil {
+			return "", err
+		}
+		if err := a.store.SaveAlertWebhookSubscription(ctx, stored); err != nil {
+			return "", err
+		}
+	}
+
+	a.mu.Lock()
+	a.subs[sub.ID] = sub
+	a.mu.Unlock()
+	return sub.ID, nil
+}
+
+// Delete removes a subscription by ID. It's not an error to delete an
+// unknown ID.
+func (a *Alerter) Delete(ctx context.Context, id string) error {
+	if a.store != nil {
+		if err := a.store.DeleteAlertWebhookSubscription(ctx, id); err != nil {
+			return err
+		}
+	}
+	a.mu.Lock()
+	delete(a.subs, id)
+	a.mu.Unlock()
+	return nil
+}
+
+// List returns every registered subscription.
+func (a *Alerter) List(ctx context.Context) ([]Subscription, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]Subscription, 0, len(a.subs))
+	for _, sub := range a.subs {
+		out = append(out, sub)
+	}
+	return out, nil
+}
+
+// Get returns a single subscription by ID, or nil if it doesn't exist.
+func (a *Alerter) Get(id string) *Subscription {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sub, ok := a.subs[id]
+	if !ok {
+		return nil
+	}
+	return &sub
+}
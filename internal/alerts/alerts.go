@@ -0,0 +1,174 @@
+// Package alerts evaluates successive rate snapshots for a provider and
+// raises alerts for parse regressions, large rate swings, and stale data.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	TypeParseRegression = "parse_regression"
+	TypeRateSwing       = "rate_swing"
+	TypeStaleSnapshot   = "stale_snapshot"
+
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Finding is a single alert condition detected for a provider, ready to be
+// upserted into storage.
+type Finding struct {
+	Provider string
+	Type     string
+	Severity string
+	Message  string
+	Data     map[string]interface{}
+}
+
+// Thresholds controls sensitivity of the evaluator.
+type Thresholds struct {
+	// RateSwingPercent is the minimum fractional change (e.g. 0.1 = 10%) in
+	// energy or fuel rate required to raise a rate_swing alert.
+	RateSwingPercent float64
+	// StaleAfter is how long a snapshot can go without a refresh before it's
+	// considered stale.
+	StaleAfter time.Duration
+}
+
+// DefaultThresholds returns reasonable defaults: a 10% swing, 48h staleness.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		RateSwingPercent: 0.10,
+		StaleAfter:       48 * time.Hour,
+	}
+}
+
+// residentialFields mirrors the subset of rates.RatesResponse needed to
+// evaluate alerts. Duplicated here (rather than imported from package
+// rates) because rates already imports storage, which imports alerts.
+type residentialFields struct {
+	Rates struct {
+		ResidentialStandard struct {
+			IsPresent                bool    `json:"is_present"`
+			CustomerChargeMonthlyUSD float64 `json:"customer_charge_monthly_usd"`
+			EnergyRateUSDPerKWh      float64 `json:"energy_rate_usd_per_kwh"`
+			TVAFuelRateUSDPerKWh     float64 `json:"tva_fuel_rate_usd_per_kwh"`
+		} `json:"residential_standard"`
+	} `json:"rates"`
+}
+
+// Evaluate compares prevPayload and nextPayload (raw JSON rates.RatesResponse
+// bodies) for provider and returns the findings that should be recorded. A
+// missing or malformed prevPayload yields no findings, so the first snapshot
+// for a provider never raises an alert.
+func Evaluate(provider string, prevPayload, nextPayload []byte, th Thresholds) []Finding {
+	if len(prevPayload) == 0 {
+		return nil
+	}
+
+	var prev, next residentialFields
+	if err := json.Unmarshal(prevPayload, &prev); err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(nextPayload, &next); err != nil {
+		return nil
+	}
+
+	var findings []Finding
+
+	p, n := prev.Rates.ResidentialStandard, next.Rates.ResidentialStandard
+	if p.IsPresent && !n.IsPresent {
+		findings = append(findings, Finding{
+			Provider: provider,
+			Type:     TypeParseRegression,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("%s: residential rates were present but are now missing", provider),
+			Data:     map[string]interface{}{"was_present": true, "now_present": false},
+		})
+		return findings
+	}
+	if !p.IsPresent && n.IsPresent {
+		// Recovery isn't itself an alert condition.
+		return findings
+	}
+
+	if swing, ok := percentSwing(p.EnergyRateUSDPerKWh, n.EnergyRateUSDPerKWh); ok && swing >= th.RateSwingPercent {
+		findings = append(findings, Finding{
+			Provider: provider,
+			Type:     TypeRateSwing,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s: energy rate moved %.1f%% (%.5f -> %.5f USD/kWh)", provider, swing*100, p.EnergyRateUSDPerKWh, n.EnergyRateUSDPerKWh),
+			Data:     map[string]interface{}{"field": "energy_rate_usd_per_kwh", "old": p.EnergyRateUSDPerKWh, "new": n.EnergyRateUSDPerKWh, "percent": swing},
+		})
+	}
+	if swing, ok := percentSwing(p.TVAFuelRateUSDPerKWh, n.TVAFuelRateUSDPerKWh); ok && swing >= th.RateSwingPercent {
+		findings = append(findings, Finding{
+			Provider: provider,
+			Type:     TypeRateSwing,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s: TVA fuel rate moved %.1f%% (%.5f -> %.5f USD/kWh)", provider, swing*100, p.TVAFuelRateUSDPerKWh, n.TVAFuelRateUSDPerKWh),
+			Data:     map[string]interface{}{"field": "tva_fuel_rate_usd_per_kwh", "old": p.TVAFuelRateUSDPerKWh, "new": n.TVAFuelRateUSDPerKWh, "percent": swing},
+		})
+	}
+
+	return findings
+}
+
+func percentSwing(old, new float64) (float64, bool) {
+	if old == 0 {
+		return 0, false
+	}
+	return abs((new - old) / old), true
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// StaleProvider describes a provider whose latest snapshot is older than the
+// configured staleness window, as found by CheckStaleness.
+type StaleProvider struct {
+	Provider  string
+	FetchedAt time.Time
+	Age       time.Duration
+}
+
+// snapshotLister is the minimal storage surface CheckStaleness needs.
+type snapshotLister interface {
+	ListProvidersWithLastSnapshot(ctx context.Context) (map[string]time.Time, error)
+}
+
+// CheckStaleness scans every provider's latest snapshot and returns findings
+// for those older than th.StaleAfter. Intended to be called periodically
+// (e.g. from the cron worker) rather than from the snapshot write path,
+// since a provider that never refreshes never triggers SaveRatesSnapshot.
+func CheckStaleness(ctx context.Context, store snapshotLister, th Thresholds) ([]Finding, error) {
+	latest, err := store.ListProvidersWithLastSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	now := time.Now()
+	for provider, fetchedAt := range latest {
+		age := now.Sub(fetchedAt)
+		if age < th.StaleAfter {
+			continue
+		}
+		findings = append(findings, Finding{
+			Provider: provider,
+			Type:     TypeStaleSnapshot,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s: latest snapshot is %s old (fetched %s)", provider, age.Round(time.Minute), fetchedAt.Format(time.RFC3339)),
+			Data:     map[string]interface{}{"fetched_at": fetchedAt, "age_seconds": age.Seconds()},
+		})
+	}
+	return findings, nil
+}
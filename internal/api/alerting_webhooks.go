@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/alerting"
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterAlertingRoutes wires the alerting package's event subscription
+// registry onto mux: admin-gated CRUD and ping under
+// /api/v1/alerts/webhooks, and an unauthenticated
+// /internal/alerts/webhooks/dead dead-letter query, matching the
+// /internal/ operational-endpoint convention RegisterRefreshHandler and
+// RegisterExtractHandler already use. It builds and owns the
+// *alerting.Alerter this process broadcasts Events through; on a backend
+// implementing storage.AlertingStore its subscriptions and dead letters
+// persist across restarts, otherwise they're in-memory/log-only for the
+// life of this process (see alerting.NewAlerter).
+func RegisterAlertingRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	alerter := alerting.NewAlerter(alerting.DefaultAlertConfig(), st)
+
+	mux.Handle(opts.mount("/api/v1/alerts/webhooks"), authSvc.Middleware(handleAlertWebhookCollection(alerter, authSvc)))
+	mux.Handle(opts.mount("/api/v1/alerts/webhooks/"), authSvc.Middleware(handleAlertWebhookItem(opts, alerter, authSvc)))
+
+	mux.HandleFunc(opts.mount("/internal/alerts/webhooks/dead"), handleAlertWebhookDeadLetters(alerter))
+}
+
+// alertWebhookRequest is the JSON body accepted by POST
+// /api/v1/alerts/webhooks.
+type alertWebhookRequest struct {
+	URL         string   `json:"url"`
+	HeaderKey   string   `json:"header_key,omitempty"`
+	HeaderValue string   `json:"header_value,omitempty"`
+	EventFilter []string `json:"event_filter,omitempty"`
+	WebhookType string   `json:"webhook_type,omitempty"`
+}
+
+// handleAlertWebhookCollection serves GET (list) and POST (register) on
+// /api/v1/alerts/webhooks, requiring alert-webhooks:read / :write
+// respectively.
+func handleAlertWebhookCollection(alerter *alerting.Alerter, authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			allowed, err := authSvc.Enforce(getUserID(r), "alert-webhooks", "read")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			subs, err := alerter.List(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, subs)
+
+		case http.MethodPost:
+			allowed, err := authSvc.Enforce(getUserID(r), "alert-webhooks", "write")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			var req alertWebhookRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			id, err := alerter.Register(r.Context(), alerting.Subscription{
+				URL:         req.URL,
+				HeaderKey:   req.HeaderKey,
+				HeaderValue: req.HeaderValue,
+				EventFilter: req.EventFilter,
+				WebhookType: req.WebhookType,
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, alerter.Get(id))
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleAlertWebhookItem serves GET, DELETE and POST .../ping on
+// /api/v1/alerts/webhooks/{id}.
+func handleAlertWebhookItem(opts Options, alerter *alerting.Alerter, authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, opts.mount("/api/v1/alerts/webhooks/"))
+		parts := strings.Split(path, "/")
+		id := parts[0]
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if len(parts) == 2 && parts[1] == "ping" {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			allowed, err := authSvc.Enforce(getUserID(r), "alert-webhooks", "write")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			if err := alerter.Ping(r.Context(), id); err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			allowed, err := authSvc.Enforce(getUserID(r), "alert-webhooks", "read")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			sub := alerter.Get(id)
+			if sub == nil {
+				http.NotFound(w, r)
+				return
+			}
+			writeJSON(w, sub)
+
+		case http.MethodDelete:
+			allowed, err := authSvc.Enforce(getUserID(r), "alert-webhooks", "write")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			if err := alerter.Delete(r.Context(), id); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleAlertWebhookDeadLetters serves GET /internal/alerts/webhooks/dead,
+// unauthenticated like the other /internal/ operational endpoints.
+func handleAlertWebhookDeadLetters(alerter *alerting.Alerter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		letters, err := alerter.DeadLetters(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, letters)
+	}
+}
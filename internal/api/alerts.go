@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// alertStore is satisfied by the storage backends that persist alerts
+// (SQLiteStorage, PostgresStorage, MemoryStorage).
+type alertStore interface {
+	ListAlerts(ctx context.Context, provider string, includeDismissed bool) ([]storage.Alert, error)
+	DismissAlert(ctx context.Context, id string) error
+}
+
+// AlertHandler serves list/filter/dismiss endpoints for rate-change alerts.
+type AlertHandler struct {
+	opts    Options
+	store   alertStore
+	authSvc *auth.Service
+}
+
+// RegisterAlertRoutes wires /api/v2/alerts onto mux, gated by authSvc when
+// one is configured. st must implement alertStore or the routes respond 501.
+func RegisterAlertRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	as, _ := st.(alertStore)
+	h := &AlertHandler{opts: opts, store: as, authSvc: authSvc}
+
+	withAuth := func(handler http.HandlerFunc) http.Handler {
+		if authSvc == nil {
+			return handler
+		}
+		return authSvc.Middleware(handler)
+	}
+
+	mux.Handle(opts.mount("/api/v2/alerts"), withAuth(h.handleList))
+	mux.Handle(opts.mount("/api/v2/alerts/"), withAuth(h.handleItem))
+}
+
+func (h *AlertHandler) enforce(r *http.Request, act string) bool {
+	if h.authSvc == nil {
+		return true
+	}
+	allowed, err := h.authSvc.Enforce(getUserID(r), "alerts", act)
+	return err == nil && allowed
+}
+
+func (h *AlertHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "alerts not supported by the configured storage backend", http.StatusNotImplemented)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.enforce(r, "read") {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	provider := r.URL.Query().Get("provider")
+	includeDismissed, _ := strconv.ParseBool(r.URL.Query().Get("include_dismissed"))
+
+	list, err := h.store.ListAlerts(r.Context(), provider, includeDismissed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, list)
+}
+
+func (h *AlertHandler) handleItem(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "alerts not supported by the configured storage backend", http.StatusNotImplemented)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, h.opts.mount("/api/v2/alerts/"))
+	parts := strings.Split(path, "/")
+	id := parts[0]
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) != 2 || parts[1] != "dismiss" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.enforce(r, "write") {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := h.store.DismissAlert(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// auditStore is satisfied by the storage backends that persist the
+// hash-chained audit log (currently only GormStorage).
+type auditStore interface {
+	ListAuditEvents(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEvent, error)
+}
+
+// RegisterAuditRoutes wires /api/v1/audit onto mux: GET lists events,
+// narrowed by ?actor=&action=&since=&until=&limit=, requiring the caller
+// hold audit:read. Passing ?format=csv returns the same rows as a CSV
+// download instead of JSON. It's a no-op when st doesn't implement
+// auditStore.
+func RegisterAuditRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	as, ok := st.(auditStore)
+	if !ok {
+		return
+	}
+
+	mux.Handle(opts.mount("/api/v1/audit"), authSvc.Middleware(handleAuditList(authSvc, as)))
+}
+
+// handleAuditList serves GET /api/v1/audit?actor=&action=&since=&until=&limit=&format=
+// where since/until are RFC3339 timestamps, limit is an integer, and format
+// is "json" (default) or "csv". All are optional.
+func handleAuditList(authSvc *auth.Service, store auditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		allowed, err := authSvc.Enforce(getUserID(r), "audit", "read")
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		filter := storage.AuditFilter{
+			Actor:  r.URL.Query().Get("actor"),
+			Action: r.URL.Query().Get("action"),
+		}
+		filter.Since, err = parseOptionalRFC3339(r.URL.Query().Get("since"))
+		if err != nil {
+			http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Until, err = parseOptionalRFC3339(r.URL.Query().Get("until"))
+		if err != nil {
+			http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			filter.Limit, err = strconv.Atoi(raw)
+			if err != nil {
+				http.Error(w, "invalid limit: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		events, err := store.ListAuditEvents(r.Context(), filter)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "csv" {
+			writeAuditCSV(w, events)
+			return
+		}
+		writeJSON(w, events)
+	}
+}
+
+// writeAuditCSV writes events as a CSV download: one header row followed
+// by one row per event, in the same field order as storage.AuditEvent.
+func writeAuditCSV(w http.ResponseWriter, events []storage.AuditEvent) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "timestamp", "actor", "action", "success", "target", "resource", "detail", "ip", "user_agent", "prev_hash", "hash"})
+	for _, e := range events {
+		_ = cw.Write([]string{
+			e.ID,
+			e.Timestamp.Format(time.RFC3339),
+			e.Actor,
+			e.Action,
+			strconv.FormatBool(e.Success),
+			e.Target,
+			e.Resource,
+			e.Detail,
+			e.IP,
+			e.UserAgent,
+			e.PrevHash,
+			e.Hash,
+		})
+	}
+	cw.Flush()
+}
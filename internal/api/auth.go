@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterAuthRoutes wires the session endpoints backing IssueSession's
+// access/refresh token pair onto mux: refreshing a pair and revoking
+// tokens. Login itself is out of scope here - it's whatever credential
+// check (password, SSO, ...) the caller already has, which should call
+// authSvc.IssueSession once it succeeds.
+func RegisterAuthRoutes(mux *http.ServeMux, opts Options, authSvc *auth.Service) {
+	mux.HandleFunc(opts.mount("/auth/refresh"), handleAuthRefresh(authSvc))
+	mux.Handle(opts.mount("/auth/logout"), authSvc.Middleware(handleAuthLogout(authSvc)))
+	mux.Handle(opts.mount("/auth/revoke-all"), authSvc.Middleware(handleAuthRevokeAll(authSvc)))
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// handleAuthRefresh exchanges a refresh token for a new access/refresh
+// pair. Unlike the other routes here it isn't gated by Middleware: the
+// refresh token itself, not a Bearer access token, is the credential.
+func handleAuthRefresh(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req refreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+			http.Error(w, "refresh_token is required", http.StatusBadRequest)
+			return
+		}
+
+		session, err := authSvc.RefreshSession(r.Context(), req.RefreshToken)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrTokenExpired):
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="refresh token expired"`)
+				http.Error(w, "refresh token expired", http.StatusUnauthorized)
+			case errors.Is(err, auth.ErrRefreshTokenReused):
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="refresh token reused"`)
+				http.Error(w, "refresh token reused, session revoked", http.StatusUnauthorized)
+			case errors.Is(err, auth.ErrTokenInvalid):
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="refresh token unknown"`)
+				http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, session)
+	}
+}
+
+// handleAuthLogout revokes the access token the caller authenticated with,
+// so it can no longer be used. Callers that want to sign out everywhere
+// should use /auth/revoke-all instead.
+func handleAuthLogout(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		token, ok := r.Context().Value(auth.TokenContextKey).(*storage.Token)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := authSvc.RevokeToken(r.Context(), token.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleAuthRevokeAll revokes every outstanding token for the caller,
+// signing them out everywhere.
+func handleAuthRevokeAll(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := authSvc.RevokeAllForUser(r.Context(), userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
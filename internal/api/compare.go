@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/rates"
+)
+
+// RegisterCompareHandler wires /compare/electric?kwh=<n> and
+// /compare/water?gallons=<n> into mux, ranking every registered provider's
+// estimated monthly bill for the given usage cheapest first.
+func RegisterCompareHandler(mux *http.ServeMux, opts Options, svc *rates.Service) {
+	cmp := rates.NewComparator(svc)
+
+	mux.HandleFunc(opts.mount("/compare/electric"), func(w http.ResponseWriter, r *http.Request) {
+		labelsPath := "/compare/electric"
+		kwh, err := parsePositiveFloat(r.URL.Query().Get("kwh"))
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues("compare", labelsPath, "400").Inc()
+			http.Error(w, "invalid or missing kwh: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		metrics.RequestsTotal.WithLabelValues("compare").Inc()
+
+		costs, err := cmp.CompareElectricProviders(r.Context(), kwh)
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues("compare", labelsPath, "500").Inc()
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, costs)
+	})
+
+	mux.HandleFunc(opts.mount("/compare/water"), func(w http.ResponseWriter, r *http.Request) {
+		labelsPath := "/compare/water"
+		gallons, err := parsePositiveFloat(r.URL.Query().Get("gallons"))
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues("compare", labelsPath, "400").Inc()
+			http.Error(w, "invalid or missing gallons: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		metrics.RequestsTotal.WithLabelValues("compare").Inc()
+
+		costs, err := cmp.CompareWaterProviders(r.Context(), gallons)
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues("compare", labelsPath, "500").Inc()
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, costs)
+	})
+}
+
+// parsePositiveFloat parses raw as a float64 greater than zero, the shape
+// every /compare query parameter (kwh, gallons) needs.
+func parsePositiveFloat(raw string) (float64, error) {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, strconv.ErrRange
+	}
+	return v, nil
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterCronHandler wires GET /cron/jobs into mux, listing every
+// registered scheduled job's cron expression, last run outcome, and next
+// scheduled run. It's a no-op when st doesn't implement storage.CronJobStore
+// (e.g. SQLite, which runs no distributed scheduler).
+func RegisterCronHandler(mux *http.ServeMux, opts Options, st storage.Storage) {
+	store, ok := st.(storage.CronJobStore)
+	if !ok {
+		return
+	}
+
+	mux.HandleFunc(opts.mount("/cron/jobs"), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		jobs, err := store.ListCronJobs(r.Context())
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		response := struct {
+			Jobs []storage.CronJobStatus `json:"jobs"`
+		}{Jobs: jobs}
+		writeJSON(w, response)
+	})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/rates"
+	"github.com/bher20/eratemanager/pkg/providers/shared"
+)
+
+// extractorSpecDir is where RegisterExtractHandler looks up a provider's
+// declarative rates.ExtractorSpec, matching the path rates.RunExtractors'
+// doc comment and the config layout ships under.
+const extractorSpecDir = "configs/extractors"
+
+// RegisterExtractHandler wires /internal/extract/{provider}, which runs
+// that provider's declarative rates.ExtractorSpec (configs/extractors/{provider}.json)
+// against an uploaded PDF/HTML body and returns the structured
+// rates.ExtractReport, letting someone debug a layout drift or try out a
+// config edit without redeploying the provider's PDFExtractor.
+func RegisterExtractHandler(mux *http.ServeMux, opts Options) {
+	mux.HandleFunc(opts.mount("/internal/extract/"), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		providerKey := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, opts.mount("/internal/extract/")), "/"))
+		if providerKey == "" {
+			http.NotFound(w, r)
+			return
+		}
+		labelsPath := "/internal/extract"
+		metrics.RequestsTotal.WithLabelValues(providerKey).Inc()
+
+		specPath := filepath.Join(extractorSpecDir, providerKey+".json")
+		spec, err := rates.LoadExtractorSpec(specPath)
+		if err != nil {
+			log.Printf("extract %s: load spec failed: %v", providerKey, err)
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "404").Inc()
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+			http.Error(w, "read body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		text := string(body)
+		if spec.Format == "pdf" {
+			text, err = extractUploadedPDFText(body)
+			if err != nil {
+				log.Printf("extract %s: pdf text extraction failed: %v", providerKey, err)
+				metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+				http.Error(w, "extract pdf text: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		report := rates.RunExtractorSpec(spec, text)
+		writeJSON(w, report)
+	})
+}
+
+// extractUploadedPDFText spills body to a temp file since
+// shared.ExtractPDFText reads from a path, then removes it once parsed.
+func extractUploadedPDFText(body []byte) (string, error) {
+	f, err := os.CreateTemp("", "extract-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	return shared.ExtractPDFText(f.Name())
+}
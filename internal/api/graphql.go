@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/graphql"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterGraphQLRoutes wires a POST /graphql endpoint (see the graphql
+// package) onto mux, mirroring the same storage.Storage the REST handlers
+// already read through so both interfaces stay consistent with a single
+// write path. enablePlayground additionally serves a minimal query console
+// on GET /graphql, gated separately from the endpoint itself so a
+// deployment can expose the API without handing out a browser UI.
+func RegisterGraphQLRoutes(mux *http.ServeMux, opts Options, st storage.Storage, enablePlayground bool) {
+	gqlHandler := graphql.Handler(st)
+	if !enablePlayground {
+		mux.Handle(opts.mount("/graphql"), gqlHandler)
+		return
+	}
+
+	playground := graphql.PlaygroundHandler()
+	mux.Handle(opts.mount("/graphql"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			playground.ServeHTTP(w, r)
+			return
+		}
+		gqlHandler.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterHistoryHandler wires GET /api/v1/history/{provider}?from=&to=&field=
+// into mux, serving a provider's per-field rate history as JSON for
+// charting. It's a no-op when st doesn't implement storage.RateHistory (e.g.
+// the in-memory backend before a refresh has ever populated it), same as
+// RegisterSnapshotsHandler.
+func RegisterHistoryHandler(mux *http.ServeMux, opts Options, st storage.Storage) {
+	hist, ok := st.(storage.RateHistory)
+	if !ok {
+		return
+	}
+
+	mux.HandleFunc(opts.mount("/api/v1/history/"), func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		providerKey := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, opts.mount("/api/v1/history/")), "/"))
+		if providerKey == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		labelsPath := "/api/v1/history"
+		defer func() {
+			metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(time.Since(start).Seconds())
+		}()
+		metrics.RequestsTotal.WithLabelValues(providerKey).Inc()
+
+		from, err := parseOptionalRFC3339(r.URL.Query().Get("from"))
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+			http.Error(w, "invalid from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseOptionalRFC3339(r.URL.Query().Get("to"))
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+			http.Error(w, "invalid to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields := r.URL.Query()["field"]
+
+		points, err := hist.Query(r.Context(), providerKey, from, to, fields...)
+		if err != nil {
+			log.Printf("query rate history for %s failed: %v", providerKey, err)
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "500").Inc()
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, points)
+	})
+}
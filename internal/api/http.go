@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,15 +14,47 @@ import (
 
 	"context"
 
+	"github.com/bher20/eratemanager/internal/api/swagger"
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/logging"
 	"github.com/bher20/eratemanager/internal/metrics"
 	migrate "github.com/bher20/eratemanager/internal/migrate"
+	"github.com/bher20/eratemanager/internal/notification"
 	"github.com/bher20/eratemanager/internal/rates"
 	"github.com/bher20/eratemanager/internal/storage"
 	"github.com/bher20/eratemanager/internal/ui"
 )
 
-// NewMux constructs the HTTP mux, wiring in the rates service, metrics, and health endpoints.
-func NewMux() *http.ServeMux {
+// blobConfigFromEnv builds a storage.BlobConfig from ERATEMANAGER_BLOB_*
+// environment variables. An empty ERATEMANAGER_BLOB_DRIVER disables blob
+// offload, leaving rates snapshots stored inline (the default).
+func blobConfigFromEnv() storage.BlobConfig {
+	return storage.BlobConfig{
+		Driver:    os.Getenv("ERATEMANAGER_BLOB_DRIVER"),
+		Endpoint:  os.Getenv("ERATEMANAGER_BLOB_ENDPOINT"),
+		Bucket:    os.Getenv("ERATEMANAGER_BLOB_BUCKET"),
+		AccessKey: os.Getenv("ERATEMANAGER_BLOB_ACCESS_KEY"),
+		SecretKey: os.Getenv("ERATEMANAGER_BLOB_SECRET_KEY"),
+		UseSSL:    strings.ToLower(os.Getenv("ERATEMANAGER_BLOB_USE_SSL")) == "true",
+		BaseDir:   os.Getenv("ERATEMANAGER_BLOB_BASE_DIR"),
+	}
+}
+
+// NewMux constructs the HTTP handler mounted at the root, matching behavior
+// before Options existed. See NewMuxWithOptions.
+func NewMux() http.Handler {
+	return NewMuxWithOptions(Options{})
+}
+
+// NewMuxWithOptions constructs the HTTP handler, wiring in the rates
+// service, metrics, health endpoints, and request-ID/logging middleware.
+// opts lets every route mount under a configured PathPrefix (e.g. to run
+// behind a reverse proxy at https://ops.example.com/tools/rates/) instead of
+// the root. The returned http.Handler wraps a *http.ServeMux, so it plugs
+// directly into http.ListenAndServe.
+func NewMuxWithOptions(opts Options) http.Handler {
+	logging.SetDefault(logging.FromEnv())
+
 	// Build PDF paths map from environment variables and provider defaults
 	pdfPaths := make(map[string]string)
 	for _, p := range rates.Providers() {
@@ -75,7 +108,7 @@ func NewMux() *http.ServeMux {
 		st = storage.NewMemoryWithProviders(pList)
 		err = nil
 	} else {
-		st, err = storage.Open(ctxSvc, storage.Config{Driver: driver, DSN: dsn})
+		st, err = storage.Open(ctxSvc, storage.Config{Driver: driver, DSN: dsn, Blob: blobConfigFromEnv()})
 	}
 	if err != nil {
 		log.Printf("storage.Open failed (driver=%s dsn=%s): %v; falling back to PDF-only mode", driver, dsn, err)
@@ -85,17 +118,49 @@ func NewMux() *http.ServeMux {
 		svc = rates.NewServiceWithStorage(cfg, st)
 	}
 
+	if sink, err := rates.SinksFromEnv(); err != nil {
+		log.Printf("rates: configure sinks failed: %v", err)
+	} else if sink != nil {
+		svc.SetSink(sink)
+	}
+
+	// Optional bulk tariff import: ERATEMANAGER_TARIFF_CSV_PATH points at an
+	// OpenEI URDB-style CSV (see rates.CSVTariffImporter) whose rows become
+	// providers, servable immediately without a per-provider Go parser.
+	if csvPath := os.Getenv("ERATEMANAGER_TARIFF_CSV_PATH"); csvPath != "" {
+		imp := rates.NewCSVTariffImporterWithStorage(st)
+		if imported, err := imp.ImportFile(ctxSvc, csvPath); err != nil {
+			log.Printf("tariff csv import from %s failed: %v", csvPath, err)
+		} else {
+			log.Printf("tariff csv import from %s: imported %d tariff(s)", csvPath, len(imported))
+		}
+	}
+
+	// authSvc gates /internal/refresh/{provider} with auth.RequireScope. It
+	// requires st to implement both storage.CasbinStore and
+	// storage.AuthStore; on a backend that doesn't (e.g. driver=="memory"),
+	// auth.NewService returns an error and authSvc stays nil, leaving the
+	// route open the same way a nil authSvc always has.
+	var authSvc *auth.Service
+	if notifSvc, err := notification.NewService(st); err != nil {
+		log.Printf("auth: notification service unavailable, API tokens disabled: %v", err)
+	} else if svc, err := auth.NewService(st, notifSvc, os.Getenv("ERATEMANAGER_PUBLIC_URL")); err != nil {
+		log.Printf("auth: storage backend does not support API tokens, /internal/refresh left open: %v", err)
+	} else {
+		authSvc = svc
+	}
+
 	mux := http.NewServeMux()
 
 	// Metrics endpoint.
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle(opts.mount("/metrics"), promhttp.Handler())
 
 	// Health / readiness / liveness.
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(opts.mount("/healthz"), func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(opts.mount("/readyz"), func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		drv := os.Getenv("ERATEMANAGER_DB_DRIVER")
 		dsn := os.Getenv("ERATEMANAGER_DB_DSN")
@@ -120,41 +185,74 @@ func NewMux() *http.ServeMux {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ready"))
 	})
-	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(opts.mount("/livez"), func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("live"))
 	})
 
 	// Rates API.
-	mux.HandleFunc("/rates/", handleRates(svc))
+	mux.HandleFunc(opts.mount("/rates/"), handleRates(opts, svc))
 
 	// Internal refresh endpoint for CronJobs / manual refresh.
-	RegisterRefreshHandler(mux, st)
-	RegisterProvidersHandler(mux)
+	RegisterRefreshHandler(mux, opts, st, authSvc)
+	RegisterProvidersHandler(mux, opts, st)
+	RegisterSnapshotsHandler(mux, opts, st)
+	RegisterHistoryHandler(mux, opts, st)
+	RegisterCronHandler(mux, opts, st)
+	RegisterCompareHandler(mux, opts, svc)
+	RegisterExtractHandler(mux, opts)
+
+	// GraphQL is opt-in: it's a second, broader read surface over the same
+	// storage.Storage the REST handlers above already use, so a deployment
+	// has to explicitly ask for it rather than getting it by default.
+	if graphqlEnabled := os.Getenv("ERATEMANAGER_GRAPHQL_ENABLED"); graphqlEnabled == "1" || strings.ToLower(graphqlEnabled) == "true" {
+		playground := os.Getenv("ERATEMANAGER_GRAPHQL_PLAYGROUND")
+		RegisterGraphQLRoutes(mux, opts, st, playground == "1" || strings.ToLower(playground) == "true")
+	}
+
+	// API documentation (Swagger UI + OpenAPI spec).
+	mux.Handle(opts.mount("/swagger/"), http.StripPrefix(opts.mount("/swagger/"), swagger.Handler()))
 
 	// Web UI
-	mux.Handle("/ui/", http.StripPrefix("/ui/", ui.Handler()))
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
+	mux.Handle(opts.mount("/ui/"), http.StripPrefix(opts.mount("/ui/"), ui.Handler()))
+	mux.HandleFunc(opts.mount("/"), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != opts.mount("/") && r.URL.Path != opts.normalizedPrefix() {
 			http.NotFound(w, r)
 			return
 		}
-		http.Redirect(w, r, "/ui/", http.StatusFound)
+		http.Redirect(w, r, opts.linkPrefix(r)+"/ui/", http.StatusFound)
 	})
 
-	return mux
+	return loggingMiddleware(mux)
 }
 
-// handleRates returns a handler that serves /rates/{provider}/residential and /rates/{provider}/pdf using the rates.Service.
-func handleRates(svc *rates.Service) http.HandlerFunc {
+// NewServer builds an *http.Server bound to addr and backed by NewMux, so
+// callers (cmd/eratemanager) can drain in-flight requests on shutdown via
+// Shutdown(ctx) instead of killing listeners outright, mirroring the
+// in-flight draining cron.Scheduler does for scheduled jobs.
+func NewServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:    addr,
+		Handler: NewMux(),
+	}
+}
+
+// handleRates returns a handler that serves /rates/{provider}/residential,
+// /rates/{provider}/water, /rates/{provider}/pdf, /rates/{provider}/refresh,
+// and /rates/{provider}/history using the rates.Service. residential and
+// water additionally accept an ?asof=<RFC3339> query param to look up rates
+// as they stood at a past instant, via the service's snapshot history.
+func handleRates(opts Options, svc *rates.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		requestID := logging.RequestIDFromContext(r.Context())
 
 		// Expected paths: /rates/{provider}/residential or /rates/{provider}/pdf
-		path := strings.TrimPrefix(r.URL.Path, "/")
+		path := strings.TrimPrefix(r.URL.Path, opts.mount("/"))
+		path = strings.TrimPrefix(path, "/")
 		parts := strings.Split(path, "/")
 		if len(parts) != 3 || parts[0] != "rates" {
-			metrics.RequestErrorsTotal.WithLabelValues("unknown", r.URL.Path, "404").Inc()
+			metrics.IncRequestErrorsTotal("unknown", r.URL.Path, "404", requestID)
 			http.NotFound(w, r)
 			return
 		}
@@ -169,18 +267,18 @@ func handleRates(svc *rates.Service) http.HandlerFunc {
 				dur := time.Since(start).Seconds()
 				metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(dur)
 			}()
-			metrics.RequestsTotal.WithLabelValues(providerKey).Inc()
+			metrics.IncRequestsTotal(providerKey, requestID)
 
 			p, ok := rates.GetProvider(providerKey)
 			if !ok {
-				metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "404").Inc()
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "404", requestID)
 				http.NotFound(w, r)
 				return
 			}
 
 			pdfPath := p.DefaultPDFPath
 			if pdfPath == "" {
-				metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "404").Inc()
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "404", requestID)
 				http.Error(w, "no PDF configured for this provider", http.StatusNotFound)
 				return
 			}
@@ -191,9 +289,126 @@ func handleRates(svc *rates.Service) http.HandlerFunc {
 			return
 		}
 
+		// Handle history queries: /rates/{provider}/history?since=&until=&limit=
+		if endpoint == "history" {
+			labelsPath := "/rates/history"
+			defer func() {
+				dur := time.Since(start).Seconds()
+				metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(dur)
+			}()
+			metrics.IncRequestsTotal(providerKey, requestID)
+
+			since, err := parseOptionalRFC3339(r.URL.Query().Get("since"))
+			if err != nil {
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "400", requestID)
+				http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			until, err := parseOptionalRFC3339(r.URL.Query().Get("until"))
+			if err != nil {
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "400", requestID)
+				http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			limit := 0
+			if raw := r.URL.Query().Get("limit"); raw != "" {
+				limit, err = strconv.Atoi(raw)
+				if err != nil {
+					metrics.IncRequestErrorsTotal(providerKey, labelsPath, "400", requestID)
+					http.Error(w, "invalid limit: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			history, err := svc.ListResidentialHistory(r.Context(), providerKey, since, until, limit)
+			if err != nil {
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "500", requestID)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(history); err != nil {
+				log.Printf("encode response failed: %v", err)
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "500", requestID)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		// Handle water rates
+		if endpoint == "water" {
+			labelsPath := "/rates/water"
+			defer func() {
+				dur := time.Since(start).Seconds()
+				metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(dur)
+			}()
+			metrics.IncRequestsTotal(providerKey, requestID)
+
+			var resp *rates.WaterRatesResponse
+			var err error
+			if asof := r.URL.Query().Get("asof"); asof != "" {
+				t, parseErr := time.Parse(time.RFC3339, asof)
+				if parseErr != nil {
+					metrics.IncRequestErrorsTotal(providerKey, labelsPath, "400", requestID)
+					http.Error(w, "invalid asof: "+parseErr.Error(), http.StatusBadRequest)
+					return
+				}
+				resp, err = svc.GetWaterAsOf(r.Context(), providerKey, t)
+			} else {
+				resp, err = svc.GetWater(r.Context(), providerKey)
+			}
+			if err != nil {
+				logging.WithRequestID(logging.WithProvider(logging.Default(), providerKey), requestID).
+					ErrorContext(r.Context(), "get water rates failed", "error", err)
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "500", requestID)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				log.Printf("encode response failed: %v", err)
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "500", requestID)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		// Handle landing-page refresh: conditionally re-fetches the
+		// provider's landing page and reports whether its content changed,
+		// without re-parsing rates (callers decide whether that's worth
+		// doing based on Changed).
+		if endpoint == "refresh" {
+			labelsPath := "/rates/refresh"
+			defer func() {
+				dur := time.Since(start).Seconds()
+				metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(dur)
+			}()
+			metrics.IncRequestsTotal(providerKey, requestID)
+
+			result, err := svc.RefreshLandingPage(r.Context(), providerKey)
+			if err != nil {
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "500", requestID)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(result); err != nil {
+				log.Printf("encode response failed: %v", err)
+				metrics.IncRequestErrorsTotal(providerKey, labelsPath, "500", requestID)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
 		// Handle residential rates
 		if endpoint != "residential" {
-			metrics.RequestErrorsTotal.WithLabelValues("unknown", r.URL.Path, "404").Inc()
+			metrics.IncRequestErrorsTotal("unknown", r.URL.Path, "404", requestID)
 			http.NotFound(w, r)
 			return
 		}
@@ -206,12 +421,25 @@ func handleRates(svc *rates.Service) http.HandlerFunc {
 			metrics.RequestDurationSeconds.WithLabelValues(labelsProvider, labelsPath).Observe(dur)
 		}()
 
-		metrics.RequestsTotal.WithLabelValues(labelsProvider).Inc()
+		metrics.IncRequestsTotal(labelsProvider, requestID)
 
-		resp, err := svc.GetResidential(r.Context(), providerKey)
+		var resp *rates.RatesResponse
+		var err error
+		if asof := r.URL.Query().Get("asof"); asof != "" {
+			t, parseErr := time.Parse(time.RFC3339, asof)
+			if parseErr != nil {
+				metrics.IncRequestErrorsTotal(labelsProvider, labelsPath, "400", requestID)
+				http.Error(w, "invalid asof: "+parseErr.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err = svc.GetResidentialAsOf(r.Context(), providerKey, t)
+		} else {
+			resp, err = svc.GetResidential(r.Context(), providerKey)
+		}
 		if err != nil {
-			log.Printf("get residential rates for %s failed: %v", providerKey, err)
-			metrics.RequestErrorsTotal.WithLabelValues(labelsProvider, labelsPath, "500").Inc()
+			logging.WithRequestID(logging.WithProvider(logging.Default(), providerKey), requestID).
+				ErrorContext(r.Context(), "get residential rates failed", "error", err)
+			metrics.IncRequestErrorsTotal(labelsProvider, labelsPath, "500", requestID)
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
@@ -219,7 +447,7 @@ func handleRates(svc *rates.Service) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			log.Printf("encode response failed: %v", err)
-			metrics.RequestErrorsTotal.WithLabelValues(labelsProvider, labelsPath, "500").Inc()
+			metrics.IncRequestErrorsTotal(labelsProvider, labelsPath, "500", requestID)
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
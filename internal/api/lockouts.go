@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterLockoutRoutes wires /api/v1/auth/lockouts onto mux, admin-gated
+// like /api/v1/audit: GET lists every currently locked-out login-throttle
+// key and DELETE clears one, both requiring the caller hold lockouts:read
+// or lockouts:clear respectively. It's a no-op when st doesn't implement
+// storage.LoginAttemptStore.
+func RegisterLockoutRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	if _, ok := st.(storage.LoginAttemptStore); !ok {
+		return
+	}
+
+	mux.Handle(opts.mount("/api/v1/auth/lockouts"), authSvc.Middleware(handleLockoutList(authSvc)))
+	mux.Handle(opts.mount("/api/v1/auth/lockouts/"), authSvc.Middleware(handleLockoutItem(opts, authSvc)))
+}
+
+// handleLockoutList serves GET /api/v1/auth/lockouts.
+func handleLockoutList(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		allowed, err := authSvc.Enforce(getUserID(r), "lockouts", "read")
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		lockouts, err := authSvc.ListLockouts(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, lockouts)
+	}
+}
+
+// handleLockoutItem serves DELETE /api/v1/auth/lockouts/{key}, clearing a
+// single throttle key before loginLockoutWindow elapses on its own.
+func handleLockoutItem(opts Options, authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		allowed, err := authSvc.Enforce(getUserID(r), "lockouts", "clear")
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, opts.mount("/api/v1/auth/lockouts/"))
+		if key == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := authSvc.ClearLockout(r.Context(), key); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/auth"
+)
+
+// RegisterMFARoutes wires the TOTP second-factor endpoints (enroll, verify,
+// disable) onto mux, gated behind authSvc.Middleware the same as
+// /auth/logout and /auth/revoke-all. Authenticating with the second factor
+// itself is out of scope here - it's whatever login handler the caller has,
+// which should call authSvc.AuthenticateWithMFA instead of Authenticate.
+func RegisterMFARoutes(mux *http.ServeMux, opts Options, authSvc *auth.Service) {
+	mux.Handle(opts.mount("/mfa/enroll"), authSvc.Middleware(handleMFAEnroll(authSvc)))
+	mux.Handle(opts.mount("/mfa/verify"), authSvc.Middleware(handleMFAVerify(authSvc)))
+	mux.Handle(opts.mount("/mfa/disable"), authSvc.Middleware(handleMFADisable(authSvc)))
+}
+
+type mfaOTPRequest struct {
+	OTP string `json:"otp"`
+}
+
+// handleMFAEnroll starts TOTP enrollment for the caller, returning the new
+// secret, its otpauth:// provisioning URI, and a set of recovery codes.
+// TOTP isn't enforced until handleMFAVerify confirms the authenticator app
+// is in sync.
+func handleMFAEnroll(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		result, err := authSvc.EnrollMFA(r.Context(), userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, result)
+	}
+}
+
+// handleMFAVerify confirms the caller's authenticator app against the
+// pending secret from handleMFAEnroll, enabling second-factor enforcement
+// on success.
+func handleMFAVerify(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req mfaOTPRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OTP == "" {
+			http.Error(w, "otp is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := authSvc.VerifyMFAEnrollment(r.Context(), userID, req.OTP); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleMFADisable turns off TOTP enforcement for the caller.
+func handleMFADisable(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := authSvc.DisableMFA(r.Context(), userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
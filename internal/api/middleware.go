@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bher20/eratemanager/internal/logging"
+)
+
+// requestIDHeader is generated (or propagated, if the caller already set
+// one) by loggingMiddleware so a request can be correlated across the API,
+// cron, and rates logs.
+const requestIDHeader = "X-Request-ID"
+
+// statusRecorder captures the status code written by the wrapped handler,
+// since http.ResponseWriter has no getter for it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// loggingMiddleware generates or propagates an X-Request-ID header, stashes
+// it in the request context (retrievable via logging.RequestIDFromContext
+// and passed through to rates.Service), and logs
+// method/path/status/duration once the handler returns.
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := logging.ContextWithRequestID(r.Context(), requestID)
+		r = r.WithContext(ctx)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		logging.WithRequestID(logging.Default(), requestID).InfoContext(ctx, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start),
+		)
+	})
+}
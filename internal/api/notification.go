@@ -3,14 +3,15 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/bher20/eratemanager/internal/auth"
 	"github.com/bher20/eratemanager/internal/notification"
 	"github.com/bher20/eratemanager/internal/storage"
 )
 
-func registerNotificationRoutes(mux *http.ServeMux, authSvc *auth.Service, notifSvc *notification.Service) {
-	mux.Handle("/api/v1/settings/email", authSvc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+func registerNotificationRoutes(mux *http.ServeMux, opts Options, authSvc *auth.Service, notifSvc *notification.Service) {
+	mux.Handle(opts.mount("/api/v1/settings/email"), authSvc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token, ok := r.Context().Value(auth.TokenContextKey).(*storage.Token)
 		if !ok {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -71,7 +72,74 @@ func registerNotificationRoutes(mux *http.ServeMux, authSvc *auth.Service, notif
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	})))
 
-	mux.Handle("/api/v1/settings/email/test", authSvc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle(opts.mount("/api/v1/settings/notifications/"), authSvc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := r.Context().Value(auth.TokenContextKey).(*storage.Token)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		channel := strings.TrimPrefix(r.URL.Path, opts.mount("/api/v1/settings/notifications/"))
+		if channel == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			allowed, err := authSvc.Enforce(token.UserID, "settings", "read")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			cfg, err := notifSvc.GetChannelConfig(r.Context(), channel)
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if cfg == nil {
+				cfg = &storage.NotificationChannelConfig{ID: channel}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(cfg)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			allowed, err := authSvc.Enforce(token.UserID, "settings", "write")
+			if err != nil {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			var req storage.NotificationChannelConfig
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			req.ID = channel
+
+			if err := notifSvc.SaveChannelConfig(r.Context(), req); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	})))
+
+	mux.Handle(opts.mount("/api/v1/settings/email/test"), authSvc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token, ok := r.Context().Value(auth.TokenContextKey).(*storage.Token)
 		if !ok {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -94,15 +162,19 @@ func registerNotificationRoutes(mux *http.ServeMux, authSvc *auth.Service, notif
 		}
 
 		var req struct {
-			Config storage.EmailConfig `json:"config"`
-			To     string              `json:"to"`
+			Channel string          `json:"channel"`
+			Config  json.RawMessage `json:"config"`
+			To      string          `json:"to"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
+		if req.Channel == "" {
+			req.Channel = "email"
+		}
 
-		if err := notifSvc.TestConfig(r.Context(), req.Config, req.To); err != nil {
+		if err := notifSvc.TestConfig(r.Context(), req.Channel, req.Config, req.To); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Options configures how NewMuxWithOptions mounts eratemanager's routes, so
+// an operator can run it behind a reverse proxy that doesn't sit at the
+// application root (e.g. https://ops.example.com/tools/rates/) without every
+// Register*Handler hardcoding its own path.
+type Options struct {
+	// PathPrefix is prepended to every route this package registers (e.g.
+	// "/tools/rates"). Empty (the zero value) mounts at the root, matching
+	// behavior before Options existed.
+	PathPrefix string
+	// TrustedProxies lists the IPs (no port) allowed to set
+	// X-Forwarded-Prefix on a request; a header from any other source is
+	// ignored, so an untrusted client can't spoof the prefix used in
+	// generated links. See Options.linkPrefix.
+	TrustedProxies []string
+}
+
+// normalizedPrefix returns PathPrefix with a leading slash and no trailing
+// slash, or "" when unset.
+func (o Options) normalizedPrefix() string {
+	p := strings.TrimSuffix(o.PathPrefix, "/")
+	if p == "" {
+		return ""
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// mount prepends the configured PathPrefix to route, for Register*Handler
+// functions to pass to mux.Handle/HandleFunc instead of a bare path.
+func (o Options) mount(route string) string {
+	return o.normalizedPrefix() + route
+}
+
+// trustsProxy reports whether remoteAddr (as seen on the connection, with or
+// without a port) is in TrustedProxies.
+func (o Options) trustsProxy(remoteAddr string) bool {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	for _, p := range o.TrustedProxies {
+		if p == host {
+			return true
+		}
+	}
+	return false
+}
+
+// linkPrefix returns the prefix a handler should use when building a
+// self-referential link (e.g. the "/" -> "/ui/" redirect) for r: a trusted
+// proxy's X-Forwarded-Prefix, when present, overrides the statically
+// configured PathPrefix, so a deployment that lets its proxy rewrite paths
+// dynamically doesn't also need to hardcode PathPrefix.
+func (o Options) linkPrefix(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-Prefix"); fwd != "" && o.trustsProxy(r.RemoteAddr) {
+		return strings.TrimSuffix(fwd, "/")
+	}
+	return o.normalizedPrefix()
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/auth"
+)
+
+// RegisterPasswordRoutes wires /api/v1/auth/password/validate onto mux, so
+// a registration or reset-password form can give the user feedback on
+// whether their candidate password will be accepted without submitting
+// it. It isn't gated by authSvc.Middleware, since it must work for a
+// not-yet-registered user.
+func RegisterPasswordRoutes(mux *http.ServeMux, opts Options, authSvc *auth.Service) {
+	mux.HandleFunc(opts.mount("/api/v1/auth/password/validate"), handlePasswordValidate(authSvc))
+}
+
+type validatePasswordRequest struct {
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password"`
+}
+
+type validatePasswordResponse struct {
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func handlePasswordValidate(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req validatePasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := authSvc.ValidatePassword(r.Context(), req.Username, req.Email, req.Password); err != nil {
+			writeJSON(w, validatePasswordResponse{Valid: false, Reason: err.Error()})
+			return
+		}
+		writeJSON(w, validatePasswordResponse{Valid: true})
+	}
+}
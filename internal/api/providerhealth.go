@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/providerhealth"
+)
+
+// RegisterProviderHealthRoutes wires providerhealth.Monitor's status
+// registry onto mux: GET /health/providers for the current snapshot of
+// every tracked provider, and GET /health/providers/{key} for that
+// provider's persisted sample history. Like RegisterV2Routes and
+// RegisterAlertingRoutes, nothing calls this from NewMuxWithOptions yet -
+// the caller wires it up alongside wherever it constructs mon (see
+// cron.Run, which is where the Monitor itself actually runs).
+func RegisterProviderHealthRoutes(mux *http.ServeMux, opts Options, mon *providerhealth.Monitor) {
+	mux.HandleFunc(opts.mount("/health/providers"), handleProviderHealthList(mon))
+	mux.HandleFunc(opts.mount("/health/providers/"), handleProviderHealthItem(opts, mon))
+}
+
+// handleProviderHealthList serves GET /health/providers, unauthenticated
+// like the other operational health endpoints (/healthz, /readyz).
+func handleProviderHealthList(mon *providerhealth.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, mon.List())
+	}
+}
+
+// handleProviderHealthItem serves GET /health/providers/{key}, returning
+// up to a ?limit= (default 50) persisted samples for key, newest first.
+func handleProviderHealthItem(opts Options, mon *providerhealth.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.Trim(strings.TrimPrefix(r.URL.Path, opts.mount("/health/providers/")), "/")
+		if key == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		limit := 50
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+				limit = n
+			}
+		}
+
+		samples, err := mon.History(r.Context(), key, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, samples)
+	}
+}
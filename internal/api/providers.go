@@ -3,12 +3,16 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/bher20/eratemanager/internal/metrics"
 	"github.com/bher20/eratemanager/internal/rates"
+	"github.com/bher20/eratemanager/internal/storage"
 )
 
-func RegisterProvidersHandler(mux *http.ServeMux) {
-	mux.HandleFunc("/providers", func(w http.ResponseWriter, r *http.Request) {
+func RegisterProvidersHandler(mux *http.ServeMux, opts Options, st storage.Storage) {
+	mux.HandleFunc(opts.mount("/providers"), func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -25,4 +29,68 @@ func RegisterProvidersHandler(mux *http.ServeMux) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	})
+
+	// /providers/{key}/history and /providers/{key}/diff let a caller ask
+	// "how has this provider's rates changed over time" without going
+	// through the eratemanager-cli or the /internal/snapshots ID-based
+	// routes. It's a no-op (404) when st doesn't implement
+	// storage.SnapshotHistory, same as RegisterSnapshotsHandler.
+	mux.HandleFunc(opts.mount("/providers/"), func(w http.ResponseWriter, r *http.Request) {
+		hist, ok := st.(storage.SnapshotHistory)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, opts.mount("/providers/"))
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		providerKey := strings.ToLower(parts[0])
+		labelsPath := "/providers/" + parts[1]
+
+		defer func(start time.Time) {
+			metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(time.Since(start).Seconds())
+		}(time.Now())
+		metrics.RequestsTotal.WithLabelValues(providerKey).Inc()
+
+		switch parts[1] {
+		case "history":
+			handleSnapshotsList(w, r, hist, providerKey, labelsPath)
+		case "diff":
+			handleProviderDiff(w, r, hist, providerKey, labelsPath)
+		default:
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "404").Inc()
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// handleProviderDiff serves GET /providers/{provider}/diff?from=<RFC3339>&to=<RFC3339>,
+// resolving each timestamp to the snapshot in effect at that instant and
+// reporting the per-field before/after values and percent change between
+// them.
+func handleProviderDiff(w http.ResponseWriter, r *http.Request, hist storage.SnapshotHistory, providerKey, labelsPath string) {
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid or missing from (want RFC3339): "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid or missing to (want RFC3339): "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diff, err := storage.DiffRatesSnapshotsAt(r.Context(), hist, providerKey, from, to)
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, diff)
 }
@@ -3,11 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/bher20/eratemanager/internal/auth"
 	"github.com/bher20/eratemanager/internal/metrics"
 	"github.com/bher20/eratemanager/internal/rates"
 	"github.com/bher20/eratemanager/internal/storage"
@@ -20,15 +22,24 @@ type RefreshResponse struct {
 	Status   string               `json:"status"`
 	Error    string               `json:"error,omitempty"`
 	Rates    *rates.RatesResponse `json:"rates,omitempty"`
+	// Warnings names rate fields the parser couldn't extract from this
+	// fetch (mirrors rates.RatesResponse.Warnings), surfaced at the
+	// top level so an operator sees a parse regression without having to
+	// dig through Rates for a bogus-looking $0.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
-// RegisterRefreshHandler wires the /internal/refresh/{provider} endpoint into the mux.
-func RegisterRefreshHandler(mux *http.ServeMux, st storage.Storage) {
-	mux.HandleFunc("/internal/refresh/", func(w http.ResponseWriter, r *http.Request) {
+// RegisterRefreshHandler wires the /internal/refresh/{provider} endpoint
+// into the mux. When authSvc is non-nil, requests must carry a bearer API
+// token (see auth.Service.Issue) whose scopes grant at least
+// auth.PermRefresh; a nil authSvc leaves the endpoint open, matching the
+// other /internal/ operational endpoints.
+func RegisterRefreshHandler(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
 		// Path: /internal/refresh/{provider}
-		path := strings.TrimPrefix(r.URL.Path, "/internal/refresh/")
+		path := strings.TrimPrefix(r.URL.Path, opts.mount("/internal/refresh/"))
 		providerKey := strings.ToLower(strings.Trim(path, "/"))
 		if providerKey == "" {
 			http.NotFound(w, r)
@@ -53,8 +64,18 @@ func RegisterRefreshHandler(mux *http.ServeMux, st storage.Storage) {
 			return
 		}
 
+		// Bound the whole refresh (discovery, download, parse) by the
+		// provider's configured deadline, derived from the request's own
+		// context so a client disconnect aborts it early too.
+		timeout := p.RefreshTimeout
+		if timeout <= 0 {
+			timeout = rates.DefaultRefreshTimeout
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
 		// Step 1: Download the latest PDF
-		pdfURL, err := rates.RefreshProviderPDF(p)
+		pdfURL, err := rates.RefreshProviderPDF(ctx, p)
 
 		resp := RefreshResponse{
 			Provider: providerKey,
@@ -63,28 +84,65 @@ func RegisterRefreshHandler(mux *http.ServeMux, st storage.Storage) {
 		}
 
 		if err != nil {
-			log.Printf("refresh %s pdf failed: %v", providerKey, err)
-			resp.Status = "error"
-			resp.Error = err.Error()
-			metrics.RequestErrorsTotal.WithLabelValues(labelsProvider, labelsPath, "500").Inc()
-			w.WriteHeader(http.StatusInternalServerError)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("refresh %s pdf timed out after %s: %v", providerKey, timeout, err)
+				resp.Status = "timeout"
+				resp.Error = err.Error()
+				metrics.RequestTimeoutsTotal.WithLabelValues(labelsProvider).Inc()
+				metrics.RequestErrorsTotal.WithLabelValues(labelsProvider, labelsPath, "504").Inc()
+				w.WriteHeader(http.StatusGatewayTimeout)
+			} else {
+				log.Printf("refresh %s pdf failed: %v", providerKey, err)
+				resp.Status = "error"
+				resp.Error = err.Error()
+				metrics.RequestErrorsTotal.WithLabelValues(labelsProvider, labelsPath, "500").Inc()
+				w.WriteHeader(http.StatusInternalServerError)
+			}
 		} else {
 			resp.Status = "ok"
 
 			// Step 2: Force re-parse the PDF and save to database
 			svc := rates.NewServiceWithStorage(rates.Config{}, st)
-			ctx := context.Background()
 			parsedRates, parseErr := svc.ForceRefresh(ctx, providerKey)
 			if parseErr != nil {
-				log.Printf("refresh %s parse failed: %v", providerKey, parseErr)
-				resp.Error = parseErr.Error()
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					log.Printf("refresh %s parse timed out after %s: %v", providerKey, timeout, parseErr)
+					resp.Status = "timeout"
+					resp.Error = parseErr.Error()
+					metrics.RequestTimeoutsTotal.WithLabelValues(labelsProvider).Inc()
+					metrics.RequestErrorsTotal.WithLabelValues(labelsProvider, labelsPath, "504").Inc()
+					w.WriteHeader(http.StatusGatewayTimeout)
+				} else {
+					log.Printf("refresh %s parse failed: %v", providerKey, parseErr)
+					resp.Error = parseErr.Error()
+				}
 			} else {
 				resp.Rates = parsedRates
+				resp.Warnings = parsedRates.Warnings
+				if len(resp.Warnings) > 0 {
+					log.Printf("refresh %s: parsed with warnings: %v", providerKey, resp.Warnings)
+				}
 				log.Printf("refresh %s: successfully parsed and saved rates", providerKey)
+
+				if rh, ok := st.(storage.RateHistory); ok {
+					if payload, err := json.Marshal(parsedRates); err != nil {
+						log.Printf("refresh %s: marshal rates for history failed: %v", providerKey, err)
+					} else if fields, err := storage.FlattenRateFields(payload); err != nil {
+						log.Printf("refresh %s: flatten rates for history failed: %v", providerKey, err)
+					} else if err := rh.AppendSnapshot(ctx, providerKey, parsedRates.FetchedAt, fields); err != nil {
+						log.Printf("refresh %s: append rate history failed: %v", providerKey, err)
+					}
+				}
 			}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
 	})
+
+	if authSvc != nil {
+		mux.Handle(opts.mount("/internal/refresh/"), authSvc.RequireScope(auth.PermRefresh, handler))
+		return
+	}
+	mux.Handle(opts.mount("/internal/refresh/"), handler)
 }
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/auth"
+)
+
+// RegisterRoleRoutes wires /api/v1/roles/hierarchy onto mux, admin-gated
+// like /api/v1/audit: GET returns every AddRoleInheritance edge so an
+// admin UI can render the role DAG, and POST/DELETE add or remove one,
+// requiring the caller hold roles:read or roles:write respectively.
+func RegisterRoleRoutes(mux *http.ServeMux, opts Options, authSvc *auth.Service) {
+	mux.Handle(opts.mount("/api/v1/roles/hierarchy"), authSvc.Middleware(handleRoleHierarchy(authSvc)))
+}
+
+type roleEdgeRequest struct {
+	Child  string `json:"child"`
+	Parent string `json:"parent"`
+}
+
+// handleRoleHierarchy serves GET (list edges), POST (add an edge) and
+// DELETE (remove an edge) on /api/v1/roles/hierarchy.
+func handleRoleHierarchy(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		act := "read"
+		if r.Method != http.MethodGet {
+			act = "write"
+		}
+		allowed, err := authSvc.Enforce(getUserID(r), "roles", act)
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			edges, err := authSvc.RoleHierarchy()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, edges)
+
+		case http.MethodPost:
+			var req roleEdgeRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			if req.Child == "" || req.Parent == "" {
+				http.Error(w, "child and parent are required", http.StatusBadRequest)
+				return
+			}
+			if err := authSvc.AddRoleInheritance(r.Context(), req.Child, req.Parent); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		case http.MethodDelete:
+			var req roleEdgeRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			if req.Child == "" || req.Parent == "" {
+				http.Error(w, "child and parent are required", http.StatusBadRequest)
+				return
+			}
+			if err := authSvc.RemoveRoleInheritance(r.Context(), req.Child, req.Parent); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
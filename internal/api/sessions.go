@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterSessionRoutes wires /api/v1/auth/sessions onto mux, self-service
+// like /api/v1/tokens: the caller lists and revokes only their own active
+// session tokens. It's a no-op when st doesn't implement storage.AuthStore.
+func RegisterSessionRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	if _, ok := st.(storage.AuthStore); !ok {
+		return
+	}
+
+	mux.Handle(opts.mount("/api/v1/auth/sessions"), authSvc.Middleware(handleSessionList(authSvc)))
+	mux.Handle(opts.mount("/api/v1/auth/sessions/"), authSvc.Middleware(handleSessionItem(opts, authSvc)))
+}
+
+// handleSessionList serves GET /api/v1/auth/sessions, listing every
+// device/session currently signed in to the caller's account.
+func handleSessionList(authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		sessions, err := authSvc.ListSessions(r.Context(), userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, sessions)
+	}
+}
+
+// handleSessionItem serves DELETE /api/v1/auth/sessions/{id}, revoking one
+// of the caller's own session tokens - e.g. to sign a lost device out
+// remotely.
+func handleSessionItem(opts Options, authSvc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, opts.mount("/api/v1/auth/sessions/"))
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		sessions, err := authSvc.ListSessions(r.Context(), userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		found := false
+		for _, s := range sessions {
+			if s.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+
+		if err := authSvc.RevokeToken(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterSnapshotsHandler wires /internal/snapshots/{provider}[/at|/diff]
+// into mux, letting an E-Rate auditor ask "what did this provider's tariff
+// look like on a given date?" and "what changed between two filings?"
+// without going through the storage.Storage interface directly. It's a
+// no-op when st doesn't implement storage.SnapshotHistory (e.g. the
+// in-memory backend, which only ever caches the latest snapshot).
+func RegisterSnapshotsHandler(mux *http.ServeMux, opts Options, st storage.Storage) {
+	hist, ok := st.(storage.SnapshotHistory)
+	if !ok {
+		return
+	}
+
+	mux.HandleFunc(opts.mount("/internal/snapshots/"), func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		path := strings.TrimPrefix(r.URL.Path, opts.mount("/internal/snapshots/"))
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) == 0 || parts[0] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		providerKey := strings.ToLower(parts[0])
+
+		labelsPath := "/internal/snapshots"
+		if len(parts) == 2 {
+			labelsPath += "/" + parts[1]
+		}
+		defer func() {
+			metrics.RequestDurationSeconds.WithLabelValues(providerKey, labelsPath).Observe(time.Since(start).Seconds())
+		}()
+		metrics.RequestsTotal.WithLabelValues(providerKey).Inc()
+
+		switch {
+		case len(parts) == 1:
+			handleSnapshotsList(w, r, hist, providerKey, labelsPath)
+		case len(parts) == 2 && parts[1] == "at":
+			handleSnapshotAt(w, r, hist, providerKey, labelsPath)
+		case len(parts) == 2 && parts[1] == "diff":
+			handleSnapshotsDiff(w, r, hist, providerKey, labelsPath)
+		default:
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "404").Inc()
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// handleSnapshotsList serves GET /internal/snapshots/{provider}?since=&until=&limit=
+// where since/until are RFC3339 timestamps and limit is an integer; all optional.
+func handleSnapshotsList(w http.ResponseWriter, r *http.Request, hist storage.SnapshotHistory, providerKey, labelsPath string) {
+	since, err := parseOptionalRFC3339(r.URL.Query().Get("since"))
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	until, err := parseOptionalRFC3339(r.URL.Query().Get("until"))
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil {
+			metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+			http.Error(w, "invalid limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	snaps, err := hist.ListRatesSnapshots(r.Context(), providerKey, since, until, limit)
+	if err != nil {
+		log.Printf("list snapshots for %s failed: %v", providerKey, err)
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "500").Inc()
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, snaps)
+}
+
+// handleSnapshotAt serves GET /internal/snapshots/{provider}/at?date=RFC3339,
+// answering "what did this tariff look like on date X?".
+func handleSnapshotAt(w http.ResponseWriter, r *http.Request, hist storage.SnapshotHistory, providerKey, labelsPath string) {
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("date"))
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid or missing date (want RFC3339): "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	snap, err := hist.GetRatesSnapshotAt(r.Context(), providerKey, at)
+	if err != nil {
+		log.Printf("get snapshot for %s at %s failed: %v", providerKey, at, err)
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "500").Inc()
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if snap == nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "404").Inc()
+		http.Error(w, "no snapshot existed yet at that time", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, snap)
+}
+
+// handleSnapshotsDiff serves GET /internal/snapshots/{provider}/diff?from=<id>&to=<id>,
+// answering "what changed between two filings?".
+func handleSnapshotsDiff(w http.ResponseWriter, r *http.Request, hist storage.SnapshotHistory, providerKey, labelsPath string) {
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid or missing from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, "invalid or missing to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diff, err := storage.DiffRatesSnapshots(r.Context(), hist, providerKey, from, to)
+	if err != nil {
+		metrics.RequestErrorsTotal.WithLabelValues(providerKey, labelsPath, "400").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, diff)
+}
+
+func parseOptionalRFC3339(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RegisterTokenRoutes wires /api/v1/tokens onto mux, self-service like
+// /mfa/*: the caller lists, creates, and deletes only their own API keys,
+// with no separate object/action permission to check beyond being
+// authenticated. It's a no-op when st doesn't implement storage.AuthStore.
+func RegisterTokenRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	as, ok := st.(storage.AuthStore)
+	if !ok {
+		return
+	}
+
+	mux.Handle(opts.mount("/api/v1/tokens"), authSvc.Middleware(handleTokenList(authSvc, as)))
+	mux.Handle(opts.mount("/api/v1/tokens/"), authSvc.Middleware(handleTokenItem(opts, authSvc, as)))
+}
+
+// tokenResponse is storage.Token shaped for API responses: Scopes is
+// decoded back into a list, and is only ever populated on the create
+// response (see handleTokenList's POST branch) - like a GitHub PAT, it's
+// shown once at creation and omitted thereafter.
+type tokenResponse struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name,omitempty"`
+	Role               string     `json:"role,omitempty"`
+	CreatedAt          time.Time  `json:"created_at,omitempty"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt         time.Time  `json:"last_used_at,omitempty"`
+	LastUsedIP         string     `json:"last_used_ip,omitempty"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute,omitempty"`
+	Scopes             []string   `json:"scopes,omitempty"`
+	Token              string     `json:"token,omitempty"`
+}
+
+func newTokenResponse(t storage.Token) tokenResponse {
+	return tokenResponse{
+		ID:                 t.ID,
+		Name:               t.Name,
+		Role:               t.Role,
+		CreatedAt:          t.CreatedAt,
+		ExpiresAt:          t.ExpiresAt,
+		LastUsedAt:         t.LastUsedAt,
+		LastUsedIP:         t.LastUsedIP,
+		RateLimitPerMinute: t.RateLimitPerMinute,
+	}
+}
+
+type createTokenRequest struct {
+	Name               string     `json:"name"`
+	Role               string     `json:"role"`
+	Scopes             []string   `json:"scopes,omitempty"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute,omitempty"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+}
+
+// handleTokenList serves GET (list the caller's own API keys) and POST
+// (mint a new one) on /api/v1/tokens.
+func handleTokenList(authSvc *auth.Service, store storage.AuthStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			tokens, err := store.ListTokens(r.Context(), userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			resp := make([]tokenResponse, len(tokens))
+			for i, t := range tokens {
+				resp[i] = newTokenResponse(t)
+			}
+			writeJSON(w, resp)
+
+		case http.MethodPost:
+			var req createTokenRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			if req.Name == "" || req.Role == "" {
+				http.Error(w, "name and role are required", http.StatusBadRequest)
+				return
+			}
+
+			t, raw, err := authSvc.CreateToken(r.Context(), userID, req.Name, req.Role, req.Scopes, req.RateLimitPerMinute, req.ExpiresAt)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			resp := newTokenResponse(*t)
+			resp.Scopes = req.Scopes
+			resp.Token = raw
+			writeJSON(w, resp)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleTokenItem serves DELETE /api/v1/tokens/{id}, revoking one of the
+// caller's own API keys. Revoking (not deleting) keeps the row so a later
+// attempt to reuse the raw value is still reported as "invalid", matching
+// RevokeToken's use elsewhere (e.g. logout).
+func handleTokenItem(opts Options, authSvc *auth.Service, store storage.AuthStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := getUserID(r)
+		if userID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, opts.mount("/api/v1/tokens/"))
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		t, err := store.GetToken(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if t == nil || t.UserID != userID {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+
+		if err := authSvc.RevokeToken(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -10,7 +10,6 @@ import (
 "github.com/bher20/eratemanager/internal/rates"
 "github.com/bher20/eratemanager/internal/storage"
 "github.com/bher20/eratemanager/pkg/providers/electricproviders"
-"github.com/bher20/eratemanager/pkg/providers/waterproviders"
 )
 
 // ProviderDTO represents a provider in the API.
@@ -21,14 +20,16 @@ Type string `json:"type"`
 }
 
 type V2Handler struct {
+opts     Options
 svc      *rates.Service
 waterSvc *rates.WaterService
 st       storage.Storage
 authSvc  *auth.Service
 }
 
-func RegisterV2Routes(mux *http.ServeMux, svc *rates.Service, waterSvc *rates.WaterService, st storage.Storage, authSvc *auth.Service) {
+func RegisterV2Routes(mux *http.ServeMux, opts Options, svc *rates.Service, waterSvc *rates.WaterService, st storage.Storage, authSvc *auth.Service) {
 h := &V2Handler{
+opts:     opts,
 svc:      svc,
 waterSvc: waterSvc,
 st:       st,
@@ -43,10 +44,10 @@ return handler
 return authSvc.Middleware(handler)
 }
 
-mux.Handle("/api/v2/electric-rates/providers", withAuth(h.ListElectricProviders))
-mux.Handle("/api/v2/electric-rates/", withAuth(h.HandleElectricRates))
-mux.Handle("/api/v2/water-rates/providers", withAuth(h.ListWaterProviders))
-mux.Handle("/api/v2/water-rates/", withAuth(h.HandleWaterRates))
+mux.Handle(opts.mount("/api/v2/electric-rates/providers"), withAuth(h.ListElectricProviders))
+mux.Handle(opts.mount("/api/v2/electric-rates/"), withAuth(h.HandleElectricRates))
+mux.Handle(opts.mount("/api/v2/water-rates/providers"), withAuth(h.ListWaterProviders))
+mux.Handle(opts.mount("/api/v2/water-rates/"), withAuth(h.HandleWaterRates))
 }
 
 // ListElectricProviders lists all electric providers
@@ -89,7 +90,7 @@ json.NewEncoder(w).Encode(list)
 // @Param action path string true "Action (residential, pdf, refresh)"
 // @Router /api/v2/electric-rates/{providerKey}/{action} [get]
 func (h *V2Handler) HandleElectricRates(w http.ResponseWriter, r *http.Request) {
-path := strings.TrimPrefix(r.URL.Path, "/api/v2/electric-rates/")
+path := strings.TrimPrefix(r.URL.Path, h.opts.mount("/api/v2/electric-rates/"))
 parts := strings.Split(path, "/")
 if len(parts) < 2 {
 http.NotFound(w, r)
@@ -187,13 +188,13 @@ if r.Method != http.MethodGet {
 http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 return
 }
-providers := waterproviders.GetAll()
+providers := rates.WaterProviders()
 var list []ProviderDTO
 for _, p := range providers {
 list = append(list, ProviderDTO{
-Key:  p.Key(),
-Name: p.Name(),
-Type: string(p.Type()),
+Key:  p.Key,
+Name: p.Name,
+Type: string(p.Type),
 })
 }
 w.Header().Set("Content-Type", "application/json")
@@ -209,7 +210,7 @@ json.NewEncoder(w).Encode(list)
 // @Param action path string false "Action (refresh)"
 // @Router /api/v2/water-rates/{providerKey} [get]
 func (h *V2Handler) HandleWaterRates(w http.ResponseWriter, r *http.Request) {
-path := strings.TrimPrefix(r.URL.Path, "/api/v2/water-rates/")
+path := strings.TrimPrefix(r.URL.Path, h.opts.mount("/api/v2/water-rates/"))
 parts := strings.Split(path, "/")
 if len(parts) < 1 {
 http.NotFound(w, r)
@@ -258,6 +259,9 @@ if resp == nil {
 http.NotFound(w, r)
 return
 }
+if resp.Stale {
+w.Header().Set("X-Rates-Stale", "true")
+}
 w.Header().Set("Content-Type", "application/json")
 json.NewEncoder(w).Encode(resp)
 }
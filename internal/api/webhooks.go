@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/auth"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// webhookStore is satisfied by the storage backends that persist webhook
+// subscriptions and deliveries (SQLiteStorage, PostgresStorage, MemoryStorage).
+type webhookStore interface {
+	ListWebhookSubscriptions(ctx context.Context) ([]storage.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id string) (*storage.WebhookSubscription, error)
+	CreateWebhookSubscription(ctx context.Context, sub storage.WebhookSubscription) (*storage.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]storage.WebhookDelivery, error)
+}
+
+// WebhookHandler serves CRUD endpoints for webhook subscriptions.
+type WebhookHandler struct {
+	opts    Options
+	store   webhookStore
+	authSvc *auth.Service
+}
+
+// RegisterWebhookRoutes wires /api/v2/webhooks onto mux, gated by authSvc
+// (the rates/providers resource convention) when one is configured. st must
+// implement webhookStore or the routes respond 501.
+func RegisterWebhookRoutes(mux *http.ServeMux, opts Options, st storage.Storage, authSvc *auth.Service) {
+	ws, _ := st.(webhookStore)
+	h := &WebhookHandler{opts: opts, store: ws, authSvc: authSvc}
+
+	withAuth := func(handler http.HandlerFunc) http.Handler {
+		if authSvc == nil {
+			return handler
+		}
+		return authSvc.Middleware(handler)
+	}
+
+	mux.Handle(opts.mount("/api/v2/webhooks"), withAuth(h.handleCollection))
+	mux.Handle(opts.mount("/api/v2/webhooks/"), withAuth(h.handleItem))
+}
+
+func (h *WebhookHandler) enforce(r *http.Request, act string) bool {
+	if h.authSvc == nil {
+		return true
+	}
+	allowed, err := h.authSvc.Enforce(getUserID(r), "webhooks", act)
+	return err == nil && allowed
+}
+
+func (h *WebhookHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "webhooks not supported by the configured storage backend", http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !h.enforce(r, "read") {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		subs, err := h.store.ListWebhookSubscriptions(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, subs)
+
+	case http.MethodPost:
+		if !h.enforce(r, "write") {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		var sub storage.WebhookSubscription
+		if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if sub.URL == "" || len(sub.EventTypes) == 0 || sub.Secret == "" {
+			http.Error(w, "url, event_types, and secret are required", http.StatusBadRequest)
+			return
+		}
+		created, err := h.store.CreateWebhookSubscription(r.Context(), sub)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, created)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WebhookHandler) handleItem(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "webhooks not supported by the configured storage backend", http.StatusNotImplemented)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, h.opts.mount("/api/v2/webhooks/"))
+	parts := strings.Split(path, "/")
+	id := parts[0]
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "deliveries" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !h.enforce(r, "read") {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		deliveries, err := h.store.ListWebhookDeliveries(r.Context(), id, 50)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, deliveries)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !h.enforce(r, "read") {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		sub, err := h.store.GetWebhookSubscription(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if sub == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, sub)
+
+	case http.MethodDelete:
+		if !h.enforce(r, "write") {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if err := h.store.DeleteWebhookSubscription(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -3,63 +3,66 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/bher20/eratemanager/internal/storage"
 	"github.com/casbin/casbin/v2/model"
 	"github.com/casbin/casbin/v2/persist"
 )
 
-// Adapter implements the Casbin persist.Adapter interface using storage.Storage.
+// Adapter implements Casbin's persist.Adapter (plus the BatchAdapter,
+// FilteredAdapter and UpdatableAdapter extensions) on top of a
+// storage.CasbinStore.
 type Adapter struct {
-	storage storage.Storage
+	storage  storage.CasbinStore
+	filtered bool
 }
 
 // NewAdapter returns a new Casbin adapter.
-func NewAdapter(s storage.Storage) *Adapter {
+func NewAdapter(s storage.CasbinStore) *Adapter {
 	return &Adapter{storage: s}
 }
 
+// policyLine renders rule back into the comma-separated form
+// persist.LoadPolicyLine expects, the inverse of ruleFromSlice.
+func policyLine(rule storage.CasbinRule) string {
+	line := rule.PType
+	if rule.V0 != "" {
+		line += ", " + rule.V0
+	}
+	if rule.V1 != "" {
+		line += ", " + rule.V1
+	}
+	if rule.V2 != "" {
+		line += ", " + rule.V2
+	}
+	if rule.V3 != "" {
+		line += ", " + rule.V3
+	}
+	if rule.V4 != "" {
+		line += ", " + rule.V4
+	}
+	if rule.V5 != "" {
+		line += ", " + rule.V5
+	}
+	return line
+}
+
 // LoadPolicy loads all policy rules from the storage.
 func (a *Adapter) LoadPolicy(model model.Model) error {
 	rules, err := a.storage.LoadCasbinRules(context.Background())
 	if err != nil {
 		return err
 	}
-
 	for _, rule := range rules {
-		line := rule.PType
-		if rule.V0 != "" {
-			line += ", " + rule.V0
-		}
-		if rule.V1 != "" {
-			line += ", " + rule.V1
-		}
-		if rule.V2 != "" {
-			line += ", " + rule.V2
-		}
-		if rule.V3 != "" {
-			line += ", " + rule.V3
-		}
-		if rule.V4 != "" {
-			line += ", " + rule.V4
-		}
-		if rule.V5 != "" {
-			line += ", " + rule.V5
-		}
-		persist.LoadPolicyLine(line, model)
+		persist.LoadPolicyLine(policyLine(rule), model)
 	}
 	return nil
 }
 
-// SavePolicy saves all policy rules to the storage.
-func (a *Adapter) SavePolicy(model model.Model) error {
-	// We don't implement SavePolicy because we use incremental Add/RemovePolicy.
-	// If we needed to support SavePolicy, we would need to clear the table and re-insert everything.
-	return errors.New("not implemented")
-}
-
-// AddPolicy adds a policy rule to the storage.
-func (a *Adapter) AddPolicy(sec string, ptype string, rule []string) error {
+// ruleFromSlice builds a storage.CasbinRule from a Casbin rule slice, the
+// shape AddPolicy/RemovePolicy/UpdatePolicy all receive it in.
+func ruleFromSlice(ptype string, rule []string) storage.CasbinRule {
 	r := storage.CasbinRule{PType: ptype}
 	if len(rule) > 0 {
 		r.V0 = rule[0]
@@ -79,44 +82,125 @@ func (a *Adapter) AddPolicy(sec string, ptype string, rule []string) error {
 	if len(rule) > 5 {
 		r.V5 = rule[5]
 	}
-	return a.storage.AddCasbinRule(context.Background(), r)
+	return r
+}
+
+// SavePolicy saves all policy rules to the storage, replacing whatever was
+// there before. Casbin calls this on enforcer.SavePolicy(), not as part of
+// the normal incremental Add/RemovePolicy path.
+func (a *Adapter) SavePolicy(model model.Model) error {
+	ctx := context.Background()
+	if err := a.storage.ClearCasbinRules(ctx); err != nil {
+		return fmt.Errorf("clear casbin rules: %w", err)
+	}
+	var rules []storage.CasbinRule
+	for ptype, ast := range model["p"] {
+		for _, rule := range ast.Policy {
+			rules = append(rules, ruleFromSlice(ptype, rule))
+		}
+	}
+	for ptype, ast := range model["g"] {
+		for _, rule := range ast.Policy {
+			rules = append(rules, ruleFromSlice(ptype, rule))
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return a.storage.AddCasbinRules(ctx, rules)
+}
+
+// AddPolicy adds a policy rule to the storage.
+func (a *Adapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.storage.AddCasbinRule(context.Background(), ruleFromSlice(ptype, rule))
 }
 
 // RemovePolicy removes a policy rule from the storage.
 func (a *Adapter) RemovePolicy(sec string, ptype string, rule []string) error {
-	r := storage.CasbinRule{PType: ptype}
-	if len(rule) > 0 {
-		r.V0 = rule[0]
+	return a.storage.RemoveCasbinRule(context.Background(), ruleFromSlice(ptype, rule))
+}
+
+// RemoveFilteredPolicy removes policy rules of ptype whose fields starting
+// at fieldIndex match fieldValues.
+func (a *Adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	return a.storage.RemoveFilteredCasbinRule(context.Background(), ptype, fieldIndex, fieldValues...)
+}
+
+// AddPolicies adds multiple policy rules to the storage in a single batch,
+// implementing persist.BatchAdapter.
+func (a *Adapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	batch := make([]storage.CasbinRule, 0, len(rules))
+	for _, rule := range rules {
+		batch = append(batch, ruleFromSlice(ptype, rule))
 	}
-	if len(rule) > 1 {
-		r.V1 = rule[1]
+	return a.storage.AddCasbinRules(context.Background(), batch)
+}
+
+// RemovePolicies removes multiple policy rules from the storage,
+// implementing persist.BatchAdapter.
+func (a *Adapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	for _, rule := range rules {
+		if err := a.storage.RemoveCasbinRule(context.Background(), ruleFromSlice(ptype, rule)); err != nil {
+			return err
+		}
 	}
-	if len(rule) > 2 {
-		r.V2 = rule[2]
+	return nil
+}
+
+// UpdatePolicy replaces oldRule with newPolicy, implementing
+// persist.UpdatableAdapter.
+func (a *Adapter) UpdatePolicy(sec string, ptype string, oldRule, newPolicy []string) error {
+	ctx := context.Background()
+	if err := a.storage.RemoveCasbinRule(ctx, ruleFromSlice(ptype, oldRule)); err != nil {
+		return err
 	}
-	if len(rule) > 3 {
-		r.V3 = rule[3]
+	return a.storage.AddCasbinRule(ctx, ruleFromSlice(ptype, newPolicy))
+}
+
+// UpdatePolicies replaces each of oldRules with the corresponding entry in
+// newRules, implementing persist.UpdatableAdapter.
+func (a *Adapter) UpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+	if len(oldRules) != len(newRules) {
+		return errors.New("auth: UpdatePolicies called with mismatched oldRules/newRules lengths")
 	}
-	if len(rule) > 4 {
-		r.V4 = rule[4]
+	for i, oldRule := range oldRules {
+		if err := a.UpdatePolicy(sec, ptype, oldRule, newRules[i]); err != nil {
+			return err
+		}
 	}
-	if len(rule) > 5 {
-		r.V5 = rule[5]
+	return nil
+}
+
+// casbinFilter is the concrete type LoadFilteredPolicy accepts, matching
+// storage.CasbinFilter field-for-field so callers don't need to import the
+// storage package just to build a filter.
+type casbinFilter = storage.CasbinFilter
+
+// LoadFilteredPolicy loads only the policy rows matching filter, implementing
+// persist.FilteredAdapter. filter must be a *storage.CasbinFilter (or nil,
+// equivalent to LoadPolicy).
+func (a *Adapter) LoadFilteredPolicy(model model.Model, filter interface{}) error {
+	if filter == nil {
+		a.filtered = false
+		return a.LoadPolicy(model)
+	}
+	f, ok := filter.(*casbinFilter)
+	if !ok {
+		return fmt.Errorf("auth: LoadFilteredPolicy: unsupported filter type %T, want *storage.CasbinFilter", filter)
+	}
+	rules, err := a.storage.LoadFilteredCasbinRules(context.Background(), *f)
+	if err != nil {
+		return err
 	}
-	return a.storage.RemoveCasbinRule(context.Background(), r)
+	for _, rule := range rules {
+		persist.LoadPolicyLine(policyLine(rule), model)
+	}
+	a.filtered = true
+	return nil
 }
 
-// RemoveFilteredPolicy removes policy rules that match the filter from the storage.
-func (a *Adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
-	// This is required for RemovePolicy to work correctly in some cases, or for API calls.
-	// Since our storage interface doesn't support complex filtering yet, we can implement a basic version
-	// or just return error.
-	// However, Casbin often calls RemoveFilteredPolicy when RemovePolicy is called? No, RemovePolicy calls RemovePolicy.
-	// But UpdatePolicy might call RemoveFilteredPolicy.
-
-	// For now, let's implement a simple loop if we can't do it in DB efficiently without changing interface.
-	// But wait, we can't loop over DB easily.
-	// Let's just return error for now and see if it breaks anything.
-	// Actually, `RemovePolicy` is what we use most.
-	return errors.New("not implemented")
+// IsFiltered reports whether the last LoadFilteredPolicy call applied a
+// non-nil filter, implementing persist.FilteredAdapter.
+func (a *Adapter) IsFiltered() bool {
+	return a.filtered
 }
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Permission is a coarse-grained capability level an API token issued by
+// Issue can carry, hierarchical like the read/write/sign/admin scheme the
+// lotus API uses: PermAdmin implies PermRefresh and PermRead, and
+// PermRefresh implies PermRead. Unlike the resource:action scopes
+// EnforceToken checks (see scopes.go), a Permission isn't tied to any
+// particular resource - it's meant for service/automation tokens guarding a
+// handful of operational endpoints (e.g. /internal/refresh/water/...)
+// rather than the full per-resource RBAC interactive users go through.
+type Permission string
+
+const (
+	PermRead    Permission = "read"
+	PermRefresh Permission = "refresh"
+	PermAdmin   Permission = "admin"
+)
+
+// permissionRank orders Permission from least to most capable, so Grants
+// can compare two levels without a case per pair.
+var permissionRank = map[Permission]int{
+	PermRead:    0,
+	PermRefresh: 1,
+	PermAdmin:   2,
+}
+
+// Grants reports whether p is sufficient to satisfy a handler requiring
+// required, e.g. PermAdmin.Grants(PermRead) is true but
+// PermRead.Grants(PermAdmin) is not.
+func (p Permission) Grants(required Permission) bool {
+	return permissionRank[p] >= permissionRank[required]
+}
+
+// Token is an issued API token's public metadata, the form Issue returns
+// alongside the one-time opaque secret - as opposed to storage.Token's
+// persisted row, which also carries the bcrypt hash and is never handed
+// back to a caller.
+type Token struct {
+	ID        string
+	Scopes    []Permission
+	ExpiresAt *time.Time
+	CreatedBy string
+}
+
+// apiTokenKind tags a storage.Token row as one of these scoped API tokens,
+// distinguishing it from session/refresh tokens ("access"/"refresh") and
+// plain one-shot links (kind "").
+const apiTokenKind = "api"
+
+// apiTokenSecretBytes is the random secret portion's length before
+// hex-encoding, in the opaque value Issue returns as "<id>.<hex-secret>".
+const apiTokenSecretBytes = 32
+
+// encodePermissions/decodePermissions (de)serialize a Permission slice for
+// storage.Token.Scopes, mirroring encodeScopes/decodeScopes in scopes.go.
+func encodePermissions(scopes []Permission) (string, error) {
+	if len(scopes) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(scopes)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func decodePermissions(encoded string) ([]Permission, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	var scopes []Permission
+	if err := json.Unmarshal([]byte(encoded), &scopes); err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// highestPermission returns the most capable Permission in scopes, or ""
+// (grants nothing) if scopes is empty.
+func highestPermission(scopes []Permission) Permission {
+	var best Permission
+	bestRank := -1
+	for _, p := range scopes {
+		if r := permissionRank[p]; r > bestRank {
+			best, bestRank = p, r
+		}
+	}
+	return best
+}
+
+// Issue mints a new scoped API token, persisting it through s.storage and
+// returning its metadata alongside the one-time opaque secret
+// ("<id>.<hex-secret>") the caller must present as a bearer token.
+// ValidateAPIToken looks the token up by id and bcrypt-compares the
+// secret, so unlike the sha256-hashed session/refresh tokens createToken
+// issues, the raw secret can't be recovered from storage even by an
+// attacker who can query it directly.
+func (s *Service) Issue(ctx context.Context, createdBy string, scopes []Permission, expiresIn string) (*Token, string, error) {
+	expiresAt, err := ParseExpirationDuration(expiresIn)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse expiration: %w", err)
+	}
+
+	secretBytes := make([]byte, apiTokenSecretBytes)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, "", err
+	}
+	secret := hex.EncodeToString(secretBytes)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	encodedScopes, err := encodePermissions(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id := uuid.New().String()
+	row := storage.Token{
+		ID:        id,
+		UserID:    createdBy,
+		TokenHash: string(hash),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		Kind:      apiTokenKind,
+		Scopes:    encodedScopes,
+	}
+	if err := s.storage.CreateToken(ctx, row); err != nil {
+		return nil, "", err
+	}
+
+	token := &Token{ID: id, Scopes: scopes, ExpiresAt: expiresAt, CreatedBy: createdBy}
+	return token, id + "." + secret, nil
+}
+
+// ValidateAPIToken looks up the storage.Token behind raw ("<id>.<secret>",
+// as returned by Issue) and returns it if it's live: known, the right
+// kind, not revoked, not expired, and secret matches its bcrypt hash. It
+// returns ErrTokenInvalid or ErrTokenExpired, the same errors
+// ValidateToken uses, so callers can reuse bearerChallenge.
+func (s *Service) ValidateAPIToken(ctx context.Context, raw string) (*storage.Token, error) {
+	id, secret, ok := strings.Cut(raw, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrTokenInvalid
+	}
+
+	t, err := s.storage.GetToken(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.Kind != apiTokenKind || t.RevokedAt != nil {
+		return nil, ErrTokenInvalid
+	}
+	if bcrypt.CompareHashAndPassword([]byte(t.TokenHash), []byte(secret)) != nil {
+		return nil, ErrTokenInvalid
+	}
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return nil, ErrTokenExpired
+	}
+
+	go s.storage.UpdateTokenLastUsed(context.Background(), t.ID, requestMetaFromContext(ctx).IP)
+
+	return t, nil
+}
+
+// RequireScope wraps next, admitting only requests bearing a live API
+// token (see Issue/ValidateAPIToken) whose highest Scope grants required -
+// e.g. RequireScope(PermRefresh, next) admits a PermRefresh or PermAdmin
+// token but rejects a PermRead-only one. It's a standalone auth path for
+// the handful of operational endpoints (like /internal/refresh/water/...)
+// that scoped automation tokens drive, independent of Middleware/Enforce's
+// interactive-user RBAC.
+func (s *Service) RequireScope(required Permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request", error_description="malformed authorization header"`)
+			http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
+			return
+		}
+
+		t, err := s.ValidateAPIToken(r.Context(), parts[1])
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", bearerChallenge(err))
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		scopes, err := decodePermissions(t.Scopes)
+		if err != nil || !highestPermission(scopes).Grants(required) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), TokenContextKey, t)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
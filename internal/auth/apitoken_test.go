@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// fakeAuthStore is a minimal in-memory storage.AuthStore, just enough to
+// exercise Issue/ValidateAPIToken/RequireScope without a real GormStorage
+// (the only built-in backend that implements storage.AuthStore, which
+// requires a live Postgres/SQLite connection Issue's tests shouldn't need).
+type fakeAuthStore struct {
+	mu     sync.Mutex
+	tokens map[string]storage.Token
+}
+
+func newFakeAuthStore() *fakeAuthStore {
+	return &fakeAuthStore{tokens: make(map[string]storage.Token)}
+}
+
+func (f *fakeAuthStore) CreateUser(ctx context.Context, user storage.User) error { return nil }
+func (f *fakeAuthStore) GetUser(ctx context.Context, id string) (*storage.User, error) {
+	return nil, nil
+}
+func (f *fakeAuthStore) GetUserByUsername(ctx context.Context, username string) (*storage.User, error) {
+	return nil, nil
+}
+func (f *fakeAuthStore) GetUserByEmail(ctx context.Context, email string) (*storage.User, error) {
+	return nil, nil
+}
+func (f *fakeAuthStore) UpdateUser(ctx context.Context, user storage.User) error { return nil }
+func (f *fakeAuthStore) DeleteUser(ctx context.Context, id string) error         { return nil }
+func (f *fakeAuthStore) ListUsers(ctx context.Context) ([]storage.User, error)   { return nil, nil }
+
+func (f *fakeAuthStore) CreateToken(ctx context.Context, token storage.Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens[token.ID] = token
+	return nil
+}
+
+func (f *fakeAuthStore) GetToken(ctx context.Context, id string) (*storage.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tokens[id]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+func (f *fakeAuthStore) GetTokenByHash(ctx context.Context, hash string) (*storage.Token, error) {
+	return nil, nil
+}
+
+func (f *fakeAuthStore) ListTokens(ctx context.Context, userID string) ([]storage.Token, error) {
+	return nil, nil
+}
+
+func (f *fakeAuthStore) DeleteToken(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.tokens, id)
+	return nil
+}
+
+func (f *fakeAuthStore) UpdateTokenLastUsed(ctx context.Context, id, ip string) error {
+	return nil
+}
+
+func (f *fakeAuthStore) RevokeToken(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tokens[id]
+	if !ok {
+		return nil
+	}
+	now := t.CreatedAt
+	t.RevokedAt = &now
+	f.tokens[id] = t
+	return nil
+}
+
+func (f *fakeAuthStore) RevokeTokenFamily(ctx context.Context, familyID string) error { return nil }
+func (f *fakeAuthStore) RevokeTokensForUser(ctx context.Context, userID string) error { return nil }
+
+func newTestService() *Service {
+	return &Service{storage: newFakeAuthStore()}
+}
+
+func TestIssue_ReturnsOpaqueSecretAndPersistsHashedToken(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+
+	tok, raw, err := svc.Issue(ctx, "admin", []Permission{PermRefresh}, "")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if tok.ID == "" {
+		t.Fatalf("expected a non-empty token ID")
+	}
+	id, secret, ok := splitOpaque(raw)
+	if !ok || id != tok.ID || secret == "" {
+		t.Fatalf("expected raw %q to be %q + a secret", raw, tok.ID)
+	}
+
+	stored, err := svc.storage.GetToken(ctx, tok.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetToken(%s) failed: %v", tok.ID, err)
+	}
+	if stored.TokenHash == "" || stored.TokenHash == secret {
+		t.Fatalf("expected TokenHash to be a bcrypt hash, not the raw secret")
+	}
+}
+
+// splitOpaque mirrors the "<id>.<secret>" split ValidateAPIToken performs,
+// so the test can check Issue's return value without reaching into
+// unexported parsing.
+func splitOpaque(raw string) (id, secret string, ok bool) {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '.' {
+			return raw[:i], raw[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func TestValidateAPIToken_AcceptsLiveTokenRejectsBadSecretAndRevoked(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+
+	tok, raw, err := svc.Issue(ctx, "admin", []Permission{PermRead}, "")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if _, err := svc.ValidateAPIToken(ctx, raw); err != nil {
+		t.Fatalf("expected freshly issued token to validate, got: %v", err)
+	}
+
+	id, _, _ := splitOpaque(raw)
+	if _, err := svc.ValidateAPIToken(ctx, id+".wrong-secret"); err != ErrTokenInvalid {
+		t.Fatalf("expected ErrTokenInvalid for a wrong secret, got: %v", err)
+	}
+
+	if _, err := svc.ValidateAPIToken(ctx, "not-a-token"); err != ErrTokenInvalid {
+		t.Fatalf("expected ErrTokenInvalid for a malformed token, got: %v", err)
+	}
+
+	if err := svc.storage.RevokeToken(ctx, tok.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := svc.ValidateAPIToken(ctx, raw); err != ErrTokenInvalid {
+		t.Fatalf("expected ErrTokenInvalid for a revoked token, got: %v", err)
+	}
+}
+
+func TestRequireScope_EnforcesHighestPermission(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+
+	_, readRaw, err := svc.Issue(ctx, "admin", []Permission{PermRead}, "")
+	if err != nil {
+		t.Fatalf("Issue(read) failed: %v", err)
+	}
+	_, refreshRaw, err := svc.Issue(ctx, "admin", []Permission{PermRefresh}, "")
+	if err != nil {
+		t.Fatalf("Issue(refresh) failed: %v", err)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := svc.RequireScope(PermRefresh, next)
+
+	cases := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"malformed header", "Bearer", http.StatusUnauthorized},
+		{"insufficient scope", "Bearer " + readRaw, http.StatusForbidden},
+		{"sufficient scope", "Bearer " + refreshRaw, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/internal/refresh/cemc", nil)
+			if c.authHeader != "" {
+				req.Header.Set("Authorization", c.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != c.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, c.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// notifyIfNewDevice records meta's fingerprint as known for user, and
+// emails a "new sign-in" alert the first time a fingerprint is seen for
+// them. It never fails Authenticate: storage and email errors are only
+// logged.
+func (s *Service) notifyIfNewDevice(ctx context.Context, user *storage.User, meta RequestMeta) {
+	if s.deviceStore == nil {
+		return
+	}
+	fp := meta.Fingerprint()
+
+	known, err := s.deviceStore.GetKnownDevice(ctx, user.ID, fp)
+	if err != nil {
+		log.Printf("auth: failed to look up known device for %s: %v", user.ID, err)
+		return
+	}
+
+	now := time.Now()
+	if known == nil {
+		known = &storage.KnownDevice{UserID: user.ID, Fingerprint: fp, FirstSeenAt: now}
+	}
+	known.LastSeenAt = now
+	if err := s.deviceStore.SaveKnownDevice(ctx, *known); err != nil {
+		log.Printf("auth: failed to save known device for %s: %v", user.ID, err)
+	}
+
+	if known.FirstSeenAt.Equal(now) && user.Email != "" {
+		if err := s.sendNewSignInEmail(ctx, user.Email, meta); err != nil {
+			log.Printf("auth: failed to send new sign-in alert to %s: %v", user.Email, err)
+		}
+	}
+}
+
+// sendNewSignInEmail alerts the user that their account was just used from
+// a device it hasn't seen before, reusing sendTemplateEmail's HTML shell
+// but without an action button - there's nothing for the recipient to
+// click, only something to notice.
+func (s *Service) sendNewSignInEmail(ctx context.Context, to string, meta RequestMeta) error {
+	ip := meta.IP
+	if ip == "" {
+		ip = "unknown"
+	}
+	ua := meta.UserAgent
+	if ua == "" {
+		ua = "unknown device"
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<style>
+  body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; line-height: 1.6; color: #333; background-color: #f4f4f4; margin: 0; padding: 0; }
+  .container { max-width: 600px; margin: 20px auto; background: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+  .header { background-color: #2563eb; color: #ffffff; padding: 20px; text-align: center; }
+  .content { padding: 30px 20px; }
+  .footer { padding: 20px; text-align: center; font-size: 0.8em; color: #666; background-color: #f9fafb; }
+</style>
+</head>
+<body>
+<div class="container">
+  <div class="header">
+    <h1 style="margin:0; font-size: 24px;">eRateManager</h1>
+  </div>
+  <div class="content">
+    <h2 style="margin-top:0; color: #2563eb; text-align: center;">New sign-in to your account</h2>
+    <p style="text-align: center;">We noticed a sign-in from a device we haven't seen before:</p>
+    <p style="text-align: center;"><strong>IP address:</strong> %s<br><strong>Device:</strong> %s</p>
+    <p style="text-align: center;">If this was you, no action is needed.</p>
+  </div>
+  <div class="footer">
+    <p>If you don't recognize this activity, reset your password immediately and review your active sessions.</p>
+  </div>
+</div>
+</body>
+</html>
+`, ip, ua)
+
+	return s.notifier.SendEmail(ctx, to, "New sign-in to your eRateManager account", htmlBody)
+}
+
+// SessionInfo is a self-service view of a live session token, returned by
+// ListSessions.
+type SessionInfo struct {
+	ID          string     `json:"id"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Kind        string     `json:"kind,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  time.Time  `json:"last_used_at,omitempty"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty"`
+}
+
+// ListSessions returns userID's live (unrevoked, unexpired) session
+// tokens - access and refresh halves of every issued pair - for
+// /api/v1/auth/sessions, so a user can see which devices are currently
+// signed in.
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]SessionInfo, error) {
+	tokens, err := s.storage.ListTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var sessions []SessionInfo
+	for _, t := range tokens {
+		if t.Kind != "access" && t.Kind != "refresh" {
+			continue
+		}
+		if t.RevokedAt != nil {
+			continue
+		}
+		if t.ExpiresAt != nil && t.ExpiresAt.Before(now) {
+			continue
+		}
+		sessions = append(sessions, SessionInfo{
+			ID:          t.ID,
+			Fingerprint: t.Fingerprint,
+			Kind:        t.Kind,
+			CreatedAt:   t.CreatedAt,
+			ExpiresAt:   t.ExpiresAt,
+			LastUsedAt:  t.LastUsedAt,
+			LastUsedIP:  t.LastUsedIP,
+		})
+	}
+	return sessions, nil
+}
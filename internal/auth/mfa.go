@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/auth/mfa"
+	"github.com/bher20/eratemanager/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mfaIssuer names the account in the otpauth:// URI authenticator apps
+// display next to the secret.
+const mfaIssuer = "eRateManager"
+
+// EnrollmentResult is returned by EnrollMFA: the data a client needs to
+// render a QR code (or show the secret for manual entry) and let the user
+// save their recovery codes before VerifyMFAEnrollment is called.
+type EnrollmentResult struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+// EnrollMFA starts TOTP enrollment for userID: generates a new secret and a
+// fresh set of recovery codes, persisting both, but leaves TOTPEnrolled
+// false until VerifyMFAEnrollment confirms the user's authenticator app is
+// actually in sync. Calling it again before verifying replaces the pending
+// secret and codes.
+func (s *Service) EnrollMFA(ctx context.Context, userID string) (*EnrollmentResult, error) {
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encryptedSecret, err := mfa.EncryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashedCodes, err := hashRecoveryCodes(recoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = encryptedSecret
+	user.TOTPEnrolled = false
+	user.RecoveryCodes = hashedCodes
+	user.UpdatedAt = time.Now()
+	if err := s.storage.UpdateUser(ctx, *user); err != nil {
+		return nil, err
+	}
+
+	return &EnrollmentResult{
+		Secret:          secret,
+		ProvisioningURI: mfa.ProvisioningURI(mfaIssuer, user.Username, secret),
+		RecoveryCodes:   recoveryCodes,
+	}, nil
+}
+
+// VerifyMFAEnrollment confirms userID's authenticator app is in sync by
+// checking otp against the pending secret from EnrollMFA, and flips
+// TOTPEnrolled on success so AuthenticateWithMFA starts requiring it.
+func (s *Service) VerifyMFAEnrollment(ctx context.Context, userID, otp string) error {
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if user.TOTPSecret == "" {
+		return errors.New("mfa: enrollment not started")
+	}
+
+	secret, err := mfa.DecryptSecret(user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+	if !mfa.Validate(secret, otp, time.Now()) {
+		return errors.New("mfa: invalid code")
+	}
+
+	user.TOTPEnrolled = true
+	user.UpdatedAt = time.Now()
+	return s.storage.UpdateUser(ctx, *user)
+}
+
+// DisableMFA turns off TOTP enforcement for userID and clears its secret
+// and recovery codes.
+func (s *Service) DisableMFA(ctx context.Context, userID string) error {
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnrolled = false
+	user.RecoveryCodes = ""
+	user.UpdatedAt = time.Now()
+	return s.storage.UpdateUser(ctx, *user)
+}
+
+// AuthenticateWithMFA is Authenticate plus a second factor: once the
+// password checks out, a user with TOTPEnrolled must also supply either a
+// current TOTP code or one of their unused recovery codes (consumed on
+// use).
+func (s *Service) AuthenticateWithMFA(ctx context.Context, username, password, otp string) (*storage.User, error) {
+	user, err := s.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnrolled {
+		return user, nil
+	}
+	if otp == "" {
+		return nil, errors.New("mfa: code required")
+	}
+
+	secret, err := mfa.DecryptSecret(user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Validate(secret, otp, time.Now()) {
+		return user, nil
+	}
+	if s.consumeRecoveryCode(ctx, user, otp) {
+		return user, nil
+	}
+
+	return nil, errors.New("invalid credentials")
+}
+
+// hashRecoveryCodes bcrypt-hashes each plaintext code, the same as
+// passwords, and JSON-encodes the result for storage.User.RecoveryCodes.
+func hashRecoveryCodes(codes []string) (string, error) {
+	hashed := make([]string, len(codes))
+	for i, c := range codes {
+		h, err := bcrypt.GenerateFromPassword([]byte(c), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		hashed[i] = string(h)
+	}
+	encoded, err := json.Marshal(hashed)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// consumeRecoveryCode checks otp against user's unused recovery code
+// hashes, removing the matching one on success so it can't be reused.
+func (s *Service) consumeRecoveryCode(ctx context.Context, user *storage.User, otp string) bool {
+	if user.RecoveryCodes == "" {
+		return false
+	}
+	var hashes []string
+	if err := json.Unmarshal([]byte(user.RecoveryCodes), &hashes); err != nil {
+		return false
+	}
+
+	for i, h := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(h), []byte(otp)) != nil {
+			continue
+		}
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		encoded, err := json.Marshal(remaining)
+		if err != nil {
+			return false
+		}
+		user.RecoveryCodes = string(encoded)
+		user.UpdatedAt = time.Now()
+		_ = s.storage.UpdateUser(ctx, *user)
+		return true
+	}
+	return false
+}
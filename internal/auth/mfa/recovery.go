@@ -0,0 +1,26 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// RecoveryCodeCount is how many single-use recovery codes Enroll generates.
+const RecoveryCodeCount = 10
+
+// GenerateRecoveryCodes returns RecoveryCodeCount fresh single-use codes,
+// formatted like "XXXXX-XXXXX" for easy transcription. Callers are
+// responsible for bcrypt-hashing them before persisting, the same as
+// passwords.
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("mfa: generate recovery code: %w", err)
+		}
+		enc := base32Enc.EncodeToString(b)
+		codes[i] = enc[:4] + "-" + enc[4:8]
+	}
+	return codes, nil
+}
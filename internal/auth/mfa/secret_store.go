@@ -0,0 +1,66 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// EncryptSecret seals plaintext (a TOTP secret) with AES-256-GCM under the
+// key in MFA_ENCRYPTION_KEY, so storage.User.TOTPSecret is never persisted
+// in the clear.
+func EncryptSecret(plaintext string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("mfa: generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret reverses EncryptSecret.
+func DecryptSecret(encoded string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("mfa: decode ciphertext: %w", err)
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", fmt.Errorf("mfa: ciphertext too short")
+	}
+	nonce, sealed := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("mfa: decrypt: %w", err)
+	}
+	return string(plain), nil
+}
+
+// newGCM builds an AES-256-GCM cipher from MFA_ENCRYPTION_KEY (a
+// base64-encoded 32-byte value). Enrollment fails closed if it isn't
+// configured rather than falling back to an unencrypted or hardcoded key.
+func newGCM() (cipher.AEAD, error) {
+	keyB64 := os.Getenv("MFA_ENCRYPTION_KEY")
+	if keyB64 == "" {
+		return nil, fmt.Errorf("mfa: MFA_ENCRYPTION_KEY is not configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("mfa: MFA_ENCRYPTION_KEY must be 32 base64-encoded bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("mfa: new cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/bher20/eratemanager/internal/storage"
@@ -26,6 +28,7 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request", error_description="malformed authorization header"`)
 			http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
 			return
 		}
@@ -33,10 +36,19 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 		tokenValue := parts[1]
 		token, err := s.ValidateToken(r.Context(), tokenValue)
 		if err != nil {
+			w.Header().Set("WWW-Authenticate", bearerChallenge(err))
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
+		if token.RateLimitPerMinute > 0 {
+			if allowed, retryAfter := s.limiter.allow(token.TokenHash, token.RateLimitPerMinute); !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
 		ctx := context.WithValue(r.Context(), TokenContextKey, token)
 		ctx = context.WithValue(ctx, RoleContextKey, token.Role)
 
@@ -44,6 +56,16 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// bearerChallenge renders the WWW-Authenticate value for a ValidateToken
+// error, distinguishing an expired token - worth a refresh - from one that's
+// simply unknown or revoked, per RFC 6750 section 3.
+func bearerChallenge(err error) string {
+	if errors.Is(err, ErrTokenExpired) {
+		return `Bearer error="invalid_token", error_description="token expired"`
+	}
+	return `Bearer error="invalid_token", error_description="token malformed or unknown"`
+}
+
 func (s *Service) RequirePermission(obj, act string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token, ok := r.Context().Value(TokenContextKey).(*storage.Token)
@@ -52,7 +74,7 @@ func (s *Service) RequirePermission(obj, act string, next http.Handler) http.Han
 			return
 		}
 
-		allowed, err := s.Enforce(token.UserID, obj, act)
+		allowed, err := s.EnforceToken(token, obj, act)
 		if err != nil {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
@@ -0,0 +1,141 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims is an ID token's decoded payload, exposing the standard claims
+// LoginWithOIDC relies on directly plus the raw claim set so callers can
+// pull a provider-specific role/group claim by name.
+type Claims struct {
+	Subject       string `json:"sub"`
+	Issuer        string `json:"iss"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Expiry        int64  `json:"exp"`
+
+	// Raw holds every claim in the token so StringClaim/StringSliceClaim
+	// can look up a provider's custom role/group claim without Claims
+	// needing a field per provider's convention.
+	Raw map[string]any `json:"-"`
+}
+
+// StringClaim returns claim's value coerced to a string, or "" if it's
+// absent or not a string.
+func (c *Claims) StringClaim(name string) string {
+	s, _ := c.Raw[name].(string)
+	return s
+}
+
+// StringSliceClaim returns claim's value as a string slice, handling both
+// a JSON array (most providers' "groups"/"roles" claim) and a bare string
+// (some providers collapse a one-element claim).
+func (c *Claims) StringSliceClaim(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	default:
+		return nil
+	}
+}
+
+// VerifyIDToken parses rawIDToken, checks its RS256 signature against
+// jwks, and validates issuer/audience/expiry, returning its claims.
+func VerifyIDToken(rawIDToken string, jwks *JWKSet, issuer, audience string) (*Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("oidc: malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("oidc: decode id_token header: %w", err)
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("oidc: parse id_token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("oidc: unsupported id_token signing alg %q", header.Alg)
+	}
+
+	key, ok := jwks.Key(header.Kid)
+	if !ok {
+		return nil, fmt.Errorf("oidc: no jwks key for kid %q", header.Kid)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("oidc: decode id_token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("oidc: id_token signature verification failed: %w", err)
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("oidc: decode id_token payload: %w", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(payloadJSON, &raw); err != nil {
+		return nil, fmt.Errorf("oidc: parse id_token payload: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, fmt.Errorf("oidc: parse id_token claims: %w", err)
+	}
+	claims.Raw = raw
+
+	if claims.Issuer != issuer {
+		return nil, fmt.Errorf("oidc: id_token issuer %q does not match provider issuer %q", claims.Issuer, issuer)
+	}
+	if !claimsHaveAudience(raw, audience) {
+		return nil, fmt.Errorf("oidc: id_token audience does not include client %q", audience)
+	}
+	if claims.Expiry != 0 && time.Unix(claims.Expiry, 0).Before(time.Now()) {
+		return nil, errors.New("oidc: id_token expired")
+	}
+
+	return &claims, nil
+}
+
+// claimsHaveAudience reports whether raw's "aud" claim - a single string or
+// an array of strings, per the OIDC spec - includes audience.
+func claimsHaveAudience(raw map[string]any, audience string) bool {
+	switch v := raw["aud"].(type) {
+	case string:
+		return v == audience
+	case []any:
+		for _, e := range v {
+			if s, ok := e.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/auth/mfa"
+	"github.com/bher20/eratemanager/internal/auth/oidc"
+	"github.com/bher20/eratemanager/internal/storage"
+	"github.com/google/uuid"
+)
+
+// ErrOIDCNotConfigured is returned by OIDCLoginURL/LoginWithOIDC when the
+// storage backend doesn't implement storage.OIDCStore, so SSO is simply
+// unavailable.
+var ErrOIDCNotConfigured = errors.New("auth: storage backend does not support SSO provider config")
+
+// ConfigureOIDCProvider creates or updates an SSO provider's config,
+// encrypting clientSecret (see auth/mfa.EncryptSecret) the same way
+// EnrollMFA encrypts a TOTP secret rather than storing it in the clear.
+// Passing an empty clientSecret leaves p.ClientSecret as given, letting
+// callers update the rest of a provider's config without resupplying it.
+func (s *Service) ConfigureOIDCProvider(ctx context.Context, p storage.OIDCProvider, clientSecret string) error {
+	if s.oidcStore == nil {
+		return ErrOIDCNotConfigured
+	}
+	if clientSecret != "" {
+		encrypted, err := mfa.EncryptSecret(clientSecret)
+		if err != nil {
+			return err
+		}
+		p.ClientSecret = encrypted
+	}
+	return s.oidcStore.SaveOIDCProvider(ctx, p)
+}
+
+// ListOIDCProviders returns every configured SSO provider.
+func (s *Service) ListOIDCProviders(ctx context.Context) ([]storage.OIDCProvider, error) {
+	if s.oidcStore == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+	return s.oidcStore.ListOIDCProviders(ctx)
+}
+
+// getEnabledOIDCProvider loads providerID, treating a missing or disabled
+// provider the same way so callers can't distinguish "unknown id" from
+// "admin turned it off" - both just mean the login flow can't proceed.
+func (s *Service) getEnabledOIDCProvider(ctx context.Context, providerID string) (*storage.OIDCProvider, error) {
+	if s.oidcStore == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+	p, err := s.oidcStore.GetOIDCProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || !p.Enabled {
+		return nil, fmt.Errorf("auth: unknown or disabled oidc provider %q", providerID)
+	}
+	return p, nil
+}
+
+// OIDCLoginURL returns the authorization URL to redirect the browser to for
+// providerID's SSO flow. state should be a value the caller can verify on
+// the /callback round-trip (e.g. by stashing it in an HTTP-only cookie) -
+// LoginWithOIDC itself only checks that state was actually supplied.
+func (s *Service) OIDCLoginURL(ctx context.Context, providerID, state string) (string, error) {
+	p, err := s.getEnabledOIDCProvider(ctx, providerID)
+	if err != nil {
+		return "", err
+	}
+
+	disc, err := oidc.Discover(ctx, p.IssuerURL)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.Scopes
+	if scopes == "" {
+		scopes = "openid email profile"
+	}
+	return oidc.AuthURL(disc, p.ClientID, p.RedirectURI, scopes, state), nil
+}
+
+// LoginWithOIDC exchanges code for an ID token against providerID's
+// provider, verifies it, and either matches its verified email to an
+// existing storage.User or JIT-provisions one with the provider's
+// DefaultRole. Casbin's grouping policy is synced from the resolved role on
+// every call, so a role change upstream (e.g. the user moved "groups" in
+// the IdP) takes effect the next time they sign in.
+//
+// Verifying that state matches the value OIDCLoginURL was called with is
+// the caller's responsibility (the same way validating a CSRF token is an
+// HTTP-layer concern elsewhere in this package) - LoginWithOIDC only checks
+// that one was supplied at all.
+func (s *Service) LoginWithOIDC(ctx context.Context, providerID, code, state string) (*Session, error) {
+	if state == "" {
+		return nil, errors.New("auth: missing state")
+	}
+
+	p, err := s.getEnabledOIDCProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret, err := mfa.DecryptSecret(p.ClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decrypt oidc client secret: %w", err)
+	}
+
+	disc, err := oidc.Discover(ctx, p.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err := oidc.ExchangeCode(ctx, disc, p.ClientID, clientSecret, p.RedirectURI, code)
+	if err != nil {
+		return nil, err
+	}
+
+	jwks, err := oidc.FetchJWKS(ctx, disc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := oidc.VerifyIDToken(tok.IDToken, jwks, disc.Issuer, p.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.New("auth: oidc provider did not return a verified email")
+	}
+
+	role := resolveOIDCRole(p, claims)
+
+	user, err := s.storage.GetUserByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		user = &storage.User{
+			ID:            uuid.New().String(),
+			Username:      claims.Email,
+			Email:         claims.Email,
+			EmailVerified: true,
+			Role:          role,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		if err := s.storage.CreateUser(ctx, *user); err != nil {
+			return nil, fmt.Errorf("auth: jit-provision oidc user: %w", err)
+		}
+		s.enforcer.AddGroupingPolicy(user.ID, role, userDomain(*user))
+	} else if user.Role != role {
+		if _, err := s.UpdateUser(ctx, user.ID, "", role, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.IssueSession(ctx, user.ID, role)
+}
+
+// resolveOIDCRole maps claims' RoleClaim values onto an eRateManager role
+// via p.RoleMap, falling back to p.DefaultRole when RoleClaim is unset or
+// none of its values are in RoleMap.
+func resolveOIDCRole(p *storage.OIDCProvider, claims *oidc.Claims) string {
+	if p.RoleClaim == "" || p.RoleMap == "" {
+		return p.DefaultRole
+	}
+
+	var roleMap map[string]string
+	if err := json.Unmarshal([]byte(p.RoleMap), &roleMap); err != nil {
+		return p.DefaultRole
+	}
+
+	for _, v := range claims.StringSliceClaim(p.RoleClaim) {
+		if role, ok := roleMap[v]; ok {
+			return role
+		}
+	}
+	return p.DefaultRole
+}
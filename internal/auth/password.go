@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bher20/eratemanager/internal/auth/password"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// ValidatePassword checks pw against the current password policy (and, if
+// enabled, the HIBP breach check) without persisting anything. It backs
+// Register/ResetPassword/SetupInvitedAccount's own enforcement and is also
+// exposed directly via /api/v1/auth/password/validate so a frontend can
+// give the user feedback before submitting. username and email narrow the
+// DisallowUsernameSubstring rule and may be "".
+func (s *Service) ValidatePassword(ctx context.Context, username, email, pw string) error {
+	policy, config, err := s.passwordPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := password.Validate(policy, pw, username, email); err != nil {
+		return err
+	}
+
+	if config == nil || !config.HIBPEnabled {
+		return nil
+	}
+
+	var allowlist []string
+	if config.HIBPAllowlist != "" {
+		if err := json.Unmarshal([]byte(config.HIBPAllowlist), &allowlist); err != nil {
+			return err
+		}
+	}
+	checker := password.NewHIBPChecker(config.HIBPThreshold, allowlist)
+	return checker.Check(ctx, pw)
+}
+
+// passwordPolicy loads the active password.Policy, falling back to
+// password.DefaultPolicy() when no storage.PasswordPolicyConfig has been
+// saved yet or the backend doesn't implement storage.PasswordPolicyStore.
+// config is returned alongside (nil in both fallback cases) so
+// ValidatePassword can also read its HIBP settings without a second fetch.
+func (s *Service) passwordPolicy(ctx context.Context) (password.Policy, *storage.PasswordPolicyConfig, error) {
+	if s.passwordPolicyStore == nil {
+		return password.DefaultPolicy(), nil, nil
+	}
+
+	config, err := s.passwordPolicyStore.GetPasswordPolicyConfig(ctx)
+	if err != nil {
+		return password.Policy{}, nil, err
+	}
+	if config == nil {
+		return password.DefaultPolicy(), nil, nil
+	}
+
+	return password.Policy{
+		MinLength:                 config.MinLength,
+		MaxLength:                 config.MaxLength,
+		RequireUpper:              config.RequireUpper,
+		RequireLower:              config.RequireLower,
+		RequireDigit:              config.RequireDigit,
+		RequireSymbol:             config.RequireSymbol,
+		DisallowUsernameSubstring: config.DisallowUsernameSubstring,
+		MinEntropyBits:            config.MinEntropyBits,
+	}, config, nil
+}
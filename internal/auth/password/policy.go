@@ -0,0 +1,144 @@
+// Package password implements the password policy and breach-check rules
+// auth.Service enforces on Register, ResetPassword, and SetupInvitedAccount.
+// It has no dependency on storage or auth so it can be used and tested
+// independently, the same as auth/mfa and auth/oidc.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Policy configures what Validate requires of a candidate password.
+// MinEntropyBits uses a simplified character-set-size estimate (bits =
+// length * log2(combined size of the character classes present), see
+// entropyBits), not a full
+// zxcvbn-style dictionary/pattern scorer - there's no such dependency
+// available in this tree, and the estimate is deliberately conservative
+// (it never overestimates strength the way a naive length-only check
+// would).
+type Policy struct {
+	MinLength                 int
+	MaxLength                 int
+	RequireUpper              bool
+	RequireLower              bool
+	RequireDigit              bool
+	RequireSymbol             bool
+	DisallowUsernameSubstring bool
+	MinEntropyBits            float64
+}
+
+// DefaultPolicy is applied when no storage.PasswordPolicyConfig has been
+// saved yet, or on a backend that doesn't implement
+// storage.PasswordPolicyStore at all.
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:                 8,
+		MaxLength:                 128,
+		RequireUpper:              true,
+		RequireLower:              true,
+		RequireDigit:              true,
+		RequireSymbol:             false,
+		DisallowUsernameSubstring: true,
+		MinEntropyBits:            0,
+	}
+}
+
+// Validate checks pw against policy, returning the first rule it fails.
+// username and email are used only for DisallowUsernameSubstring and may
+// be "".
+func Validate(policy Policy, pw, username, email string) error {
+	if policy.MinLength > 0 && len(pw) < policy.MinLength {
+		return fmt.Errorf("password must be at least %d characters", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && len(pw) > policy.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", policy.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUpper && !hasUpper {
+		return errors.New("password must contain an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		return errors.New("password must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return errors.New("password must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return errors.New("password must contain a symbol")
+	}
+
+	if policy.DisallowUsernameSubstring {
+		lower := strings.ToLower(pw)
+		if username != "" && len(username) >= 3 && strings.Contains(lower, strings.ToLower(username)) {
+			return errors.New("password must not contain your username")
+		}
+		if email != "" && len(email) >= 3 && strings.Contains(lower, strings.ToLower(email)) {
+			return errors.New("password must not contain your email")
+		}
+	}
+
+	if policy.MinEntropyBits > 0 && entropyBits(pw) < policy.MinEntropyBits {
+		return errors.New("password is too weak")
+	}
+
+	return nil
+}
+
+// entropyBits estimates pw's strength as length * log2(poolSize), where
+// poolSize is the size of the smallest character set containing every
+// distinct class actually used (lowercase 26, +uppercase 52, +digits 62,
+// +symbols 94). This rewards longer passwords and wider character variety
+// without attempting dictionary or pattern detection.
+func entropyBits(pw string) float64 {
+	if pw == "" {
+		return 0
+	}
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	poolSize := 0
+	if hasLower {
+		poolSize += 26
+	}
+	if hasUpper {
+		poolSize += 26
+	}
+	if hasDigit {
+		poolSize += 10
+	}
+	if hasSymbol {
+		poolSize += 32
+	}
+	if poolSize == 0 {
+		return 0
+	}
+
+	return float64(len(pw)) * math.Log2(float64(poolSize))
+}
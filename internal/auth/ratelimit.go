@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter implements a continuous-refill token bucket per key, used by
+// Middleware to enforce Token.RateLimitPerMinute. It's in-memory only: a
+// single eratemanager process's limits, not shared across replicas.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens     float64
+	capacity   float64
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow reports whether a request against key is permitted under a bucket
+// refilling to perMinute tokens/minute, creating the bucket (full) on first
+// use. When denied, retryAfter is how long the caller should wait before
+// the next token becomes available, rounded up to a whole second.
+func (l *rateLimiter) allow(key string, perMinute int) (ok bool, retryAfter time.Duration) {
+	if perMinute <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{
+			tokens:     float64(perMinute),
+			capacity:   float64(perMinute),
+			refillRate: float64(perMinute) / 60,
+			lastRefill: now,
+		}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens += elapsed * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		deficit := 1 - b.tokens
+		wait := time.Duration(deficit/b.refillRate*float64(time.Second)) + time.Second
+		return false, wait.Truncate(time.Second)
+	}
+
+	b.tokens--
+	return true, 0
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// requestMetaKey is the context key WithRequestMeta stores a RequestMeta
+// under.
+type requestMetaKey struct{}
+
+// RequestMeta carries the caller IP/User-Agent an HTTP handler observed,
+// threaded through ctx so Service's audit logging can record them without
+// every Service method taking them as explicit parameters - most callers
+// (the CLI, batch jobs, OIDC login) have no such thing to report.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
+
+// WithRequestMeta returns a context carrying meta, for HTTP handlers to
+// attach before calling into Service so its audit log records who made the
+// request.
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// requestMetaFromContext returns the RequestMeta attached to ctx, or the
+// zero value if none was attached.
+func requestMetaFromContext(ctx context.Context) RequestMeta {
+	meta, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta
+}
+
+// Fingerprint returns a stable identifier for the device a request came
+// from, used by Authenticate to recognize a never-before-seen device and
+// to key per-source-IP login throttling. It's recorded on session tokens
+// (storage.Token.Fingerprint) and compared against storage.KnownDevice on
+// each login.
+func (m RequestMeta) Fingerprint() string {
+	sum := sha256.Sum256([]byte(m.IP + "|" + m.UserAgent))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// encodeScopes JSON-encodes scopes for storage.Token.Scopes, returning ""
+// (not "[]" or "null") for an empty/nil slice so "no scopes recorded" reads
+// the same way an older, pre-scopes token does.
+func encodeScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(scopes)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decodeScopes reverses encodeScopes, returning a nil slice for "".
+func decodeScopes(encoded string) ([]string, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	var scopes []string
+	if err := json.Unmarshal([]byte(encoded), &scopes); err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// EnforceToken reports whether token may perform act on obj: its user's
+// role must grant it (the same check Enforce does), and if the token
+// itself carries scopes, at least one of them must also grant it. A token
+// with no scopes recorded inherits its role's full grant, matching
+// behavior from before per-token scoping existed.
+//
+// A scope is "resource:action", e.g. "rates:read" or "settings:*" - a "*"
+// on either half matches anything, the same wildcard Enforce's Casbin
+// matcher already gives roles.
+func (s *Service) EnforceToken(token *storage.Token, obj, act string) (bool, error) {
+	allowed, err := s.Enforce(token.UserID, obj, act)
+	if err != nil || !allowed {
+		return false, err
+	}
+
+	scopes, err := decodeScopes(token.Scopes)
+	if err != nil {
+		return false, err
+	}
+	if len(scopes) == 0 {
+		return true, nil
+	}
+
+	for _, scope := range scopes {
+		if scopeGrants(scope, obj, act) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// scopeGrants reports whether scope (a "resource:action" string) grants
+// act on obj.
+func scopeGrants(scope, obj, act string) bool {
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok {
+		return false
+	}
+	if resource != obj && resource != "*" {
+		return false
+	}
+	return action == act || action == "*"
+}
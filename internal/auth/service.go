@@ -9,6 +9,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/bher20/eratemanager/internal/audit"
 	"github.com/bher20/eratemanager/internal/notification"
 	"github.com/bher20/eratemanager/internal/storage"
 	"github.com/casbin/casbin/v2"
@@ -17,38 +18,128 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// accessTokenTTL and refreshTokenTTL bound the two halves of a session
+// issued by IssueSession/RefreshSession: a short-lived access token that's
+// sent on every request, and a longer-lived refresh token that's only sent
+// to mint a new pair.
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// ErrTokenInvalid and ErrTokenExpired let Middleware tell a bad token from
+// an expired one apart, so it can send a different WWW-Authenticate
+// challenge and callers know whether a refresh is worth attempting.
+var (
+	ErrTokenInvalid = errors.New("auth: invalid token")
+	ErrTokenExpired = errors.New("auth: token expired")
+	// ErrRefreshTokenReused is returned by RefreshSession when a refresh
+	// token is presented after it (or an earlier token in its rotation
+	// chain) was already used to mint a new pair. This indicates the token
+	// was stolen, so the entire session family is revoked in response.
+	ErrRefreshTokenReused = errors.New("auth: refresh token reuse detected, session revoked")
+)
+
 type Service struct {
-	storage   storage.Storage
+	storage   storage.AuthStore
 	enforcer  *casbin.Enforcer
 	adapter   *Adapter
 	notifier  *notification.Service
 	publicURL string
+	// oidcStore persists SSO provider config for LoginWithOIDC. Left nil on
+	// backends that don't implement storage.OIDCStore, in which case SSO
+	// login is simply unavailable.
+	oidcStore storage.OIDCStore
+	// audit records authentication attempts and policy changes. Left nil on
+	// backends that don't implement storage.AuditStore, in which case audit
+	// logging is simply unavailable; audit.Logger.Log is a no-op on a nil
+	// receiver so call sites don't need to check this themselves.
+	audit *audit.Logger
+	// limiter enforces Token.RateLimitPerMinute in Middleware. It's pure
+	// in-memory (not storage-backed), so limits reset on restart and aren't
+	// shared across multiple eratemanager instances.
+	limiter *rateLimiter
+	// passwordPolicyStore persists the runtime-configurable password
+	// policy consulted by ValidatePassword. Left nil on backends that don't
+	// implement storage.PasswordPolicyStore, in which case
+	// password.DefaultPolicy() applies and the HIBP check is disabled.
+	passwordPolicyStore storage.PasswordPolicyStore
+	// loginAttemptStore backs Authenticate's per-key lockout/backoff. Left
+	// nil on backends that don't implement storage.LoginAttemptStore, in
+	// which case Authenticate applies no throttling at all.
+	loginAttemptStore storage.LoginAttemptStore
+	// deviceStore backs Authenticate's new-sign-in notification. Left nil
+	// on backends that don't implement storage.DeviceStore, in which case
+	// no device is ever "known" and the notification is never sent.
+	deviceStore storage.DeviceStore
+}
+
+// defaultDomain is the Casbin domain used by every policy/grouping API that
+// predates multi-tenancy (Enforce, AddPolicy, AddGroupingPolicy, ...) and by
+// users with no storage.User.Domain set, so a single-tenant deployment never
+// has to think about domains at all.
+const defaultDomain = "*"
+
+// userDomain returns u's Casbin domain: u.Domain if set, else defaultDomain.
+func userDomain(u storage.User) string {
+	if u.Domain != "" {
+		return u.Domain
+	}
+	return defaultDomain
+}
+
+// Session is the access/refresh token pair returned by IssueSession and
+// RefreshSession.
+type Session struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
 
 func NewService(s storage.Storage, n *notification.Service, publicURL string) (*Service, error) {
 	// Initialize Casbin model
+	// sub/dom/obj/act plus g (user/role -> role, scoped to a domain) lets
+	// multi-tenant deployments scope a role assignment to a single
+	// organization/provider (see storage.User.Domain), while g2 (role ->
+	// role, domain-independent) is a separate role-hierarchy graph managed
+	// entirely in Go by AddRoleInheritance/EnforceInDomain - Casbin's own
+	// role manager only resolves g's transitive closure, so g2's edges are
+	// walked by hand rather than folded into the matcher below. Every API
+	// that predates domains (Enforce, AddPolicy, AddGroupingPolicy, ...)
+	// keeps working unmodified by always reading/writing defaultDomain, and
+	// a policy/grouping row stored with domain "*" matches any requested
+	// domain.
 	m, err := model.NewModelFromString(`
 [request_definition]
-r = sub, obj, act
+r = sub, dom, obj, act
 
 [policy_definition]
-p = sub, obj, act
+p = sub, dom, obj, act
 
 [role_definition]
-g = _, _
+g = _, _, _
+g2 = _, _
 
 [policy_effect]
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && (r.obj == p.obj || p.obj == "*") && (r.act == p.act || p.act == "*")
+m = g(r.sub, p.sub, r.dom) && (r.obj == p.obj || p.obj == "*") && (r.act == p.act || p.act == "*") && (r.dom == p.dom || p.dom == "*")
 `)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create adapter for database persistence
-	adapter := NewAdapter(s)
+	cs, ok := s.(storage.CasbinStore)
+	if !ok {
+		return nil, fmt.Errorf("auth: storage backend does not support casbin policy persistence")
+	}
+	as, ok := s.(storage.AuthStore)
+	if !ok {
+		return nil, fmt.Errorf("auth: storage backend does not support user/token persistence")
+	}
+	adapter := NewAdapter(cs)
 
 	// Create enforcer with adapter for persistence
 	e, err := casbin.NewEnforcer(m, adapter)
@@ -71,22 +162,22 @@ m = g(r.sub, p.sub) && (r.obj == p.obj || p.obj == "*") && (r.act == p.act || p.
 		log.Println("auth: no policies found in database, adding defaults")
 		
 		// Admin can do everything
-		e.AddPolicy("admin", "*", "*")
+		e.AddPolicy("admin", defaultDomain, "*", "*")
 		// Editor can read and write rates/providers
-		e.AddPolicy("editor", "rates", "read")
-		e.AddPolicy("editor", "rates", "write")
-		e.AddPolicy("editor", "providers", "read")
-		e.AddPolicy("editor", "providers", "write")
+		e.AddPolicy("editor", defaultDomain, "rates", "read")
+		e.AddPolicy("editor", defaultDomain, "rates", "write")
+		e.AddPolicy("editor", defaultDomain, "providers", "read")
+		e.AddPolicy("editor", defaultDomain, "providers", "write")
 		// Viewer can only read
-		e.AddPolicy("viewer", "rates", "read")
-		e.AddPolicy("viewer", "providers", "read")
+		e.AddPolicy("viewer", defaultDomain, "rates", "read")
+		e.AddPolicy("viewer", defaultDomain, "providers", "read")
 	} else {
 		log.Printf("auth: loaded %d policies from database", len(policies))
 	}
 
 	// Load existing users and ensure their role mappings exist
 	ctx := context.Background()
-	users, err := s.ListUsers(ctx)
+	users, err := as.ListUsers(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +186,7 @@ m = g(r.sub, p.sub) && (r.obj == p.obj || p.obj == "*") && (r.act == p.act || p.
 		log.Printf("auth: syncing user %s role=%q", u.ID, u.Role)
 		if u.Role != "" {
 			// AddGroupingPolicy is idempotent - won't duplicate
-			added, err := e.AddGroupingPolicy(u.ID, u.Role)
+			added, err := e.AddGroupingPolicy(u.ID, u.Role, userDomain(u))
 			if err != nil {
 				log.Printf("auth: error adding policy for user %s: %v", u.ID, err)
 			} else if added {
@@ -104,26 +195,72 @@ m = g(r.sub, p.sub) && (r.obj == p.obj || p.obj == "*") && (r.act == p.act || p.
 		}
 	}
 
+	// OIDC provider config is optional: only GormStorage implements
+	// OIDCStore today, so other backends simply can't have SSO configured.
+	oidcStore, _ := s.(storage.OIDCStore)
+
+	// Audit logging is optional the same way: only GormStorage implements
+	// AuditStore today.
+	var auditLogger *audit.Logger
+	if auditStore, ok := s.(storage.AuditStore); ok {
+		auditLogger = audit.NewLogger(auditStore)
+	}
+
+	passwordPolicyStore, _ := s.(storage.PasswordPolicyStore)
+	loginAttemptStore, _ := s.(storage.LoginAttemptStore)
+	deviceStore, _ := s.(storage.DeviceStore)
+
 	return &Service{
-		storage:   s,
-		enforcer:  e,
-		adapter:   adapter,
-		notifier:  n,
-		publicURL: publicURL,
+		storage:             as,
+		enforcer:            e,
+		adapter:             adapter,
+		notifier:            n,
+		publicURL:           publicURL,
+		oidcStore:           oidcStore,
+		audit:               auditLogger,
+		limiter:             newRateLimiter(),
+		passwordPolicyStore: passwordPolicyStore,
+		loginAttemptStore:   loginAttemptStore,
+		deviceStore:         deviceStore,
 	}, nil
 }
 
+// Authenticate checks username/password, applying per-username and
+// per-source-IP login throttling (see lockout.go) before ever touching
+// bcrypt, and returns the same generic error on every failure - unknown
+// username, locked out, or wrong password - so a caller can't use the
+// response to enumerate valid usernames.
 func (s *Service) Authenticate(ctx context.Context, username, password string) (*storage.User, error) {
+	meta := requestMetaFromContext(ctx)
+	userKey, ipKey := loginAttemptKey("user", username), loginAttemptKey("ip", meta.IP)
+
+	locked, wait := s.checkLoginThrottle(ctx, userKey, ipKey)
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	if locked {
+		s.audit.Log(ctx, audit.Event{Action: "authenticate", Success: false, Target: username, Resource: "user", Detail: "locked out", IP: meta.IP, UserAgent: meta.UserAgent})
+		return nil, errors.New("invalid credentials")
+	}
+
 	u, err := s.storage.GetUserByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
 	if u == nil {
+		s.recordLoginFailure(ctx, userKey, ipKey)
+		s.audit.Log(ctx, audit.Event{Action: "authenticate", Success: false, Target: username, Resource: "user", IP: meta.IP, UserAgent: meta.UserAgent})
 		return nil, errors.New("invalid credentials")
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		s.recordLoginFailure(ctx, userKey, ipKey)
+		s.audit.Log(ctx, audit.Event{Actor: u.ID, Action: "authenticate", Success: false, Target: username, Resource: "user", IP: meta.IP, UserAgent: meta.UserAgent})
 		return nil, errors.New("invalid credentials")
 	}
+
+	s.clearLoginThrottle(ctx, userKey, ipKey)
+	s.audit.Log(ctx, audit.Event{Actor: u.ID, Action: "authenticate", Success: true, Target: username, Resource: "user", IP: meta.IP, UserAgent: meta.UserAgent})
+	s.notifyIfNewDevice(ctx, u, meta)
 	return u, nil
 }
 
@@ -160,6 +297,15 @@ func (s *Service) register(ctx context.Context, username, firstName, lastName, p
 		return nil, errors.New("email is required")
 	}
 
+	// Invited users get a random password they never see or type (they set
+	// their own via SetupInvitedAccount, which enforces the policy itself),
+	// so it's exempt here.
+	if !isInvite {
+		if err := s.ValidatePassword(ctx, username, email, password); err != nil {
+			return nil, err
+		}
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -183,7 +329,10 @@ func (s *Service) register(ctx context.Context, username, firstName, lastName, p
 	}
 
 	// Add user to role in Casbin
-	s.enforcer.AddGroupingPolicy(u.ID, role)
+	s.enforcer.AddGroupingPolicy(u.ID, role, userDomain(u))
+
+	meta := requestMetaFromContext(ctx)
+	s.audit.Log(ctx, audit.Event{Actor: u.ID, Action: "register", Success: true, Target: u.ID, Resource: "user", Detail: map[string]string{"username": username, "role": role}, IP: meta.IP, UserAgent: meta.UserAgent})
 
 	// Send appropriate email
 	go func() {
@@ -201,7 +350,24 @@ func (s *Service) register(ctx context.Context, username, firstName, lastName, p
 	return &u, nil
 }
 
-func (s *Service) CreateToken(ctx context.Context, userID, name, role string, expiresAt *time.Time) (*storage.Token, string, error) {
+// CreateToken mints a one-shot or API-key token for userID. scopes, if
+// non-empty, narrows what the token can do within role's grants (see
+// EnforceToken); rateLimitPerMinute, if > 0, caps requests/minute for this
+// token specifically in Middleware.
+func (s *Service) CreateToken(ctx context.Context, userID, name, role string, scopes []string, rateLimitPerMinute int, expiresAt *time.Time) (*storage.Token, string, error) {
+	t, raw, err := s.createToken(ctx, userID, name, role, "", "", scopes, rateLimitPerMinute, expiresAt)
+	if err == nil {
+		meta := requestMetaFromContext(ctx)
+		s.audit.Log(ctx, audit.Event{Actor: userID, Action: "create_token", Success: true, Target: t.ID, Resource: "token", Detail: map[string]any{"name": name, "scopes": scopes}, IP: meta.IP, UserAgent: meta.UserAgent})
+	}
+	return t, raw, err
+}
+
+// createToken is the shared constructor behind the public CreateToken (one-
+// shot verification/reset links and API keys, kind "") and issueSessionPair
+// (session access/refresh tokens, kind "access"/"refresh" sharing familyID,
+// which never carry scopes or a rate limit of their own).
+func (s *Service) createToken(ctx context.Context, userID, name, role, kind, familyID string, scopes []string, rateLimitPerMinute int, expiresAt *time.Time) (*storage.Token, string, error) {
 	// Generate token
 	rawToken := uuid.New().String() + uuid.New().String()
 
@@ -210,14 +376,24 @@ func (s *Service) CreateToken(ctx context.Context, userID, name, role string, ex
 	hasher.Write([]byte(rawToken))
 	tokenHash := hex.EncodeToString(hasher.Sum(nil))
 
+	encodedScopes, err := encodeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
 	t := storage.Token{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		Name:      name,
-		TokenHash: tokenHash,
-		Role:      role,
-		CreatedAt: time.Now(),
-		ExpiresAt: expiresAt,
+		ID:                 uuid.New().String(),
+		UserID:             userID,
+		Name:               name,
+		TokenHash:          tokenHash,
+		Role:               role,
+		CreatedAt:          time.Now(),
+		ExpiresAt:          expiresAt,
+		Kind:               kind,
+		FamilyID:           familyID,
+		Scopes:             encodedScopes,
+		RateLimitPerMinute: rateLimitPerMinute,
+		Fingerprint:        requestMetaFromContext(ctx).Fingerprint(),
 	}
 
 	if err := s.storage.CreateToken(ctx, t); err != nil {
@@ -227,6 +403,9 @@ func (s *Service) CreateToken(ctx context.Context, userID, name, role string, ex
 	return &t, rawToken, nil
 }
 
+// ValidateToken looks up rawToken and returns it if it is live: known, not
+// revoked, and not expired. It returns ErrTokenInvalid or ErrTokenExpired so
+// callers like Middleware can send the right WWW-Authenticate challenge.
 func (s *Service) ValidateToken(ctx context.Context, rawToken string) (*storage.Token, error) {
 	hasher := sha256.New()
 	hasher.Write([]byte(rawToken))
@@ -236,22 +415,140 @@ func (s *Service) ValidateToken(ctx context.Context, rawToken string) (*storage.
 	if err != nil {
 		return nil, err
 	}
-	if t == nil {
-		return nil, errors.New("invalid token")
+	meta := requestMetaFromContext(ctx)
+	if t == nil || t.RevokedAt != nil {
+		s.audit.Log(ctx, audit.Event{Action: "validate_token", Success: false, Resource: "token", Detail: "invalid", IP: meta.IP, UserAgent: meta.UserAgent})
+		return nil, ErrTokenInvalid
 	}
 
 	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("token expired")
+		s.audit.Log(ctx, audit.Event{Actor: t.UserID, Action: "validate_token", Success: false, Target: t.ID, Resource: "token", Detail: "expired", IP: meta.IP, UserAgent: meta.UserAgent})
+		return nil, ErrTokenExpired
 	}
 
 	// Update last used
-	go s.storage.UpdateTokenLastUsed(context.Background(), t.ID)
+	go s.storage.UpdateTokenLastUsed(context.Background(), t.ID, meta.IP)
 
 	return t, nil
 }
 
+// IssueSession creates a fresh access/refresh token pair for userID, in a
+// new rotation family.
+func (s *Service) IssueSession(ctx context.Context, userID, role string) (*Session, error) {
+	return s.issueSessionPair(ctx, userID, role, uuid.New().String())
+}
+
+// issueSessionPair mints an access token (accessTokenTTL) and a refresh
+// token (refreshTokenTTL), both tagged with familyID so a later reuse of
+// the refresh token can be traced back to every token issued alongside it.
+func (s *Service) issueSessionPair(ctx context.Context, userID, role, familyID string) (*Session, error) {
+	accessExpiresAt := time.Now().Add(accessTokenTTL)
+	_, accessRaw, err := s.createToken(ctx, userID, "access", role, "access", familyID, nil, 0, &accessExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("issue access token: %w", err)
+	}
+
+	refreshExpiresAt := time.Now().Add(refreshTokenTTL)
+	_, refreshRaw, err := s.createToken(ctx, userID, "refresh", role, "refresh", familyID, nil, 0, &refreshExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("issue refresh token: %w", err)
+	}
+
+	return &Session{AccessToken: accessRaw, RefreshToken: refreshRaw, ExpiresAt: accessExpiresAt}, nil
+}
+
+// RefreshSession exchanges rawRefreshToken for a new access/refresh pair,
+// revoking rawRefreshToken in the process so it can't be used again. If
+// rawRefreshToken was already revoked - meaning it was already exchanged
+// once before - this is treated as the token having been stolen and replayed,
+// and the entire rotation family is revoked, returning ErrRefreshTokenReused.
+func (s *Service) RefreshSession(ctx context.Context, rawRefreshToken string) (*Session, error) {
+	hasher := sha256.New()
+	hasher.Write([]byte(rawRefreshToken))
+	tokenHash := hex.EncodeToString(hasher.Sum(nil))
+
+	t, err := s.storage.GetTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.Kind != "refresh" {
+		return nil, ErrTokenInvalid
+	}
+	if t.RevokedAt != nil {
+		if err := s.storage.RevokeTokenFamily(ctx, t.FamilyID); err != nil {
+			log.Printf("auth: failed to revoke token family %s after refresh token reuse: %v", t.FamilyID, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return nil, ErrTokenExpired
+	}
+
+	if err := s.storage.RevokeToken(ctx, t.ID); err != nil {
+		return nil, fmt.Errorf("revoke used refresh token: %w", err)
+	}
+
+	return s.issueSessionPair(ctx, t.UserID, t.Role, t.FamilyID)
+}
+
+// RevokeToken revokes a single token (e.g. on logout).
+func (s *Service) RevokeToken(ctx context.Context, tokenID string) error {
+	return s.storage.RevokeToken(ctx, tokenID)
+}
+
+// RevokeAllForUser revokes every outstanding token for userID, e.g. after a
+// password change or a suspected account compromise.
+func (s *Service) RevokeAllForUser(ctx context.Context, userID string) error {
+	return s.storage.RevokeTokensForUser(ctx, userID)
+}
+
+// Enforce reports whether sub is allowed to act on obj, within
+// defaultDomain - the single-tenant entry point every pre-existing caller
+// uses. Multi-tenant callers that need to scope the check to one
+// organization/provider should call EnforceInDomain instead.
 func (s *Service) Enforce(sub, obj, act string) (bool, error) {
-	return s.enforcer.Enforce(sub, obj, act)
+	return s.EnforceInDomain(sub, defaultDomain, obj, act)
+}
+
+// EnforceInDomain reports whether sub is allowed to act on obj within dom.
+// It also walks sub's role(s) up the AddRoleInheritance hierarchy (g2),
+// which Casbin's own role manager doesn't resolve on its own since g2 is
+// kept separate from the domain-scoped g grouping: a policy granted to a
+// parent role (e.g. "editor") is automatically granted to every role that
+// inherits it (e.g. "admin"), in every domain that role holds.
+func (s *Service) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	if allowed, err := s.enforcer.Enforce(sub, dom, obj, act); err != nil || allowed {
+		return allowed, err
+	}
+
+	roles, err := s.enforcer.GetImplicitRolesForUser(sub, dom)
+	if err != nil {
+		return false, err
+	}
+
+	checked := map[string]bool{sub: true}
+	for _, role := range roles {
+		if checked[role] {
+			continue
+		}
+		checked[role] = true
+		ancestors, err := s.roleHierarchyAncestors(role)
+		if err != nil {
+			return false, err
+		}
+		for _, ancestor := range ancestors {
+			if checked[ancestor] {
+				continue
+			}
+			checked[ancestor] = true
+			if allowed, err := s.enforcer.Enforce(ancestor, dom, obj, act); err != nil {
+				return false, err
+			} else if allowed {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
 }
 
 func (s *Service) LoadPolicy() error {
@@ -266,20 +563,146 @@ func (s *Service) GetAllPolicies() ([][]string, error) {
 	return s.enforcer.GetPolicy()
 }
 
-func (s *Service) AddPolicy(role, resource, action string) (bool, error) {
-	return s.enforcer.AddPolicy(role, resource, action)
+func (s *Service) AddPolicy(ctx context.Context, role, resource, action string) (bool, error) {
+	added, err := s.enforcer.AddPolicy(role, defaultDomain, resource, action)
+	if err == nil {
+		meta := requestMetaFromContext(ctx)
+		s.audit.Log(ctx, audit.Event{Action: "add_policy", Success: added, Target: role, Resource: "policy", Detail: map[string]string{"resource": resource, "action": action}, IP: meta.IP, UserAgent: meta.UserAgent})
+	}
+	return added, err
+}
+
+func (s *Service) RemovePolicy(ctx context.Context, role, resource, action string) (bool, error) {
+	removed, err := s.enforcer.RemovePolicy(role, defaultDomain, resource, action)
+	if err == nil {
+		meta := requestMetaFromContext(ctx)
+		s.audit.Log(ctx, audit.Event{Action: "remove_policy", Success: removed, Target: role, Resource: "policy", Detail: map[string]string{"resource": resource, "action": action}, IP: meta.IP, UserAgent: meta.UserAgent})
+	}
+	return removed, err
+}
+
+func (s *Service) AddGroupingPolicy(ctx context.Context, user, role string) (bool, error) {
+	return s.AddGroupingPolicyInDomain(ctx, user, role, defaultDomain)
+}
+
+func (s *Service) RemoveGroupingPolicy(ctx context.Context, user, role string) (bool, error) {
+	return s.RemoveGroupingPolicyInDomain(ctx, user, role, defaultDomain)
+}
+
+// AddGroupingPolicyInDomain assigns user to role within dom, the
+// multi-tenant counterpart of AddGroupingPolicy for a user scoped to one
+// organization/provider (see storage.User.Domain).
+func (s *Service) AddGroupingPolicyInDomain(ctx context.Context, user, role, dom string) (bool, error) {
+	added, err := s.enforcer.AddGroupingPolicy(user, role, dom)
+	if err == nil {
+		meta := requestMetaFromContext(ctx)
+		s.audit.Log(ctx, audit.Event{Action: "add_grouping_policy", Success: added, Target: user, Resource: "user_role", Detail: map[string]string{"role": role, "domain": dom}, IP: meta.IP, UserAgent: meta.UserAgent})
+	}
+	return added, err
+}
+
+// RemoveGroupingPolicyInDomain undoes AddGroupingPolicyInDomain.
+func (s *Service) RemoveGroupingPolicyInDomain(ctx context.Context, user, role, dom string) (bool, error) {
+	removed, err := s.enforcer.RemoveGroupingPolicy(user, role, dom)
+	if err == nil {
+		meta := requestMetaFromContext(ctx)
+		s.audit.Log(ctx, audit.Event{Action: "remove_grouping_policy", Success: removed, Target: user, Resource: "user_role", Detail: map[string]string{"role": role, "domain": dom}, IP: meta.IP, UserAgent: meta.UserAgent})
+	}
+	return removed, err
+}
+
+// roleHierarchyAncestors returns every role that role transitively
+// inherits via AddRoleInheritance (g2), not including role itself, parent
+// before grandparent.
+func (s *Service) roleHierarchyAncestors(role string) ([]string, error) {
+	edges, err := s.enforcer.GetNamedGroupingPolicy("g2")
+	if err != nil {
+		return nil, err
+	}
+	parents := map[string][]string{}
+	for _, e := range edges {
+		if len(e) < 2 {
+			continue
+		}
+		parents[e[0]] = append(parents[e[0]], e[1])
+	}
+
+	var ancestors []string
+	seen := map[string]bool{role: true}
+	queue := []string{role}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, parent := range parents[cur] {
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			ancestors = append(ancestors, parent)
+			queue = append(queue, parent)
+		}
+	}
+	return ancestors, nil
+}
+
+// AddRoleInheritance declares that child inherits every permission granted
+// to parent, and everything parent itself inherits in turn - e.g.
+// AddRoleInheritance("admin", "editor") followed by
+// AddRoleInheritance("editor", "viewer") gives admin both editor's and
+// viewer's grants. It refuses an edge that would create a cycle.
+func (s *Service) AddRoleInheritance(ctx context.Context, child, parent string) error {
+	if child == parent {
+		return fmt.Errorf("auth: role %q cannot inherit itself", child)
+	}
+	ancestors, err := s.roleHierarchyAncestors(parent)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range append([]string{parent}, ancestors...) {
+		if ancestor == child {
+			return fmt.Errorf("auth: %q already inherits %q, adding %q -> %q would create a cycle", parent, child, child, parent)
+		}
+	}
+
+	if _, err := s.enforcer.AddNamedGroupingPolicy("g2", child, parent); err != nil {
+		return err
+	}
+	meta := requestMetaFromContext(ctx)
+	s.audit.Log(ctx, audit.Event{Action: "add_role_inheritance", Success: true, Target: child, Resource: "role_hierarchy", Detail: map[string]string{"parent": parent}, IP: meta.IP, UserAgent: meta.UserAgent})
+	return nil
 }
 
-func (s *Service) RemovePolicy(role, resource, action string) (bool, error) {
-	return s.enforcer.RemovePolicy(role, resource, action)
+// RemoveRoleInheritance undoes AddRoleInheritance.
+func (s *Service) RemoveRoleInheritance(ctx context.Context, child, parent string) error {
+	_, err := s.enforcer.RemoveNamedGroupingPolicy("g2", child, parent)
+	if err == nil {
+		meta := requestMetaFromContext(ctx)
+		s.audit.Log(ctx, audit.Event{Action: "remove_role_inheritance", Success: true, Target: child, Resource: "role_hierarchy", Detail: map[string]string{"parent": parent}, IP: meta.IP, UserAgent: meta.UserAgent})
+	}
+	return err
 }
 
-func (s *Service) AddGroupingPolicy(user, role string) (bool, error) {
-	return s.enforcer.AddGroupingPolicy(user, role)
+// RoleEdge is one child-inherits-parent link, returned by RoleHierarchy.
+type RoleEdge struct {
+	Child  string `json:"child"`
+	Parent string `json:"parent"`
 }
 
-func (s *Service) RemoveGroupingPolicy(user, role string) (bool, error) {
-	return s.enforcer.RemoveGroupingPolicy(user, role)
+// RoleHierarchy returns every AddRoleInheritance edge, for
+// /api/v1/roles/hierarchy to render the role DAG.
+func (s *Service) RoleHierarchy() ([]RoleEdge, error) {
+	edges, err := s.enforcer.GetNamedGroupingPolicy("g2")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RoleEdge, 0, len(edges))
+	for _, e := range edges {
+		if len(e) < 2 {
+			continue
+		}
+		out = append(out, RoleEdge{Child: e[0], Parent: e[1]})
+	}
+	return out, nil
 }
 
 type Policy struct {
@@ -290,12 +713,12 @@ type Policy struct {
 func (s *Service) CreateRole(role string, policies []Policy) (bool, error) {
 	// If no policies provided, add a default one to ensure role exists
 	if len(policies) == 0 {
-		return s.enforcer.AddPolicy(role, "system", "init")
+		return s.enforcer.AddPolicy(role, defaultDomain, "system", "init")
 	}
 
 	// Add all policies
 	for _, p := range policies {
-		if _, err := s.enforcer.AddPolicy(role, p.Resource, p.Action); err != nil {
+		if _, err := s.enforcer.AddPolicy(role, defaultDomain, p.Resource, p.Action); err != nil {
 			return false, err
 		}
 	}
@@ -331,10 +754,10 @@ func (s *Service) UpdateUser(ctx context.Context, id string, email, role string,
 
 	if role != "" && role != user.Role {
 		// Remove old role policy
-		s.enforcer.RemoveGroupingPolicy(user.ID, user.Role)
+		s.enforcer.RemoveGroupingPolicy(user.ID, user.Role, userDomain(*user))
 		user.Role = role
 		// Add new role policy
-		s.enforcer.AddGroupingPolicy(user.ID, role)
+		s.enforcer.AddGroupingPolicy(user.ID, role, userDomain(*user))
 		changed = true
 	}
 
@@ -368,7 +791,7 @@ func (s *Service) UpdateUser(ctx context.Context, id string, email, role string,
 
 func (s *Service) SendVerificationEmail(ctx context.Context, userID, email string) error {
 	expiresAt := time.Now().Add(24 * time.Hour)
-	_, rawToken, err := s.CreateToken(ctx, userID, "email-verification", "verification", &expiresAt)
+	_, rawToken, err := s.CreateToken(ctx, userID, "email-verification", "verification", nil, 0, &expiresAt)
 	if err != nil {
 		return err
 	}
@@ -379,7 +802,7 @@ func (s *Service) SendVerificationEmail(ctx context.Context, userID, email strin
 
 func (s *Service) SendInvitationEmail(ctx context.Context, userID, email, role string) error {
 	expiresAt := time.Now().Add(72 * time.Hour) // 3 days for invitations
-	_, rawToken, err := s.CreateToken(ctx, userID, "account-setup", "verification", &expiresAt)
+	_, rawToken, err := s.CreateToken(ctx, userID, "account-setup", "verification", nil, 0, &expiresAt)
 	if err != nil {
 		return err
 	}
@@ -454,11 +877,14 @@ func (s *Service) VerifyEmail(ctx context.Context, rawToken string) error {
 
 	user.EmailVerified = true
 	user.UpdatedAt = time.Now()
-	
+
 	if err := s.storage.UpdateUser(ctx, *user); err != nil {
 		return err
 	}
 
+	meta := requestMetaFromContext(ctx)
+	s.audit.Log(ctx, audit.Event{Actor: user.ID, Action: "verify_email", Success: true, Target: user.ID, Resource: "user", IP: meta.IP, UserAgent: meta.UserAgent})
+
 	// Delete token
 	return s.storage.DeleteToken(ctx, token.ID)
 }
@@ -479,7 +905,7 @@ func (s *Service) RequestPasswordReset(ctx context.Context, email string) error
 
 	// Create a reset token
 	expiresAt := time.Now().Add(1 * time.Hour)
-	_, rawToken, err := s.CreateToken(ctx, user.ID, "password-reset", "reset", &expiresAt)
+	_, rawToken, err := s.CreateToken(ctx, user.ID, "password-reset", "reset", nil, 0, &expiresAt)
 	if err != nil {
 		return err
 	}
@@ -551,6 +977,10 @@ func (s *Service) ResetPassword(ctx context.Context, rawToken, newPassword strin
 		return errors.New("user not found")
 	}
 
+	if err := s.ValidatePassword(ctx, user.Username, user.Email, newPassword); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -560,16 +990,19 @@ func (s *Service) ResetPassword(ctx context.Context, rawToken, newPassword strin
 	// Update user
 	user.PasswordHash = string(hash)
 	user.UpdatedAt = time.Now()
-	
+
 	// If this is an account setup token, also verify the email
 	if token.Name == "account-setup" {
 		user.EmailVerified = true
 	}
-	
+
 	if err := s.storage.UpdateUser(ctx, *user); err != nil {
 		return err
 	}
 
+	meta := requestMetaFromContext(ctx)
+	s.audit.Log(ctx, audit.Event{Actor: user.ID, Action: "reset_password", Success: true, Target: user.ID, Resource: "user", IP: meta.IP, UserAgent: meta.UserAgent})
+
 	// Delete the used token
 	if err := s.storage.DeleteToken(ctx, token.ID); err != nil {
 		log.Printf("failed to delete used reset token: %v", err)
@@ -630,6 +1063,10 @@ func (s *Service) SetupInvitedAccount(ctx context.Context, rawToken, username, f
 		}
 	}
 
+	if err := s.ValidatePassword(ctx, username, user.Email, newPassword); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -643,11 +1080,14 @@ func (s *Service) SetupInvitedAccount(ctx context.Context, rawToken, username, f
 	user.PasswordHash = string(hash)
 	user.EmailVerified = true
 	user.UpdatedAt = time.Now()
-	
+
 	if err := s.storage.UpdateUser(ctx, *user); err != nil {
 		return err
 	}
 
+	meta := requestMetaFromContext(ctx)
+	s.audit.Log(ctx, audit.Event{Actor: user.ID, Action: "setup_invited_account", Success: true, Target: user.ID, Resource: "user", IP: meta.IP, UserAgent: meta.UserAgent})
+
 	// Delete the used token
 	if err := s.storage.DeleteToken(ctx, token.ID); err != nil {
 		log.Printf("failed to delete used setup token: %v", err)
@@ -1,10 +1,23 @@
 package config
 
-import "os"
+import (
+    "os"
+    "strings"
+)
 
 type Config struct {
     CEMCPDFPath string
     NESPDFPath  string
+
+    // StorageDriver and StorageDSN select the storage.Storage backend
+    // (e.g. "sqlite", "postgres", "etcd", "valkey"); see storage.Config.
+    StorageDriver string
+    StorageDSN    string
+
+    // ValkeyTLS and ValkeyTLSInsecureSkipVerify feed storage.ValkeyConfig
+    // when StorageDriver is "valkey"; ignored otherwise.
+    ValkeyTLS                   bool
+    ValkeyTLSInsecureSkipVerify bool
 }
 
 // FromEnv builds a Config from environment variables, with sane defaults.
@@ -18,7 +31,11 @@ func FromEnv() Config {
         nes = "/data/nes_rates.pdf"
     }
     return Config{
-        CEMCPDFPath: cemc,
-        NESPDFPath:  nes,
+        CEMCPDFPath:                 cemc,
+        NESPDFPath:                  nes,
+        StorageDriver:               os.Getenv("ERATEMANAGER_DB_DRIVER"),
+        StorageDSN:                  os.Getenv("ERATEMANAGER_DB_DSN"),
+        ValkeyTLS:                   strings.ToLower(os.Getenv("ERATEMANAGER_VALKEY_TLS")) == "true",
+        ValkeyTLSInsecureSkipVerify: strings.ToLower(os.Getenv("ERATEMANAGER_VALKEY_TLS_INSECURE_SKIP_VERIFY")) == "true",
     }
 }
@@ -5,17 +5,47 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
 	"strconv"
-	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
 	"github.com/bher20/eratemanager/internal/alerting"
+	"github.com/bher20/eratemanager/internal/cron/pool"
 	"github.com/bher20/eratemanager/internal/metrics"
 	"github.com/bher20/eratemanager/internal/rates"
 	"github.com/bher20/eratemanager/internal/storage"
 )
 
+var (
+	batchPoolQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "eratemanager_batch_pool_queue_depth",
+			Help: "Number of provider refresh tasks submitted but not yet started, per batch",
+		},
+		[]string{"batch_id"},
+	)
+
+	batchPoolActiveWorkers = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "eratemanager_batch_pool_active_workers",
+			Help: "Configured worker count for the current batch's pool (0 when idle)",
+		},
+		[]string{"batch_id"},
+	)
+
+	batchPoolRejectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eratemanager_batch_pool_rejected_total",
+			Help: "Total number of provider refreshes rejected because the pool had already stopped (e.g. ctx cancelled)",
+		},
+		[]string{"batch_id"},
+	)
+)
+
 // BatchConfig controls batch processing behavior.
 type BatchConfig struct {
 	// MaxConcurrency limits parallel provider refreshes (0 = sequential)
@@ -26,14 +56,74 @@ type BatchConfig struct {
 	RetryAttempts int
 	// RetryDelay is the wait between retry attempts
 	RetryDelay time.Duration
-	// RateLimitDelay is the minimum time between starting provider refreshes
+	// RateLimitDelay is the minimum time between starting provider refreshes.
+	// Deprecated: superseded by Limiter, which paces per-provider instead of
+	// globally; kept only as the sequential-path fallback when Limiter is nil.
 	RateLimitDelay time.Duration
+	// Limiter paces each provider's refreshes independently (see
+	// ProviderLimiter), letting a high-throughput provider run back-to-back
+	// while a low-throughput one is still waiting out its own delay. Set by
+	// DefaultBatchConfig from BATCH_PROVIDER_LIMITS; nil disables per-provider
+	// pacing in favor of RateLimitDelay.
+	Limiter *ProviderLimiter
 	// CacheTTL is how long cached rates are considered fresh (skip re-parsing)
 	CacheTTL time.Duration
 	// ResumeFromProgress enables resuming incomplete batches
 	ResumeFromProgress bool
 	// BatchID identifies this batch run (for progress tracking)
 	BatchID string
+	// FailureThreshold is how many consecutive refresh failures a provider
+	// must accumulate before its circuit breaker opens and short-circuits
+	// further attempts. 0 disables the circuit breaker entirely.
+	FailureThreshold int
+	// OpenDuration is how long a newly-opened circuit stays open before a
+	// single half-open probe is allowed through. Doubles (capped at
+	// MaxOpenDuration) each time a probe fails.
+	OpenDuration time.Duration
+	// MaxOpenDuration caps the exponential growth of OpenDuration across
+	// repeated half-open probe failures.
+	MaxOpenDuration time.Duration
+	// HalfOpenProbes is how many refresh attempts are allowed through while
+	// a circuit is half-open before its outcome decides the next state. The
+	// current implementation only supports the default of 1: a single
+	// success closes the circuit, a single failure re-opens it.
+	HalfOpenProbes int
+	// BaseBackoff seeds the retry delay computed by nextBackoff: the delay
+	// before the first retry, and the floor decorrelated/full jitter grows
+	// from afterward.
+	BaseBackoff time.Duration
+	// MaxBackoff caps how long nextBackoff will ever wait between retries.
+	MaxBackoff time.Duration
+	// JitterMode selects how nextBackoff spaces out retries: "decorrelated"
+	// (default) picks each delay up to 3x the previous one so concurrent
+	// replicas retrying the same provider desynchronize; "full" picks
+	// uniformly between 0 and an exponentially growing cap; "none" always
+	// waits exactly RetryDelay, matching this package's pre-jitter behavior.
+	JitterMode string
+	// LeaderElection guards RunBatchOnce against two CronJob replicas (e.g.
+	// under concurrencyPolicy: Allow, or a manual trigger racing a scheduled
+	// run) refreshing the same providers concurrently.
+	LeaderElection LeaderElectionConfig
+}
+
+// LeaderElectionConfig controls RunBatchOnce's optional leader election. A
+// backend that implements batchLeaser (MemoryStorage, SQLiteStorage,
+// PostgresPoolStorage) is required for Enabled to take effect; other
+// backends log and skip election rather than failing the batch.
+type LeaderElectionConfig struct {
+	// Enabled turns on lease acquisition before processing. Disabled by
+	// default so existing single-replica deployments are unaffected.
+	Enabled bool
+	// LeaseKey identifies the lease row this batch run contends for. Runs
+	// that should never coordinate (e.g. distinct environments sharing a
+	// database) must use distinct keys.
+	LeaseKey string
+	// LeaseTTL is how long an acquired lease stays valid without renewal.
+	LeaseTTL time.Duration
+	// RenewInterval is how often the heartbeat goroutine renews the lease.
+	// Should be comfortably shorter than LeaseTTL so a slow renewal doesn't
+	// cost the lease.
+	RenewInterval time.Duration
 }
 
 // DefaultBatchConfig returns sensible defaults for batch processing.
@@ -47,6 +137,20 @@ func DefaultBatchConfig() BatchConfig {
 		CacheTTL:           24 * time.Hour,
 		ResumeFromProgress: true,
 		BatchID:            fmt.Sprintf("batch_%d", time.Now().Unix()),
+		Limiter:            NewProviderLimiterFromEnv(),
+		FailureThreshold:   5,
+		OpenDuration:       30 * time.Minute,
+		MaxOpenDuration:    4 * time.Hour,
+		HalfOpenProbes:     1,
+		BaseBackoff:        5 * time.Second,
+		MaxBackoff:         2 * time.Minute,
+		JitterMode:         "decorrelated",
+		LeaderElection: LeaderElectionConfig{
+			Enabled:       false,
+			LeaseKey:      "batch_refresh",
+			LeaseTTL:      2 * time.Minute,
+			RenewInterval: 30 * time.Second,
+		},
 	}
 
 	// Allow env overrides
@@ -81,6 +185,55 @@ func DefaultBatchConfig() BatchConfig {
 	if v := os.Getenv("BATCH_ID"); v != "" {
 		cfg.BatchID = v
 	}
+	if v := os.Getenv("BATCH_FAILURE_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.FailureThreshold = n
+		}
+	}
+	if v := os.Getenv("BATCH_OPEN_DURATION_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.OpenDuration = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("BATCH_MAX_OPEN_DURATION_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxOpenDuration = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("BATCH_HALF_OPEN_PROBES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.HalfOpenProbes = n
+		}
+	}
+	if v := os.Getenv("BATCH_BASE_BACKOFF_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.BaseBackoff = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("BATCH_MAX_BACKOFF_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxBackoff = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("BATCH_JITTER_MODE"); v == "none" || v == "full" || v == "decorrelated" {
+		cfg.JitterMode = v
+	}
+	if v := os.Getenv("BATCH_LEADER_ELECTION_ENABLED"); v == "true" || v == "1" {
+		cfg.LeaderElection.Enabled = true
+	}
+	if v := os.Getenv("BATCH_LEASE_KEY"); v != "" {
+		cfg.LeaderElection.LeaseKey = v
+	}
+	if v := os.Getenv("BATCH_LEASE_TTL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.LeaderElection.LeaseTTL = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("BATCH_LEASE_RENEW_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.LeaderElection.RenewInterval = time.Duration(n) * time.Second
+		}
+	}
 
 	return cfg
 }
@@ -94,6 +247,9 @@ type ProviderResult struct {
 	Error      error
 	Skipped    bool   // True if skipped due to fresh cache
 	SkipReason string // Why it was skipped
+	// Backoffs records the delay slept before each retry, in attempt order
+	// (so len(Backoffs) == Attempts-1 on a provider that was retried at all).
+	Backoffs []time.Duration
 }
 
 // RunBatchOnce executes a single batch refresh of all provider rates.
@@ -117,18 +273,65 @@ func RunBatchOnce(ctx context.Context, driver, dsn string) error {
 	}
 	defer st.Close()
 
+	// Leader election: claim the batch lease before doing any work so a
+	// second replica triggered concurrently (concurrencyPolicy: Allow, or a
+	// manual re-run) exits instead of racing this one on the same providers.
+	var leaseLost chan struct{}
+	if cfg.LeaderElection.Enabled {
+		leaser, ok := st.(batchLeaser)
+		if !ok {
+			log.Printf("batch: leader election enabled but driver=%s does not support batch leases, skipping election", driver)
+		} else {
+			holderID := leaseHolderID()
+			acquired, err := leaser.AcquireBatchLease(ctx, cfg.LeaderElection.LeaseKey, holderID, cfg.LeaderElection.LeaseTTL)
+			if err != nil {
+				return fmt.Errorf("batch: acquire lease %q: %w", cfg.LeaderElection.LeaseKey, err)
+			}
+			if !acquired {
+				log.Printf("batch: lease %q held by another replica, exiting", cfg.LeaderElection.LeaseKey)
+				return nil
+			}
+
+			leaseCtx, cancel := context.WithCancel(ctx)
+			ctx = leaseCtx
+			leaseLost = make(chan struct{})
+			heartbeatDone := make(chan struct{})
+
+			go func() {
+				defer close(heartbeatDone)
+				ticker := time.NewTicker(cfg.LeaderElection.RenewInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-leaseCtx.Done():
+						return
+					case <-ticker.C:
+						renewed, err := leaser.RenewBatchLease(context.Background(), cfg.LeaderElection.LeaseKey, holderID, cfg.LeaderElection.LeaseTTL)
+						if err != nil || !renewed {
+							log.Printf("batch: lost lease %q, cancelling in-flight refreshes: %v", cfg.LeaderElection.LeaseKey, err)
+							close(leaseLost)
+							cancel()
+							return
+						}
+					}
+				}
+			}()
+
+			defer func() {
+				cancel()
+				<-heartbeatDone
+				_ = leaser.ReleaseBatchLease(context.Background(), cfg.LeaderElection.LeaseKey, holderID)
+			}()
+		}
+	}
+
 	// Initialize alerter
 	alertCfg := alerting.DefaultAlertConfig()
-	alerter := alerting.NewAlerter(alertCfg)
-	if alertCfg.Enabled {
-		log.Printf("batch: alerting enabled (webhook type: %s)", alertCfg.WebhookType)
-	}
+	alerter := alerting.NewAlerter(alertCfg, st)
+	defer alerter.Close()
 
 	// Build rate fetching service
-	svc := rates.NewServiceWithStorage(rates.Config{
-		CEMCPDFPath: os.Getenv("CEMC_PDF_PATH"),
-		NESPDFPath:  os.Getenv("NES_PDF_PATH"),
-	}, st)
+	svc := rates.NewServiceWithStorage(buildRatesConfigWorker(), st)
 
 	providers := rates.Providers()
 	jobName := "batch_refresh"
@@ -155,7 +358,7 @@ func RunBatchOnce(ctx context.Context, driver, dsn string) error {
 
 		// Check for resumed batch progress
 		if cfg.ResumeFromProgress {
-			progress, _ := st.GetBatchProgress(ctx, cfg.BatchID, p.Key)
+			progress := getBatchProgress(ctx, st, cfg.BatchID, p.Key)
 			if progress != nil && progress.Status == "completed" {
 				log.Printf("batch: skipping %s (already completed in this batch)", p.Key)
 				skippedResults = append(skippedResults, ProviderResult{
@@ -172,7 +375,7 @@ func RunBatchOnce(ctx context.Context, driver, dsn string) error {
 
 		// Initialize progress tracking
 		if cfg.ResumeFromProgress {
-			_ = st.SaveBatchProgress(ctx, storage.BatchProgress{
+			saveBatchProgress(ctx, st, storage.BatchProgress{
 				BatchID:  cfg.BatchID,
 				Provider: p.Key,
 				Status:   "pending",
@@ -186,46 +389,77 @@ func RunBatchOnce(ctx context.Context, driver, dsn string) error {
 	results := make([]ProviderResult, len(providersToProcess))
 
 	if cfg.MaxConcurrency <= 1 {
-		// Sequential processing with rate limiting
+		// Sequential processing: still paced per-provider so a slow provider
+		// doesn't hold up a fast one on the next batch run.
 		for i, p := range providersToProcess {
-			results[i] = refreshProviderWithTracking(ctx, svc, st, p.Key, cfg)
-
-			// Rate limiting between providers
-			if i < len(providersToProcess)-1 && cfg.RateLimitDelay > 0 {
-				select {
-				case <-ctx.Done():
-					break
-				case <-time.After(cfg.RateLimitDelay):
-				}
+			if err := takeProviderToken(ctx, cfg, p.Key); err != nil {
+				break
 			}
+			results[i] = refreshProviderWithTracking(ctx, svc, st, p.Key, cfg, alerter)
 		}
 	} else {
-		// Parallel processing with semaphore and rate limiting
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, cfg.MaxConcurrency)
-		rateLimiter := time.NewTicker(cfg.RateLimitDelay)
-		defer rateLimiter.Stop()
+		// Parallel processing via a bounded worker pool: Submit blocks once
+		// the queue is full instead of spawning one goroutine per provider
+		// up front, so the memory footprint stays bounded when there are
+		// many providers to process. Each task still paces itself against
+		// its own provider's bucket, so different providers naturally run
+		// concurrently while repeat hits on the same provider serialize.
+		workerPool := pool.NewPool(ctx, pool.Opts{
+			MaxWorkers: cfg.MaxConcurrency,
+			QueueSize:  len(providersToProcess),
+		})
+		batchPoolActiveWorkers.WithLabelValues(cfg.BatchID).Set(float64(cfg.MaxConcurrency))
 
 		for i, p := range providersToProcess {
-			// Rate limiting: wait for ticker before starting each goroutine
-			if i > 0 && cfg.RateLimitDelay > 0 {
-				select {
-				case <-ctx.Done():
-					break
-				case <-rateLimiter.C:
+			idx, providerKey := i, p.Key
+			batchPoolQueueDepth.WithLabelValues(cfg.BatchID).Inc()
+			if err := workerPool.Submit(func() {
+				batchPoolQueueDepth.WithLabelValues(cfg.BatchID).Dec()
+				if err := takeProviderToken(ctx, cfg, providerKey); err != nil {
+					return
 				}
+				results[idx] = refreshProviderWithTracking(ctx, svc, st, providerKey, cfg, alerter)
+			}); err != nil {
+				batchPoolQueueDepth.WithLabelValues(cfg.BatchID).Dec()
+				batchPoolRejectedTotal.WithLabelValues(cfg.BatchID).Inc()
+				log.Printf("batch: submit %s rejected (pool stopped): %v", providerKey, err)
+				results[idx] = ProviderResult{Provider: providerKey, Error: fmt.Errorf("batch: cancelled before starting: %w", err)}
+				saveBatchProgress(ctx, st, storage.BatchProgress{
+					BatchID:      cfg.BatchID,
+					Provider:     providerKey,
+					Status:       "failed",
+					CompletedAt:  time.Now(),
+					ErrorMessage: results[idx].Error.Error(),
+				})
 			}
+		}
+		workerPool.StopAndWait()
+		batchPoolActiveWorkers.WithLabelValues(cfg.BatchID).Set(0)
+	}
 
-			wg.Add(1)
-			go func(idx int, providerKey string) {
-				defer wg.Done()
-				sem <- struct{}{}        // acquire
-				defer func() { <-sem }() // release
-
-				results[idx] = refreshProviderWithTracking(ctx, svc, st, providerKey, cfg)
-			}(i, p.Key)
+	// If the lease was lost mid-run, the cancelled context stopped any
+	// in-flight refreshes early. Mark whatever didn't finish as failed with
+	// a distinct reason so the replica that holds the lease next picks them
+	// back up via ResumeFromProgress instead of treating them as done.
+	if leaseLost != nil {
+		select {
+		case <-leaseLost:
+			log.Printf("batch: lease lost mid-run, marking incomplete providers failed (lease_lost)")
+			bgCtx := context.Background()
+			for i, p := range providersToProcess {
+				if results[i].Success {
+					continue
+				}
+				saveBatchProgress(bgCtx, st, storage.BatchProgress{
+					BatchID:      cfg.BatchID,
+					Provider:     p.Key,
+					Status:       "failed",
+					CompletedAt:  time.Now(),
+					ErrorMessage: "lease_lost",
+				})
+			}
+		default:
 		}
-		wg.Wait()
 	}
 
 	// Combine skipped and processed results
@@ -285,6 +519,44 @@ func RunBatchOnce(ctx context.Context, driver, dsn string) error {
 	return nil
 }
 
+// batchLeaser is implemented by storage backends that support RunBatchOnce's
+// leader-election lease (MemoryStorage, SQLiteStorage, PostgresPoolStorage).
+// Backends without it (e.g. PostgresStorage, EtcdStorage) cause
+// LeaderElection.Enabled to be ignored rather than failing the batch.
+type batchLeaser interface {
+	AcquireBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	RenewBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	ReleaseBatchLease(ctx context.Context, key, holder string) error
+}
+
+// leaseHolderID identifies this process as a batch lease holder, distinct
+// across replicas sharing the same storage backend.
+func leaseHolderID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// takeProviderToken paces a provider's refresh via cfg.Limiter when set,
+// falling back to a flat cfg.RateLimitDelay sleep for callers that still
+// configure the deprecated global delay instead.
+func takeProviderToken(ctx context.Context, cfg BatchConfig, providerKey string) error {
+	if cfg.Limiter != nil {
+		return cfg.Limiter.Take(ctx, providerKey)
+	}
+	if cfg.RateLimitDelay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(cfg.RateLimitDelay):
+		return nil
+	}
+}
+
 // isCacheFresh checks if the cached rates for a provider are still fresh.
 func isCacheFresh(ctx context.Context, st storage.Storage, provider string, ttl time.Duration) (bool, string) {
 	snap, err := st.GetRatesSnapshot(ctx, provider)
@@ -300,10 +572,28 @@ func isCacheFresh(ctx context.Context, st storage.Storage, provider string, ttl
 	return false, ""
 }
 
-// refreshProviderWithTracking wraps refreshProviderWithRetry with progress tracking.
-func refreshProviderWithTracking(ctx context.Context, svc *rates.Service, st storage.Storage, provider string, cfg BatchConfig) ProviderResult {
+// refreshProviderWithTracking wraps refreshProviderWithRetry with progress
+// tracking and circuit breaker enforcement: a provider whose circuit is open
+// is skipped without ever calling refreshProviderWithRetry, so it doesn't
+// burn ProviderTimeout * (RetryAttempts+1) on a cycle that was always going
+// to fail.
+func refreshProviderWithTracking(ctx context.Context, svc *rates.Service, st storage.Storage, provider string, cfg BatchConfig, alerter *alerting.Alerter) ProviderResult {
+	if cfg.FailureThreshold > 0 {
+		if allowed, reason := circuitBreakerAllows(ctx, st, provider); !allowed {
+			log.Printf("batch: skipping %s (%s)", provider, reason)
+			result := ProviderResult{Provider: provider, Success: true, Skipped: true, SkipReason: reason}
+			saveBatchProgress(ctx, st, storage.BatchProgress{
+				BatchID:     cfg.BatchID,
+				Provider:    provider,
+				Status:      "completed",
+				CompletedAt: time.Now(),
+			})
+			return result
+		}
+	}
+
 	// Mark as in-progress
-	_ = st.SaveBatchProgress(ctx, storage.BatchProgress{
+	saveBatchProgress(ctx, st, storage.BatchProgress{
 		BatchID:   cfg.BatchID,
 		Provider:  provider,
 		Status:    "in_progress",
@@ -312,6 +602,10 @@ func refreshProviderWithTracking(ctx context.Context, svc *rates.Service, st sto
 
 	result := refreshProviderWithRetry(ctx, svc, provider, cfg)
 
+	if cfg.FailureThreshold > 0 {
+		recordCircuitResult(ctx, st, provider, cfg, result, alerter)
+	}
+
 	// Update progress based on result
 	now := time.Now()
 	progress := storage.BatchProgress{
@@ -330,12 +624,131 @@ func refreshProviderWithTracking(ctx context.Context, svc *rates.Service, st sto
 		}
 	}
 
-	_ = st.SaveBatchProgress(ctx, progress)
+	saveBatchProgress(ctx, st, progress)
 
 	return result
 }
 
-// refreshProviderWithRetry attempts to refresh a provider with retries.
+// circuitBreakerAllows reports whether provider's circuit breaker currently
+// permits a refresh attempt. An open circuit whose NextAttemptAt has passed
+// is advanced to half_open and allowed through as a probe. Every built-in
+// storage.Storage backend implements storage.CircuitBreakerStore; the type
+// assertion only matters for a future/third-party backend that doesn't,
+// which gets the circuit breaker silently disabled rather than a panic -
+// the same fallback batchLeaser uses for leader election.
+func circuitBreakerAllows(ctx context.Context, st storage.Storage, provider string) (bool, string) {
+	cb, ok := st.(storage.CircuitBreakerStore)
+	if !ok {
+		return true, ""
+	}
+
+	cs, err := cb.GetCircuitState(ctx, provider)
+	if err != nil || cs == nil || cs.State != "open" {
+		return true, ""
+	}
+
+	if time.Now().Before(cs.NextAttemptAt) {
+		return false, fmt.Sprintf("circuit open until %s (%d consecutive failures)",
+			cs.NextAttemptAt.Format(time.RFC3339), cs.ConsecutiveFailures)
+	}
+
+	cs.State = "half_open"
+	_ = cb.SaveCircuitState(ctx, *cs)
+	return true, ""
+}
+
+// recordCircuitResult updates provider's stored circuit breaker state based
+// on the outcome of a refresh attempt, opening the circuit after
+// cfg.FailureThreshold consecutive failures and closing it again on the
+// first success while half-open. It fires a CircuitAlert on every
+// closed<->open transition. A no-op when st doesn't implement
+// storage.CircuitBreakerStore, matching circuitBreakerAllows's fallback.
+func recordCircuitResult(ctx context.Context, st storage.Storage, provider string, cfg BatchConfig, result ProviderResult, alerter *alerting.Alerter) {
+	cb, ok := st.(storage.CircuitBreakerStore)
+	if !ok {
+		return
+	}
+
+	cs, err := cb.GetCircuitState(ctx, provider)
+	if err != nil {
+		return
+	}
+	if cs == nil {
+		cs = &storage.CircuitState{Provider: provider, State: "closed"}
+	}
+	fromState := cs.State
+
+	if result.Success {
+		cs.State = "closed"
+		cs.ConsecutiveFailures = 0
+		cs.LastError = ""
+	} else {
+		errMsg := ""
+		if result.Error != nil {
+			errMsg = result.Error.Error()
+		}
+		cs.LastError = errMsg
+		cs.ConsecutiveFailures++
+
+		if fromState == "half_open" {
+			openDuration := cfg.OpenDuration * 2
+			if cfg.MaxOpenDuration > 0 && openDuration > cfg.MaxOpenDuration {
+				openDuration = cfg.MaxOpenDuration
+			}
+			cs.State = "open"
+			cs.OpenedAt = time.Now()
+			cs.NextAttemptAt = cs.OpenedAt.Add(openDuration)
+		} else if cs.ConsecutiveFailures >= cfg.FailureThreshold {
+			cs.State = "open"
+			cs.OpenedAt = time.Now()
+			cs.NextAttemptAt = cs.OpenedAt.Add(cfg.OpenDuration)
+		}
+	}
+
+	_ = cb.SaveCircuitState(ctx, *cs)
+
+	if cs.State != fromState && (cs.State == "open" || (fromState == "half_open" && cs.State == "closed")) {
+		alert := alerting.CircuitAlert{
+			Provider:            provider,
+			FromState:           fromState,
+			ToState:             cs.State,
+			ConsecutiveFailures: cs.ConsecutiveFailures,
+			LastError:           cs.LastError,
+			Timestamp:           time.Now(),
+		}
+		if err := alerter.SendCircuitAlert(ctx, alert); err != nil {
+			log.Printf("batch: failed to send circuit alert for %s: %v", provider, err)
+		}
+	}
+}
+
+// getBatchProgress returns batchID/provider's stored progress, or nil if st
+// doesn't implement storage.BatchProgressStore (e.g. PostgresStorage,
+// PostgresPoolStorage) or nothing is stored yet, matching
+// circuitBreakerAllows's fallback for backends without the facet interface.
+func getBatchProgress(ctx context.Context, st storage.Storage, batchID, provider string) *storage.BatchProgress {
+	bp, ok := st.(storage.BatchProgressStore)
+	if !ok {
+		return nil
+	}
+	progress, _ := bp.GetBatchProgress(ctx, batchID, provider)
+	return progress
+}
+
+// saveBatchProgress persists progress, silently doing nothing when st
+// doesn't implement storage.BatchProgressStore, matching getBatchProgress.
+func saveBatchProgress(ctx context.Context, st storage.Storage, progress storage.BatchProgress) {
+	bp, ok := st.(storage.BatchProgressStore)
+	if !ok {
+		return
+	}
+	_ = bp.SaveBatchProgress(ctx, progress)
+}
+
+// refreshProviderWithRetry attempts to refresh a provider with retries,
+// backing off between attempts per cfg.JitterMode and giving up immediately
+// (without spending the rest of the attempt budget) on a terminal error per
+// rates.IsRetryable.
 func refreshProviderWithRetry(ctx context.Context, svc *rates.Service, provider string, cfg BatchConfig) ProviderResult {
 	result := ProviderResult{
 		Provider: provider,
@@ -343,6 +756,7 @@ func refreshProviderWithRetry(ctx context.Context, svc *rates.Service, provider
 	}
 
 	started := time.Now()
+	var prevBackoff time.Duration
 
 	for attempt := 0; attempt <= cfg.RetryAttempts; attempt++ {
 		result.Attempts = attempt + 1
@@ -360,6 +774,17 @@ func refreshProviderWithRetry(ctx context.Context, svc *rates.Service, provider
 			default:
 			}
 
+			// Conditionally refresh the provider's landing page first: if its
+			// content hasn't changed since the last refresh, skip the
+			// (expensive) re-parse entirely. A refresh failure (e.g. no
+			// LandingURL configured, or a transient network error) is logged
+			// and falls through to the parse as before.
+			if refreshResult, err := svc.RefreshLandingPage(attemptCtx, provider); err != nil {
+				log.Printf("batch: refresh landing page for %s failed, parsing anyway: %v", provider, err)
+			} else if !refreshResult.Changed {
+				return nil
+			}
+
 			_, err := svc.GetResidential(attemptCtx, provider)
 			return err
 		}()
@@ -377,15 +802,27 @@ func refreshProviderWithRetry(ctx context.Context, svc *rates.Service, provider
 			break
 		}
 
+		// A terminal error (e.g. a 4xx response or a parse failure) won't
+		// succeed on a later attempt, so stop spending the retry budget.
+		if !rates.IsRetryable(err) {
+			log.Printf("batch: %s attempt %d failed with a terminal error, not retrying: %v",
+				provider, attempt+1, err)
+			break
+		}
+
 		// Wait before retry (unless last attempt)
 		if attempt < cfg.RetryAttempts {
+			backoff := nextBackoff(prevBackoff, cfg)
+			prevBackoff = backoff
+			result.Backoffs = append(result.Backoffs, backoff)
+
 			log.Printf("batch: %s attempt %d failed, retrying in %s: %v",
-				provider, attempt+1, cfg.RetryDelay, err)
+				provider, attempt+1, backoff, err)
 			select {
 			case <-ctx.Done():
 				result.Error = ctx.Err()
 				return result
-			case <-time.After(cfg.RetryDelay):
+			case <-time.After(backoff):
 			}
 		}
 	}
@@ -394,6 +831,56 @@ func refreshProviderWithRetry(ctx context.Context, svc *rates.Service, provider
 	return result
 }
 
+// nextBackoff computes the delay before the next retry per cfg.JitterMode.
+// prev is the previously-returned backoff (zero for the first retry, which
+// seeds from cfg.BaseBackoff).
+func nextBackoff(prev time.Duration, cfg BatchConfig) time.Duration {
+	switch cfg.JitterMode {
+	case "none":
+		return cfg.RetryDelay
+	case "full":
+		return fullJitterBackoff(prev, cfg)
+	default: // "decorrelated"
+		return decorrelatedJitterBackoff(prev, cfg)
+	}
+}
+
+// decorrelatedJitterBackoff implements the "decorrelated jitter" strategy
+// from the AWS architecture blog's retry write-up: each delay is random up
+// to 3x the previous one, capped at MaxBackoff, so multiple replicas (or
+// successive cron runs) retrying the same recovering provider spread out
+// instead of re-hitting it in lockstep.
+func decorrelatedJitterBackoff(prev time.Duration, cfg BatchConfig) time.Duration {
+	if prev <= 0 {
+		prev = cfg.BaseBackoff
+	}
+	upper := prev * 3
+	if upper > cfg.MaxBackoff {
+		upper = cfg.MaxBackoff
+	}
+	if upper <= 0 {
+		return cfg.BaseBackoff
+	}
+	next := time.Duration(rand.Int63n(int64(upper)))
+	if next > cfg.MaxBackoff {
+		next = cfg.MaxBackoff
+	}
+	return next
+}
+
+// fullJitterBackoff picks uniformly between 0 and an exponentially growing
+// cap (BaseBackoff doubled once per prior attempt, capped at MaxBackoff).
+func fullJitterBackoff(prev time.Duration, cfg BatchConfig) time.Duration {
+	backoff := cfg.BaseBackoff
+	if prev > 0 {
+		backoff = prev * 2
+	}
+	if backoff > cfg.MaxBackoff {
+		backoff = cfg.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
 // RunBatch periodically refreshes ALL provider rates using *advisory locks*
 // so that multiple replicas DO NOT run the batch simultaneously.
 // This is designed for long-running deployments, not CronJobs.
@@ -415,10 +902,7 @@ func RunBatch(ctx context.Context, driver, dsn string) error {
 	}
 
 	// Build rate fetching service
-	svc := rates.NewServiceWithStorage(rates.Config{
-		CEMCPDFPath: os.Getenv("CEMC_PDF_PATH"),
-		NESPDFPath:  os.Getenv("NES_PDF_PATH"),
-	}, st)
+	svc := rates.NewServiceWithStorage(buildRatesConfigWorker(), st)
 
 	// Configurable interval
 	intervalSec := 3600
@@ -502,10 +986,7 @@ func RunBatch(ctx context.Context, driver, dsn string) error {
 // ForceRefreshProvider bypasses the cache and forces a fresh PDF parse for a provider.
 // This is useful for manual refreshes triggered by the UI.
 func ForceRefreshProvider(ctx context.Context, st storage.Storage, provider string) (*rates.RatesResponse, error) {
-	svc := rates.NewServiceWithStorage(rates.Config{
-		CEMCPDFPath: os.Getenv("CEMC_PDF_PATH"),
-		NESPDFPath:  os.Getenv("NES_PDF_PATH"),
-	}, st)
+	svc := rates.NewServiceWithStorage(buildRatesConfigWorker(), st)
 
 	// Force refresh by calling the internal method that always parses the PDF
 	resp, err := svc.ForceRefresh(ctx, provider)
@@ -0,0 +1,136 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed standard cron expression with a leading seconds
+// field: "sec min hour dom month dow". Only numeric fields are supported
+// (no named months/weekdays) — that's all the job set in this package
+// needs, and it keeps the parser small.
+type Schedule struct {
+	seconds, minutes, hours, doms, months, dows fieldSet
+}
+
+// fieldSet is a bitmask of the allowed values for one cron field.
+type fieldSet uint64
+
+func (f fieldSet) has(v int) bool { return f&(1<<uint(v)) != 0 }
+
+var fieldRanges = [6][2]int{
+	{0, 59}, // seconds
+	{0, 59}, // minutes
+	{0, 23}, // hours
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week (0 = Sunday)
+}
+
+// ParseSchedule parses a 6-field cron expression ("sec min hour dom month
+// dow"), where each field is "*", "*/n", "a", "a-b", "a-b/n", or a
+// comma-separated list of those.
+func ParseSchedule(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("cron: expected 6 fields (sec min hour dom month dow), got %d in %q", len(fields), expr)
+	}
+
+	sets := make([]fieldSet, 6)
+	for i, f := range fields {
+		set, err := parseField(f, fieldRanges[i][0], fieldRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron: field %d (%q): %w", i, f, err)
+		}
+		sets[i] = set
+	}
+
+	return &Schedule{
+		seconds: sets[0],
+		minutes: sets[1],
+		hours:   sets[2],
+		doms:    sets[3],
+		months:  sets[4],
+		dows:    sets[5],
+	}, nil
+}
+
+func parseField(field string, lo, hi int) (fieldSet, error) {
+	var set fieldSet
+	for _, part := range strings.Split(field, ",") {
+		if err := parsePart(&set, part, lo, hi); err != nil {
+			return 0, err
+		}
+	}
+	return set, nil
+}
+
+func parsePart(set *fieldSet, part string, lo, hi int) error {
+	step := 1
+	rangeExpr := part
+	if i := strings.IndexByte(part, '/'); i >= 0 {
+		rangeExpr = part[:i]
+		n, err := strconv.Atoi(part[i+1:])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid step %q", part[i+1:])
+		}
+		step = n
+	}
+
+	start, end := lo, hi
+	switch {
+	case rangeExpr == "*":
+		// start/end already cover the full range
+	case strings.Contains(rangeExpr, "-"):
+		bounds := strings.SplitN(rangeExpr, "-", 2)
+		a, err1 := strconv.Atoi(bounds[0])
+		b, err2 := strconv.Atoi(bounds[1])
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("invalid range %q", rangeExpr)
+		}
+		start, end = a, b
+	default:
+		v, err := strconv.Atoi(rangeExpr)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", rangeExpr)
+		}
+		start, end = v, v
+	}
+
+	if start < lo || end > hi || start > end {
+		return fmt.Errorf("value out of range [%d,%d]: %q", lo, hi, part)
+	}
+	for v := start; v <= end; v += step {
+		*set |= 1 << uint(v)
+	}
+	return nil
+}
+
+// Next returns the earliest time strictly after t that matches the
+// schedule, truncated to the second. It scans second by second, which is
+// plenty fast for cron-interval schedules (seconds to days apart) and
+// avoids the edge cases of a calendar-arithmetic "next" implementation.
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Second).Add(time.Second)
+	// Bound the scan so a malformed schedule (e.g. Feb 30) can't loop
+	// forever; four years covers every possible day/month/weekday combo.
+	limit := next.AddDate(4, 0, 0)
+	for next.Before(limit) {
+		if s.matches(next) {
+			return next
+		}
+		next = next.Add(time.Second)
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matches(t time.Time) bool {
+	return s.seconds.has(t.Second()) &&
+		s.minutes.has(t.Minute()) &&
+		s.hours.has(t.Hour()) &&
+		s.doms.has(t.Day()) &&
+		s.months.has(int(t.Month())) &&
+		s.dows.has(int(t.Weekday()))
+}
@@ -0,0 +1,229 @@
+package cron
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// LimiterMode selects the algorithm ProviderLimiter uses to pace requests.
+type LimiterMode string
+
+const (
+	// LimiterModeTokenBucket allows bursts up to Burst tokens, refilling at
+	// Rate tokens/sec. This is the default.
+	LimiterModeTokenBucket LimiterMode = "token_bucket"
+	// LimiterModeLeakyBucket admits requests at a strictly constant Rate,
+	// ignoring any accrued burst allowance (each Take waits for the next
+	// 1/Rate-spaced slot instead of draining a reservoir).
+	LimiterModeLeakyBucket LimiterMode = "leaky_bucket"
+)
+
+// providerLimit is one provider's configured rate/burst pair.
+type providerLimit struct {
+	Rate  float64 // tokens (requests) per second
+	Burst float64 // max tokens a token bucket can hold; unused in leaky mode
+}
+
+// bucket is the per-provider mutable state backing both limiter modes.
+type bucket struct {
+	mu        sync.Mutex
+	tokens    float64   // token_bucket only
+	lastEvent time.Time // last Take() (leaky_bucket) or last refill (token_bucket)
+}
+
+// ProviderLimiter rate-limits provider refreshes independently per provider
+// key, so a utility that tolerates 10 req/sec doesn't have to wait behind
+// one that allows 1 req/min (the problem with BatchConfig.RateLimitDelay,
+// a single global delay shared by every provider).
+type ProviderLimiter struct {
+	mode    LimiterMode
+	def     providerLimit
+	limits  map[string]providerLimit
+	buckets sync.Map // provider key -> *bucket
+}
+
+// NewProviderLimiter builds a ProviderLimiter with per-provider limits and a
+// fallback for any provider key not present in limits.
+func NewProviderLimiter(mode LimiterMode, def providerLimit, limits map[string]providerLimit) *ProviderLimiter {
+	if mode == "" {
+		mode = LimiterModeTokenBucket
+	}
+	if limits == nil {
+		limits = map[string]providerLimit{}
+	}
+	return &ProviderLimiter{mode: mode, def: def, limits: limits}
+}
+
+// NewProviderLimiterFromEnv builds a ProviderLimiter from BATCH_PROVIDER_LIMITS
+// (format "cemc:0.5:2,nes:2:5", provider:rate:burst) and BATCH_LIMITER_MODE
+// ("token_bucket" or "leaky_bucket"), falling back to a conservative default
+// of 1 req/sec with burst 1 for any provider not named in the map.
+func NewProviderLimiterFromEnv() *ProviderLimiter {
+	def := providerLimit{Rate: 1, Burst: 1}
+	if v := os.Getenv("BATCH_DEFAULT_RATE_LIMIT"); v != "" {
+		if parsed, ok := parseProviderLimit(v); ok {
+			def = parsed
+		}
+	}
+
+	limits := map[string]providerLimit{}
+	for _, entry := range strings.Split(os.Getenv("BATCH_PROVIDER_LIMITS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || rate <= 0 {
+			continue
+		}
+		burst, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil || burst <= 0 {
+			continue
+		}
+		limits[strings.ToLower(parts[0])] = providerLimit{Rate: rate, Burst: burst}
+	}
+
+	mode := LimiterModeTokenBucket
+	if strings.ToLower(os.Getenv("BATCH_LIMITER_MODE")) == string(LimiterModeLeakyBucket) {
+		mode = LimiterModeLeakyBucket
+	}
+
+	return NewProviderLimiter(mode, def, limits)
+}
+
+// parseProviderLimit parses a bare "rate:burst" pair, used for
+// BATCH_DEFAULT_RATE_LIMIT (no provider key prefix).
+func parseProviderLimit(raw string) (providerLimit, bool) {
+	parts := strings.SplitN(raw, ":", 2)
+	if len(parts) != 2 {
+		return providerLimit{}, false
+	}
+	rate, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || rate <= 0 {
+		return providerLimit{}, false
+	}
+	burst, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || burst <= 0 {
+		return providerLimit{}, false
+	}
+	return providerLimit{Rate: rate, Burst: burst}, true
+}
+
+func (l *ProviderLimiter) limitFor(providerKey string) providerLimit {
+	if lim, ok := l.limits[providerKey]; ok {
+		return lim
+	}
+	return l.def
+}
+
+// Take blocks until providerKey has a token available (or ctx is done),
+// whichever comes first. Concurrent goroutines calling Take for different
+// providers never block each other; calls for the same provider serialize
+// on that provider's bucket.
+func (l *ProviderLimiter) Take(ctx context.Context, providerKey string) error {
+	lim := l.limitFor(providerKey)
+	bRaw, _ := l.buckets.LoadOrStore(providerKey, &bucket{tokens: lim.Burst, lastEvent: time.Now()})
+	b := bRaw.(*bucket)
+
+	if l.mode == LimiterModeLeakyBucket {
+		return l.takeLeaky(ctx, providerKey, b, lim)
+	}
+	return l.takeTokenBucket(ctx, providerKey, b, lim)
+}
+
+// takeTokenBucket computes tokens accrued since the bucket's last refill
+// (elapsed * Rate, capped at Burst), deducts one, and sleeps for the
+// shortfall (1 - available) / Rate when fewer than one token is available.
+func (l *ProviderLimiter) takeTokenBucket(ctx context.Context, providerKey string, b *bucket, lim providerLimit) error {
+	b.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(b.lastEvent).Seconds()
+	b.tokens = minFloat(lim.Burst, b.tokens+elapsed*lim.Rate)
+	b.lastEvent = now
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / lim.Rate * float64(time.Second))
+	}
+	b.tokens -= 1
+	b.mu.Unlock()
+
+	return l.sleep(ctx, providerKey, wait)
+}
+
+// takeLeaky admits requests at a strictly constant spacing of 1/Rate,
+// ignoring any burst allowance: each call waits until Rate-spaced since the
+// previous one, regardless of how long the caller idled beforehand.
+func (l *ProviderLimiter) takeLeaky(ctx context.Context, providerKey string, b *bucket, lim providerLimit) error {
+	interval := time.Duration(float64(time.Second) / lim.Rate)
+
+	b.mu.Lock()
+	now := time.Now()
+	next := b.lastEvent.Add(interval)
+	var wait time.Duration
+	if next.After(now) {
+		wait = next.Sub(now)
+		b.lastEvent = next
+	} else {
+		b.lastEvent = now
+	}
+	b.mu.Unlock()
+
+	return l.sleep(ctx, providerKey, wait)
+}
+
+// sleep waits for d (recording it as throttled time via metrics), returning
+// early with ctx.Err() if ctx is cancelled first.
+func (l *ProviderLimiter) sleep(ctx context.Context, providerKey string, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	providerLimiterWaitSeconds.WithLabelValues(providerKey).Observe(d.Seconds())
+	providerLimiterThrottledTotal.WithLabelValues(providerKey).Inc()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+var (
+	providerLimiterThrottledTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "eratemanager_batch_limiter_throttled_total",
+			Help: "Total number of provider refreshes that had to wait for ProviderLimiter.Take",
+		},
+		[]string{"provider"},
+	)
+
+	providerLimiterWaitSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "eratemanager_batch_limiter_wait_seconds",
+			Help:    "Time spent waiting in ProviderLimiter.Take per provider",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"provider"},
+	)
+)
@@ -0,0 +1,171 @@
+// Package pool implements a bounded worker pool with queue backpressure,
+// used in place of ad-hoc sync.WaitGroup + chan struct{} fan-out so large
+// provider lists don't spawn every goroutine up front.
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Opts configures a Pool.
+type Opts struct {
+	// MaxWorkers is how many tasks may run concurrently. Values <= 0 are
+	// treated as 1 (sequential).
+	MaxWorkers int
+	// QueueSize bounds how many submitted-but-not-yet-running tasks Submit
+	// will buffer before it blocks the caller. 0 means unbuffered: Submit
+	// blocks until a worker is free to accept the task directly.
+	QueueSize int
+	// IdleTimeout is unused once a worker is running (workers run for the
+	// pool's lifetime), but is honored before the first task arrives: a
+	// worker that never receives a task within IdleTimeout exits early to
+	// avoid holding a goroutine open for a pool that ends up mostly idle.
+	// 0 disables this and keeps all MaxWorkers alive for the pool's life.
+	IdleTimeout time.Duration
+}
+
+// Pool runs submitted tasks across a fixed number of worker goroutines,
+// providing backpressure (Submit blocks once the queue is full) instead of
+// spawning one goroutine per task.
+type Pool struct {
+	tasks chan func()
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	stopped  bool
+	rejected int
+}
+
+// NewPool starts a Pool with opts.MaxWorkers workers draining a queue of
+// opts.QueueSize. The pool stops accepting new work (Submit returns
+// ErrPoolStopped) once ctx is done or StopAndWait is called.
+func NewPool(ctx context.Context, opts Opts) *Pool {
+	maxWorkers := opts.MaxWorkers
+	if maxWorkers <= 0 {
+		maxWorkers = 1
+	}
+
+	p := &Pool{
+		tasks: make(chan func(), opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+
+	p.wg.Add(maxWorkers)
+	for i := 0; i < maxWorkers; i++ {
+		go p.worker(ctx, opts.IdleTimeout)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.stop()
+		case <-p.done:
+		}
+	}()
+
+	return p
+}
+
+func (p *Pool) worker(ctx context.Context, idleTimeout time.Duration) {
+	defer p.wg.Done()
+
+	if idleTimeout > 0 {
+		timer := time.NewTimer(idleTimeout)
+		select {
+		case task := <-p.tasks:
+			timer.Stop()
+			task()
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	for {
+		// Prefer draining an already-queued task over exiting, so
+		// StopAndWait/ctx cancellation doesn't abandon work that was
+		// already accepted by Submit.
+		select {
+		case task := <-p.tasks:
+			task()
+			continue
+		default:
+		}
+
+		select {
+		case task := <-p.tasks:
+			task()
+		case <-ctx.Done():
+			return
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Submit enqueues task, blocking when the queue (and every worker) is
+// already full. It returns ErrPoolStopped without running task if the pool
+// has already been stopped.
+func (p *Pool) Submit(task func()) error {
+	p.mu.Lock()
+	if p.stopped {
+		p.rejected++
+		p.mu.Unlock()
+		return ErrPoolStopped
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-p.done:
+		p.mu.Lock()
+		p.rejected++
+		p.mu.Unlock()
+		return ErrPoolStopped
+	}
+}
+
+// StopAndWait stops accepting new submissions and blocks until every
+// already-submitted task has finished (or its worker was cancelled via the
+// Pool's ctx).
+func (p *Pool) StopAndWait() {
+	p.stop()
+	p.wg.Wait()
+}
+
+// Rejected reports how many Submit calls were turned away after the pool
+// stopped accepting work.
+func (p *Pool) Rejected() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rejected
+}
+
+func (p *Pool) stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	p.mu.Unlock()
+	// p.tasks is deliberately never closed: Submit and stop() can race, and
+	// closing it here could make a concurrent Submit panic sending on a
+	// closed channel. Workers instead drain p.tasks until empty (see
+	// worker's non-blocking select) before observing p.done and exiting.
+	close(p.done)
+}
+
+// ErrPoolStopped is returned by Submit once the pool has stopped accepting
+// new work, either via StopAndWait or the pool's context being cancelled.
+var ErrPoolStopped = poolStoppedError{}
+
+type poolStoppedError struct{}
+
+func (poolStoppedError) Error() string { return "pool: stopped, not accepting new tasks" }
@@ -0,0 +1,233 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bher20/eratemanager/internal/logging"
+	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// Job is one named unit of scheduled work, run whenever its Cron expression
+// (6 fields: "sec min hour dom month dow") comes due.
+type Job struct {
+	Name string
+	Cron string
+	Run  func(ctx context.Context) error
+}
+
+// scheduledJob pairs a Job with its parsed Schedule and the next instant it
+// is due, so Scheduler.dispatch doesn't re-parse/re-derive either per tick.
+type scheduledJob struct {
+	Job
+	schedule *Schedule
+	nextRun  time.Time
+}
+
+// defaultLeaseDuration is how long a Postgres advisory lock holder is
+// trusted as leader before a non-leader retries acquiring it; see
+// storage.NewCoordinatorWithPoll.
+const defaultLeaseDuration = 30 * time.Second
+
+// defaultShutdownTimeout is how long Run waits for in-flight jobs to finish
+// on their own once ctx is cancelled before force-cancelling them; see
+// Scheduler.shutdown.
+const defaultShutdownTimeout = 30 * time.Second
+
+// dispatchTick is how often the scheduler checks whether any job is due.
+// It's independent of, and much finer-grained than, leaseDuration.
+const dispatchTick = 1 * time.Second
+
+// runningJob tracks one in-flight job execution so shutdown can force-cancel
+// it if it hasn't finished by shutdownTimeout.
+type runningJob struct {
+	id     uint64
+	name   string
+	cancel context.CancelFunc
+}
+
+// Scheduler runs a set of named cron Jobs, dispatching only while leader of
+// a fleet of replicas sharing st. Unlike the single-job fixed-interval loop
+// it replaces, it supports any number of independently-scheduled jobs and
+// persists each job's schedule/last-run/next-run to storage.CronJobStore
+// (when st supports it) so GET /cron/jobs can report fleet-wide status.
+type Scheduler struct {
+	st              storage.Storage
+	leaseDuration   time.Duration
+	shutdownTimeout time.Duration
+	jobs            []*scheduledJob
+
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	running   []runningJob
+	nextRunID uint64
+}
+
+// NewScheduler parses each job's cron expression and returns a Scheduler
+// ready to Run. leaseDuration is the non-leader retry interval for the
+// underlying advisory lock (defaultLeaseDuration if zero). shutdownTimeout
+// bounds how long Run waits for in-flight jobs to finish once ctx is
+// cancelled before force-cancelling them (defaultShutdownTimeout if zero).
+func NewScheduler(st storage.Storage, leaseDuration, shutdownTimeout time.Duration, jobs ...Job) (*Scheduler, error) {
+	if leaseDuration <= 0 {
+		leaseDuration = defaultLeaseDuration
+	}
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	s := &Scheduler{st: st, leaseDuration: leaseDuration, shutdownTimeout: shutdownTimeout}
+	now := time.Now()
+	for _, j := range jobs {
+		schedule, err := ParseSchedule(j.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", j.Name, err)
+		}
+		s.jobs = append(s.jobs, &scheduledJob{Job: j, schedule: schedule, nextRun: schedule.Next(now)})
+	}
+	return s, nil
+}
+
+// Run elects a leader via a Postgres advisory lock (storage.NewCoordinatorWithPoll)
+// and, while leader, dispatches due jobs on a 1s tick until ctx is canceled.
+// On backends that don't support multi-instance coordination (e.g. SQLite),
+// this instance is always leader.
+//
+// Once ctx is canceled, Run does not return immediately: it waits (see
+// shutdown) for any in-flight jobs to drain, up to shutdownTimeout, before
+// releasing the advisory lock. This keeps a job's HTTP fetches/PDF parses
+// from outliving the process, and keeps this replica as leader for as long
+// as it still has work in flight.
+func (s *Scheduler) Run(ctx context.Context) error {
+	const schedulerLockKey int64 = 42
+
+	coordinator := storage.NewCoordinatorWithPoll(s.st, schedulerLockKey, s.leaseDuration)
+	if err := coordinator.Start(ctx); err != nil {
+		return fmt.Errorf("start coordinator: %w", err)
+	}
+	defer coordinator.Stop()
+
+	store, _ := s.st.(storage.CronJobStore)
+	if store != nil {
+		for _, j := range s.jobs {
+			if err := store.UpsertCronJobSchedule(ctx, j.Name, j.Cron, j.nextRun); err != nil {
+				log.Printf("cron: persist schedule for %s failed: %v", j.Name, err)
+			}
+		}
+	}
+
+	ticker := time.NewTicker(dispatchTick)
+	defer ticker.Stop()
+
+	log.Printf("cron scheduler starting with %d job(s), lease=%s, shutdown_timeout=%s", len(s.jobs), s.leaseDuration, s.shutdownTimeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return s.shutdown(store)
+		case <-ticker.C:
+			if !coordinator.IsLeader() {
+				continue
+			}
+			now := time.Now()
+			for _, j := range s.jobs {
+				if now.Before(j.nextRun) {
+					continue
+				}
+				j.nextRun = j.schedule.Next(now)
+				s.dispatch(j, store, now)
+			}
+		}
+	}
+}
+
+// shutdown waits up to s.shutdownTimeout for every in-flight job launched by
+// dispatch to finish on its own; if the timeout elapses first, it
+// force-cancels each still-running job's context and waits for them to
+// unwind before returning, so Run's caller (and the deferred
+// coordinator.Stop in Run) never observes the advisory lock released while
+// a job goroutine is still running.
+func (s *Scheduler) shutdown(store storage.CronJobStore) error {
+	drained := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		log.Printf("cron scheduler: shutdown complete, all jobs drained")
+	case <-time.After(s.shutdownTimeout):
+		log.Printf("cron scheduler: shutdown_timeout=%s exceeded, force-cancelling in-flight jobs", s.shutdownTimeout)
+		s.mu.Lock()
+		for _, rj := range s.running {
+			metrics.JobsCancelledTotal.WithLabelValues(rj.name).Inc()
+			rj.cancel()
+		}
+		s.mu.Unlock()
+		<-drained
+	}
+	return context.Canceled
+}
+
+// dispatch launches one due job in its own goroutine under a context
+// independent of Run's ctx, so a job already in flight when Run's ctx is
+// canceled keeps running until it finishes or shutdown force-cancels it.
+// Each job gets its own request ID (see logging.ContextWithRequestID) so a
+// single scheduled run can be traced end-to-end through rates.Service and
+// storage logs, the same way an HTTP request is via X-Request-ID.
+func (s *Scheduler) dispatch(j *scheduledJob, store storage.CronJobStore, now time.Time) {
+	jobCtx, cancel := context.WithCancel(context.Background())
+	jobCtx = logging.ContextWithRequestID(jobCtx, uuid.NewString())
+
+	s.mu.Lock()
+	s.nextRunID++
+	runID := s.nextRunID
+	s.running = append(s.running, runningJob{id: runID, name: j.Name, cancel: cancel})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		defer s.removeRunning(runID)
+
+		started := time.Now()
+		err := j.Run(jobCtx)
+		cancelled := jobCtx.Err() != nil
+		logging.RecordJobResult(jobCtx, logging.Default(), j.Name, started, err)
+
+		if store == nil {
+			return
+		}
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		// Record the outcome with a fresh context: jobCtx may already be
+		// force-cancelled by shutdown, which would otherwise fail this write.
+		writeCtx, writeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer writeCancel()
+		if err := store.RecordCronJobRun(writeCtx, j.Name, started, err == nil, errMsg, cancelled, j.nextRun); err != nil {
+			log.Printf("cron: record run for %s failed: %v", j.Name, err)
+		}
+	}()
+}
+
+// removeRunning drops runID's entry from s.running once its job goroutine
+// has returned, so shutdown doesn't call an already-fired CancelFunc.
+func (s *Scheduler) removeRunning(runID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, rj := range s.running {
+		if rj.id == runID {
+			s.running = append(s.running[:i], s.running[i+1:]...)
+			return
+		}
+	}
+}
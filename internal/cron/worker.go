@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/alerting"
+	"github.com/bher20/eratemanager/internal/logging"
+	"github.com/bher20/eratemanager/internal/providerhealth"
 	"github.com/bher20/eratemanager/internal/rates"
 	"github.com/bher20/eratemanager/internal/storage"
 )
@@ -26,13 +29,93 @@ func buildRatesConfigWorker() rates.Config {
 			pdfPaths[p.Key] = p.DefaultPDFPath
 		}
 	}
-	return rates.Config{PDFPaths: pdfPaths}
+	cacheDir := os.Getenv("ERATEMANAGER_PDF_CACHE_DIR")
+	return rates.Config{PDFPaths: pdfPaths, CacheDir: cacheDir}
 }
 
-// Run starts a simple cron worker that periodically refreshes provider rates
-// using a Postgres pgxpool backend and PostgreSQL advisory locks so that in a
-// multi-instance deployment only one worker executes the job.
+// providerRefreshTimeout bounds a single provider's refresh within
+// refreshElectricJob/refreshWaterJob, the same role cron/batch.go's
+// Config.ProviderTimeout plays for the batch CLI path: one unresponsive
+// provider delays the run by at most this long instead of consuming the
+// scheduler's whole tick.
+const providerRefreshTimeout = 15 * time.Second
+
+// refreshElectricJob fetches a fresh PDF for every electric provider (when
+// one has a SourceURL or LandingURL to fetch from) and re-parses it,
+// returning the first error encountered (after attempting every provider).
+func refreshElectricJob(cfg rates.Config, svc *rates.Service, httpClient *http.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var firstErr error
+		for _, p := range rates.ElectricProviders() {
+			providerCtx, cancel := context.WithTimeout(ctx, providerRefreshTimeout)
+			if p.SourceURL != "" || p.LandingURL != "" {
+				if res, err := rates.FetchProviderPDF(providerCtx, httpClient, p, cfg.CacheDir); err != nil {
+					log.Printf("cron: fetch %s pdf failed: %v", p.Key, err)
+				} else {
+					cfg.PDFPaths[p.Key] = res.Path
+				}
+			}
+			if _, err := svc.GetResidential(providerCtx, p.Key); err != nil {
+				log.Printf("cron: refresh electric provider %s failed: %v", p.Key, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			cancel()
+		}
+		return firstErr
+	}
+}
+
+// refreshWaterJob re-fetches and parses every water provider's rates page.
+func refreshWaterJob(waterSvc *rates.WaterService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var firstErr error
+		for _, p := range rates.WaterProviders() {
+			providerCtx, cancel := context.WithTimeout(ctx, providerRefreshTimeout)
+			if _, err := waterSvc.GetWaterRates(providerCtx, p.Key); err != nil {
+				log.Printf("cron: refresh water provider %s failed: %v", p.Key, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			cancel()
+		}
+		return firstErr
+	}
+}
+
+// snapshotGCJob prunes old rates_snapshots rows, keeping the most recent
+// snapshotGCKeepLast per provider and deleting anything older than
+// snapshotGCOlderThan. It's a no-op when st doesn't retain snapshot history.
+const (
+	snapshotGCKeepLast  = 30
+	snapshotGCOlderThan = 90 * 24 * time.Hour
+)
+
+func snapshotGCJob(st storage.Storage) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		hist, ok := st.(storage.SnapshotHistory)
+		if !ok {
+			return nil
+		}
+		deleted, err := hist.PruneRatesSnapshots(ctx, snapshotGCKeepLast, snapshotGCOlderThan)
+		if err != nil {
+			return fmt.Errorf("prune rates snapshots: %w", err)
+		}
+		log.Printf("cron: snapshot_gc deleted %d row(s)", deleted)
+		return nil
+	}
+}
+
+// Run starts the distributed cron scheduler: refresh_electric and
+// refresh_water pull fresh rates every 5 minutes, and snapshot_gc prunes old
+// history nightly. Only the replica holding the Postgres advisory lock
+// dispatches jobs (see Scheduler.Run), so running several replicas against
+// the same database is safe.
 func Run(ctx context.Context, driver, dsn string) error {
+	logging.SetDefault(logging.FromEnv())
+
 	if driver == "" {
 		driver = "postgrespool"
 	}
@@ -40,94 +123,56 @@ func Run(ctx context.Context, driver, dsn string) error {
 		return fmt.Errorf("cron worker requires ERATEMANAGER_DB_DRIVER=postgrespool (got %q)", driver)
 	}
 
-	// Open storage via the generic factory so that it still satisfies the
-	// storage.Storage interface for rates.Service. We then assert the concrete
-	// type to gain access to advisory locks.
-	stGeneric, err := storage.Open(ctx, storage.Config{Driver: driver, DSN: dsn})
+	st, err := storage.Open(ctx, storage.Config{Driver: driver, DSN: dsn})
 	if err != nil {
 		return fmt.Errorf("open storage: %w", err)
 	}
-	defer stGeneric.Close()
+	defer st.Close()
+
+	cfg := buildRatesConfigWorker()
+	svc := rates.NewServiceWithStorage(cfg, st)
+	if sink, err := rates.SinksFromEnv(); err != nil {
+		log.Printf("cron: configure sinks failed: %v", err)
+	} else if sink != nil {
+		svc.SetSink(sink)
+	}
+	alerter := alerting.NewAlerter(alerting.DefaultAlertConfig(), st)
+	defer alerter.Close()
 
-	pg, ok := stGeneric.(*storage.PostgresPoolStorage)
-	if !ok {
-		return fmt.Errorf("storage driver %q is not PostgresPoolStorage", driver)
+	var healthTargets []providerhealth.Target
+	for _, p := range rates.Providers() {
+		if p.LandingURL != "" {
+			healthTargets = append(healthTargets, providerhealth.Target{Key: p.Key, URL: p.LandingURL})
+		}
 	}
+	monitor := providerhealth.NewMonitor(providerhealth.DefaultConfig(), healthTargets, st, alerter)
+	defer monitor.Close()
 
-	// Build rates service with storage so results are cached to the DB.
-	svc := rates.NewServiceWithStorage(buildRatesConfigWorker(), stGeneric)
+	waterSvc := rates.NewWaterServiceWithStorageAndMonitor(st, monitor)
+	httpClient := rates.DefaultHTTPClient()
 
-	// Simple fixed-interval schedule; configurable via env.
-	intervalSec := 300
-	if raw := os.Getenv("ERATEMANAGER_CRON_INTERVAL_SECONDS"); raw != "" {
+	leaseDuration := defaultLeaseDuration
+	if raw := os.Getenv("ERATEMANAGER_CRON_LEASE_SECONDS"); raw != "" {
 		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
-			intervalSec = v
+			leaseDuration = time.Duration(v) * time.Second
 		}
 	}
-	ticker := time.NewTicker(time.Duration(intervalSec) * time.Second)
-	defer ticker.Stop()
-
-	jobName := "refresh_rates"
-	const lockKey int64 = 42
-
-	log.Printf("cron worker starting, interval=%ds driver=%s", intervalSec, driver)
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			started := time.Now()
-
-			ok, err := pg.AcquireAdvisoryLock(ctx, lockKey)
-			if err != nil {
-				log.Printf("cron: acquire advisory lock failed: %v", err)
-				metrics.UpdateJobMetrics(jobName, started, err)
-				continue
-			}
-			if !ok {
-				// Another worker is running this job.
-				log.Printf("cron: advisory lock held by another worker, skipping run")
-				continue
-			}
-
-			// We hold the lock for the duration of the job.
-			var runErr error
-			func() {
-				defer func() {
-					if _, err := pg.ReleaseAdvisoryLock(ctx, lockKey); err != nil {
-						log.Printf("cron: release advisory lock failed: %v", err)
-					}
-				}()
-
-				// Execute the job: refresh all known providers.
-				for _, p := range rates.Providers() {
-					if _, err := svc.GetResidential(ctx, p.Key); err != nil {
-						log.Printf("cron: refresh provider %s failed: %v", p.Key, err)
-						if runErr == nil {
-							runErr = err
-						}
-					}
-				}
-			}()
-
-			// Record metrics & job row.
-			metrics.UpdateJobMetrics(jobName, started, runErr)
-			dur := time.Since(started)
-			errMsg := ""
-			success := runErr == nil
-			if runErr != nil {
-				errMsg = runErr.Error()
-			}
-			if err := pg.UpdateScheduledJob(ctx, jobName, started, dur, success, errMsg); err != nil {
-				log.Printf("cron: update scheduled_jobs failed: %v", err)
-			}
 
-			if runErr != nil {
-				log.Printf("cron: job %s completed with error: %v (duration=%s)", jobName, runErr, dur)
-			} else {
-				log.Printf("cron: job %s completed successfully (duration=%s)", jobName, dur)
-			}
+	shutdownTimeout := defaultShutdownTimeout
+	if raw := os.Getenv("ERATEMANAGER_CRON_SHUTDOWN_SECONDS"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			shutdownTimeout = time.Duration(v) * time.Second
 		}
 	}
+
+	scheduler, err := NewScheduler(st, leaseDuration, shutdownTimeout,
+		Job{Name: "refresh_electric", Cron: "0 */5 * * * *", Run: refreshElectricJob(cfg, svc, httpClient)},
+		Job{Name: "refresh_water", Cron: "0 */5 * * * *", Run: refreshWaterJob(waterSvc)},
+		Job{Name: "snapshot_gc", Cron: "0 0 3 * * *", Run: snapshotGCJob(st)},
+	)
+	if err != nil {
+		return fmt.Errorf("build scheduler: %w", err)
+	}
+
+	return scheduler.Run(ctx)
 }
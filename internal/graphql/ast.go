@@ -0,0 +1,56 @@
+// Package graphql is a small, purpose-built GraphQL query layer over the
+// same data the REST/JSON API (internal/api, producing rates.RatesResponse)
+// already serves: storage.Storage's providers and rate snapshots. It is not
+// a general-purpose GraphQL engine - it implements just enough of the query
+// language (selection sets, aliases, arguments, variables) to let a caller
+// (Home Assistant, a dashboard) ask for only the fields it needs in one
+// round trip instead of fetching a whole RatesResponse blob. There are no
+// mutations or subscriptions: every query this package serves is read-only.
+package graphql
+
+// Value is a parsed GraphQL argument value: string, float64, int64, bool,
+// nil, or a VarRef awaiting substitution from the request's variables map.
+type Value interface{}
+
+// VarRef is an unresolved "$name" argument value, substituted by Execute
+// against the request's variables map before a resolver sees it.
+type VarRef struct {
+	Name string
+}
+
+// Field is one selected field in a GraphQL selection set, with its
+// arguments and (for object-typed fields) a nested selection set.
+type Field struct {
+	Name         string
+	Alias        string
+	Arguments    map[string]Value
+	SelectionSet []*Field
+}
+
+// ResponseName is the key this field's result is reported under: Alias
+// when set, otherwise Name, matching the GraphQL spec's field-aliasing
+// rule.
+func (f *Field) ResponseName() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// HasSelected reports whether name appears in f's selection set, letting a
+// resolver skip expensive work (e.g. decoding a snapshot's JSON payload)
+// for a field the query never asked for.
+func (f *Field) HasSelected(name string) bool {
+	for _, sel := range f.SelectionSet {
+		if sel.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Document is a parsed GraphQL request body: the single top-level
+// operation's selection set.
+type Document struct {
+	Fields []*Field
+}
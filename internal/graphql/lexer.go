@@ -0,0 +1,174 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokVariable // $name
+	tokString
+	tokInt
+	tokFloat
+	tokPunctuator // one of { } ( ) : ,
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lexer tokenizes a GraphQL query document. It understands just the subset
+// this package's parser consumes: names, variables, string/int/float
+// literals, and the punctuators a selection set and argument list need.
+// Comments (# to end of line) are skipped like whitespace.
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: []rune(src)}
+}
+
+func (l *lexer) peekRune() (rune, bool) {
+	if l.pos >= len(l.src) {
+		return 0, false
+	}
+	return l.src[l.pos], true
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case unicode.IsSpace(r) || r == ',':
+			l.pos++
+		case r == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isNameCont(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// next returns the next token, or a tokEOF token once the input is
+// exhausted.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	r, ok := l.peekRune()
+	if !ok {
+		return token{kind: tokEOF}, nil
+	}
+
+	switch {
+	case r == '{' || r == '}' || r == '(' || r == ')' || r == ':':
+		l.pos++
+		return token{kind: tokPunctuator, text: string(r)}, nil
+
+	case r == '$':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.src) && isNameCont(l.src[l.pos]) {
+			l.pos++
+		}
+		if l.pos == start {
+			return token{}, fmt.Errorf("graphql: expected variable name after '$' at offset %d", start)
+		}
+		return token{kind: tokVariable, text: string(l.src[start:l.pos])}, nil
+
+	case r == '"':
+		return l.lexString()
+
+	case isNameStart(r):
+		start := l.pos
+		for l.pos < len(l.src) && isNameCont(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, text: string(l.src[start:l.pos])}, nil
+
+	case r == '-' || unicode.IsDigit(r):
+		return l.lexNumber()
+
+	default:
+		return token{}, fmt.Errorf("graphql: unexpected character %q at offset %d", r, l.pos)
+	}
+}
+
+func (l *lexer) lexString() (token, error) {
+	start := l.pos
+	l.pos++ // opening quote
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return token{}, fmt.Errorf("graphql: unterminated string starting at offset %d", start)
+		}
+		r := l.src[l.pos]
+		if r == '"' {
+			l.pos++
+			return token{kind: tokString, text: sb.String()}, nil
+		}
+		if r == '\\' && l.pos+1 < len(l.src) {
+			l.pos++
+			switch l.src[l.pos] {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '"', '\\':
+				sb.WriteRune(l.src[l.pos])
+			default:
+				sb.WriteRune(l.src[l.pos])
+			}
+			l.pos++
+			continue
+		}
+		sb.WriteRune(r)
+		l.pos++
+	}
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	for l.pos < len(l.src) && unicode.IsDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	isFloat := false
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		isFloat = true
+		l.pos++
+		for l.pos < len(l.src) && unicode.IsDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	text := string(l.src[start:l.pos])
+	if isFloat {
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return token{}, fmt.Errorf("graphql: invalid float literal %q: %w", text, err)
+		}
+		return token{kind: tokFloat, text: text}, nil
+	}
+	if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+		return token{}, fmt.Errorf("graphql: invalid int literal %q: %w", text, err)
+	}
+	return token{kind: tokInt, text: text}, nil
+}
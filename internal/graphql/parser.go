@@ -0,0 +1,190 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Parse parses a GraphQL query document's source text into a Document.
+// Only an (optionally named, optionally "query"-keyworded) selection set is
+// accepted - enough to cover `{ providers { key } }`, `query { ... }`, and
+// `query Name { ... }`, since this package never serves mutations.
+func Parse(src string) (*Document, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	// Optional "query" keyword and optional operation name, both of which
+	// lex as a bare tokName - skip every leading name before the selection set.
+	for p.tok.kind == tokName {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	fields, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("graphql: unexpected trailing token %q", p.tok.text)
+	}
+	return &Document{Fields: fields}, nil
+}
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) expectPunct(text string) error {
+	if p.tok.kind != tokPunctuator || p.tok.text != text {
+		return fmt.Errorf("graphql: expected %q, got %q", text, p.tok.text)
+	}
+	return p.advance()
+}
+
+// parseSelectionSet parses a brace-delimited list of fields.
+func (p *parser) parseSelectionSet() ([]*Field, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var fields []*Field
+	for {
+		if p.tok.kind == tokPunctuator && p.tok.text == "}" {
+			return fields, p.advance()
+		}
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+}
+
+// parseField parses one "[alias:] name [(args)] [{ selectionSet }]".
+func (p *parser) parseField() (*Field, error) {
+	if p.tok.kind != tokName {
+		return nil, fmt.Errorf("graphql: expected a field name, got %q", p.tok.text)
+	}
+	first := p.tok.text
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	field := &Field{Name: first}
+	if p.tok.kind == tokPunctuator && p.tok.text == ":" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokName {
+			return nil, fmt.Errorf("graphql: expected a field name after alias %q, got %q", first, p.tok.text)
+		}
+		field.Alias = first
+		field.Name = p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.tok.kind == tokPunctuator && p.tok.text == "(" {
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		field.Arguments = args
+	}
+
+	if p.tok.kind == tokPunctuator && p.tok.text == "{" {
+		sub, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		field.SelectionSet = sub
+	}
+
+	return field, nil
+}
+
+func (p *parser) parseArguments() (map[string]Value, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	args := make(map[string]Value)
+	for {
+		if p.tok.kind == tokPunctuator && p.tok.text == ")" {
+			return args, p.advance()
+		}
+		if p.tok.kind != tokName {
+			return nil, fmt.Errorf("graphql: expected an argument name, got %q", p.tok.text)
+		}
+		name := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = val
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokVariable:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return VarRef{Name: tok.text}, nil
+	case tokString:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return tok.text, nil
+	case tokInt:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokFloat:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case tokName:
+		switch tok.text {
+		case "true":
+			return true, p.advance()
+		case "false":
+			return false, p.advance()
+		case "null":
+			return nil, p.advance()
+		}
+		return nil, fmt.Errorf("graphql: unsupported value keyword %q", tok.text)
+	default:
+		return nil, fmt.Errorf("graphql: unexpected token %q where a value was expected", tok.text)
+	}
+}
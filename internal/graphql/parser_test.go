@@ -0,0 +1,54 @@
+package graphql
+
+import "testing"
+
+func TestParseSelectionSetWithArgumentsAndAlias(t *testing.T) {
+	doc, err := Parse(`
+		query {
+			providers(residentialTouPresent: true) { key name }
+			cemc: provider(key: "cemc") { key landingUrl }
+		}
+	`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(doc.Fields) != 2 {
+		t.Fatalf("expected 2 top-level fields, got %d", len(doc.Fields))
+	}
+
+	providers := doc.Fields[0]
+	if providers.Name != "providers" || providers.ResponseName() != "providers" {
+		t.Errorf("unexpected providers field: %+v", providers)
+	}
+	if v, ok := providers.Arguments["residentialTouPresent"].(bool); !ok || !v {
+		t.Errorf("expected residentialTouPresent=true, got %#v", providers.Arguments["residentialTouPresent"])
+	}
+	if !providers.HasSelected("name") {
+		t.Error("expected providers selection set to include name")
+	}
+
+	provider := doc.Fields[1]
+	if provider.Name != "provider" || provider.Alias != "cemc" || provider.ResponseName() != "cemc" {
+		t.Errorf("unexpected aliased provider field: %+v", provider)
+	}
+	if got, ok := provider.Arguments["key"].(string); !ok || got != "cemc" {
+		t.Errorf("expected key=\"cemc\", got %#v", provider.Arguments["key"])
+	}
+}
+
+func TestParseVariableArgument(t *testing.T) {
+	doc, err := Parse(`{ provider(key: $providerKey) { key } }`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	ref, ok := doc.Fields[0].Arguments["key"].(VarRef)
+	if !ok || ref.Name != "providerKey" {
+		t.Errorf("expected key to be a VarRef(providerKey), got %#v", doc.Fields[0].Arguments["key"])
+	}
+}
+
+func TestParseRejectsUnterminatedString(t *testing.T) {
+	if _, err := Parse(`{ provider(key: "cemc) { key } }`); err == nil {
+		t.Error("expected an error for an unterminated string literal")
+	}
+}
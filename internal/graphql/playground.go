@@ -0,0 +1,68 @@
+package graphql
+
+import "net/http"
+
+// playgroundHTML is a minimal, dependency-free query console: a textarea,
+// a "Run" button, and a pre block for the response. It deliberately isn't a
+// full GraphiQL (no syntax highlighting, no schema introspection) - just
+// enough to let someone poke at the API from a browser without shipping a
+// bundled JS app alongside a Go binary.
+const playgroundHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>eRateManager GraphQL</title>
+<style>
+body { font-family: monospace; margin: 1.5rem; }
+textarea, pre { width: 100%; box-sizing: border-box; }
+textarea { height: 12rem; }
+pre { background: #f4f4f4; padding: 0.75rem; min-height: 8rem; white-space: pre-wrap; }
+button { margin: 0.5rem 0; padding: 0.4rem 1rem; }
+</style>
+</head>
+<body>
+<h3>eRateManager GraphQL</h3>
+<textarea id="query">{
+  providers {
+    key
+    name
+  }
+}</textarea>
+<br>
+<button id="run">Run</button>
+<pre id="result"></pre>
+<script>
+document.getElementById("run").addEventListener("click", function () {
+  var query = document.getElementById("query").value;
+  fetch(window.location.pathname, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ query: query })
+  })
+    .then(function (r) { return r.json(); })
+    .then(function (body) {
+      document.getElementById("result").textContent = JSON.stringify(body, null, 2);
+    })
+    .catch(function (err) {
+      document.getElementById("result").textContent = String(err);
+    });
+});
+</script>
+</body>
+</html>
+`
+
+// PlaygroundHandler serves playgroundHTML for GET requests, for deployments
+// that enable it via ERATEMANAGER_GRAPHQL_PLAYGROUND (see
+// api.NewMuxWithOptions). Disabled by default since it has no auth of its
+// own beyond whatever sits in front of the mux.
+func PlaygroundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(playgroundHTML))
+	})
+}
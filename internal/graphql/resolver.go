@@ -0,0 +1,293 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/rates"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// Resolver answers the queries this package's schema exposes by reading
+// through storage.Storage, the same interface every storage backend
+// (memory, sqlite, postgres via pgx) implements for the REST API.
+type Resolver struct {
+	store storage.Storage
+}
+
+// NewResolver returns a Resolver backed by st.
+func NewResolver(st storage.Storage) *Resolver {
+	return &Resolver{store: st}
+}
+
+// Execute resolves every top-level field in doc against variables (the
+// request's "variables" map, may be nil), returning the GraphQL-over-HTTP
+// response shape's two halves: data and a list of per-field errors. A field
+// that errors still lets its siblings resolve, matching the GraphQL spec's
+// partial-results behavior.
+func (r *Resolver) Execute(ctx context.Context, doc *Document, variables map[string]interface{}) (map[string]interface{}, []error) {
+	data := make(map[string]interface{}, len(doc.Fields))
+	var errs []error
+	for _, f := range doc.Fields {
+		val, err := r.resolveField(ctx, f, variables)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.ResponseName(), err))
+			data[f.ResponseName()] = nil
+			continue
+		}
+		data[f.ResponseName()] = val
+	}
+	return data, errs
+}
+
+func (r *Resolver) resolveField(ctx context.Context, f *Field, variables map[string]interface{}) (interface{}, error) {
+	switch f.Name {
+	case "providers":
+		return r.resolveProviders(ctx, f, variables)
+	case "provider":
+		return r.resolveProvider(ctx, f, variables)
+	case "currentSnapshot":
+		return r.resolveCurrentSnapshot(ctx, f, variables)
+	case "snapshotsBetween":
+		return r.resolveSnapshotsBetween(ctx, f, variables)
+	default:
+		return nil, fmt.Errorf("unknown query field %q", f.Name)
+	}
+}
+
+// argString/argFloat/argBool resolve a single argument, following a VarRef
+// through variables when present. ok is false when the argument was never
+// supplied (as opposed to supplied-but-null).
+func argValue(args map[string]Value, name string, variables map[string]interface{}) (interface{}, bool) {
+	v, ok := args[name]
+	if !ok {
+		return nil, false
+	}
+	if ref, isRef := v.(VarRef); isRef {
+		resolved, present := variables[ref.Name]
+		return resolved, present
+	}
+	return v, true
+}
+
+func argString(args map[string]Value, name string, variables map[string]interface{}) (string, bool, error) {
+	v, ok := argValue(args, name, variables)
+	if !ok || v == nil {
+		return "", false, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", false, fmt.Errorf("argument %q must be a string, got %T", name, v)
+	}
+	return s, true, nil
+}
+
+func argBool(args map[string]Value, name string, variables map[string]interface{}) (bool, bool, error) {
+	v, ok := argValue(args, name, variables)
+	if !ok || v == nil {
+		return false, false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, false, fmt.Errorf("argument %q must be a boolean, got %T", name, v)
+	}
+	return b, true, nil
+}
+
+func argFloat(args map[string]Value, name string, variables map[string]interface{}) (float64, bool, error) {
+	v, ok := argValue(args, name, variables)
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	switch n := v.(type) {
+	case float64:
+		return n, true, nil
+	case int64:
+		return float64(n), true, nil
+	default:
+		return 0, false, fmt.Errorf("argument %q must be numeric, got %T", name, v)
+	}
+}
+
+// resolveProviders answers `providers`, optionally narrowed by
+// residentialTouPresent and/or onPeakRateGt - attribute filters over each
+// provider's current rates snapshot rather than the provider record itself,
+// so applying either forces a snapshot read per provider.
+func (r *Resolver) resolveProviders(ctx context.Context, f *Field, variables map[string]interface{}) (interface{}, error) {
+	touPresent, touPresentSet, err := argBool(f.Arguments, "residentialTouPresent", variables)
+	if err != nil {
+		return nil, err
+	}
+	onPeakGt, onPeakGtSet, err := argFloat(f.Arguments, "onPeakRateGt", variables)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := r.store.ListProviders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list providers: %w", err)
+	}
+
+	if !touPresentSet && !onPeakGtSet {
+		return shape(f.SelectionSet, all)
+	}
+
+	filtered := []storage.Provider{}
+	for _, p := range all {
+		resp, err := r.currentRates(ctx, p.Key)
+		if err != nil || resp == nil {
+			continue
+		}
+		tou := resp.Rates.ResidentialTOU
+		if touPresentSet && tou.IsPresent != touPresent {
+			continue
+		}
+		if onPeakGtSet {
+			if tou.OnPeakRateUSDPerKWh == nil || *tou.OnPeakRateUSDPerKWh <= onPeakGt {
+				continue
+			}
+		}
+		filtered = append(filtered, p)
+	}
+	return shape(f.SelectionSet, filtered)
+}
+
+func (r *Resolver) resolveProvider(ctx context.Context, f *Field, variables map[string]interface{}) (interface{}, error) {
+	key, ok, err := argString(f.Arguments, "key", variables)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || key == "" {
+		return nil, fmt.Errorf("argument %q is required", "key")
+	}
+	p, err := r.store.GetProvider(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("get provider %q: %w", key, err)
+	}
+	if p == nil {
+		return nil, nil
+	}
+	return shape(f.SelectionSet, p)
+}
+
+// resolveCurrentSnapshot answers `currentSnapshot`, decoding the snapshot's
+// stored RatesResponse payload into a "rates" field only when the query
+// actually selected it, so a caller asking only for fetchedAt/contentHash
+// never pays for the json.Unmarshal.
+func (r *Resolver) resolveCurrentSnapshot(ctx context.Context, f *Field, variables map[string]interface{}) (interface{}, error) {
+	provider, ok, err := argString(f.Arguments, "provider", variables)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || provider == "" {
+		return nil, fmt.Errorf("argument %q is required", "provider")
+	}
+
+	snap, err := r.store.GetRatesSnapshot(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot for %s: %w", provider, err)
+	}
+	if snap == nil {
+		return nil, nil
+	}
+	return shapeSnapshot(f, *snap)
+}
+
+// resolveSnapshotsBetween answers `snapshotsBetween`, requiring a storage
+// backend that implements storage.SnapshotHistory (SQLiteStorage,
+// PostgresStorage); storage.MemoryStorage only retains the latest snapshot
+// per provider and can't answer a range query.
+func (r *Resolver) resolveSnapshotsBetween(ctx context.Context, f *Field, variables map[string]interface{}) (interface{}, error) {
+	provider, ok, err := argString(f.Arguments, "provider", variables)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || provider == "" {
+		return nil, fmt.Errorf("argument %q is required", "provider")
+	}
+	fromStr, _, err := argString(f.Arguments, "from", variables)
+	if err != nil {
+		return nil, err
+	}
+	toStr, _, err := argString(f.Arguments, "to", variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to time.Time
+	if fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return nil, fmt.Errorf("argument %q must be RFC3339: %w", "from", err)
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return nil, fmt.Errorf("argument %q must be RFC3339: %w", "to", err)
+		}
+	}
+
+	hist, ok := r.store.(storage.SnapshotHistory)
+	if !ok {
+		return nil, fmt.Errorf("storage backend does not retain snapshot history")
+	}
+	snaps, err := hist.ListRatesSnapshots(ctx, provider, from, to, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots for %s between %s and %s: %w", provider, fromStr, toStr, err)
+	}
+
+	out := make([]interface{}, 0, len(snaps))
+	for _, snap := range snaps {
+		shaped, err := shapeSnapshot(f, snap)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, shaped)
+	}
+	return out, nil
+}
+
+// shapeSnapshot projects snap's own fields per f's selection set, and (only
+// when "rates" was selected) decodes snap.Payload into a rates.RatesResponse
+// and shapes that against the "rates" field's nested selection set.
+func shapeSnapshot(f *Field, snap storage.RatesSnapshot) (interface{}, error) {
+	shaped, err := shape(f.SelectionSet, snap)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := shaped.(map[string]interface{})
+	if !ok {
+		return shaped, nil
+	}
+
+	for _, sel := range f.SelectionSet {
+		if sel.Name != "rates" || len(snap.Payload) == 0 {
+			continue
+		}
+		var resp rates.RatesResponse
+		if err := json.Unmarshal(snap.Payload, &resp); err != nil {
+			return nil, fmt.Errorf("decode snapshot payload for %s: %w", snap.Provider, err)
+		}
+		shapedRates, err := shape(sel.SelectionSet, resp)
+		if err != nil {
+			return nil, err
+		}
+		out[sel.ResponseName()] = shapedRates
+	}
+	return out, nil
+}
+
+// currentRates loads and decodes provider's current snapshot, returning
+// (nil, nil) when no snapshot exists yet.
+func (r *Resolver) currentRates(ctx context.Context, provider string) (*rates.RatesResponse, error) {
+	snap, err := r.store.GetRatesSnapshot(ctx, provider)
+	if err != nil || snap == nil || len(snap.Payload) == 0 {
+		return nil, err
+	}
+	var resp rates.RatesResponse
+	if err := json.Unmarshal(snap.Payload, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// request is the standard GraphQL-over-HTTP POST body.
+type request struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// response is the standard GraphQL-over-HTTP response envelope: Data is
+// always present (possibly with null fields), Errors is omitted when every
+// field resolved cleanly.
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors,omitempty"`
+}
+
+// Handler returns an http.Handler serving POST /graphql against st: it
+// decodes a {query, variables} body, parses the query, resolves it through
+// a Resolver, and writes back {data, errors} per the GraphQL-over-HTTP
+// convention. A resolver error for one field is reported in errors without
+// failing the whole request (partial results), matching the GraphQL spec.
+func Handler(st storage.Storage) http.Handler {
+	resolver := NewResolver(st)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "read request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "decode request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		doc, err := Parse(req.Query)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response{Errors: []string{err.Error()}})
+			return
+		}
+
+		data, errs := resolver.Execute(r.Context(), doc, req.Variables)
+		resp := response{Data: data}
+		for _, e := range errs {
+			resp.Errors = append(resp.Errors, e.Error())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,55 @@
+package graphql
+
+import "encoding/json"
+
+// shape projects value (any JSON-marshalable Go value) down to just the
+// fields named in fields, recursing into nested objects and arrays per
+// their own nested selection sets. It's implemented as a JSON round trip
+// rather than reflection so it automatically respects each type's existing
+// `json:"..."` tags - the same names the REST API already serves - instead
+// of needing a second, parallel field-name mapping.
+func shape(fields []*Field, value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return project(fields, generic), nil
+}
+
+// project narrows generic (the result of decoding a JSON value into
+// interface{}) to only the keys selected by fields.
+func project(fields []*Field, generic interface{}) interface{} {
+	switch v := generic.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			child, ok := v[f.Name]
+			if !ok {
+				out[f.ResponseName()] = nil
+				continue
+			}
+			if len(f.SelectionSet) == 0 {
+				out[f.ResponseName()] = child
+				continue
+			}
+			out[f.ResponseName()] = project(f.SelectionSet, child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = project(fields, elem)
+		}
+		return out
+	default:
+		// Scalar reached with a still-nested selection set (e.g. the query
+		// asked for sub-fields of something that wasn't an object): return
+		// it as-is rather than erroring, the same permissive behavior
+		// conformance.diff uses for a type mismatch it doesn't recognize.
+		return v
+	}
+}
@@ -0,0 +1,23 @@
+package logging
+
+import "context"
+
+// ctxKey namespaces context values stashed by this package so they can't
+// collide with keys other packages add to the same context.
+type ctxKey int
+
+const requestIDCtxKey ctxKey = iota
+
+// ContextWithRequestID returns ctx with requestID attached, retrievable via
+// RequestIDFromContext. Used by api's request middleware to propagate the
+// X-Request-ID header, and by cron.Scheduler to propagate a per-run ID.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stashed by
+// ContextWithRequestID, or "" if none was attached.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey).(string)
+	return id
+}
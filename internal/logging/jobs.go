@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+)
+
+// defaultLogger is used by storage and scheduler call sites that don't carry
+// their own *slog.Logger through. Replace it with SetDefault for custom
+// output configuration (e.g. a rotating JSON file).
+var defaultLogger = New(Config{Stderr: true})
+
+// SetDefault replaces the package-level default logger used by Default().
+func SetDefault(l *slog.Logger) {
+	defaultLogger = l
+}
+
+// Default returns the package-level logger.
+func Default() *slog.Logger {
+	return defaultLogger
+}
+
+// RecordJobResult is the single call site for a scheduled job's outcome: it
+// updates the Prometheus job metrics (metrics.UpdateJobMetrics) and emits a
+// correlated slog line, so the two never drift out of sync.
+func RecordJobResult(ctx context.Context, l *slog.Logger, job string, started time.Time, err error) {
+	metrics.UpdateJobMetrics(job, started, err)
+
+	jl := WithRequestID(WithJob(l, job), RequestIDFromContext(ctx))
+	dur := time.Since(started)
+	if err != nil {
+		jl.ErrorContext(ctx, "job completed with error", "duration", dur, "error", err)
+		return
+	}
+	jl.InfoContext(ctx, "job completed", "duration", dur)
+}
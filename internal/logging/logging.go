@@ -0,0 +1,210 @@
+// Package logging provides a slog-based logger shared across the storage
+// and scheduler packages, so job/request/batch logs carry the same
+// correlation attributes (provider, path, batch_id, job) as the
+// Prometheus label set in internal/metrics.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config controls where log output goes and how it's formatted.
+type Config struct {
+	// JSONFile, if non-nil, receives JSON-formatted records (e.g. a rotating
+	// file writer). Left nil, JSON output is skipped.
+	JSONFile io.Writer
+	// Stderr, if false, suppresses the default text-to-stderr output.
+	Stderr bool
+	// Format selects the Stderr handler: "json" for slog.NewJSONHandler,
+	// anything else (including empty) for slog.NewTextHandler. Has no
+	// effect on JSONFile, which is always JSON.
+	Format string
+	// Level sets the minimum level for both outputs. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+	// DedupWindow suppresses identical repeated log messages within this
+	// window, useful when a provider's PDF fetch fails in a tight retry
+	// loop. Zero disables dedup.
+	DedupWindow time.Duration
+}
+
+// FromEnv builds the process-wide default logger from
+// ERATEMANAGER_LOG_FORMAT ("json" or "text", default "text") and
+// ERATEMANAGER_LOG_LEVEL ("debug", "info", "warn", "error", default
+// "info"). Callers typically pass the result to SetDefault at startup.
+func FromEnv() *slog.Logger {
+	return New(Config{
+		Stderr: true,
+		Format: os.Getenv("ERATEMANAGER_LOG_FORMAT"),
+		Level:  levelFromEnv(os.Getenv("ERATEMANAGER_LOG_LEVEL")),
+	})
+}
+
+func levelFromEnv(raw string) slog.Level {
+	switch strings.ToLower(raw) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// New builds a *slog.Logger that fans out to JSON file and/or stderr text
+// output per cfg.
+func New(cfg Config) *slog.Logger {
+	level := cfg.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	var handlers []slog.Handler
+	if cfg.JSONFile != nil {
+		handlers = append(handlers, slog.NewJSONHandler(cfg.JSONFile, &slog.HandlerOptions{Level: level}))
+	}
+	if cfg.Stderr {
+		if strings.ToLower(cfg.Format) == "json" {
+			handlers = append(handlers, slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+		} else {
+			handlers = append(handlers, slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+		}
+	}
+
+	var h slog.Handler
+	switch len(handlers) {
+	case 0:
+		h = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	case 1:
+		h = handlers[0]
+	default:
+		h = multiHandler(handlers)
+	}
+
+	if cfg.DedupWindow > 0 {
+		h = newDedupHandler(h, cfg.DedupWindow)
+	}
+
+	return slog.New(h)
+}
+
+// WithProvider returns l with a "provider" attribute attached, matching the
+// metrics.RequestsTotal/RequestDurationSeconds label.
+func WithProvider(l *slog.Logger, provider string) *slog.Logger {
+	return l.With("provider", provider)
+}
+
+// WithPath returns l with a "path" attribute attached, matching the
+// metrics.RequestDurationSeconds label.
+func WithPath(l *slog.Logger, path string) *slog.Logger {
+	return l.With("path", path)
+}
+
+// WithBatch returns l with a "batch_id" attribute attached.
+func WithBatch(l *slog.Logger, batchID string) *slog.Logger {
+	return l.With("batch_id", batchID)
+}
+
+// WithJob returns l with a "job" attribute attached, matching the
+// metrics.ScheduledJobLastRun label.
+func WithJob(l *slog.Logger, job string) *slog.Logger {
+	return l.With("job", job)
+}
+
+// WithRequestID returns l with a "request_id" attribute attached, matching
+// the X-Request-ID header generated or propagated by api's request
+// middleware and the per-run ID cron.Scheduler attaches to each dispatch.
+func WithRequestID(l *slog.Logger, requestID string) *slog.Logger {
+	if requestID == "" {
+		return l
+	}
+	return l.With("request_id", requestID)
+}
+
+// multiHandler fans out log records to every handler in the slice.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// dedupHandler suppresses a record whose message and level were already
+// logged within window, collapsing tight retry-loop spam to one line.
+type dedupHandler struct {
+	next   slog.Handler
+	window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newDedupHandler(next slog.Handler, window time.Duration) *dedupHandler {
+	return &dedupHandler{next: next, window: window, seen: make(map[string]time.Time)}
+}
+
+func (d *dedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return d.next.Enabled(ctx, level)
+}
+
+func (d *dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	key := r.Level.String() + "|" + r.Message
+	now := time.Now()
+
+	d.mu.Lock()
+	last, ok := d.seen[key]
+	if ok && now.Sub(last) < d.window {
+		d.mu.Unlock()
+		return nil
+	}
+	d.seen[key] = now
+	d.mu.Unlock()
+
+	return d.next.Handle(ctx, r)
+}
+
+func (d *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &dedupHandler{next: d.next.WithAttrs(attrs), window: d.window, seen: d.seen}
+}
+
+func (d *dedupHandler) WithGroup(name string) slog.Handler {
+	return &dedupHandler{next: d.next.WithGroup(name), window: d.window, seen: d.seen}
+}
@@ -30,7 +30,15 @@ var (
             Help: "Total number of error responses per provider and path",
         },
         []string{"provider", "path", "code"},
-    
+    )
+
+    RequestTimeoutsTotal = promauto.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "eratemanager_request_timeouts_total",
+            Help: "Total number of requests that exceeded their per-provider deadline",
+        },
+        []string{"provider"},
+    )
 
     DBPoolTotalConns = promauto.NewGaugeVec(
         prometheus.GaugeOpts{
@@ -65,6 +73,34 @@ var (
     )
 )
 
+// IncRequestsTotal increments RequestsTotal for provider, attaching
+// requestID as a "request_id" exemplar when non-empty and the Prometheus
+// registry has exemplar support enabled (OpenMetrics scrape format).
+func IncRequestsTotal(provider, requestID string) {
+    addCounterExemplar(RequestsTotal.WithLabelValues(provider), requestID)
+}
+
+// IncRequestErrorsTotal increments RequestErrorsTotal for provider/path/code,
+// attaching requestID as a "request_id" exemplar when non-empty.
+func IncRequestErrorsTotal(provider, path, code, requestID string) {
+    addCounterExemplar(RequestErrorsTotal.WithLabelValues(provider, path, code), requestID)
+}
+
+// addCounterExemplar increments c, attaching requestID as an exemplar when
+// the underlying counter supports it (prometheus.ExemplarAdder) and
+// requestID is non-empty; otherwise it's a plain Inc().
+func addCounterExemplar(c prometheus.Counter, requestID string) {
+    if requestID == "" {
+        c.Inc()
+        return
+    }
+    if adder, ok := c.(prometheus.ExemplarAdder); ok {
+        adder.AddWithExemplar(1, prometheus.Labels{"request_id": requestID})
+        return
+    }
+    c.Inc()
+}
+
 func UpdateDBPoolMetrics(driver string, total, idle, acquired float64, acquires uint64) {
     DBPoolTotalConns.WithLabelValues(driver).Set(total)
     DBPoolIdleConns.WithLabelValues(driver).Set(idle)
@@ -72,6 +108,22 @@ func UpdateDBPoolMetrics(driver string, total, idle, acquired float64, acquires
     DBPoolAcquiresTotal.WithLabelValues(driver).Add(float64(acquires))
 }
 
+var (
+    BlobStoreInfo = promauto.NewGaugeVec(
+        prometheus.GaugeOpts{
+            Name: "eratemanager_blob_store_info",
+            Help: "Always 1, labeled with the configured blob store driver",
+        },
+        []string{"driver"},
+    )
+)
+
+// UpdateBlobStoreMetrics records which blob store driver is active, using
+// the same info-gauge-by-label pattern as the DB pool metrics.
+func UpdateBlobStoreMetrics(driver string) {
+    BlobStoreInfo.WithLabelValues(driver).Set(1)
+}
+
 
 var (
     ScheduledJobLastRun = promauto.NewGaugeVec(
@@ -97,6 +149,14 @@ var (
         },
         []string{"job"},
     )
+
+    JobsCancelledTotal = promauto.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "eratemanager_jobs_cancelled_total",
+            Help: "Total number of job executions force-cancelled by scheduler shutdown",
+        },
+        []string{"job"},
+    )
 )
 
 func UpdateJobMetrics(job string, startedAt time.Time, err error) {
@@ -107,3 +167,39 @@ func UpdateJobMetrics(job string, startedAt time.Time, err error) {
         ScheduledJobFailuresTotal.WithLabelValues(job).Inc()
     }
 }
+
+var (
+    RatesFetchesTotal = promauto.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "eratemanager_rates_fetches_total",
+            Help: "Total number of provider PDF fetch attempts, by provider and outcome",
+        },
+        []string{"provider", "outcome"},
+    )
+
+    RatesFetchDurationSeconds = promauto.NewHistogramVec(
+        prometheus.HistogramOpts{
+            Name:    "eratemanager_rates_fetch_duration_seconds",
+            Help:    "Duration of provider PDF fetches in seconds, including retries",
+            Buckets: prometheus.DefBuckets,
+        },
+        []string{"provider"},
+    )
+
+    RatesRefreshesTotal = promauto.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "eratemanager_rates_refreshes_total",
+            Help: "Total number of provider landing-page conditional refresh attempts, by provider and outcome",
+        },
+        []string{"provider", "outcome"},
+    )
+
+    RatesRefreshDurationSeconds = promauto.NewHistogramVec(
+        prometheus.HistogramOpts{
+            Name:    "eratemanager_rates_refresh_duration_seconds",
+            Help:    "Duration of provider landing-page conditional refreshes in seconds, including retries",
+            Buckets: prometheus.DefBuckets,
+        },
+        []string{"provider"},
+    )
+)
@@ -0,0 +1,178 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WebhookSettings configures the generic channel: a JSON POST of the
+// NotificationEvent, signed the same way internal/webhooks signs its
+// subscriber deliveries, so existing verification code can be reused.
+type WebhookSettings struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// SlackSettings configures delivery to a Slack incoming webhook.
+type SlackSettings struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// DiscordSettings configures delivery to a Discord channel webhook.
+type DiscordSettings struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// NtfySettings configures delivery to an ntfy.sh (or self-hosted ntfy)
+// topic. ServerURL defaults to https://ntfy.sh when empty.
+type NtfySettings struct {
+	ServerURL string `json:"server_url,omitempty"`
+	Topic     string `json:"topic"`
+}
+
+// newChannelNotifier builds the Notifier for channel from its persisted
+// settings, for Service.dispatch and TestConfig. channel is one of
+// "webhook", "slack", "discord", "ntfy" - the NotificationChannelConfig.ID
+// values Service.dispatch reads from storage.
+func newChannelNotifier(channel string, settings json.RawMessage, client *http.Client) (Notifier, error) {
+	switch channel {
+	case "webhook":
+		var s WebhookSettings
+		if err := json.Unmarshal(settings, &s); err != nil {
+			return nil, fmt.Errorf("decode webhook settings: %w", err)
+		}
+		return &webhookNotifier{client: client, settings: s}, nil
+	case "slack":
+		var s SlackSettings
+		if err := json.Unmarshal(settings, &s); err != nil {
+			return nil, fmt.Errorf("decode slack settings: %w", err)
+		}
+		return &slackNotifier{client: client, settings: s}, nil
+	case "discord":
+		var s DiscordSettings
+		if err := json.Unmarshal(settings, &s); err != nil {
+			return nil, fmt.Errorf("decode discord settings: %w", err)
+		}
+		return &discordNotifier{client: client, settings: s}, nil
+	case "ntfy":
+		var s NtfySettings
+		if err := json.Unmarshal(settings, &s); err != nil {
+			return nil, fmt.Errorf("decode ntfy settings: %w", err)
+		}
+		return &ntfyNotifier{client: client, settings: s}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification channel: %s", channel)
+	}
+}
+
+type webhookNotifier struct {
+	client   *http.Client
+	settings WebhookSettings
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.settings.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-ERM-Signature", signWebhookBody(n.settings.Secret, body))
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signWebhookBody returns the hex-encoded HMAC-SHA256 of body using secret,
+// in the form expected in the X-ERM-Signature header ("sha256=<hex>") -
+// the same scheme internal/webhooks and rates.WebhookSink use.
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type slackNotifier struct {
+	client   *http.Client
+	settings SlackSettings
+}
+
+func (n *slackNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	return postJSON(ctx, n.client, n.settings.WebhookURL, map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", event.Subject, event.Body),
+	})
+}
+
+type discordNotifier struct {
+	client   *http.Client
+	settings DiscordSettings
+}
+
+func (n *discordNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	return postJSON(ctx, n.client, n.settings.WebhookURL, map[string]string{
+		"content": fmt.Sprintf("**%s**\n%s", event.Subject, event.Body),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type ntfyNotifier struct {
+	client   *http.Client
+	settings NtfySettings
+}
+
+func (n *ntfyNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	server := strings.TrimRight(n.settings.ServerURL, "/")
+	if server == "" {
+		server = "https://ntfy.sh"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server+"/"+n.settings.Topic, strings.NewReader(event.Body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Title", event.Subject)
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationEvent is the payload delivered to every enabled channel. To is
+// only meaningful to the email channel; the others (webhook, Slack, Discord,
+// ntfy) ignore it and post Subject/Body to their own configured destination.
+type NotificationEvent struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	To      string `json:"to,omitempty"`
+}
+
+// Notifier delivers a NotificationEvent over one channel. SendEmail's
+// provider switch (smtp/gmail/sendgrid/resend) is one implementation;
+// webhook, Slack, Discord, and ntfy are the others, built by
+// newChannelNotifier from a channel's persisted settings.
+type Notifier interface {
+	Notify(ctx context.Context, event NotificationEvent) error
+}
+
+const (
+	notifyInitialBackoff = 500 * time.Millisecond
+	notifyMaxBackoff     = 30 * time.Second
+	notifyMaxRetries     = 4
+)
+
+// Notify queues event for delivery to every enabled channel and returns
+// immediately. It returns an error only if the queue is full - a caller
+// that can't wait should fall back to SendEmail/TestConfig directly.
+func (s *Service) Notify(ctx context.Context, event NotificationEvent) error {
+	select {
+	case s.queue <- queuedEvent{id: uuid.New().String(), event: event}:
+		return nil
+	default:
+		return fmt.Errorf("notification: queue full, dropping event %q", event.Subject)
+	}
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+	for {
+		select {
+		case qe := <-s.queue:
+			s.dispatch(context.Background(), qe.event)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// dispatch fans event out to the email channel (if enabled) and every
+// enabled NotificationChannelConfig, retrying each delivery independently so
+// one channel's outage doesn't hold up another's.
+func (s *Service) dispatch(ctx context.Context, event NotificationEvent) {
+	if cfg, err := s.storage.GetEmailConfig(ctx); err == nil && cfg != nil && cfg.Enabled {
+		if err := retryNotify(ctx, emailNotifier{svc: s}, event); err != nil {
+			log.Printf("notification: email delivery failed permanently: %v", err)
+		}
+	}
+
+	channels, err := s.storage.ListNotificationChannelConfigs(ctx)
+	if err != nil {
+		log.Printf("notification: list channel configs failed: %v", err)
+		return
+	}
+	for _, ch := range channels {
+		if !ch.Enabled {
+			continue
+		}
+		notifier, err := newChannelNotifier(ch.ID, ch.Settings, s.client)
+		if err != nil {
+			log.Printf("notification: skipping channel %s: %v", ch.ID, err)
+			continue
+		}
+		if err := retryNotify(ctx, notifier, event); err != nil {
+			log.Printf("notification: %s delivery failed permanently: %v", ch.ID, err)
+		}
+	}
+}
+
+// emailNotifier adapts Service.SendEmail to the Notifier interface so the
+// email channel can be retried and dispatched the same way as the others.
+type emailNotifier struct {
+	svc *Service
+}
+
+func (n emailNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	return n.svc.SendEmail(ctx, event.To, event.Subject, event.Body)
+}
+
+// retryNotify drives notifier.Notify through up to notifyMaxRetries
+// retries, backing off with full jitter between attempts - the same
+// strategy rates.FetchProviderPDF uses for its own outbound requests.
+func retryNotify(ctx context.Context, notifier Notifier, event NotificationEvent) error {
+	var lastErr error
+	for attempt := 0; attempt <= notifyMaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(notifyFullJitterBackoff(attempt)):
+			}
+		}
+		if err := notifier.Notify(ctx, event); err == nil {
+			return nil
+		} else {
+			lastErr = err
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", notifyMaxRetries+1, lastErr)
+}
+
+// notifyFullJitterBackoff returns a random duration in
+// [0, min(max, initial*2^(attempt-1))], the "full jitter" strategy from the
+// AWS architecture blog's backoff write-up.
+func notifyFullJitterBackoff(attempt int) time.Duration {
+	backoff := notifyInitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= notifyMaxBackoff {
+			backoff = notifyMaxBackoff
+			break
+		}
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
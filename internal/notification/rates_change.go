@@ -0,0 +1,35 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// RatesChangeNotifier adapts Service to storage.RatesChangeNotifier, turning
+// a RatesDiff into a NotificationEvent and queuing it for delivery through
+// every enabled channel the same way Notify does for any other event.
+type RatesChangeNotifier struct {
+	svc *Service
+}
+
+// NewRatesChangeNotifier returns a storage.RatesChangeNotifier backed by svc,
+// for wiring into storage.Storage.(interface{ SetRatesChangeNotifier(...) })
+// at startup.
+func NewRatesChangeNotifier(svc *Service) *RatesChangeNotifier {
+	return &RatesChangeNotifier{svc: svc}
+}
+
+// NotifyRatesChanged implements storage.RatesChangeNotifier.
+func (n *RatesChangeNotifier) NotifyRatesChanged(ctx context.Context, diff *storage.RatesDiff) error {
+	body, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("marshal rates diff: %w", err)
+	}
+	return n.svc.Notify(ctx, NotificationEvent{
+		Subject: fmt.Sprintf("%s: %s", storage.EventRatesChanged, diff.Provider),
+		Body:    string(body),
+	})
+}
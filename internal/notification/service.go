@@ -10,6 +10,8 @@ import (
 	"io"
 	"net/http"
 	"net/smtp"
+	"sync"
+	"time"
 
 	"github.com/bher20/eratemanager/internal/storage"
 	"github.com/google/uuid"
@@ -17,12 +19,97 @@ import (
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
+// queueCapacity bounds Service's in-memory delivery queue. Notify returns an
+// error rather than blocking once it's full; Close spills whatever is still
+// queued to storage so a restart can redeliver it instead of dropping it.
+const queueCapacity = 256
+
+type queuedEvent struct {
+	id    string
+	event NotificationEvent
+}
+
+// Service sends outbound notifications over email and any configured
+// pluggable channel (webhook, Slack, Discord, ntfy). Notify queues the event
+// and returns immediately; a background worker fans it out to every enabled
+// channel, retrying transient failures with backoff so a slow SMTP server or
+// flaky webhook endpoint can't block the caller or silently drop a
+// rate-change alert.
 type Service struct {
-	storage storage.Storage
+	storage storage.NotificationStore
+	client  *http.Client
+
+	queue chan queuedEvent
+	wg    sync.WaitGroup
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// NewService starts Service's delivery worker and requeues any events a
+// prior Close spilled to storage.
+func NewService(s storage.Storage) (*Service, error) {
+	ns, ok := s.(storage.NotificationStore)
+	if !ok {
+		return nil, fmt.Errorf("notification: storage backend %T does not implement NotificationStore", s)
+	}
+	svc := &Service{
+		storage: ns,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan queuedEvent, queueCapacity),
+		stop:    make(chan struct{}),
+	}
+	svc.wg.Add(1)
+	go svc.run()
+	go svc.redeliverPending(context.Background())
+	return svc, nil
 }
 
-func NewService(s storage.Storage) *Service {
-	return &Service{storage: s}
+// Close stops the delivery worker and spills every event still sitting in
+// the queue to storage as a PendingNotification, so NewService's next
+// startup can pick delivery back up instead of losing it.
+func (s *Service) Close(ctx context.Context) error {
+	var err error
+	s.once.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+		close(s.queue)
+		for qe := range s.queue {
+			if spillErr := s.spill(ctx, qe); spillErr != nil && err == nil {
+				err = spillErr
+			}
+		}
+	})
+	return err
+}
+
+func (s *Service) spill(ctx context.Context, qe queuedEvent) error {
+	body, marshalErr := json.Marshal(qe.event)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	return s.storage.SavePendingNotification(ctx, storage.PendingNotification{
+		ID:       qe.id,
+		Event:    body,
+		QueuedAt: time.Now(),
+	})
+}
+
+// redeliverPending re-enqueues every event a prior Close spilled to storage.
+// Failures are logged, not returned: a missing backend table or a transient
+// read error shouldn't block startup.
+func (s *Service) redeliverPending(ctx context.Context) {
+	pending, err := s.storage.ListPendingNotifications(ctx)
+	if err != nil {
+		return
+	}
+	for _, p := range pending {
+		var event NotificationEvent
+		if err := json.Unmarshal(p.Event, &event); err != nil {
+			continue
+		}
+		s.queue <- queuedEvent{id: p.ID, event: event}
+		_ = s.storage.DeletePendingNotification(ctx, p.ID)
+	}
 }
 
 func (s *Service) GetConfig(ctx context.Context) (*storage.EmailConfig, error) {
@@ -36,6 +123,22 @@ func (s *Service) SaveConfig(ctx context.Context, cfg storage.EmailConfig) error
 	return s.storage.SaveEmailConfig(ctx, cfg)
 }
 
+// GetChannelConfig returns channel's persisted settings, or nil if it has
+// never been configured.
+func (s *Service) GetChannelConfig(ctx context.Context, channel string) (*storage.NotificationChannelConfig, error) {
+	return s.storage.GetNotificationChannelConfig(ctx, channel)
+}
+
+// SaveChannelConfig persists cfg, keyed by cfg.ID as the channel name
+// ("webhook", "slack", "discord", or "ntfy").
+func (s *Service) SaveChannelConfig(ctx context.Context, cfg storage.NotificationChannelConfig) error {
+	if cfg.ID == "" {
+		return errors.New("notification: channel config requires an ID naming the channel")
+	}
+	cfg.UpdatedAt = time.Now()
+	return s.storage.SaveNotificationChannelConfig(ctx, cfg)
+}
+
 func (s *Service) SendEmail(ctx context.Context, to, subject, body string) error {
 	cfg, err := s.storage.GetEmailConfig(ctx)
 	if err != nil {
@@ -44,7 +147,10 @@ func (s *Service) SendEmail(ctx context.Context, to, subject, body string) error
 	if cfg == nil || !cfg.Enabled {
 		return errors.New("email not configured or disabled")
 	}
+	return s.sendEmailWithConfig(cfg, to, subject, body)
+}
 
+func (s *Service) sendEmailWithConfig(cfg *storage.EmailConfig, to, subject, body string) error {
 	switch cfg.Provider {
 	case "smtp", "gmail":
 		return s.sendSMTP(cfg, to, subject, body)
@@ -57,18 +163,29 @@ func (s *Service) SendEmail(ctx context.Context, to, subject, body string) error
 	}
 }
 
-func (s *Service) TestConfig(ctx context.Context, cfg storage.EmailConfig, to string) error {
-	// Use the provided config to send a test email
-	switch cfg.Provider {
-	case "smtp", "gmail":
-		return s.sendSMTP(&cfg, to, "Test Email", "This is a test email from eRateManager.")
-	case "sendgrid":
-		return s.sendSendgrid(&cfg, to, "Test Email", "This is a test email from eRateManager.")
-	case "resend":
-		return s.sendResend(&cfg, to, "Test Email", "This is a test email from eRateManager.")
-	default:
-		return fmt.Errorf("unknown provider: %s", cfg.Provider)
+// TestConfig sends a one-off test notification over channel using settings
+// directly, without persisting them, so a caller can validate credentials
+// before saving. For channel "email", settings decodes as storage.EmailConfig;
+// for every other channel it decodes as that channel's own settings struct
+// (WebhookSettings, SlackSettings, DiscordSettings, or NtfySettings).
+func (s *Service) TestConfig(ctx context.Context, channel string, settings json.RawMessage, to string) error {
+	event := NotificationEvent{
+		Subject: "Test Notification",
+		Body:    "This is a test notification from eRateManager.",
+		To:      to,
+	}
+	if channel == "email" {
+		var cfg storage.EmailConfig
+		if err := json.Unmarshal(settings, &cfg); err != nil {
+			return fmt.Errorf("decode email settings: %w", err)
+		}
+		return s.sendEmailWithConfig(&cfg, to, event.Subject, event.Body)
+	}
+	notifier, err := newChannelNotifier(channel, settings, s.client)
+	if err != nil {
+		return err
 	}
+	return notifier.Notify(ctx, event)
 }
 
 func (s *Service) sendSMTP(cfg *storage.EmailConfig, to, subject, body string) error {
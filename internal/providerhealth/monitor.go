@@ -0,0 +1,348 @@
+// Package providerhealth runs a background keepalive check against each
+// rate provider's landing page, tracking consecutive failures and recovery
+// the way a storage scrubber tracks host uptime - so an outage is visible
+// on a status page before it surfaces as a failed user request. Checks run
+// on Monitor's own interval (see Config), independent of the cron
+// package's per-provider circuit breaker, which only updates as a side
+// effect of a scheduled batch refresh.
+//
+// Monitor is decoupled from the rates package's provider registry (it
+// takes a plain []Target instead of []rates.ProviderDescriptor) because
+// rates.WaterService consults Monitor.State directly, and rates importing
+// providerhealth while providerhealth imports rates would be a cycle.
+package providerhealth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bher20/eratemanager/internal/alerting"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// State classifies a provider's current liveness.
+type State string
+
+const (
+	Healthy  State = "healthy"
+	Degraded State = "degraded"
+	Down     State = "down"
+)
+
+// Status is a provider's current health, as tracked in Monitor's in-memory
+// table and returned by GET /health/providers.
+type Status struct {
+	Key                 string        `json:"key"`
+	State               State         `json:"state"`
+	LastSuccess         time.Time     `json:"last_success,omitempty"`
+	LastError           string        `json:"last_error,omitempty"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LatencyEWMA         time.Duration `json:"latency_ewma"`
+}
+
+// Target is a single provider Monitor checks: just enough to probe it,
+// decoupled from rates.ProviderDescriptor (see package doc).
+type Target struct {
+	Key string
+	URL string
+}
+
+// Config controls how often and how aggressively Monitor checks targets.
+type Config struct {
+	// Interval between rounds of checking every target.
+	Interval time.Duration
+	// Timeout bounds a single target's check.
+	Timeout time.Duration
+	// DegradedThreshold is the number of consecutive failures at which a
+	// target's State becomes Degraded.
+	DegradedThreshold int
+	// DownThreshold is the number of consecutive failures at which a
+	// target's State becomes Down.
+	DownThreshold int
+}
+
+// DefaultConfig returns Config seeded from PROVIDERHEALTH_* environment
+// variables, falling back to a 5 minute interval, 10s per-check timeout,
+// and the 2/5 consecutive-failure thresholds renterd-style scanners use
+// for degraded/down.
+func DefaultConfig() Config {
+	cfg := Config{
+		Interval:          5 * time.Minute,
+		Timeout:           10 * time.Second,
+		DegradedThreshold: 2,
+		DownThreshold:     5,
+	}
+
+	if v := os.Getenv("PROVIDERHEALTH_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Interval = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("PROVIDERHEALTH_TIMEOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Timeout = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("PROVIDERHEALTH_DEGRADED_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.DegradedThreshold = n
+		}
+	}
+	if v := os.Getenv("PROVIDERHEALTH_DOWN_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.DownThreshold = n
+		}
+	}
+
+	return cfg
+}
+
+// ewmaAlpha weights each new latency sample against Status.LatencyEWMA's
+// running average.
+const ewmaAlpha = 0.3
+
+// Monitor periodically HEAD-checks every Target's URL, keeping an
+// in-memory Status table and - on a backend implementing
+// storage.ProviderHealthStore - a persisted sample history. It emits
+// provider.health_degraded/provider.health_recovered Events through
+// alerter (when non-nil) whenever a target crosses a threshold.
+type Monitor struct {
+	cfg     Config
+	targets []Target
+	client  *http.Client
+	store   storage.ProviderHealthStore
+	alerter *alerting.Alerter
+
+	mu       sync.RWMutex
+	statuses map[string]Status
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewMonitor starts Monitor's background check loop against targets,
+// running immediately and then every cfg.Interval until Close. st is
+// type-asserted against storage.ProviderHealthStore the same way
+// alerting.NewAlerter does against storage.AlertingStore: a backend
+// without it still gets live checks, it just can't answer a history query.
+// alerter may be nil, in which case state transitions are only logged.
+func NewMonitor(cfg Config, targets []Target, st storage.Storage, alerter *alerting.Alerter) *Monitor {
+	store, _ := st.(storage.ProviderHealthStore)
+
+	m := &Monitor{
+		cfg:      cfg,
+		targets:  targets,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		store:    store,
+		alerter:  alerter,
+		statuses: make(map[string]Status),
+		stop:     make(chan struct{}),
+	}
+
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// Close stops Monitor's check loop, letting an in-flight round of checks
+// finish but skipping any still scheduled.
+func (m *Monitor) Close() {
+	m.once.Do(func() {
+		close(m.stop)
+		m.wg.Wait()
+	})
+}
+
+func (m *Monitor) run() {
+	defer m.wg.Done()
+
+	m.checkAll()
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.checkAll()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *Monitor) checkAll() {
+	for _, t := range m.targets {
+		m.checkOne(t)
+	}
+}
+
+func (m *Monitor) checkOne(t Target) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	success, checkErr := probe(ctx, m.client, t.URL)
+	latency := time.Since(start)
+
+	m.mu.Lock()
+	prev := m.statuses[t.Key]
+	next := prev
+	next.Key = t.Key
+	next.LatencyEWMA = ewma(prev.LatencyEWMA, latency)
+	if success {
+		next.LastSuccess = time.Now()
+		next.LastError = ""
+		next.ConsecutiveFailures = 0
+	} else {
+		next.ConsecutiveFailures = prev.ConsecutiveFailures + 1
+		if checkErr != nil {
+			next.LastError = checkErr.Error()
+		}
+	}
+	next.State = stateFor(next.ConsecutiveFailures, m.cfg)
+	m.statuses[t.Key] = next
+	m.mu.Unlock()
+
+	m.persist(t.Key, success, latency, checkErr)
+	m.notify(t.Key, prev.State, next.State, next.ConsecutiveFailures, next.LastError)
+}
+
+// probe issues a HEAD request against url, treating a server error (5xx)
+// or a transport-level failure as down and anything else (including a
+// 4xx - the page may just disallow HEAD) as reachable.
+func probe(ctx context.Context, client *http.Client, url string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return true, nil
+}
+
+func ewma(prev, sample time.Duration) time.Duration {
+	if prev == 0 {
+		return sample
+	}
+	return time.Duration(ewmaAlpha*float64(sample) + (1-ewmaAlpha)*float64(prev))
+}
+
+func stateFor(consecutiveFailures int, cfg Config) State {
+	switch {
+	case consecutiveFailures >= cfg.DownThreshold:
+		return Down
+	case consecutiveFailures >= cfg.DegradedThreshold:
+		return Degraded
+	default:
+		return Healthy
+	}
+}
+
+func (m *Monitor) persist(key string, success bool, latency time.Duration, checkErr error) {
+	if m.store == nil {
+		return
+	}
+
+	sample := storage.ProviderHealthSample{
+		ID:        uuid.New().String(),
+		Key:       key,
+		Success:   success,
+		LatencyMS: latency.Milliseconds(),
+		CheckedAt: time.Now(),
+	}
+	if checkErr != nil {
+		sample.Error = checkErr.Error()
+	}
+	if err := m.store.AppendProviderHealthSample(context.Background(), sample); err != nil {
+		log.Printf("providerhealth: persist sample for %s failed: %v", key, err)
+	}
+}
+
+// notify broadcasts a provider.health_degraded or provider.health_recovered
+// Event when to differs from from, logging either way so an operator
+// without alerting configured still sees the transition.
+func (m *Monitor) notify(key string, from, to State, consecutiveFailures int, lastErr string) {
+	if from == to {
+		return
+	}
+
+	switch {
+	case to == Degraded || to == Down:
+		log.Printf("providerhealth: %s is now %s (%d consecutive failures): %s", key, to, consecutiveFailures, lastErr)
+		if m.alerter != nil {
+			m.alerter.Broadcast(context.Background(), alerting.NewEvent("provider", "health_degraded", map[string]interface{}{
+				"key":                  key,
+				"state":                string(to),
+				"consecutive_failures": consecutiveFailures,
+				"last_error":           lastErr,
+			}))
+		}
+	case to == Healthy && (from == Degraded || from == Down):
+		log.Printf("providerhealth: %s recovered (was %s)", key, from)
+		if m.alerter != nil {
+			m.alerter.Broadcast(context.Background(), alerting.NewEvent("provider", "health_recovered", map[string]interface{}{
+				"key":   key,
+				"state": string(to),
+			}))
+		}
+	}
+}
+
+// Get returns key's current Status, or false if Monitor has no status for
+// it yet (e.g. before its first check completes).
+func (m *Monitor) Get(key string) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.statuses[key]
+	return s, ok
+}
+
+// List returns every tracked target's current Status, sorted by Key.
+func (m *Monitor) List() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]Status, 0, len(m.statuses))
+	for _, s := range m.statuses {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// State reports key's current State, or "" if Monitor has no status for it
+// yet. Callers like rates.WaterService treat "" the same as Healthy (no
+// known reason to avoid a live fetch).
+func (m *Monitor) State(key string) State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.statuses[key].State
+}
+
+// History returns up to limit of key's most recently persisted samples,
+// newest first. It requires st (passed to NewMonitor) to implement
+// storage.ProviderHealthStore (currently only GormStorage).
+func (m *Monitor) History(ctx context.Context, key string, limit int) ([]storage.ProviderHealthSample, error) {
+	if m.store == nil {
+		return nil, fmt.Errorf("providerhealth: storage backend does not retain health sample history")
+	}
+	return m.store.ListProviderHealthSamples(ctx, key, limit)
+}
@@ -0,0 +1,142 @@
+package rates
+
+import (
+	"context"
+	"sort"
+)
+
+// CostLine is one component of a ProviderCost's monthly total (base charge,
+// energy, fuel adjustment, sewer, ...), so a bill-shopping caller can show
+// why one provider is cheaper than another instead of just the totals.
+type CostLine struct {
+	Label     string  `json:"label"`
+	AmountUSD float64 `json:"amount_usd"`
+}
+
+// ProviderCost is one provider's estimated monthly bill for the usage a
+// Comparator method was asked to price, ranked against its peers (Rank 1 is
+// cheapest).
+type ProviderCost struct {
+	ProviderKey  string     `json:"provider_key"`
+	ProviderName string     `json:"provider_name"`
+	TotalUSD     float64    `json:"total_usd"`
+	Rank         int        `json:"rank"`
+	Breakdown    []CostLine `json:"breakdown"`
+}
+
+// Comparator normalizes RatesResponse and WaterRatesResponse from every
+// registered provider onto a common $/month basis so they can be ranked
+// against each other, something neither response type supports on its own
+// since they're parsed and cached independently per provider.
+type Comparator struct {
+	svc *Service
+}
+
+// NewComparator returns a Comparator that reads provider rates through svc,
+// benefiting from the same cache-first behavior as GetResidential/GetWater.
+func NewComparator(svc *Service) *Comparator {
+	return &Comparator{svc: svc}
+}
+
+// CompareElectricProviders estimates every registered electric provider's
+// monthly bill for kWhPerMonth of usage and returns them sorted cheapest
+// first. A provider whose rates can't be loaded (no cached snapshot and no
+// PDF available) is skipped rather than failing the whole comparison.
+func (c *Comparator) CompareElectricProviders(ctx context.Context, kWhPerMonth float64) ([]ProviderCost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var costs []ProviderCost
+	for _, p := range ElectricProviders() {
+		resp, err := c.svc.GetResidential(ctx, p.Key)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		fixed := resp.FixedMonthlyChargeUSD
+		if fixed == 0 {
+			fixed = floatOrZero(resp.Rates.ResidentialStandard.CustomerChargeMonthlyUSD)
+		}
+		var energyCost float64
+		if len(resp.TieredRates) > 0 {
+			energyCost = resp.CalculateTieredEnergyCost(kWhPerMonth)
+		} else {
+			energyCost = kWhPerMonth * floatOrZero(resp.Rates.ResidentialStandard.EnergyRateUSDPerKWh)
+		}
+		fuelCost := kWhPerMonth * resp.FuelAdjustmentUSDPerKWh
+
+		breakdown := []CostLine{
+			{Label: "base_charge", AmountUSD: fixed},
+			{Label: "energy", AmountUSD: energyCost},
+		}
+		if fuelCost != 0 {
+			breakdown = append(breakdown, CostLine{Label: "fuel_adjustment", AmountUSD: fuelCost})
+		}
+
+		costs = append(costs, ProviderCost{
+			ProviderKey:  p.Key,
+			ProviderName: p.Name,
+			TotalUSD:     resp.CalculateBill(kWhPerMonth, 0),
+			Breakdown:    breakdown,
+		})
+	}
+
+	rankByTotal(costs)
+	return costs, nil
+}
+
+// CompareWaterProviders estimates every registered water provider's monthly
+// bill for gallonsPerMonth of usage (water plus sewer, when a provider
+// bundles sewer service) and returns them sorted cheapest first. A
+// provider whose rates can't be loaded is skipped rather than failing the
+// whole comparison.
+func (c *Comparator) CompareWaterProviders(ctx context.Context, gallonsPerMonth float64) ([]ProviderCost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var costs []ProviderCost
+	for _, p := range WaterProviders() {
+		resp, err := c.svc.GetWater(ctx, p.Key)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		waterBase := floatOrZero(resp.Water.BaseCharge)
+		waterCost := waterBase + gallonsPerMonth*resp.Water.NormalizedRatePerGallon()
+		breakdown := []CostLine{
+			{Label: "water_base_charge", AmountUSD: waterBase},
+			{Label: "water_usage", AmountUSD: waterCost - waterBase},
+		}
+		total := waterCost
+
+		if resp.Sewer != nil {
+			sewerBase := floatOrZero(resp.Sewer.BaseCharge)
+			sewerCost := sewerBase + gallonsPerMonth*resp.Sewer.NormalizedRatePerGallon()
+			breakdown = append(breakdown,
+				CostLine{Label: "sewer_base_charge", AmountUSD: sewerBase},
+				CostLine{Label: "sewer_usage", AmountUSD: sewerCost - sewerBase},
+			)
+			total += sewerCost
+		}
+
+		costs = append(costs, ProviderCost{
+			ProviderKey:  p.Key,
+			ProviderName: resp.ProviderName,
+			TotalUSD:     total,
+			Breakdown:    breakdown,
+		})
+	}
+
+	rankByTotal(costs)
+	return costs, nil
+}
+
+// rankByTotal sorts costs cheapest-first in place and assigns Rank 1..N.
+func rankByTotal(costs []ProviderCost) {
+	sort.Slice(costs, func(i, j int) bool { return costs[i].TotalUSD < costs[j].TotalUSD })
+	for i := range costs {
+		costs[i].Rank = i + 1
+	}
+}
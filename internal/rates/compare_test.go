@@ -0,0 +1,42 @@
+package rates
+
+import "testing"
+
+func TestRankByTotal(t *testing.T) {
+	costs := []ProviderCost{
+		{ProviderKey: "b", TotalUSD: 50},
+		{ProviderKey: "a", TotalUSD: 10},
+		{ProviderKey: "c", TotalUSD: 30},
+	}
+
+	rankByTotal(costs)
+
+	want := []string{"a", "c", "b"}
+	for i, key := range want {
+		if costs[i].ProviderKey != key {
+			t.Fatalf("position %d: got %q, want %q", i, costs[i].ProviderKey, key)
+		}
+		if costs[i].Rank != i+1 {
+			t.Fatalf("position %d: got rank %d, want %d", i, costs[i].Rank, i+1)
+		}
+	}
+}
+
+func TestWaterRateDetails_NormalizedRatePerGallon(t *testing.T) {
+	cases := []struct {
+		unit string
+		rate float64
+		want float64
+	}{
+		{unit: "gallon", rate: 0.005, want: 0.005},
+		{unit: "1000 Gallons", rate: 5.0, want: 5.0 / 1000},
+		{unit: "CCF", rate: 3.74026, want: 3.74026 / gallonsPerCCF},
+	}
+
+	for _, tc := range cases {
+		w := WaterRateDetails{UseRate: ptrFloat(tc.rate), UseRateUnit: tc.unit}
+		if got := w.NormalizedRatePerGallon(); got != tc.want {
+			t.Errorf("unit %q: got %v, want %v", tc.unit, got, tc.want)
+		}
+	}
+}
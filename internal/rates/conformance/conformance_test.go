@@ -0,0 +1,44 @@
+package conformance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestConformance drives every vector under testdata/vectors/<provider>/
+// through its registered parser, failing with a descriptive diff on the
+// first field that drifts past its vector's tolerance. Set
+// SKIP_CONFORMANCE=1 to skip this suite locally (e.g. while iterating on
+// an unrelated package); CI always runs it.
+func TestConformance(t *testing.T) {
+	if os.Getenv("SKIP_CONFORMANCE") != "" {
+		t.Skip("SKIP_CONFORMANCE set")
+	}
+
+	root := filepath.Join("testdata", "vectors")
+	providerDirs, err := LoadProviderDirs(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.Skip("no testdata/vectors directory")
+		}
+		t.Fatalf("list provider dirs: %v", err)
+	}
+
+	for _, dir := range providerDirs {
+		provider := filepath.Base(dir)
+		vectors, err := LoadVectors(dir)
+		if err != nil {
+			t.Fatalf("load vectors for %s: %v", provider, err)
+		}
+		for i, v := range vectors {
+			v := v
+			t.Run(fmt.Sprintf("%s/%d", provider, i), func(t *testing.T) {
+				if err := Run(v); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package conformance drives every registered rates.ParserConfig against a
+// corpus of recorded input/output pairs ("vectors"), the same approach the
+// Filecoin test-vectors project uses to pin a parser's behavior as a data
+// file instead of only asserting a handful of fields inline from Go source.
+// A vector's Expected is diffed against the parser's actual output within
+// Tolerance, absorbing the float noise a cents<->USD conversion introduces.
+package conformance
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bher20/eratemanager/internal/rates"
+)
+
+// Vector is one recorded parser input/output pair, as loaded from
+// testdata/vectors/<parser>/*.json (see cmd/ratevec for how one is
+// captured).
+type Vector struct {
+	// Parser is the rates.ParserConfig.Key this vector exercises.
+	Parser string `json:"parser"`
+	// InputKind selects ParseText ("text") or ParsePDF ("pdf").
+	InputKind string `json:"input_kind"`
+	// Input is the raw parser input, base64-encoded so binary PDFs and
+	// plain-text fixtures share one JSON shape.
+	Input string `json:"input"`
+	// Expected is the RatesResponse Input should parse to. Only the keys
+	// present here are checked, so a vector can focus on the fields a
+	// provider's regexes actually exercise instead of pinning every
+	// RatesResponse field (fetched_at is always skipped - see Run).
+	Expected json.RawMessage `json:"expected"`
+	// Tolerance bounds how far a numeric field may drift from Expected
+	// before Run reports a mismatch. Zero falls back to defaultTolerance.
+	Tolerance float64 `json:"tolerance"`
+}
+
+// defaultTolerance applies when a vector leaves Tolerance unset, tight
+// enough to catch a real parser regression but loose enough to absorb
+// cents<->USD rounding.
+const defaultTolerance = 1e-6
+
+// LoadProviderDirs returns every immediate subdirectory of root (e.g.
+// testdata/vectors), one per provider key.
+func LoadProviderDirs(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join(root, e.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// LoadVectors reads every *.json file directly under dir (no recursion -
+// one directory per provider) as a Vector.
+func LoadVectors(dir string) ([]Vector, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []Vector
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", e.Name(), err)
+		}
+		var v Vector
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", e.Name(), err)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+// Run decodes v.Input, drives v.Parser's registered ParseText/ParsePDF, and
+// diffs the result against v.Expected within v.Tolerance, returning a
+// descriptive error on the first mismatch.
+func Run(v Vector) error {
+	cfg, ok := rates.GetParser(v.Parser)
+	if !ok {
+		return fmt.Errorf("no parser registered for %q", v.Parser)
+	}
+
+	input, err := base64.StdEncoding.DecodeString(v.Input)
+	if err != nil {
+		return fmt.Errorf("decode input: %w", err)
+	}
+
+	var got *rates.RatesResponse
+	switch v.InputKind {
+	case "text":
+		if cfg.ParseText == nil {
+			return fmt.Errorf("parser %q has no ParseText", v.Parser)
+		}
+		got, err = cfg.ParseText(string(input))
+	case "pdf":
+		if cfg.ParsePDF == nil {
+			return fmt.Errorf("parser %q has no ParsePDF", v.Parser)
+		}
+		got, err = parsePDFBytes(cfg, input)
+	default:
+		return fmt.Errorf("unknown input_kind %q (want \"text\" or \"pdf\")", v.InputKind)
+	}
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		return fmt.Errorf("marshal actual output: %w", err)
+	}
+
+	var gotVal, wantVal interface{}
+	if err := json.Unmarshal(gotJSON, &gotVal); err != nil {
+		return fmt.Errorf("decode actual output: %w", err)
+	}
+	if err := json.Unmarshal(v.Expected, &wantVal); err != nil {
+		return fmt.Errorf("decode expected output: %w", err)
+	}
+
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = defaultTolerance
+	}
+	return diffTop(wantVal, gotVal, tolerance)
+}
+
+// parsePDFBytes spills raw PDF bytes to a temp file so they can go through
+// ParsePDF, which - like every registered parser - takes a filesystem path
+// rather than an io.Reader.
+func parsePDFBytes(cfg rates.ParserConfig, raw []byte) (*rates.RatesResponse, error) {
+	f, err := os.CreateTemp("", "conformance-*.pdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return cfg.ParsePDF(f.Name())
+}
+
+// fetchedAtKey is skipped by diff: RatesResponse.FetchedAt is stamped with
+// the current time at parse time, so a recorded vector can never match it.
+const fetchedAtKey = "fetched_at"
+
+// diffTop compares the two top-level objects, skipping fetchedAtKey.
+func diffTop(want, got interface{}, tolerance float64) error {
+	wantObj, ok := want.(map[string]interface{})
+	if !ok {
+		return diff("", want, got, tolerance)
+	}
+	gotObj, _ := got.(map[string]interface{})
+	for k, wv := range wantObj {
+		if k == fetchedAtKey {
+			continue
+		}
+		if err := diff("."+k, wv, gotObj[k], tolerance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff recursively compares want and got - both the generic
+// map[string]interface{}/[]interface{}/float64/string/bool/nil shapes
+// encoding/json produces - allowing numeric leaves to differ by up to
+// tolerance. Only keys present in want are checked, so a vector's Expected
+// can cover just the fields a provider's parser actually sets.
+func diff(path string, want, got interface{}, tolerance float64) error {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T (%v)", path, got, got)
+		}
+		for k, wv := range w {
+			if err := diff(path+"."+k, wv, g[k], tolerance); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T (%v)", path, got, got)
+		}
+		if len(w) != len(g) {
+			return fmt.Errorf("%s: expected %d elements, got %d", path, len(w), len(g))
+		}
+		for i := range w {
+			if err := diff(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], tolerance); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case float64:
+		g, ok := got.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected number %v, got %T (%v)", path, want, got, got)
+		}
+		if d := w - g; d > tolerance || -d > tolerance {
+			return fmt.Errorf("%s: expected %v, got %v (tolerance %v)", path, w, g, tolerance)
+		}
+		return nil
+
+	default:
+		if want != got {
+			return fmt.Errorf("%s: expected %v, got %v", path, want, got)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package rates
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultFetchTimeout bounds a single provider's live fetch (WaterService's
+// HTML fetch, Service's PDF parse) so one slow or hanging provider can't
+// stall a refresh indefinitely. It's applied as a child of the caller's
+// ctx via context.WithTimeout, so a tighter deadline already on ctx (e.g.
+// cron/batch.go's ProviderTimeout) still wins.
+const defaultFetchTimeout = 15 * time.Second
+
+// deadlineTimer is a resettable cancellation signal modeled on the
+// setDeadline/cancelCh pattern net.Conn implementations use internally:
+// readCancelCh and writeCancelCh start open and are closed exactly once
+// their respective deadline expires, so any number of goroutines can select
+// on them without additional synchronization. reset replaces both channels
+// (rather than reusing them), since a closed channel can never be reopened.
+//
+// It exists so the legacy, non-context parser functions (WaterParserFunc,
+// ParserFunc) can still be raced against ctx cancellation: the caller
+// starts the legacy call on its own goroutine, then selects between that
+// goroutine finishing and the deadlineTimer expiring. The legacy call
+// itself is not aborted (it has no ctx to abort with), but the caller stops
+// waiting on it and returns ctx.Err() immediately, which is what lets a
+// retry loop built on top of it skip any further attempts. readCancelCh
+// guards the response-read phase of a fetch and writeCancelCh the
+// request-send phase; callers that don't distinguish the two (most don't)
+// can just select on both.
+type deadlineTimer struct {
+	mu            sync.Mutex
+	readCancelCh  chan struct{}
+	writeCancelCh chan struct{}
+	timer         *time.Timer
+}
+
+// newDeadlineTimer returns a deadlineTimer armed to fire after d.
+func newDeadlineTimer(d time.Duration) *deadlineTimer {
+	t := &deadlineTimer{}
+	t.reset(d)
+	return t
+}
+
+// reset rearms the timer to fire after d, replacing the channels returned
+// by readCancel/writeCancel so a still-pending select on an old channel
+// does not observe an expiry that no longer applies.
+func (t *deadlineTimer) reset(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	read := make(chan struct{})
+	write := make(chan struct{})
+	t.readCancelCh = read
+	t.writeCancelCh = write
+	t.timer = time.AfterFunc(d, func() {
+		close(read)
+		close(write)
+	})
+}
+
+// readCancel returns the channel that closes when the timer expires, for
+// selecting against an in-flight response read. The returned channel is a
+// snapshot: a concurrent reset replaces it, so callers should re-fetch
+// readCancel after every reset.
+func (t *deadlineTimer) readCancel() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.readCancelCh
+}
+
+// writeCancel is readCancel's counterpart for an in-flight request send.
+func (t *deadlineTimer) writeCancel() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.writeCancelCh
+}
+
+// cancel stops the timer early without closing its channels, so a caller
+// that has already gotten its result doesn't leak the underlying
+// time.Timer until the deadline would otherwise have fired.
+func (t *deadlineTimer) cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
@@ -1,162 +1,309 @@
 package rates
 
 import (
-    "errors"
-    "fmt"
-    "io"
-    "net/http"
-    "net/url"
-    "regexp"
-    "sort"
-    "strings"
-    "time"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/bher20/eratemanager/internal/logging"
 )
 
 // PDFDiscoveryTimeout controls how long we wait for the landing page.
 var PDFDiscoveryTimeout = 10 * time.Second
 
-// DiscoverPDFURL fetches the provider's landing page and discovers the best PDF URL.
-func DiscoverPDFURL(p ProviderDescriptor) (string, error) {
-    if p.LandingURL == "" {
-        return "", fmt.Errorf("provider %q has no LandingURL", p.Key)
-    }
-
-    client := &http.Client{Timeout: PDFDiscoveryTimeout}
-    resp, err := client.Get(p.LandingURL)
-    if err != nil {
-        return "", fmt.Errorf("fetch landing url: %w", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-        return "", fmt.Errorf("landing url returned status %d", resp.StatusCode)
-    }
-
-    body, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return "", fmt.Errorf("read landing body: %w", err)
-    }
-
-    return discoverPDFURLFromHTML(p.LandingURL, string(body))
+// PDFCandidate is one PDF-shaped URL DiscoverPDFCandidates found, along with
+// the score scorePDFCandidate assigned it and where it came from, so
+// operators can tell why DiscoverPDFURL picked the PDF it did.
+type PDFCandidate struct {
+	URL    string
+	Text   string
+	Source string // "a", "link", "iframe", "jsonld", "sitemap", "robots"
+	Score  int
+}
+
+// DiscoverPDFURL fetches the provider's landing page and discovers the best
+// PDF URL. It's a thin wrapper around DiscoverPDFCandidates for callers that
+// just want the answer, not the reasoning behind it.
+func DiscoverPDFURL(ctx context.Context, p ProviderDescriptor) (string, error) {
+	candidates, err := DiscoverPDFCandidates(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	return candidates[0].URL, nil
+}
+
+// DiscoverPDFCandidates fetches p's landing page, walks its DOM for every
+// PDF-shaped link - <a href>, <link rel="alternate" type="application/pdf">,
+// <iframe src>, and PDFs named inside inline JSON/JSON-LD blobs - and scores
+// each with scorePDFCandidate, returning them best first. When the landing
+// page itself yields nothing (a JS-rendered page with no server-rendered
+// links, say), it falls back to /sitemap.xml and /robots.txt on the same
+// host and scores any .pdf URLs found there instead.
+func DiscoverPDFCandidates(ctx context.Context, p ProviderDescriptor) ([]PDFCandidate, error) {
+	if p.LandingURL == "" {
+		return nil, fmt.Errorf("provider %q has no LandingURL", p.Key)
+	}
+
+	client := &http.Client{Timeout: PDFDiscoveryTimeout}
+	body, err := fetchDiscoveryBody(client, p.LandingURL)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := pdfCandidatesFromHTML(p.LandingURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		candidates, err = pdfCandidatesFromHostFallback(client, p.LandingURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.New("no PDF links found on page")
+	}
+
+	sortPDFCandidates(candidates)
+
+	l := logging.WithProvider(logging.Default(), p.Key)
+	for _, c := range candidates {
+		l.DebugContext(ctx, "pdf.candidate.scored", "href", c.URL, "text", c.Text, "source", c.Source, "score", c.Score)
+	}
+	return candidates, nil
+}
+
+// fetchDiscoveryBody GETs target with client, returning its body as a
+// string. Used for both the landing page itself and its sitemap.xml/
+// robots.txt fallbacks, so all three share the same timeout and status
+// checking.
+func fetchDiscoveryBody(client *http.Client, target string) (string, error) {
+	resp, err := client.Get(target)
+	if err != nil {
+		return "", fmt.Errorf("fetch %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("fetch %s: %w", target, &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", target, err)
+	}
+	return string(body), nil
+}
+
+// pdfCandidatesFromHTML walks html's DOM with goquery (the same DOM-parsing
+// library parser_whud_html.go's extractSewerSection already uses) rather
+// than scanning it with regexes, so a PDF link split across lines, held in
+// a <link> or <iframe> tag, or buried in a script's JSON blob isn't missed
+// the way the old pair of hand-rolled <a> regexes missed them.
+func pdfCandidatesFromHTML(baseURL, html string) ([]PDFCandidate, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse landing page html: %w", err)
+	}
+
+	var candidates []PDFCandidate
+	addCandidate := func(href, text, source string) {
+		href = strings.TrimSpace(href)
+		if !strings.Contains(strings.ToLower(href), ".pdf") {
+			return
+		}
+		resolved, err := base.Parse(href)
+		if err != nil {
+			return
+		}
+		text = strings.TrimSpace(text)
+		candidates = append(candidates, PDFCandidate{
+			URL:    resolved.String(),
+			Text:   text,
+			Source: source,
+			Score:  scorePDFCandidate(href, text),
+		})
+	}
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		addCandidate(href, s.Text(), "a")
+	})
+	doc.Find(`link[rel="alternate"][type="application/pdf"]`).Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		addCandidate(href, s.AttrOr("title", ""), "link")
+	})
+	doc.Find("iframe[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		addCandidate(src, s.AttrOr("title", ""), "iframe")
+	})
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		for _, href := range pdfURLsFromText(s.Text()) {
+			addCandidate(href, "", "jsonld")
+		}
+	})
+
+	return candidates, nil
+}
+
+// pdfCandidatesFromHostFallback fetches /sitemap.xml and /robots.txt from
+// landingURL's host and scores any .pdf URL found in either. A missing or
+// unreachable sitemap/robots.txt is normal (most providers have neither
+// pointing at their tariff PDF) and simply yields no candidates from that
+// source rather than failing DiscoverPDFCandidates outright.
+func pdfCandidatesFromHostFallback(client *http.Client, landingURL string) ([]PDFCandidate, error) {
+	base, err := url.Parse(landingURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
+	fallbacks := []struct{ path, source string }{
+		{"/sitemap.xml", "sitemap"},
+		{"/robots.txt", "robots"},
+	}
+
+	var candidates []PDFCandidate
+	for _, fb := range fallbacks {
+		target, err := base.Parse(fb.path)
+		if err != nil {
+			continue
+		}
+		body, err := fetchDiscoveryBody(client, target.String())
+		if err != nil {
+			continue
+		}
+		for _, href := range pdfURLsFromText(body) {
+			resolved, err := base.Parse(href)
+			if err != nil {
+				continue
+			}
+			candidates = append(candidates, PDFCandidate{
+				URL:    resolved.String(),
+				Source: fb.source,
+				Score:  scorePDFCandidate(href, ""),
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// pdfURLInTextRe matches a bare (quoted or not) URL-like token ending in
+// .pdf, used to pull candidates out of contexts that aren't necessarily
+// well-formed HTML: a <script> blob's JSON (JSON-LD's contentUrl, or any
+// other key a provider happens to use), a sitemap.xml's <loc> entries, or a
+// robots.txt Sitemap:/Disallow: line.
+var pdfURLInTextRe = regexp.MustCompile(`(?i)[^\s"'<>]+\.pdf`)
+
+func pdfURLsFromText(text string) []string {
+	return pdfURLInTextRe.FindAllString(text, -1)
 }
 
-func discoverPDFURLFromHTML(baseURL, html string) (string, error) {
-    base, err := url.Parse(baseURL)
-    if err != nil {
-        return "", fmt.Errorf("parse base url: %w", err)
-    }
-
-    type candidate struct {
-        rawHref string
-        text    string
-        score   int
-    }
-
-    var candidates []candidate
-
-    // Anchor tags with link text
-    anchorRe := regexp.MustCompile(`(?is)<a[^>]+href="([^"]+\.pdf)"[^>]*>([^<]*)</a>`)
-    for _, m := range anchorRe.FindAllStringSubmatch(html, -1) {
-        href := strings.TrimSpace(m[1])
-        text := strings.TrimSpace(htmlUnescape(m[2]))
-        score := scorePDFCandidate(href, text)
-        candidates = append(candidates, candidate{rawHref: href, text: text, score: score})
-    }
-
-    // Fallback: any href="...pdf"
-    if len(candidates) == 0 {
-        hrefRe := regexp.MustCompile(`(?i)href="([^"]+\.pdf)"`)
-        for _, m := range hrefRe.FindAllStringSubmatch(html, -1) {
-            href := strings.TrimSpace(m[1])
-            score := scorePDFCandidate(href, "")
-            candidates = append(candidates, candidate{rawHref: href, text: "", score: score})
-        }
-    }
-
-    if len(candidates) == 0 {
-        return "", errors.New("no PDF links found on page")
-    }
-
-    sort.SliceStable(candidates, func(i, j int) bool {
-        if candidates[i].score != candidates[j].score {
-            return candidates[i].score > candidates[j].score
-        }
-        iHTTPS := strings.HasPrefix(strings.ToLower(candidates[i].rawHref), "https://")
-        jHTTPS := strings.HasPrefix(strings.ToLower(candidates[j].rawHref), "https://")
-        if iHTTPS != jHTTPS {
-            return iHTTPS
-        }
-        return candidates[i].rawHref < candidates[j].rawHref
-    })
-
-    best := candidates[0].rawHref
-    bestURL, err := base.Parse(best)
-    if err != nil {
-        return "", fmt.Errorf("resolve href %q: %w", best, err)
-    }
-
-    return bestURL.String(), nil
+// sortPDFCandidates orders candidates best first: highest scorePDFCandidate
+// score wins, ties prefer https over http, and further ties fall back to a
+// lexical comparison so the result is deterministic across runs.
+func sortPDFCandidates(candidates []PDFCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		iHTTPS := strings.HasPrefix(strings.ToLower(candidates[i].URL), "https://")
+		jHTTPS := strings.HasPrefix(strings.ToLower(candidates[j].URL), "https://")
+		if iHTTPS != jHTTPS {
+			return iHTTPS
+		}
+		return candidates[i].URL < candidates[j].URL
+	})
 }
 
 func scorePDFCandidate(href, text string) int {
-    hrefLower := strings.ToLower(href)
-    textLower := strings.ToLower(text)
-
-    score := 0
-
-    if strings.Contains(textLower, "residential") {
-        score += 5
-    }
-    if strings.Contains(textLower, "rate") || strings.Contains(textLower, "schedule") {
-        score += 3
-    }
-    if strings.Contains(hrefLower, "residential") {
-        score += 3
-    }
-    if strings.Contains(hrefLower, "rates") || strings.Contains(hrefLower, "rs") {
-        score += 2
-    }
-    if strings.Contains(textLower, "current") || strings.Contains(hrefLower, "2025") {
-        score += 1
-    }
-
-    return score
+	hrefLower := strings.ToLower(href)
+	textLower := strings.ToLower(text)
+
+	score := 0
+
+	if strings.Contains(textLower, "residential") {
+		score += 5
+	}
+	if strings.Contains(textLower, "rate") || strings.Contains(textLower, "schedule") {
+		score += 3
+	}
+	if strings.Contains(hrefLower, "residential") {
+		score += 3
+	}
+	if strings.Contains(hrefLower, "rates") || strings.Contains(hrefLower, "rs") {
+		score += 2
+	}
+	if strings.Contains(textLower, "current") || strings.Contains(hrefLower, "2025") {
+		score += 1
+	}
+
+	return score
+}
+
+// RefreshProviderPDF discovers and downloads the provider PDF into
+// DefaultPDFPath. The download request is bound to ctx, so a caller-imposed
+// deadline (or client disconnect, via a context derived from
+// http.Request.Context) aborts the in-flight download instead of letting it
+// run to completion.
+func RefreshProviderPDF(ctx context.Context, p ProviderDescriptor) (string, error) {
+	pdfURL, err := DiscoverPDFURL(ctx, p)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pdfURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("build pdf request: %w", err)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download pdf: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("pdf download: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	if p.DefaultPDFPath == "" {
+		return "", fmt.Errorf("provider %q has no DefaultPDFPath configured", p.Key)
+	}
+
+	counting := &countingReader{r: resp.Body}
+	if err := writeFileAtomically(p.DefaultPDFPath, counting); err != nil {
+		return "", err
+	}
+
+	logging.WithProvider(logging.Default(), p.Key).InfoContext(ctx, "pdf.download.ok",
+		"url", pdfURL, "bytes", counting.n)
+	return pdfURL, nil
 }
 
-func htmlUnescape(s string) string {
-    replacer := strings.NewReplacer(
-        "&amp;", "&",
-        "&lt;", "<",
-        "&gt;", ">",
-        "&quot;", `"`,
-        "&#39;", "'",
-    )
-    return replacer.Replace(s)
+// countingReader wraps an io.Reader, tallying bytes read so
+// RefreshProviderPDF can log pdf.download.ok's byte count without writeFileAtomically's reader needing to know why.
+type countingReader struct {
+	r io.Reader
+	n int64
 }
 
-// RefreshProviderPDF discovers and downloads the provider PDF into DefaultPDFPath.
-func RefreshProviderPDF(p ProviderDescriptor) (string, error) {
-    pdfURL, err := DiscoverPDFURL(p)
-    if err != nil {
-        return "", err
-    }
-
-    client := &http.Client{Timeout: 30 * time.Second}
-    resp, err := client.Get(pdfURL)
-    if err != nil {
-        return "", fmt.Errorf("download pdf: %w", err)
-    }
-    defer resp.Body.Close()
-    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-        return "", fmt.Errorf("pdf download returned status %d", resp.StatusCode)
-    }
-
-    if p.DefaultPDFPath == "" {
-        return "", fmt.Errorf("provider %q has no DefaultPDFPath configured", p.Key)
-    }
-
-    if err := writeFileAtomically(p.DefaultPDFPath, resp.Body); err != nil {
-        return "", err
-    }
-    return pdfURL, nil
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
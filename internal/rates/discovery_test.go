@@ -0,0 +1,61 @@
+package rates
+
+import "testing"
+
+const sampleLandingHTML = `
+<html>
+<head>
+<link rel="alternate" type="application/pdf" href="/rates/schedule.pdf" title="Rate Schedule">
+</head>
+<body>
+<a href="/docs/brochure.pdf">Brochure</a>
+<a href="/docs/residential-rates-2025.pdf">Residential Rate Schedule</a>
+<iframe src="/embed/current-rates.pdf"></iframe>
+<script>var data = {"contentUrl": "/data/tariff.pdf"};</script>
+</body>
+</html>
+`
+
+func TestPdfCandidatesFromHTMLFindsEveryKind(t *testing.T) {
+	candidates, err := pdfCandidatesFromHTML("https://example.com/rates/", sampleLandingHTML)
+	if err != nil {
+		t.Fatalf("pdfCandidatesFromHTML failed: %v", err)
+	}
+
+	bySource := make(map[string]bool)
+	for _, c := range candidates {
+		bySource[c.Source] = true
+	}
+	for _, want := range []string{"a", "link", "iframe", "jsonld"} {
+		if !bySource[want] {
+			t.Errorf("expected a candidate from source %q, got %+v", want, candidates)
+		}
+	}
+}
+
+func TestPdfCandidatesFromHTMLPrefersResidentialRateSchedule(t *testing.T) {
+	candidates, err := pdfCandidatesFromHTML("https://example.com/rates/", sampleLandingHTML)
+	if err != nil {
+		t.Fatalf("pdfCandidatesFromHTML failed: %v", err)
+	}
+	sortPDFCandidates(candidates)
+
+	if len(candidates) == 0 {
+		t.Fatal("expected at least one candidate")
+	}
+	if candidates[0].Source != "a" || candidates[0].Text != "Residential Rate Schedule" {
+		t.Errorf("expected the residential rate schedule anchor to score highest, got %+v", candidates[0])
+	}
+}
+
+func TestPdfURLsFromTextExtractsSitemapAndRobotsEntries(t *testing.T) {
+	sitemap := `<urlset><url><loc>https://example.com/rates/tariff.pdf</loc></url></urlset>`
+	if urls := pdfURLsFromText(sitemap); len(urls) != 1 || urls[0] != "https://example.com/rates/tariff.pdf" {
+		t.Errorf("expected one sitemap pdf url, got %v", urls)
+	}
+
+	robots := "User-agent: *\nSitemap: https://example.com/rates/schedule.pdf\n"
+	if urls := pdfURLsFromText(robots); len(urls) != 1 || urls[0] != "https://example.com/rates/schedule.pdf" {
+		t.Errorf("expected one robots.txt pdf url, got %v", urls)
+	}
+}
@@ -0,0 +1,75 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// StatusError reports an HTTP response status code that didn't indicate
+// success, so callers classifying retryability (see IsRetryable) don't have
+// to parse it back out of a formatted message.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// ParseError wraps a failure to extract or interpret a provider's rate
+// document (a malformed PDF, a missing expected field, an unrecognized
+// table layout). It is never worth retrying: the document won't parse any
+// differently on the next attempt.
+type ParseError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse %s: %v", e.Provider, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// IsRetryable classifies an error encountered while refreshing a provider as
+// worth retrying (a transient condition that might succeed on the next
+// attempt) or terminal. Unrecognized errors default to terminal: most errors
+// surfaced by this package are configuration or parse failures that won't
+// change between attempts, so only explicitly transient conditions —
+// a context deadline, a 5xx/429/408 response, or a network-level timeout —
+// are retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusRequestTimeout, statusErr.StatusCode == http.StatusTooManyRequests:
+			return true
+		case statusErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+
+	return false
+}
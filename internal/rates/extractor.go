@@ -0,0 +1,260 @@
+package rates
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+
+	pdf "github.com/ledongthuc/pdf"
+)
+
+// Cell is one table cell recovered by a layout-aware PDFExtractor, with the
+// bounding box of the text span(s) it was built from so a caller can relate
+// cells across rows (e.g. "does this cell sit under that header").
+type Cell struct {
+	Text           string
+	X0, Y0, X1, Y1 float64
+}
+
+// Table is a grid of Cells reconstructed from one PDF page, in reading
+// order: Rows[0] is the topmost row, and each row's cells are left-to-right.
+type Table struct {
+	Page int
+	Rows [][]Cell
+}
+
+// PDFExtractor turns a PDF file into text and tables. ParserConfig.Extractor
+// lets a provider pick which implementation its parser uses; providers
+// whose PDFs don't reflow text in a useful order benefit from
+// NewLayoutTableExtractor, while the rest are fine with the cheaper
+// NewLedongthucTextExtractor.
+type PDFExtractor interface {
+	// ExtractText returns the PDF's text, flattened in whatever order the
+	// underlying library emits it.
+	ExtractText(path string) (string, error)
+	// ExtractTables returns one Table per page that yielded recoverable rows.
+	// A page with no clusterable spans (e.g. a scanned image) is omitted
+	// rather than returned as an empty Table.
+	ExtractTables(path string) ([]Table, error)
+}
+
+// ledongthucTextExtractor is the original extraction path: github.com/ledongthuc/pdf's
+// GetPlainText, which flattens a page's content stream into reading-order
+// text but discards column/row structure. ExtractTables is unsupported
+// since GetPlainText gives no coordinates to cluster.
+type ledongthucTextExtractor struct{}
+
+// NewLedongthucTextExtractor returns the plain-text-only PDFExtractor every
+// parser used before table extraction existed.
+func NewLedongthucTextExtractor() PDFExtractor {
+	return ledongthucTextExtractor{}
+}
+
+func (ledongthucTextExtractor) ExtractText(path string) (string, error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open pdf: %w", err)
+	}
+	defer f.Close()
+
+	rc, err := r.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("extract pdf text: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, rc); err != nil {
+		return "", fmt.Errorf("read pdf text: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func (ledongthucTextExtractor) ExtractTables(path string) ([]Table, error) {
+	return nil, nil
+}
+
+// Layout clustering tolerances, named after the pdfplumber parameters they
+// port: two spans on the same visual line if their Y-tops are within
+// lineClusterTolerance points of each other, and a new column starts
+// wherever the gap between consecutive spans on a line exceeds
+// columnGapTolerance points.
+const (
+	lineClusterTolerance = 2.0
+	columnGapTolerance   = 8.0
+)
+
+// layoutTableExtractor groups each page's text spans into lines by
+// Y-coordinate and then into columns by X-gaps, a Go port of pdfplumber's
+// "words -> lines -> tables" heuristic. Unlike ledongthucTextExtractor it
+// keeps enough structure to recover a label's adjacent value even when the
+// content stream doesn't emit them in reading order.
+type layoutTableExtractor struct{}
+
+// NewLayoutTableExtractor returns a PDFExtractor that reconstructs rows and
+// columns from text-span coordinates instead of relying on the PDF
+// library's own reading-order flattening.
+func NewLayoutTableExtractor() PDFExtractor {
+	return layoutTableExtractor{}
+}
+
+func (e layoutTableExtractor) ExtractText(path string) (string, error) {
+	pages, err := e.pageSpans(path)
+	if err != nil {
+		return "", err
+	}
+	var out []byte
+	for _, page := range pages {
+		for _, line := range clusterLines(page.Spans) {
+			for i, span := range line {
+				if i > 0 {
+					out = append(out, ' ')
+				}
+				out = append(out, span.S...)
+			}
+			out = append(out, '\n')
+		}
+	}
+	return string(out), nil
+}
+
+func (e layoutTableExtractor) ExtractTables(path string) ([]Table, error) {
+	pages, err := e.pageSpans(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	for _, page := range pages {
+		lines := clusterLines(page.Spans)
+		if len(lines) == 0 {
+			continue
+		}
+		var rows [][]Cell
+		for _, line := range lines {
+			rows = append(rows, splitColumns(line))
+		}
+		tables = append(tables, Table{Page: page.Page, Rows: rows})
+	}
+	return tables, nil
+}
+
+// pageSpans opens path and returns every page's text spans with their
+// original PDF coordinates, the raw material clusterLines/splitColumns
+// group into rows and columns.
+func (layoutTableExtractor) pageSpans(path string) ([]PageText, error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open pdf: %w", err)
+	}
+	defer f.Close()
+
+	pages := make([]PageText, 0, r.NumPage())
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		content := page.Content()
+		spans := make([]TextSpan, 0, len(content.Text))
+		for _, t := range content.Text {
+			spans = append(spans, TextSpan{X: t.X, Y: t.Y, FontSize: t.FontSize, S: t.S})
+		}
+		pages = append(pages, PageText{Page: i, Spans: spans})
+	}
+	return pages, nil
+}
+
+// clusterLines groups spans whose Y coordinates fall within
+// lineClusterTolerance of each other into the same line, top-to-bottom,
+// each line sorted left-to-right by X.
+func clusterLines(spans []TextSpan) [][]TextSpan {
+	sorted := make([]TextSpan, len(spans))
+	copy(sorted, spans)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Y > sorted[j].Y })
+
+	var lines [][]TextSpan
+	for _, span := range sorted {
+		placed := false
+		for i := range lines {
+			if math.Abs(lines[i][0].Y-span.Y) <= lineClusterTolerance {
+				lines[i] = append(lines[i], span)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			lines = append(lines, []TextSpan{span})
+		}
+	}
+	for _, line := range lines {
+		sort.Slice(line, func(i, j int) bool { return line[i].X < line[j].X })
+	}
+	return lines
+}
+
+// splitColumns walks a line's spans left-to-right and starts a new Cell
+// whenever the gap to the previous span exceeds columnGapTolerance,
+// merging spans that belong to the same cell with a single space.
+func splitColumns(line []TextSpan) []Cell {
+	var cells []Cell
+	for _, span := range line {
+		width := estimateSpanWidth(span)
+		if len(cells) > 0 {
+			last := &cells[len(cells)-1]
+			if span.X-last.X1 <= columnGapTolerance {
+				last.Text += " " + span.S
+				last.X1 = span.X + width
+				continue
+			}
+		}
+		cells = append(cells, Cell{
+			Text: span.S,
+			X0:   span.X,
+			Y0:   span.Y,
+			X1:   span.X + width,
+			Y1:   span.Y,
+		})
+	}
+	return cells
+}
+
+// estimateSpanWidth approximates a text span's on-page width from its font
+// size, since the PDF library's Text struct carries no width field. This is
+// only ever used to decide where one cell ends and the next begins, so an
+// approximation is good enough.
+func estimateSpanWidth(span TextSpan) float64 {
+	return float64(len(span.S)) * span.FontSize * 0.5
+}
+
+// tablesText flattens every table's rows back into text, one row per line
+// and cells joined left-to-right, so a parser that only knows how to run a
+// regex over text can still benefit from cells being in their visual row
+// even when the PDF content stream interleaved them.
+func tablesText(tables []Table) string {
+	var out []byte
+	for _, t := range tables {
+		for _, row := range t.Rows {
+			for i, cell := range row {
+				if i > 0 {
+					out = append(out, ' ')
+				}
+				out = append(out, cell.Text...)
+			}
+			out = append(out, '\n')
+		}
+	}
+	return string(out)
+}
+
+// firstFloatPreferTables runs re against the text reconstructed from
+// tables' rows first, since a table cell keeps a label next to its value
+// even when the PDF's underlying content stream doesn't; it falls back to
+// running re against text (the flattened extractor output) when tables
+// yielded nothing or didn't match.
+func firstFloatPreferTables(re *regexp.Regexp, tables []Table, text string) float64 {
+	if tableText := tablesText(tables); tableText != "" {
+		if v := parseFirstFloat(re, tableText); v != 0 {
+			return v
+		}
+	}
+	return parseFirstFloat(re, text)
+}
@@ -0,0 +1,277 @@
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/logging"
+)
+
+// ExtractorUnit is the physical unit an ExtractorField's matched value is
+// expressed in, used to normalize it onto the $/kWh, $/month, $/gallon
+// convention ResidentialStandard, WaterRateDetails, and SewerRateDetails
+// expect.
+type ExtractorUnit string
+
+const (
+	ExtractorUnitUSDPerKWh    ExtractorUnit = "$/kWh"
+	ExtractorUnitCentsPerKWh  ExtractorUnit = "¢/kWh"
+	ExtractorUnitUSDPerMonth  ExtractorUnit = "$/month"
+	ExtractorUnitUSDPerGallon ExtractorUnit = "$/gallon"
+)
+
+// ExtractorPattern is one candidate regex for an ExtractorField, tried in
+// order against the spec's text until one matches. It must have exactly one
+// capture group, the value to extract.
+type ExtractorPattern struct {
+	Regex string `json:"regex"`
+}
+
+// ExtractorField is one named value an ExtractorSpec pulls out of a
+// provider's PDF/HTML text.
+type ExtractorField struct {
+	Name     string             `json:"name"`
+	Patterns []ExtractorPattern `json:"patterns"`
+	Unit     ExtractorUnit      `json:"unit"`
+	// Transform is an optional post-processing step applied once the field
+	// has resolved to a value: "cents_to_usd" divides it by 100, and
+	// "sum_with:<other_field>" adds another field's resolved value into it
+	// (e.g. folding a grid access charge into the customer charge).
+	// Transforms run after every field's patterns have been tried, so
+	// sum_with can name a field defined later in Fields.
+	Transform string `json:"transform,omitempty"`
+}
+
+// ExtractorSpec is a provider's declarative field-extraction recipe, loaded
+// from a JSON file under configs/extractors/ so a layout drift or a new
+// provider can be handled by editing a config file instead of shipping a
+// new parser_*.go.
+type ExtractorSpec struct {
+	ProviderKey string `json:"provider_key"`
+	Utility     string `json:"utility"`
+	Source      string `json:"source"`
+	SourceURL   string `json:"source_url"`
+	// Format is "pdf" or "html", telling /internal/extract/{provider} how
+	// to turn an uploaded body into text before matching fields against it.
+	Format string           `json:"format"`
+	Fields []ExtractorField `json:"fields"`
+}
+
+// LoadExtractorSpec reads and parses a provider's extraction spec from path.
+func LoadExtractorSpec(path string) (*ExtractorSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read extractor spec %s: %w", path, err)
+	}
+	spec, err := ParseExtractorSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseExtractorSpec parses an ExtractorSpec from its JSON encoding,
+// validating that every field has at least one pattern to try.
+func ParseExtractorSpec(data []byte) (*ExtractorSpec, error) {
+	var spec ExtractorSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse extractor spec: %w", err)
+	}
+	for _, f := range spec.Fields {
+		if len(f.Patterns) == 0 {
+			return nil, fmt.Errorf("field %q has no patterns", f.Name)
+		}
+	}
+	return &spec, nil
+}
+
+// ExtractedField is one field's resolved outcome from running an
+// ExtractorSpec against a provider's text.
+type ExtractedField struct {
+	Name           string  `json:"name"`
+	Matched        bool    `json:"matched"`
+	Value          float64 `json:"value,omitempty"`
+	MatchedPattern string  `json:"matched_pattern,omitempty"`
+}
+
+// ExtractReport is the structured result of running an ExtractorSpec: which
+// fields matched, their resolved values, and which pattern (if any) hit, so
+// an operator can tell whether a config fix worked without redeploying.
+// Warnings names every field no pattern matched, mirroring
+// RatesResponse.Warnings.
+type ExtractReport struct {
+	ProviderKey string           `json:"provider_key"`
+	Fields      []ExtractedField `json:"fields"`
+	Warnings    []string         `json:"warnings,omitempty"`
+}
+
+// ValueOf returns the resolved value of the named field and whether it
+// matched, for callers that only need one or two fields rather than the
+// whole report (e.g. RunExtractors building a ResidentialStandard).
+func (r ExtractReport) ValueOf(name string) (float64, bool) {
+	for _, f := range r.Fields {
+		if f.Name == name {
+			return f.Value, f.Matched
+		}
+	}
+	return 0, false
+}
+
+// RunExtractorSpec runs spec against text, trying each field's patterns in
+// order and applying its Transform once a value resolves.
+func RunExtractorSpec(spec *ExtractorSpec, text string) ExtractReport {
+	values := make(map[string]float64, len(spec.Fields))
+	matched := make(map[string]bool, len(spec.Fields))
+	matchedPattern := make(map[string]string, len(spec.Fields))
+	l := logging.WithProvider(logging.Default(), spec.ProviderKey)
+
+	for _, f := range spec.Fields {
+		for _, pat := range f.Patterns {
+			re, err := regexp.Compile(pat.Regex)
+			if err != nil {
+				continue
+			}
+			if v, ok := matchFirstFloat(re, text); ok {
+				values[f.Name] = convertExtractorUnit(f.Unit, v)
+				matched[f.Name] = true
+				matchedPattern[f.Name] = pat.Regex
+				l.Debug("parse.section.matched", "section", f.Name, "regex", pat.Regex)
+				break
+			}
+		}
+	}
+
+	for _, f := range spec.Fields {
+		if !matched[f.Name] || f.Transform == "" {
+			continue
+		}
+		switch {
+		case f.Transform == "cents_to_usd":
+			values[f.Name] /= 100
+		case strings.HasPrefix(f.Transform, "sum_with:"):
+			other := strings.TrimPrefix(f.Transform, "sum_with:")
+			values[f.Name] += values[other]
+		}
+	}
+
+	report := ExtractReport{ProviderKey: spec.ProviderKey}
+	for _, f := range spec.Fields {
+		report.Fields = append(report.Fields, ExtractedField{
+			Name:           f.Name,
+			Matched:        matched[f.Name],
+			Value:          values[f.Name],
+			MatchedPattern: matchedPattern[f.Name],
+		})
+		if !matched[f.Name] {
+			report.Warnings = append(report.Warnings, f.Name)
+		}
+	}
+	return report
+}
+
+func convertExtractorUnit(unit ExtractorUnit, v float64) float64 {
+	if unit == ExtractorUnitCentsPerKWh {
+		return v / 100
+	}
+	return v
+}
+
+// matchFirstFloat finds the first float match in s using re, the way
+// shared.ParseFirstFloat does for pkg/providers; kept as a local copy since
+// internal/rates and pkg/providers/shared are independent generations of
+// the rate-parsing code. ok reports whether re matched at all, so a zero
+// value can be told apart from no match.
+func matchFirstFloat(re *regexp.Regexp, s string) (float64, bool) {
+	m := re.FindStringSubmatch(s)
+	if len(m) < 2 {
+		return 0, false
+	}
+	var v float64
+	fmt.Sscanf(m[1], "%f", &v)
+	return v, true
+}
+
+// RunExtractors loads the electric ExtractorSpec at specPath and runs it
+// against text, mapping its "customer_charge", "energy_rate", and
+// "fuel_rate" fields onto a RatesResponse.ResidentialStandard - the same
+// three quantities ParseNESRatesFromText resolves by hand. Any field no
+// pattern matched is recorded in the response's Warnings instead of
+// defaulting to $0.
+func RunExtractors(specPath string, text string) (*RatesResponse, error) {
+	spec, err := LoadExtractorSpec(specPath)
+	if err != nil {
+		return nil, err
+	}
+	report := RunExtractorSpec(spec, text)
+
+	rawCopy := text
+	rs := ResidentialStandard{IsPresent: true, RawSection: &rawCopy}
+	if v, ok := report.ValueOf("customer_charge"); ok {
+		rs.CustomerChargeMonthlyUSD = &v
+	}
+	if v, ok := report.ValueOf("energy_rate"); ok {
+		cents := v * 100
+		rs.EnergyRateUSDPerKWh = &v
+		rs.EnergyRateCentsPerKWh = &cents
+	}
+	if v, ok := report.ValueOf("fuel_rate"); ok {
+		cents := v * 100
+		rs.TVAFuelRateUSDPerKWh = &v
+		rs.TVAFuelRateCentsPerKWh = &cents
+	}
+
+	return &RatesResponse{
+		Utility:   spec.Utility,
+		Source:    spec.Source,
+		SourceURL: spec.SourceURL,
+		FetchedAt: time.Now().UTC(),
+		Rates:     Rates{ResidentialStandard: rs},
+		Warnings:  report.Warnings,
+	}, nil
+}
+
+// RunWaterExtractors loads the water ExtractorSpec at specPath and runs it
+// against text, mapping its "water_base_charge", "water_use_rate",
+// "sewer_base_charge", and "sewer_use_rate" fields onto a
+// WaterRatesResponse, the declarative counterpart to RunExtractors for
+// water/sewer providers like WHUD. Sewer fields are only populated, and a
+// *SewerRateDetails allocated, when at least one of them matched.
+func RunWaterExtractors(specPath string, text string) (*WaterRatesResponse, error) {
+	spec, err := LoadExtractorSpec(specPath)
+	if err != nil {
+		return nil, err
+	}
+	report := RunExtractorSpec(spec, text)
+
+	resp := &WaterRatesResponse{
+		ProviderKey:  spec.ProviderKey,
+		ProviderName: spec.Utility,
+		FetchedAt:    time.Now().UTC(),
+		Water:        WaterRateDetails{UseRateUnit: "gallon"},
+	}
+	if v, ok := report.ValueOf("water_base_charge"); ok {
+		resp.Water.BaseCharge = &v
+	}
+	if v, ok := report.ValueOf("water_use_rate"); ok {
+		resp.Water.UseRate = &v
+	}
+
+	sewerBase, hasSewerBase := report.ValueOf("sewer_base_charge")
+	sewerUse, hasSewerUse := report.ValueOf("sewer_use_rate")
+	if hasSewerBase || hasSewerUse {
+		resp.Sewer = &SewerRateDetails{UseRateUnit: "gallon"}
+		if hasSewerBase {
+			resp.Sewer.BaseCharge = &sewerBase
+		}
+		if hasSewerUse {
+			resp.Sewer.UseRate = &sewerUse
+		}
+	}
+
+	resp.Warnings = report.Warnings
+	return resp, nil
+}
@@ -0,0 +1,137 @@
+package rates
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestRunExtractorSpec_SumWithAndCentsUnit(t *testing.T) {
+	spec := &ExtractorSpec{
+		ProviderKey: "nes",
+		Fields: []ExtractorField{
+			{
+				Name:      "customer_charge",
+				Unit:      ExtractorUnitUSDPerMonth,
+				Patterns:  []ExtractorPattern{{Regex: `Service Charge: \$([0-9.]+)`}},
+				Transform: "sum_with:grid_access",
+			},
+			{
+				Name:     "grid_access",
+				Unit:     ExtractorUnitUSDPerMonth,
+				Patterns: []ExtractorPattern{{Regex: `Grid Access Charge: \$([0-9.]+)`}},
+			},
+			{
+				Name:     "energy_rate",
+				Unit:     ExtractorUnitCentsPerKWh,
+				Patterns: []ExtractorPattern{{Regex: `Energy Charge: ([0-9.]+)\s*cents`}},
+			},
+			{
+				Name:     "fuel_rate",
+				Patterns: []ExtractorPattern{{Regex: `Fuel Adjustment: ([0-9.]+)`}},
+			},
+		},
+	}
+
+	text := "Service Charge: $14.06\nGrid Access Charge: $4.50\nEnergy Charge: 9.254 cents\n"
+
+	report := RunExtractorSpec(spec, text)
+
+	if v, ok := report.ValueOf("customer_charge"); !ok || v < 18.5599 || v > 18.5601 {
+		t.Errorf("customer_charge: got %v (matched=%v), want 18.56", v, ok)
+	}
+	if v, ok := report.ValueOf("energy_rate"); !ok || v < 0.09253 || v > 0.09255 {
+		t.Errorf("energy_rate: got %v (matched=%v), want ~0.09254", v, ok)
+	}
+	if _, ok := report.ValueOf("fuel_rate"); ok {
+		t.Errorf("fuel_rate: expected no match, since the fixture text has no Fuel Adjustment line")
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0] != "fuel_rate" {
+		t.Errorf("expected exactly one warning for fuel_rate, got %v", report.Warnings)
+	}
+}
+
+func TestRunExtractors_BuildsResidentialStandard(t *testing.T) {
+	spec := &ExtractorSpec{
+		ProviderKey: "nes",
+		Utility:     "NES",
+		Fields: []ExtractorField{
+			{Name: "customer_charge", Patterns: []ExtractorPattern{{Regex: `Service Charge: \$([0-9.]+)`}}},
+			{Name: "energy_rate", Unit: ExtractorUnitCentsPerKWh, Patterns: []ExtractorPattern{{Regex: `Energy Charge: ([0-9.]+)\s*cents`}}},
+			{Name: "fuel_rate", Patterns: []ExtractorPattern{{Regex: `Fuel Adjustment: ([0-9.]+)`}}},
+		},
+	}
+
+	specPath := writeTempExtractorSpec(t, spec)
+	resp, err := RunExtractors(specPath, "Service Charge: $14.06\nEnergy Charge: 9.254 cents\n")
+	if err != nil {
+		t.Fatalf("RunExtractors: unexpected error: %v", err)
+	}
+
+	rs := resp.Rates.ResidentialStandard
+	if got := floatOrZero(rs.CustomerChargeMonthlyUSD); got != 14.06 {
+		t.Errorf("customer charge: got %v, want 14.06", got)
+	}
+	if got := floatOrZero(rs.EnergyRateUSDPerKWh); got < 0.09253 || got > 0.09255 {
+		t.Errorf("energy rate: got %v, want ~0.09254", got)
+	}
+	if rs.TVAFuelRateUSDPerKWh != nil {
+		t.Errorf("expected fuel rate to stay unmatched, got %v", *rs.TVAFuelRateUSDPerKWh)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0] != "fuel_rate" {
+		t.Errorf("expected exactly one warning for fuel_rate, got %v", resp.Warnings)
+	}
+}
+
+func TestRunWaterExtractors_BuildsWaterAndSewer(t *testing.T) {
+	spec := &ExtractorSpec{
+		ProviderKey: "whud",
+		Utility:     "White House Utility District",
+		Fields: []ExtractorField{
+			{Name: "water_base_charge", Patterns: []ExtractorPattern{{Regex: `Meter \$([0-9.]+)`}}},
+			{Name: "water_use_rate", Patterns: []ExtractorPattern{{Regex: `Water Use Charge \$([0-9.]+)/gallon`}}},
+			{Name: "sewer_base_charge", Patterns: []ExtractorPattern{{Regex: `Sewer Basic Service Charge: \$([0-9.]+)`}}},
+		},
+	}
+
+	specPath := writeTempExtractorSpec(t, spec)
+	resp, err := RunWaterExtractors(specPath, "Meter $9.85\nWater Use Charge $0.00866/gallon\nSewer Basic Service Charge: $10.49\n")
+	if err != nil {
+		t.Fatalf("RunWaterExtractors: unexpected error: %v", err)
+	}
+
+	if got := floatOrZero(resp.Water.BaseCharge); got != 9.85 {
+		t.Errorf("water base charge: got %v, want 9.85", got)
+	}
+	if got := floatOrZero(resp.Water.UseRate); got != 0.00866 {
+		t.Errorf("water use rate: got %v, want 0.00866", got)
+	}
+	if resp.Sewer == nil {
+		t.Fatal("expected sewer rates to be populated from sewer_base_charge matching")
+	}
+	if got := floatOrZero(resp.Sewer.BaseCharge); got != 10.49 {
+		t.Errorf("sewer base charge: got %v, want 10.49", got)
+	}
+	if resp.Sewer.UseRate != nil {
+		t.Errorf("expected sewer use rate to stay unmatched, got %v", *resp.Sewer.UseRate)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0] != "sewer_use_rate" {
+		t.Errorf("expected exactly one warning for sewer_use_rate, got %v", resp.Warnings)
+	}
+}
+
+// writeTempExtractorSpec marshals spec to a temp JSON file so
+// RunExtractors/RunWaterExtractors (which take a spec path, mirroring
+// LoadExtractorSpec) can be exercised without a fixture under configs/.
+func writeTempExtractorSpec(t *testing.T, spec *ExtractorSpec) string {
+	t.Helper()
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+	path := t.TempDir() + "/spec.json"
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	return path
+}
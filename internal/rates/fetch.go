@@ -0,0 +1,188 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+)
+
+const (
+	fetchInitialBackoff = 500 * time.Millisecond
+	fetchMaxBackoff     = 30 * time.Second
+	fetchMaxRetries     = 5
+)
+
+// fetchMeta is the conditional-request state persisted alongside a cached
+// PDF so subsequent fetches can send If-None-Match / If-Modified-Since and
+// short-circuit on 304 instead of re-downloading an unchanged file.
+type fetchMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// FetchResult describes the outcome of FetchProviderPDF.
+type FetchResult struct {
+	// Path is where the provider's PDF now lives on disk.
+	Path string
+	// Downloaded is false when a 304 Not Modified meant the cached file at
+	// Path was already current.
+	Downloaded bool
+}
+
+// FetchProviderPDF downloads p's tariff PDF into cacheDir (named
+// "<provider-key>.pdf"), retrying transient errors (5xx responses, network
+// timeouts) with exponential backoff and full jitter (initial 500ms, cap
+// 30s, up to 5 retries). It sends If-None-Match / If-Modified-Since on
+// subsequent calls and returns Downloaded=false without touching the file
+// when the server answers 304. p.SourceURL is used when set; otherwise the
+// PDF URL is discovered from p.LandingURL via DiscoverPDFURL.
+func FetchProviderPDF(ctx context.Context, client *http.Client, p ProviderDescriptor, cacheDir string) (*FetchResult, error) {
+	if client == nil {
+		client = DefaultHTTPClient()
+	}
+	if cacheDir == "" {
+		cacheDir = os.TempDir()
+	}
+
+	url := p.SourceURL
+	if url == "" {
+		discovered, err := DiscoverPDFURL(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("discover pdf url for %s: %w", p.Key, err)
+		}
+		url = discovered
+	}
+
+	path := filepath.Join(cacheDir, p.Key+".pdf")
+	meta := loadFetchMeta(path)
+
+	started := time.Now()
+	result, err := fetchWithRetry(ctx, client, url, path, meta)
+	dur := time.Since(started).Seconds()
+
+	metrics.RatesFetchDurationSeconds.WithLabelValues(p.Key).Observe(dur)
+	outcome := "downloaded"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case result != nil && !result.Downloaded:
+		outcome = "not_modified"
+	}
+	metrics.RatesFetchesTotal.WithLabelValues(p.Key, outcome).Inc()
+
+	return result, err
+}
+
+// fetchWithRetry drives the retry loop around fetchOnce, backing off with
+// full jitter between attempts.
+func fetchWithRetry(ctx context.Context, client *http.Client, url, path string, meta *fetchMeta) (*FetchResult, error) {
+	var lastErr error
+	for attempt := 0; attempt <= fetchMaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(fullJitterBackoff(attempt)):
+			}
+		}
+
+		result, retryable, err := fetchOnce(ctx, client, url, path, meta)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if !retryable {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("fetch %s: giving up after %d attempts: %w", url, fetchMaxRetries+1, lastErr)
+}
+
+// fetchOnce performs a single conditional GET. The bool return reports
+// whether the error (if any) is worth retrying.
+func fetchOnce(ctx context.Context, client *http.Client, url, path string, meta *fetchMeta) (*FetchResult, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("build request: %w", err)
+	}
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("fetch pdf: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return &FetchResult{Path: path, Downloaded: false}, false, nil
+	case resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("pdf download: %w", &StatusError{StatusCode: resp.StatusCode})
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, false, fmt.Errorf("pdf download: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	if err := writeFileAtomically(path, resp.Body); err != nil {
+		return nil, false, fmt.Errorf("write pdf: %w", err)
+	}
+	saveFetchMeta(path, &fetchMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	})
+
+	return &FetchResult{Path: path, Downloaded: true}, false, nil
+}
+
+// fullJitterBackoff returns a random duration in [0, min(max, initial*2^(attempt-1))],
+// the "full jitter" strategy from the AWS architecture blog's backoff write-up.
+func fullJitterBackoff(attempt int) time.Duration {
+	backoff := fetchInitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= fetchMaxBackoff {
+			backoff = fetchMaxBackoff
+			break
+		}
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+func fetchMetaPath(pdfPath string) string {
+	return pdfPath + ".meta.json"
+}
+
+// loadFetchMeta returns the persisted conditional-request state for pdfPath,
+// or a zero-value fetchMeta if none exists yet or it can't be read.
+func loadFetchMeta(pdfPath string) *fetchMeta {
+	data, err := os.ReadFile(fetchMetaPath(pdfPath))
+	if err != nil {
+		return &fetchMeta{}
+	}
+	var meta fetchMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return &fetchMeta{}
+	}
+	return &meta
+}
+
+// saveFetchMeta is best-effort: losing it just means the next fetch
+// re-downloads instead of sending a conditional request.
+func saveFetchMeta(pdfPath string, meta *fetchMeta) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(fetchMetaPath(pdfPath), data, 0o644)
+}
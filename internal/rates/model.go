@@ -10,6 +10,34 @@ type RatesResponse struct {
     SourceURL string    `json:"source_url"`
     FetchedAt time.Time `json:"fetched_at"`
     Rates     Rates     `json:"rates"`
+
+    // Sector, EffectiveDate, and EndDate describe the tariff schedule this
+    // response was built from (set by CSVTariffImporter; zero-valued for
+    // PDF-parsed providers).
+    Sector        string     `json:"sector,omitempty"`
+    EffectiveDate *time.Time `json:"effective_date,omitempty"`
+    EndDate       *time.Time `json:"end_date,omitempty"`
+
+    // FixedMonthlyChargeUSD, DemandChargeUSDPerKW, and FuelAdjustmentUSDPerKWh
+    // are additional tariff components CalculateBill applies on top of
+    // TieredRates/TOU, beyond what ResidentialStandard's flat rate models.
+    FixedMonthlyChargeUSD   float64 `json:"fixed_monthly_charge_usd,omitempty"`
+    DemandChargeUSDPerKW    float64 `json:"demand_charge_usd_per_kw,omitempty"`
+    FuelAdjustmentUSDPerKWh float64 `json:"fuel_adjustment_usd_per_kwh,omitempty"`
+
+    // TieredRates, when non-empty, overrides ResidentialStandard's flat
+    // EnergyRateUSDPerKWh with tiered (inclining/declining block) pricing.
+    TieredRates []TieredRate `json:"tiered_rates,omitempty"`
+
+    // TOU, when set, describes a time-of-use schedule CalculateTOUBill can
+    // apply to period-bucketed usage.
+    TOU *TOUSchedule `json:"tou,omitempty"`
+
+    // Warnings names rate fields a parser's regexes failed to match against
+    // this fetch, so a bare $0 in ResidentialStandard (or a water/sewer
+    // field) can be told apart from a legitimately free rate. Empty when
+    // every field the parser expects to find was matched.
+    Warnings []string `json:"warnings,omitempty"`
 }
 
 type Rates struct {
@@ -19,14 +47,18 @@ type Rates struct {
     ResidentialTOU          ResidentialTOU          `json:"residential_tou"`
 }
 
+// ResidentialStandard's rate fields are pointers: a parser leaves one nil
+// (JSON null) when its regexes didn't match anything, rather than
+// returning 0, which would be indistinguishable from a utility that
+// genuinely charges nothing for that component.
 type ResidentialStandard struct {
-    IsPresent                bool    `json:"is_present"`
-    CustomerChargeMonthlyUSD float64 `json:"customer_charge_monthly_usd"`
-    EnergyRateUSDPerKWh      float64 `json:"energy_rate_usd_per_kwh"`
-    EnergyRateCentsPerKWh    float64 `json:"energy_rate_cents_per_kwh"`
-    TVAFuelRateUSDPerKWh     float64 `json:"tva_fuel_rate_usd_per_kwh"`
-    TVAFuelRateCentsPerKWh   float64 `json:"tva_fuel_rate_cents_per_kwh"`
-    RawSection               *string `json:"raw_section"`
+    IsPresent                bool     `json:"is_present"`
+    CustomerChargeMonthlyUSD *float64 `json:"customer_charge_monthly_usd"`
+    EnergyRateUSDPerKWh      *float64 `json:"energy_rate_usd_per_kwh"`
+    EnergyRateCentsPerKWh    *float64 `json:"energy_rate_cents_per_kwh"`
+    TVAFuelRateUSDPerKWh     *float64 `json:"tva_fuel_rate_usd_per_kwh"`
+    TVAFuelRateCentsPerKWh   *float64 `json:"tva_fuel_rate_cents_per_kwh"`
+    RawSection               *string  `json:"raw_section"`
 }
 
 type ResidentialSupplemental struct {
@@ -59,3 +91,14 @@ type ResidentialTOU struct {
     OffPeakHours          []string  `json:"off_peak_hours"`
     ShoulderHours         []string  `json:"shoulder_hours"`
 }
+
+// floatOrZero returns 0 for a nil pointer (an unmatched rate field) or the
+// pointed-to value otherwise, for billing math that needs a concrete
+// number even though the field itself distinguishes "zero" from
+// "unspecified".
+func floatOrZero(f *float64) float64 {
+    if f == nil {
+        return 0
+    }
+    return *f
+}
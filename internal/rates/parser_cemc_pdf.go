@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	pdf "github.com/ledongthuc/pdf"
@@ -19,8 +22,21 @@ func init() {
 	})
 }
 
+// cemcStrategies returns the extraction strategies to try, in order of
+// increasing trust: the whole-document regex is kept first since it's the
+// only one that works without page coordinates (e.g. from ParseText), but a
+// layout-aware strategy beats it whenever real coordinates are available.
+func cemcStrategies() []Strategy {
+	return []Strategy{
+		cemcRegexStrategy{},
+		cemcLayoutStrategy{},
+		cemcTableStrategy{},
+	}
+}
+
 // ParseCEMCRatesFromPDF opens a CEMC rates PDF at the given path, extracts
-// text, and delegates to ParseCEMCRatesFromText.
+// per-page text with coordinates, and runs it through the CEMC extraction
+// strategies, keeping the highest-confidence non-zero result.
 func ParseCEMCRatesFromPDF(path string) (*RatesResponse, error) {
 	f, r, err := pdf.Open(path)
 	if err != nil {
@@ -28,65 +44,223 @@ func ParseCEMCRatesFromPDF(path string) (*RatesResponse, error) {
 	}
 	defer f.Close()
 
-	rc, err := r.GetPlainText()
-	if err != nil {
-		return nil, fmt.Errorf("extract pdf text: %w", err)
+	pages := make([]PageText, 0, r.NumPage())
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		content := page.Content()
+		spans := make([]TextSpan, 0, len(content.Text))
+		for _, t := range content.Text {
+			spans = append(spans, TextSpan{X: t.X, Y: t.Y, FontSize: t.FontSize, S: t.S})
+		}
+		pages = append(pages, PageText{Page: i, Spans: spans})
 	}
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, rc); err != nil {
-		return nil, fmt.Errorf("read pdf text: %w", err)
+	extracted, _ := runStrategies(pages, cemcStrategies())
+	if extracted.IsZero() {
+		// Fall back to the flattened plain-text path so a PDF the library
+		// couldn't give us coordinates for still parses something.
+		rc, err := r.GetPlainText()
+		if err != nil {
+			return nil, fmt.Errorf("extract pdf text: %w", err)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, rc); err != nil {
+			return nil, fmt.Errorf("read pdf text: %w", err)
+		}
+		return ParseCEMCRatesFromText(buf.String())
 	}
 
-	return ParseCEMCRatesFromText(buf.String())
+	return cemcResponse(extracted), nil
 }
 
 // ParseCEMCRatesFromText parses a plain-text representation of the CEMC
-// rates PDF and extracts the residential standard fields using regex.
+// rates PDF. It has no page coordinates to work with, so only the
+// whole-document regex strategy applies; it exists mainly for tests and for
+// providers whose PDF library only yields flattened text.
 func ParseCEMCRatesFromText(text string) (*RatesResponse, error) {
-	// Try to narrow to the residential RS section.
-	rsRe := regexp.MustCompile(`RESIDENTIAL RATE[^\n]*SCHEDULE RS(?s)(.+?)(?:SUPPLEMENTAL RESIDENTIAL RATE|$)`)
-	rsMatch := rsRe.FindStringSubmatch(text)
-	rsSection := ""
-	if len(rsMatch) >= 2 {
-		rsSection = rsMatch[0]
+	pages := []PageText{{Page: 1, Spans: []TextSpan{{S: text}}}}
+	extracted, _ := runStrategies(pages, []Strategy{cemcRegexStrategy{}})
+	return cemcResponse(extracted), nil
+}
+
+func cemcResponse(e Extracted) *RatesResponse {
+	energyCents := e.EnergyRateUSDPerKWh * 100
+	fuelCents := e.TVAFuelRateUSDPerKWh * 100
+	rawCopy := e.RawSection
+
+	rs := ResidentialStandard{IsPresent: true, RawSection: &rawCopy}
+	var warnings []string
+	if e.CustomerChargeMonthlyUSD > 0 {
+		rs.CustomerChargeMonthlyUSD = &e.CustomerChargeMonthlyUSD
+	} else {
+		warnings = append(warnings, "customer_charge_monthly_usd")
+	}
+	if e.EnergyRateUSDPerKWh > 0 {
+		rs.EnergyRateUSDPerKWh = &e.EnergyRateUSDPerKWh
+		rs.EnergyRateCentsPerKWh = &energyCents
 	} else {
-		rsSection = text
+		warnings = append(warnings, "energy_rate_usd_per_kwh")
+	}
+	if e.TVAFuelRateUSDPerKWh > 0 {
+		rs.TVAFuelRateUSDPerKWh = &e.TVAFuelRateUSDPerKWh
+		rs.TVAFuelRateCentsPerKWh = &fuelCents
+	} else {
+		warnings = append(warnings, "tva_fuel_rate_usd_per_kwh")
+	}
+
+	return &RatesResponse{
+		Utility:   "CEMC",
+		Source:    "CEMC Current Rates PDF",
+		SourceURL: "https://cemc.org/my-account/#residential-rates",
+		FetchedAt: time.Now().UTC(),
+		Rates:     Rates{ResidentialStandard: rs},
+		Warnings:  warnings,
 	}
+}
 
-	custRe := regexp.MustCompile(`Customer Charge:\s*\$?([0-9]+(?:\.[0-9]+)?)`)
-	energyRe := regexp.MustCompile(`Energy Charge:\s*(\d+\.\d+|\.\d+|\d+)\$?\s*per kWh`)
-	fuelRe := regexp.MustCompile(`TVA Fuel Charge:\s*(\d+\.\d+|\.\d+|\d+)\$?\s*per kWh`)
+var (
+	cemcRSRe     = regexp.MustCompile(`RESIDENTIAL RATE[^\n]*SCHEDULE RS(?s)(.+?)(?:SUPPLEMENTAL RESIDENTIAL RATE|$)`)
+	cemcCustRe   = regexp.MustCompile(`Customer Charge:?\s*\$?([0-9]+(?:\.[0-9]+)?)`)
+	cemcEnergyRe = regexp.MustCompile(`Energy Charge:?\s*(\d+\.\d+|\.\d+|\d+)\$?\s*per kWh`)
+	cemcFuelRe   = regexp.MustCompile(`TVA Fuel Charge:?\s*(\d+\.\d+|\.\d+|\d+)\$?\s*per kWh`)
+)
 
-	customerCharge := parseFirstFloat(custRe, rsSection)
-	energyRate := parseFirstFloat(energyRe, rsSection)
-	fuelRate := parseFirstFloat(fuelRe, rsSection)
+// cemcRegexStrategy is the original whole-document regex pass. It's the
+// most brittle of the three (a reflowed layout can merge the label and
+// value across lines in a way the regex doesn't expect) so it's given the
+// lowest confidence.
+type cemcRegexStrategy struct{}
 
-	energyCents := energyRate * 100
-	fuelCents := fuelRate * 100
+func (cemcRegexStrategy) Extract(pages []PageText) (Extracted, float64, error) {
+	text := joinPageText(pages)
 
-	now := time.Now().UTC()
-	rawCopy := rsSection
+	rsSection := text
+	if m := cemcRSRe.FindStringSubmatch(text); len(m) >= 1 {
+		rsSection = m[0]
+	}
 
-	resp := &RatesResponse{
-		Utility:   "CEMC",
-		Source:    "CEMC Current Rates PDF",
-		SourceURL: "https://cemc.org/my-account/#residential-rates",
-		FetchedAt: now,
-		Rates: Rates{
-			ResidentialStandard: ResidentialStandard{
-				IsPresent:                true,
-				CustomerChargeMonthlyUSD: customerCharge,
-				EnergyRateUSDPerKWh:      energyRate,
-				EnergyRateCentsPerKWh:    energyCents,
-				TVAFuelRateUSDPerKWh:     fuelRate,
-				TVAFuelRateCentsPerKWh:   fuelCents,
-				RawSection:               &rawCopy,
-			},
-		},
-	}
-
-	return resp, nil
+	e := Extracted{
+		CustomerChargeMonthlyUSD: parseFirstFloat(cemcCustRe, rsSection),
+		EnergyRateUSDPerKWh:      parseFirstFloat(cemcEnergyRe, rsSection),
+		TVAFuelRateUSDPerKWh:     parseFirstFloat(cemcFuelRe, rsSection),
+		RawSection:               rsSection,
+	}
+	if e.IsZero() {
+		return e, 0, nil
+	}
+	return e, 0.5, nil
+}
+
+// cemcLayoutStrategy finds each label ("Customer Charge", "Energy Charge",
+// "TVA Fuel Charge") by its text span and reads the numeric value from
+// spans on the same row (same Y, to the right of the label), which
+// survives a reflowed PDF that the whole-document regex can't.
+type cemcLayoutStrategy struct{}
+
+func (cemcLayoutStrategy) Extract(pages []PageText) (Extracted, float64, error) {
+	e := Extracted{
+		CustomerChargeMonthlyUSD: valueRightOfLabel(pages, "Customer Charge", cemcCustRe),
+		EnergyRateUSDPerKWh:      valueRightOfLabel(pages, "Energy Charge", cemcEnergyRe),
+		TVAFuelRateUSDPerKWh:     valueRightOfLabel(pages, "TVA Fuel Charge", cemcFuelRe),
+	}
+	if e.IsZero() {
+		return e, 0, nil
+	}
+	return e, 0.8, nil
+}
+
+// rowTolerance is how close two spans' Y coordinates must be to be
+// considered part of the same visual row.
+const rowTolerance = 2.0
+
+// valueRightOfLabel locates a span containing label and returns the first
+// numeric match of valueRe against the text of every span in the same row
+// (including the label span itself, so "Customer Charge: $39.00" in a
+// single span still matches).
+func valueRightOfLabel(pages []PageText, label string, valueRe *regexp.Regexp) float64 {
+	for _, page := range pages {
+		for _, labelSpan := range page.Spans {
+			if !containsFold(labelSpan.S, label) {
+				continue
+			}
+			var row string
+			for _, span := range page.Spans {
+				if math.Abs(span.Y-labelSpan.Y) > rowTolerance || span.X < labelSpan.X {
+					continue
+				}
+				row += span.S + " "
+			}
+			if v := parseFirstFloat(valueRe, row); v != 0 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// cemcTableStrategy buckets every span on a page into rows by Y coordinate,
+// orders each row left-to-right by X, and reconstructs a full-document text
+// view from those rows before applying the same regexes as
+// cemcRegexStrategy. This is more robust than a single whole-document regex
+// pass because a label and its value end up adjacent in the reconstructed
+// text even when the PDF's underlying content stream interleaves columns.
+type cemcTableStrategy struct{}
+
+func (cemcTableStrategy) Extract(pages []PageText) (Extracted, float64, error) {
+	text := reconstructRows(pages)
+
+	rsSection := text
+	if m := cemcRSRe.FindStringSubmatch(text); len(m) >= 1 {
+		rsSection = m[0]
+	}
+
+	e := Extracted{
+		CustomerChargeMonthlyUSD: parseFirstFloat(cemcCustRe, rsSection),
+		EnergyRateUSDPerKWh:      parseFirstFloat(cemcEnergyRe, rsSection),
+		TVAFuelRateUSDPerKWh:     parseFirstFloat(cemcFuelRe, rsSection),
+		RawSection:               rsSection,
+	}
+	if e.IsZero() {
+		return e, 0, nil
+	}
+	return e, 0.9, nil
+}
+
+// reconstructRows buckets each page's spans into rows by Y coordinate,
+// sorts each row left-to-right by X, and joins rows (top to bottom, in
+// page order) into a newline-delimited text block.
+func reconstructRows(pages []PageText) string {
+	var lines []string
+	for _, page := range pages {
+		rows := make(map[int][]TextSpan)
+		var ys []int
+		for _, span := range page.Spans {
+			key := int(math.Round(span.Y / rowTolerance))
+			if _, ok := rows[key]; !ok {
+				ys = append(ys, key)
+			}
+			rows[key] = append(rows[key], span)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(ys)))
+		for _, key := range ys {
+			row := rows[key]
+			sort.Slice(row, func(i, j int) bool { return row[i].X < row[j].X })
+			var line string
+			for _, span := range row {
+				line += span.S + " "
+			}
+			lines = append(lines, line)
+		}
+	}
+	out := ""
+	for _, l := range lines {
+		out += l + "\n"
+	}
+	return out
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
 
 func parseFirstFloat(re *regexp.Regexp, s string) float64 {
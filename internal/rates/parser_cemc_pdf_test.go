@@ -1,9 +1,13 @@
-    package rates
+package rates
 
-    import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
-    func TestParseCEMCRatesFromText(t *testing.T) {
-        sample := `
+func TestParseCEMCRatesFromText(t *testing.T) {
+	sample := `
 RESIDENTIAL RATE – SCHEDULE RS
 (22) Customer Charge: $39.00 per month
 Energy Charge: .08058$ per kWh per month
@@ -12,21 +16,64 @@ TVA Fuel Charge: .02177$ per kWh per month
 SUPPLEMENTAL RESIDENTIAL RATE – SCHEDULE SRS
 (21) Customer Charge:
 `
-        res, err := ParseCEMCRatesFromText(sample)
-        if err != nil {
-            t.Fatalf("unexpected error: %v", err)
-        }
-        rs := res.Rates.ResidentialStandard
-        if !rs.IsPresent {
-            t.Fatalf("expected residential standard to be present")
-        }
-        if rs.CustomerChargeMonthlyUSD != 39.0 {
-            t.Errorf("unexpected customer charge: %v", rs.CustomerChargeMonthlyUSD)
-        }
-        if rs.EnergyRateUSDPerKWh <= 0 {
-            t.Errorf("expected positive energy rate, got %v", rs.EnergyRateUSDPerKWh)
-        }
-        if rs.TVAFuelRateUSDPerKWh <= 0 {
-            t.Errorf("expected positive fuel rate, got %v", rs.TVAFuelRateUSDPerKWh)
-        }
-    }
+	res, err := ParseCEMCRatesFromText(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rs := res.Rates.ResidentialStandard
+	if !rs.IsPresent {
+		t.Fatalf("expected residential standard to be present")
+	}
+	if floatOrZero(rs.CustomerChargeMonthlyUSD) != 39.0 {
+		t.Errorf("unexpected customer charge: %v", rs.CustomerChargeMonthlyUSD)
+	}
+	if floatOrZero(rs.EnergyRateUSDPerKWh) <= 0 {
+		t.Errorf("expected positive energy rate, got %v", rs.EnergyRateUSDPerKWh)
+	}
+	if floatOrZero(rs.TVAFuelRateUSDPerKWh) <= 0 {
+		t.Errorf("expected positive fuel rate, got %v", rs.TVAFuelRateUSDPerKWh)
+	}
+}
+
+// TestParseCEMCRatesFromFixtures guards against layout regressions: each
+// fixture under testdata/rates/cemc/ must keep parsing to the same known
+// values, so a future CEMC PDF reflow fails this test instead of silently
+// producing zeros in production.
+func TestParseCEMCRatesFromFixtures(t *testing.T) {
+	cases := []struct {
+		file           string
+		customerCharge float64
+		energyRate     float64
+		fuelRate       float64
+	}{
+		{"standard.txt", 39.00, 0.08058, 0.02177},
+		{"reflow.txt", 42.50, 0.08512, 0.02301},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "rates", "cemc", tc.file))
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+
+			res, err := ParseCEMCRatesFromText(string(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rs := res.Rates.ResidentialStandard
+			if !rs.IsPresent {
+				t.Fatalf("expected residential standard to be present")
+			}
+			if got := floatOrZero(rs.CustomerChargeMonthlyUSD); got != tc.customerCharge {
+				t.Errorf("customer charge: got %v, want %v", got, tc.customerCharge)
+			}
+			if got := floatOrZero(rs.EnergyRateUSDPerKWh); got != tc.energyRate {
+				t.Errorf("energy rate: got %v, want %v", got, tc.energyRate)
+			}
+			if got := floatOrZero(rs.TVAFuelRateUSDPerKWh); got != tc.fuelRate {
+				t.Errorf("fuel rate: got %v, want %v", got, tc.fuelRate)
+			}
+		})
+	}
+}
@@ -1,13 +1,8 @@
 package rates
 
 import (
-	"bytes"
-	"fmt"
-	"io"
 	"regexp"
 	"time"
-
-	pdf "github.com/ledongthuc/pdf"
 )
 
 func init() {
@@ -19,32 +14,35 @@ func init() {
 	})
 }
 
-// ParseKubRatesFromPDF opens a Knoxville Utilities Board rates PDF at the given path,
-// extracts text, and delegates to ParseKubRatesFromText.
+// ParseKubRatesFromPDF opens a Knoxville Utilities Board rates PDF at the
+// given path through the "kub" provider's configured PDFExtractor, and
+// delegates to ParseKubRatesFromTextAndTables.
 func ParseKubRatesFromPDF(path string) (*RatesResponse, error) {
-	f, r, err := pdf.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("open pdf: %w", err)
-	}
-	defer f.Close()
+	ext := extractorFor("kub")
 
-	rc, err := r.GetPlainText()
+	text, err := ext.ExtractText(path)
 	if err != nil {
-		return nil, fmt.Errorf("extract pdf text: %w", err)
+		return nil, err
 	}
+	tables, _ := ext.ExtractTables(path)
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, rc); err != nil {
-		return nil, fmt.Errorf("read pdf text: %w", err)
-	}
-
-	return ParseKubRatesFromText(buf.String())
+	return ParseKubRatesFromTextAndTables(text, tables)
 }
 
 // ParseKubRatesFromText parses a plain-text representation of the
-// Knoxville Utilities Board residential rates and extracts fields using regex heuristics.
+// Knoxville Utilities Board residential rates and extracts fields using
+// regex heuristics. It exists for tests and providers with no table data;
+// ParseKubRatesFromPDF prefers ParseKubRatesFromTextAndTables when tables
+// are available.
 // KUB is a TVA distributor - handles both their actual PDF format and test formats.
 func ParseKubRatesFromText(text string) (*RatesResponse, error) {
+	return ParseKubRatesFromTextAndTables(text, nil)
+}
+
+// ParseKubRatesFromTextAndTables parses KUB residential rates, preferring a
+// label's value from tables (when a layout-aware PDFExtractor recovered
+// any) over running the same regex against the flattened text.
+func ParseKubRatesFromTextAndTables(text string, tables []Table) (*RatesResponse, error) {
 	// === CUSTOMER CHARGE PATTERNS ===
 	// KUB uses "Basic Service Charge: $20.50 per month"
 	basicServiceRe := regexp.MustCompile(`Basic Service Charge[:\s]*\$([0-9]+(?:\.[0-9]+)?)\s*per month`)
@@ -77,38 +75,38 @@ func ParseKubRatesFromText(text string) (*RatesResponse, error) {
 	fuelCentSymbolRe := regexp.MustCompile(`(?:TVA )?Fuel(?: Cost)?\s*(?:Adjustment|Charge)[:\s]*([0-9]+(?:\.[0-9]+)?)\s*[¢c]\s*per kWh`)
 
 	// === PARSE CUSTOMER CHARGE ===
-	customerCharge := parseFirstFloat(basicServiceRe, text)
+	customerCharge := firstFloatPreferTables(basicServiceRe, tables, text)
 	if customerCharge == 0 {
-		customerCharge = parseFirstFloat(custRe, text)
+		customerCharge = firstFloatPreferTables(custRe, tables, text)
 	}
 	// Add Grid Access Charge if present (TVA format)
-	if gridAccess := parseFirstFloat(gridAccessRe, text); gridAccess > 0 {
+	if gridAccess := firstFloatPreferTables(gridAccessRe, tables, text); gridAccess > 0 {
 		customerCharge += gridAccess
 	}
 
 	// === PARSE ENERGY RATE ===
 	energyRate := 0.0
 	// Try KUB actual format first (dollars per kWh)
-	if rate := parseFirstFloat(summerRateRe, text); rate > 0 {
+	if rate := firstFloatPreferTables(summerRateRe, tables, text); rate > 0 {
 		energyRate = rate
-	} else if rate := parseFirstFloat(winterRateRe, text); rate > 0 {
+	} else if rate := firstFloatPreferTables(winterRateRe, tables, text); rate > 0 {
 		energyRate = rate
-	} else if rate := parseFirstFloat(transitionRateRe, text); rate > 0 {
+	} else if rate := firstFloatPreferTables(transitionRateRe, tables, text); rate > 0 {
 		energyRate = rate
 	}
 	// Try cents formats (test/alternate formats)
 	if energyRate == 0 {
-		if cents := parseFirstFloat(energyCentsRe, text); cents > 0 {
+		if cents := firstFloatPreferTables(energyCentsRe, tables, text); cents > 0 {
 			energyRate = cents / 100.0
-		} else if cents := parseFirstFloat(energyCentSymbolRe, text); cents > 0 {
+		} else if cents := firstFloatPreferTables(energyCentSymbolRe, tables, text); cents > 0 {
 			energyRate = cents / 100.0
 		}
 	}
 	// Try dollar format fallbacks
 	if energyRate == 0 {
-		if rate := parseFirstFloat(energyUSDRe, text); rate > 0 {
+		if rate := firstFloatPreferTables(energyUSDRe, tables, text); rate > 0 {
 			energyRate = rate
-		} else if rate := parseFirstFloat(genericEnergyRe, text); rate > 0 {
+		} else if rate := firstFloatPreferTables(genericEnergyRe, tables, text); rate > 0 {
 			energyRate = rate
 		}
 	}
@@ -116,14 +114,14 @@ func ParseKubRatesFromText(text string) (*RatesResponse, error) {
 	// === PARSE FUEL RATE ===
 	fuelRate := 0.0
 	// Try Purchased Power Adjustment (KUB actual format, in cents)
-	if ppaCents := parseFirstFloat(ppaRe, text); ppaCents > 0 {
+	if ppaCents := firstFloatPreferTables(ppaRe, tables, text); ppaCents > 0 {
 		fuelRate = ppaCents / 100.0
 	}
 	// Try fuel adjustment formats (test formats, in cents)
 	if fuelRate == 0 {
-		if cents := parseFirstFloat(fuelCentsRe, text); cents > 0 {
+		if cents := firstFloatPreferTables(fuelCentsRe, tables, text); cents > 0 {
 			fuelRate = cents / 100.0
-		} else if cents := parseFirstFloat(fuelCentSymbolRe, text); cents > 0 {
+		} else if cents := firstFloatPreferTables(fuelCentSymbolRe, tables, text); cents > 0 {
 			fuelRate = cents / 100.0
 		}
 	}
@@ -134,22 +132,33 @@ func ParseKubRatesFromText(text string) (*RatesResponse, error) {
 	now := time.Now().UTC()
 	rawCopy := text
 
+	rs := ResidentialStandard{IsPresent: true, RawSection: &rawCopy}
+	var warnings []string
+	if customerCharge > 0 {
+		rs.CustomerChargeMonthlyUSD = &customerCharge
+	} else {
+		warnings = append(warnings, "customer_charge_monthly_usd")
+	}
+	if energyRate > 0 {
+		rs.EnergyRateUSDPerKWh = &energyRate
+		rs.EnergyRateCentsPerKWh = &energyCents
+	} else {
+		warnings = append(warnings, "energy_rate_usd_per_kwh")
+	}
+	if fuelRate > 0 {
+		rs.TVAFuelRateUSDPerKWh = &fuelRate
+		rs.TVAFuelRateCentsPerKWh = &fuelCents
+	} else {
+		warnings = append(warnings, "tva_fuel_rate_usd_per_kwh")
+	}
+
 	resp := &RatesResponse{
 		Utility:   "KUB",
 		Source:    "KUB Residential Rates PDF",
 		SourceURL: "https://www.kub.org/bills-payments/understand-your-bill/residential-rates/",
 		FetchedAt: now,
-		Rates: Rates{
-			ResidentialStandard: ResidentialStandard{
-				IsPresent:                true,
-				CustomerChargeMonthlyUSD: customerCharge,
-				EnergyRateUSDPerKWh:      energyRate,
-				EnergyRateCentsPerKWh:    energyCents,
-				TVAFuelRateUSDPerKWh:     fuelRate,
-				TVAFuelRateCentsPerKWh:   fuelCents,
-				RawSection:               &rawCopy,
-			},
-		},
+		Rates:     Rates{ResidentialStandard: rs},
+		Warnings:  warnings,
 	}
 	return resp, nil
 }
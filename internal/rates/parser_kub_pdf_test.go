@@ -16,10 +16,10 @@ Fuel Cost Adjustment: 0.50 cents per kWh
 	if !rs.IsPresent {
 		t.Fatalf("expected residential standard to be present")
 	}
-	if rs.CustomerChargeMonthlyUSD != 20.0 {
+	if floatOrZero(rs.CustomerChargeMonthlyUSD) != 20.0 {
 		t.Errorf("expected customer charge 20.0, got %v", rs.CustomerChargeMonthlyUSD)
 	}
-	if rs.EnergyRateUSDPerKWh <= 0 {
+	if floatOrZero(rs.EnergyRateUSDPerKWh) <= 0 {
 		t.Errorf("expected positive energy rate, got %v", rs.EnergyRateUSDPerKWh)
 	}
 }
@@ -42,13 +42,15 @@ TVA Fuel Cost Adjustment: 0.25¢ per kWh
 	}
 	// Service Charge ($14.00) + Grid Access ($5.00) = $19.00
 	expectedCustomer := 19.0
-	if rs.CustomerChargeMonthlyUSD < expectedCustomer-0.01 || rs.CustomerChargeMonthlyUSD > expectedCustomer+0.01 {
-		t.Errorf("expected customer charge ~%v, got %v", expectedCustomer, rs.CustomerChargeMonthlyUSD)
+	customerCharge := floatOrZero(rs.CustomerChargeMonthlyUSD)
+	if customerCharge < expectedCustomer-0.01 || customerCharge > expectedCustomer+0.01 {
+		t.Errorf("expected customer charge ~%v, got %v", expectedCustomer, customerCharge)
 	}
 	// Energy rate should be 9.5 cents = 0.095 $/kWh
 	expectedEnergy := 0.095
-	if rs.EnergyRateUSDPerKWh < expectedEnergy-0.001 || rs.EnergyRateUSDPerKWh > expectedEnergy+0.001 {
-		t.Errorf("expected energy rate ~%v, got %v", expectedEnergy, rs.EnergyRateUSDPerKWh)
+	energyRate := floatOrZero(rs.EnergyRateUSDPerKWh)
+	if energyRate < expectedEnergy-0.001 || energyRate > expectedEnergy+0.001 {
+		t.Errorf("expected energy rate ~%v, got %v", expectedEnergy, energyRate)
 	}
 }
 
@@ -63,7 +65,7 @@ Energy Charge: 10.25 cents per kWh
 		t.Fatalf("unexpected error: %v", err)
 	}
 	rs := res.Rates.ResidentialStandard
-	if rs.CustomerChargeMonthlyUSD != 16.5 {
+	if floatOrZero(rs.CustomerChargeMonthlyUSD) != 16.5 {
 		t.Errorf("expected customer charge 16.5, got %v", rs.CustomerChargeMonthlyUSD)
 	}
 }
@@ -102,17 +104,19 @@ Purchased Power Adjustment (1.053 cents per kWh) – Effective October 1, 2025
 		t.Fatalf("expected residential standard to be present")
 	}
 	// Basic Service Charge = $20.50
-	if rs.CustomerChargeMonthlyUSD != 20.50 {
+	if floatOrZero(rs.CustomerChargeMonthlyUSD) != 20.50 {
 		t.Errorf("expected customer charge 20.50, got %v", rs.CustomerChargeMonthlyUSD)
 	}
 	// Summer energy rate = $0.11740 per kWh
 	expectedEnergy := 0.11740
-	if rs.EnergyRateUSDPerKWh < expectedEnergy-0.0001 || rs.EnergyRateUSDPerKWh > expectedEnergy+0.0001 {
-		t.Errorf("expected energy rate ~%v, got %v", expectedEnergy, rs.EnergyRateUSDPerKWh)
+	energyRate := floatOrZero(rs.EnergyRateUSDPerKWh)
+	if energyRate < expectedEnergy-0.0001 || energyRate > expectedEnergy+0.0001 {
+		t.Errorf("expected energy rate ~%v, got %v", expectedEnergy, energyRate)
 	}
 	// Purchased Power Adjustment = 1.053 cents = $0.01053
 	expectedFuel := 0.01053
-	if rs.TVAFuelRateUSDPerKWh < expectedFuel-0.0001 || rs.TVAFuelRateUSDPerKWh > expectedFuel+0.0001 {
-		t.Errorf("expected fuel rate ~%v, got %v", expectedFuel, rs.TVAFuelRateUSDPerKWh)
+	fuelRate := floatOrZero(rs.TVAFuelRateUSDPerKWh)
+	if fuelRate < expectedFuel-0.0001 || fuelRate > expectedFuel+0.0001 {
+		t.Errorf("expected fuel rate ~%v, got %v", expectedFuel, fuelRate)
 	}
 }
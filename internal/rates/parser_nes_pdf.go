@@ -1,40 +1,41 @@
 package rates
 
 import (
-	"bytes"
-	"fmt"
-	"io"
 	"regexp"
 	"time"
-
-	pdf "github.com/ledongthuc/pdf"
 )
 
-// ParseNESRatesFromPDF opens a NES rates PDF at the given path, extracts
-// text, and delegates to ParseNESRatesFromText.
+// ParseNESRatesFromPDF opens a NES rates PDF at the given path through the
+// "nes" provider's configured PDFExtractor, and delegates to
+// ParseNESRatesFromTextAndTables.
 func ParseNESRatesFromPDF(path string) (*RatesResponse, error) {
-	f, r, err := pdf.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("open pdf: %w", err)
-	}
-	defer f.Close()
+	ext := extractorFor("nes")
 
-	rc, err := r.GetPlainText()
+	text, err := ext.ExtractText(path)
 	if err != nil {
-		return nil, fmt.Errorf("extract pdf text: %w", err)
+		return nil, err
 	}
+	// Tables are a best-effort aid: a provider stuck on
+	// NewLedongthucTextExtractor() (or a layout that doesn't cluster
+	// cleanly) still parses via the regex-on-flattened-text path below.
+	tables, _ := ext.ExtractTables(path)
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, rc); err != nil {
-		return nil, fmt.Errorf("read pdf text: %w", err)
-	}
-
-	return ParseNESRatesFromText(buf.String())
+	return ParseNESRatesFromTextAndTables(text, tables)
 }
 
 // ParseNESRatesFromText parses a plain-text representation of the NES
-// residential rates and extracts fields using regex heuristics.
+// residential rates and extracts fields using regex heuristics. It exists
+// for tests and providers with no table data; ParseNESRatesFromPDF prefers
+// ParseNESRatesFromTextAndTables when tables are available.
 func ParseNESRatesFromText(text string) (*RatesResponse, error) {
+	return ParseNESRatesFromTextAndTables(text, nil)
+}
+
+// ParseNESRatesFromTextAndTables parses NES residential rates, preferring a
+// label's value from tables (when a layout-aware PDFExtractor recovered
+// any) over running the same regex against the flattened text, which is
+// prone to merging an unrelated label and value when the PDF reflows.
+func ParseNESRatesFromTextAndTables(text string, tables []Table) (*RatesResponse, error) {
 	// NES uses "Service Charge" instead of "Customer Charge"
 	// Format: "Service Charge: $14.06 per month" or similar
 	custRe := regexp.MustCompile(`(?:Customer|Service)\s+Charge[:\s]*\$?([0-9]+(?:\.[0-9]+)?)\s*(?:per month)?`)
@@ -55,8 +56,8 @@ func ParseNESRatesFromText(text string) (*RatesResponse, error) {
 	// TVA Grid Access Charge - this is part of the monthly charge
 	gridAccessRe := regexp.MustCompile(`(?:TVA )?Grid Access Charge[:\s]*\$?([0-9]+(?:\.[0-9]+)?)\s*per month`)
 
-	customerCharge := parseFirstFloat(custRe, text)
-	gridAccessCharge := parseFirstFloat(gridAccessRe, text)
+	customerCharge := firstFloatPreferTables(custRe, tables, text)
+	gridAccessCharge := firstFloatPreferTables(gridAccessRe, tables, text)
 
 	// Add grid access charge to customer charge if found
 	totalCustomerCharge := customerCharge
@@ -66,16 +67,16 @@ func ParseNESRatesFromText(text string) (*RatesResponse, error) {
 
 	// Try to extract energy rate - prefer cents format for NES
 	energyRate := 0.0
-	if cents := parseFirstFloat(energyCentsRe, text); cents > 0 {
+	if cents := firstFloatPreferTables(energyCentsRe, tables, text); cents > 0 {
 		energyRate = cents / 100.0
-	} else if usd := parseFirstFloat(energyUSDRe, text); usd > 0 {
+	} else if usd := firstFloatPreferTables(energyUSDRe, tables, text); usd > 0 {
 		energyRate = usd
-	} else if cents := parseFirstFloat(energyCentsAltRe, text); cents > 0 {
+	} else if cents := firstFloatPreferTables(energyCentsAltRe, tables, text); cents > 0 {
 		energyRate = cents / 100.0
 	}
 
 	fuelRate := 0.0
-	if v := parseFirstFloat(fuelRe, text); v > 0 {
+	if v := firstFloatPreferTables(fuelRe, tables, text); v > 0 {
 		// If value looks like cents (small number), convert
 		if v < 1 {
 			fuelRate = v // Already in dollars
@@ -90,22 +91,33 @@ func ParseNESRatesFromText(text string) (*RatesResponse, error) {
 	now := time.Now().UTC()
 	rawCopy := text
 
+	rs := ResidentialStandard{IsPresent: true, RawSection: &rawCopy}
+	var warnings []string
+	if totalCustomerCharge > 0 {
+		rs.CustomerChargeMonthlyUSD = &totalCustomerCharge
+	} else {
+		warnings = append(warnings, "customer_charge_monthly_usd")
+	}
+	if energyRate > 0 {
+		rs.EnergyRateUSDPerKWh = &energyRate
+		rs.EnergyRateCentsPerKWh = &energyCents
+	} else {
+		warnings = append(warnings, "energy_rate_usd_per_kwh")
+	}
+	if fuelRate > 0 {
+		rs.TVAFuelRateUSDPerKWh = &fuelRate
+		rs.TVAFuelRateCentsPerKWh = &fuelCents
+	} else {
+		warnings = append(warnings, "tva_fuel_rate_usd_per_kwh")
+	}
+
 	resp := &RatesResponse{
 		Utility:   "NES",
 		Source:    "NES Residential Rates PDF",
 		SourceURL: "https://www.nespower.com/rates/",
 		FetchedAt: now,
-		Rates: Rates{
-			ResidentialStandard: ResidentialStandard{
-				IsPresent:                true,
-				CustomerChargeMonthlyUSD: totalCustomerCharge,
-				EnergyRateUSDPerKWh:      energyRate,
-				EnergyRateCentsPerKWh:    energyCents,
-				TVAFuelRateUSDPerKWh:     fuelRate,
-				TVAFuelRateCentsPerKWh:   fuelCents,
-				RawSection:               &rawCopy,
-			},
-		},
+		Rates:     Rates{ResidentialStandard: rs},
+		Warnings:  warnings,
 	}
 	return resp, nil
 }
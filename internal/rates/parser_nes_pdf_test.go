@@ -17,13 +17,13 @@ Fuel Cost Adjustment: 0.50 cents per kWh
 	if !rs.IsPresent {
 		t.Fatalf("expected residential standard to be present")
 	}
-	if rs.CustomerChargeMonthlyUSD != 20.0 {
+	if floatOrZero(rs.CustomerChargeMonthlyUSD) != 20.0 {
 		t.Errorf("unexpected customer charge: %v", rs.CustomerChargeMonthlyUSD)
 	}
-	if rs.EnergyRateUSDPerKWh <= 0 {
+	if floatOrZero(rs.EnergyRateUSDPerKWh) <= 0 {
 		t.Errorf("expected positive energy rate, got %v", rs.EnergyRateUSDPerKWh)
 	}
-	if rs.TVAFuelRateUSDPerKWh <= 0 {
+	if floatOrZero(rs.TVAFuelRateUSDPerKWh) <= 0 {
 		t.Errorf("expected positive fuel rate, got %v", rs.TVAFuelRateUSDPerKWh)
 	}
 }
@@ -48,16 +48,19 @@ Transition Period 8.664¢ per kWh per month
 	}
 	// Service Charge ($14.06) + Grid Access ($4.50) = $18.56
 	expectedCustomer := 18.56
-	if rs.CustomerChargeMonthlyUSD < expectedCustomer-0.01 || rs.CustomerChargeMonthlyUSD > expectedCustomer+0.01 {
-		t.Errorf("expected customer charge ~%v, got %v", expectedCustomer, rs.CustomerChargeMonthlyUSD)
+	customerCharge := floatOrZero(rs.CustomerChargeMonthlyUSD)
+	if customerCharge < expectedCustomer-0.01 || customerCharge > expectedCustomer+0.01 {
+		t.Errorf("expected customer charge ~%v, got %v", expectedCustomer, customerCharge)
 	}
 	// Energy rate should be 9.254 cents = 0.09254 $/kWh
 	expectedEnergy := 0.09254
-	if rs.EnergyRateUSDPerKWh < expectedEnergy-0.0001 || rs.EnergyRateUSDPerKWh > expectedEnergy+0.0001 {
-		t.Errorf("expected energy rate ~%v, got %v", expectedEnergy, rs.EnergyRateUSDPerKWh)
+	energyRate := floatOrZero(rs.EnergyRateUSDPerKWh)
+	if energyRate < expectedEnergy-0.0001 || energyRate > expectedEnergy+0.0001 {
+		t.Errorf("expected energy rate ~%v, got %v", expectedEnergy, energyRate)
 	}
 	// Energy cents should be ~9.254
-	if rs.EnergyRateCentsPerKWh < 9.25 || rs.EnergyRateCentsPerKWh > 9.26 {
-		t.Errorf("expected energy rate cents ~9.254, got %v", rs.EnergyRateCentsPerKWh)
+	energyCents := floatOrZero(rs.EnergyRateCentsPerKWh)
+	if energyCents < 9.25 || energyCents > 9.26 {
+		t.Errorf("expected energy rate cents ~9.254, got %v", energyCents)
 	}
 }
@@ -1,28 +1,46 @@
 package rates
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func init() {
 	RegisterWaterParser(WaterParserConfig{
-		Key:       "whud",
-		Name:      "White House Utility District",
-		ParseHTML: ParseWHUDRatesFromURL,
+		Key:          "whud",
+		Name:         "White House Utility District",
+		ParseHTML:    ParseWHUDRatesFromURL,
+		ParseHTMLCtx: ParseWHUDRatesFromURLCtx,
 	})
 }
 
 // ParseWHUDRatesFromURL fetches the WHUD rates page and extracts water/sewer rates.
 func ParseWHUDRatesFromURL(url string) (*WaterRatesResponse, error) {
+	return ParseWHUDRatesFromURLCtx(context.Background(), url)
+}
+
+// ParseWHUDRatesFromURLCtx is ParseWHUDRatesFromURL's context-aware
+// counterpart: it drives the fetch's timeout entirely through ctx (via
+// http.NewRequestWithContext) rather than the client's blunt Timeout, so a
+// caller's deadline aborts an in-flight request instead of waiting out the
+// full 30s InsecureHTTPClient default.
+func ParseWHUDRatesFromURLCtx(ctx context.Context, url string) (*WaterRatesResponse, error) {
 	// WHUD server has a misconfigured SSL certificate chain (missing intermediate certs).
 	// We use an insecure client as a workaround.
-	client := InsecureHTTPClient()
-	resp, err := client.Get(url)
+	client := NewHTTPClient(0, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build WHUD rates page request: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("fetch WHUD rates page: %w", err)
 	}
@@ -64,9 +82,12 @@ func ParseWHUDRatesFromHTML(html string) (*WaterRatesResponse, error) {
 	waterUseRe := regexp.MustCompile(`Water\s+Use\s+Charge[^$]*\$([0-9.]+)/gallon`)
 	if match := waterUseRe.FindStringSubmatch(html); len(match) > 1 {
 		if rate, err := strconv.ParseFloat(match[1], 64); err == nil {
-			result.Water.UseRate = rate
+			result.Water.UseRate = &rate
 		}
 	}
+	if result.Water.UseRate == nil {
+		result.Warnings = append(result.Warnings, "water.use_rate")
+	}
 
 	// Extract meter base rates
 	// Pattern: "5/8" x 3/4" Meter $9.85" or similar
@@ -77,13 +98,15 @@ func ParseWHUDRatesFromHTML(html string) (*WaterRatesResponse, error) {
 
 	// Set default base charge from the standard residential meter
 	if rate, ok := result.Water.MeterSizes["5/8 x 3/4 inch"]; ok {
-		result.Water.BaseCharge = rate
+		result.Water.BaseCharge = &rate
 	} else if len(result.Water.MeterSizes) > 0 {
 		// Use the first/smallest meter size as default
 		for _, rate := range result.Water.MeterSizes {
-			result.Water.BaseCharge = rate
+			result.Water.BaseCharge = &rate
 			break
 		}
+	} else {
+		result.Warnings = append(result.Warnings, "water.base_charge")
 	}
 
 	// Extract sewer rates
@@ -98,7 +121,7 @@ func ParseWHUDRatesFromHTML(html string) (*WaterRatesResponse, error) {
 			if result.Sewer == nil {
 				result.Sewer = &SewerRateDetails{UseRateUnit: "gallon"}
 			}
-			result.Sewer.BaseCharge = rate
+			result.Sewer.BaseCharge = &rate
 		}
 	} else {
 		// Try simpler pattern: look in sewer section for base charge
@@ -110,20 +133,26 @@ func ParseWHUDRatesFromHTML(html string) (*WaterRatesResponse, error) {
 					if result.Sewer == nil {
 						result.Sewer = &SewerRateDetails{UseRateUnit: "gallon"}
 					}
-					result.Sewer.BaseCharge = rate
+					result.Sewer.BaseCharge = &rate
 				}
 			}
 		}
 	}
+	if result.Sewer != nil && result.Sewer.BaseCharge == nil {
+		result.Warnings = append(result.Warnings, "sewer.base_charge")
+	}
 
 	if match := sewerUseRe.FindStringSubmatch(html); len(match) > 1 {
 		if rate, err := strconv.ParseFloat(match[1], 64); err == nil {
 			if result.Sewer == nil {
 				result.Sewer = &SewerRateDetails{UseRateUnit: "gallon"}
 			}
-			result.Sewer.UseRate = rate
+			result.Sewer.UseRate = &rate
 		}
 	}
+	if result.Sewer != nil && result.Sewer.UseRate == nil {
+		result.Warnings = append(result.Warnings, "sewer.use_rate")
+	}
 
 	// Set effective date for sewer if we have the year
 	if result.Sewer != nil && result.Water.EffectiveDate != "" {
@@ -131,7 +160,7 @@ func ParseWHUDRatesFromHTML(html string) (*WaterRatesResponse, error) {
 	}
 
 	// Validate we got the essential rates
-	if result.Water.UseRate == 0 {
+	if result.Water.UseRate == nil {
 		return nil, fmt.Errorf("failed to parse water use rate from WHUD page")
 	}
 
@@ -173,8 +202,38 @@ func parseMeterRates(html string) map[string]float64 {
 	return rates
 }
 
-// extractSewerSection tries to extract just the sewer rates section from HTML
+// extractSewerSection finds the "... Sewer Rates" heading and returns the
+// text of every sibling element up to (but not including) the next <h2>,
+// using goquery rather than a single blind regex so the section boundary
+// follows the page's actual structure instead of hoping "Other Fees" (or
+// nothing) marks the end. Falls back to a plain regex over the raw HTML
+// when the document doesn't parse (e.g. a malformed fragment).
 func extractSewerSection(html string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return extractSewerSectionRegex(html)
+	}
+
+	var section strings.Builder
+	doc.Find("h2").EachWithBreak(func(_ int, h2 *goquery.Selection) bool {
+		if !strings.Contains(strings.ToLower(h2.Text()), "sewer") {
+			return true // keep looking
+		}
+		h2.NextUntil("h2").Each(func(_ int, s *goquery.Selection) {
+			section.WriteString(s.Text())
+		})
+		return false // found it, stop
+	})
+
+	if section.Len() == 0 {
+		return extractSewerSectionRegex(html)
+	}
+	return section.String()
+}
+
+// extractSewerSectionRegex is the fallback used when html isn't parseable
+// as a document; it mirrors the boundary extractSewerSection looks for.
+func extractSewerSectionRegex(html string) string {
 	sewerRe := regexp.MustCompile(`(?is)WHUD\s+Sewer\s+Rates(.+?)(?:Other\s+Fees|$)`)
 	if match := sewerRe.FindStringSubmatch(html); len(match) > 1 {
 		return match[1]
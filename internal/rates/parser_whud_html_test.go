@@ -39,8 +39,8 @@ func TestParseWHUDRatesFromHTML(t *testing.T) {
 	}
 
 	// Check water rates
-	if result.Water.UseRate != 0.00866 {
-		t.Errorf("expected water use rate 0.00866, got %f", result.Water.UseRate)
+	if got := floatOrZero(result.Water.UseRate); got != 0.00866 {
+		t.Errorf("expected water use rate 0.00866, got %f", got)
 	}
 	if result.Water.UseRateUnit != "gallon" {
 		t.Errorf("expected water use rate unit 'gallon', got %q", result.Water.UseRateUnit)
@@ -50,8 +50,8 @@ func TestParseWHUDRatesFromHTML(t *testing.T) {
 	}
 
 	// Check base charge for default meter
-	if result.Water.BaseCharge != 9.85 {
-		t.Errorf("expected base charge 9.85, got %f", result.Water.BaseCharge)
+	if got := floatOrZero(result.Water.BaseCharge); got != 9.85 {
+		t.Errorf("expected base charge 9.85, got %f", got)
 	}
 
 	// Check meter sizes
@@ -71,24 +71,24 @@ func TestParseWHUDRatesFromHTML(t *testing.T) {
 	if result.Sewer == nil {
 		t.Fatal("expected sewer rates to be parsed")
 	}
-	if result.Sewer.BaseCharge != 10.49 {
-		t.Errorf("expected sewer base charge 10.49, got %f", result.Sewer.BaseCharge)
+	if got := floatOrZero(result.Sewer.BaseCharge); got != 10.49 {
+		t.Errorf("expected sewer base charge 10.49, got %f", got)
 	}
-	if result.Sewer.UseRate != 0.011 {
-		t.Errorf("expected sewer use rate 0.011, got %f", result.Sewer.UseRate)
+	if got := floatOrZero(result.Sewer.UseRate); got != 0.011 {
+		t.Errorf("expected sewer use rate 0.011, got %f", got)
 	}
 }
 
 func TestCalculateWaterBill(t *testing.T) {
 	rates := &WaterRatesResponse{
 		Water: WaterRateDetails{
-			BaseCharge:  9.85,
-			UseRate:     0.00866,
+			BaseCharge:  ptrFloat(9.85),
+			UseRate:     ptrFloat(0.00866),
 			UseRateUnit: "gallon",
 		},
 		Sewer: &SewerRateDetails{
-			BaseCharge:  10.49,
-			UseRate:     0.011,
+			BaseCharge:  ptrFloat(10.49),
+			UseRate:     ptrFloat(0.011),
 			UseRateUnit: "gallon",
 		},
 	}
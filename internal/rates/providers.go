@@ -3,6 +3,8 @@ package rates
 import (
 	"encoding/json"
 	"os"
+	"sync"
+	"time"
 )
 
 // ProviderType indicates the utility type
@@ -21,11 +23,50 @@ type ProviderDescriptor struct {
 	Name           string       `json:"name"`
 	LandingURL     string       `json:"landingUrl"`
 	DefaultPDFPath string       `json:"defaultPdfPath,omitempty"`
-	Notes          string       `json:"notes,omitempty"`
+	// SourceURL is a direct PDF download URL, skipping landing-page discovery
+	// (DiscoverPDFURL). Optional: when empty, FetchProviderPDF falls back to
+	// discovery via LandingURL.
+	SourceURL string `json:"sourceUrl,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	// RefreshTimeout bounds how long /internal/refresh/{key} may spend
+	// discovering, downloading, and parsing this provider's PDF before the
+	// handler gives up and responds 504. Zero means DefaultRefreshTimeout.
+	RefreshTimeout time.Duration `json:"refreshTimeout,omitempty"`
 }
 
+// DefaultRefreshTimeout is used in place of ProviderDescriptor.RefreshTimeout
+// when it's unset (the zero value).
+const DefaultRefreshTimeout = 45 * time.Second
+
 const providersEnv = "ERATEMANAGER_PROVIDERS_JSON"
 
+// importedProvidersMu guards importedProviders, the set of descriptors
+// registered at runtime by RegisterImportedProvider (currently only
+// CSVTariffImporter) rather than coming from defaultProviders or
+// ERATEMANAGER_PROVIDERS_JSON.
+var (
+	importedProvidersMu sync.RWMutex
+	importedProviders   []ProviderDescriptor
+)
+
+// RegisterImportedProvider adds p to the set Providers() returns, without
+// requiring a code change or an ERATEMANAGER_PROVIDERS_JSON restart. It's
+// used by CSVTariffImporter so each imported tariff row becomes a provider
+// the API and cron worker can serve immediately. Registering the same key
+// twice replaces the earlier descriptor (e.g. re-importing an updated CSV).
+func RegisterImportedProvider(p ProviderDescriptor) {
+	importedProvidersMu.Lock()
+	defer importedProvidersMu.Unlock()
+
+	for i, existing := range importedProviders {
+		if existing.Key == p.Key {
+			importedProviders[i] = p
+			return
+		}
+	}
+	importedProviders = append(importedProviders, p)
+}
+
 func defaultProviders() []ProviderDescriptor {
 	return []ProviderDescriptor{
 		// Electric providers
@@ -65,15 +106,17 @@ func defaultProviders() []ProviderDescriptor {
 }
 
 func Providers() []ProviderDescriptor {
+	var out []ProviderDescriptor
 	raw := os.Getenv(providersEnv)
 	if raw == "" {
-		return withAPIURLs(defaultProviders())
+		out = withAPIURLs(defaultProviders())
+	} else if err := json.Unmarshal([]byte(raw), &out); err != nil || len(out) == 0 {
+		out = withAPIURLs(defaultProviders())
 	}
-	var out []ProviderDescriptor
-	if err := json.Unmarshal([]byte(raw), &out); err != nil || len(out) == 0 {
-		return withAPIURLs(defaultProviders())
-	}
-	return out
+
+	importedProvidersMu.RLock()
+	defer importedProvidersMu.RUnlock()
+	return append(out, importedProviders...)
 }
 
 // ElectricProviders returns only electric utility providers
@@ -114,7 +157,7 @@ func withAPIURLs(list []ProviderDescriptor) []ProviderDescriptor {
 			case ProviderTypeElectric:
 				list[i].PDFAPIURL = "/rates/" + list[i].Key + "/pdf"
 			case ProviderTypeWater:
-				list[i].HTMLAPIURL = "/water/rates/" + list[i].Key
+				list[i].HTMLAPIURL = "/rates/" + list[i].Key + "/water"
 			}
 		}
 	}
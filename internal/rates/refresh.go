@@ -0,0 +1,239 @@
+package rates
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// landingSnapshotKey is the storage.RatesSnapshot provider key a provider's
+// fetched landing page is cached under, namespaced the same way GetWater
+// namespaces water snapshots under "water:"+providerKey.
+func landingSnapshotKey(providerKey string) string {
+	return "landing:" + providerKey
+}
+
+// RefreshResult describes the outcome of Fetcher.RefreshProvider.
+type RefreshResult struct {
+	// Changed is true when the landing page's content hash differs from the
+	// last cached snapshot (or no snapshot existed yet). False means the
+	// caller can skip re-parsing.
+	Changed bool
+	// StatusCode is the HTTP status the landing page fetch returned (304 on
+	// an unchanged page, via conditional If-None-Match/If-Modified-Since).
+	StatusCode int
+}
+
+// Fetcher performs conditional, polite HTTP fetches of provider landing
+// pages: it honors ETag/Last-Modified validators cached in storage (so an
+// unchanged page costs a 304 instead of a full download), checks the host's
+// robots.txt before crawling, and retries transient failures with the same
+// full-jitter exponential backoff FetchProviderPDF uses.
+type Fetcher struct {
+	client *http.Client
+	store  storage.Storage
+}
+
+// NewFetcher returns a Fetcher that caches conditional-request validators
+// and content hashes via st. client may be nil, in which case
+// DefaultHTTPClient is used.
+func NewFetcher(client *http.Client, st storage.Storage) *Fetcher {
+	if client == nil {
+		client = DefaultHTTPClient()
+	}
+	return &Fetcher{client: client, store: st}
+}
+
+// RefreshProvider conditionally fetches p.LandingURL, skipping the request
+// entirely if robots.txt disallows it. On success it updates the cached
+// ETag/Last-Modified/content hash in storage, regardless of whether the
+// content changed, so the next call can send an accurate conditional
+// request.
+func (f *Fetcher) RefreshProvider(ctx context.Context, p ProviderDescriptor) (*RefreshResult, error) {
+	if p.LandingURL == "" {
+		return nil, fmt.Errorf("provider %q has no LandingURL to refresh", p.Key)
+	}
+
+	allowed, err := f.robotsAllowed(ctx, p.LandingURL)
+	if err != nil {
+		// robots.txt fetch failures shouldn't block a refresh: absent or
+		// unreachable robots.txt conventionally means "crawling is allowed".
+		allowed = true
+	}
+	if !allowed {
+		return nil, fmt.Errorf("refresh %s: robots.txt disallows %s", p.Key, p.LandingURL)
+	}
+
+	key := landingSnapshotKey(p.Key)
+	var prev *storage.RatesSnapshot
+	if f.store != nil {
+		prev, _ = f.store.GetRatesSnapshot(ctx, key)
+	}
+
+	started := time.Now()
+	result, err := f.fetchWithRetry(ctx, p.Key, p.LandingURL, prev)
+	dur := time.Since(started).Seconds()
+
+	outcome := "changed"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case result != nil && !result.Changed:
+		outcome = "not_modified"
+	}
+	metrics.RatesRefreshesTotal.WithLabelValues(p.Key, outcome).Inc()
+	metrics.RatesRefreshDurationSeconds.WithLabelValues(p.Key).Observe(dur)
+
+	return result, err
+}
+
+func (f *Fetcher) fetchWithRetry(ctx context.Context, providerKey, pageURL string, prev *storage.RatesSnapshot) (*RefreshResult, error) {
+	var lastErr error
+	for attempt := 0; attempt <= fetchMaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(fullJitterBackoff(attempt)):
+			}
+		}
+
+		result, retryable, err := f.refreshOnce(ctx, providerKey, pageURL, prev)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if !retryable {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("refresh %s: giving up after %d attempts: %w", pageURL, fetchMaxRetries+1, lastErr)
+}
+
+// refreshOnce performs a single conditional GET of pageURL. The bool return
+// reports whether the error (if any) is worth retrying.
+func (f *Fetcher) refreshOnce(ctx context.Context, providerKey, pageURL string, prev *storage.RatesSnapshot) (*RefreshResult, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("build request: %w", err)
+	}
+	if prev != nil {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("fetch landing page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &RefreshResult{Changed: false, StatusCode: resp.StatusCode}, false, nil
+	}
+	if resp.StatusCode >= 500 {
+		return nil, true, fmt.Errorf("landing page fetch: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false, fmt.Errorf("landing page fetch: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("read landing page body: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	changed := prev == nil || prev.ContentHash != hash
+
+	if f.store != nil {
+		snap := storage.RatesSnapshot{
+			Provider:     landingSnapshotKey(providerKey),
+			Payload:      body,
+			FetchedAt:    time.Now(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if err := f.store.SaveRatesSnapshot(ctx, snap); err != nil {
+			return nil, false, fmt.Errorf("save landing page snapshot: %w", err)
+		}
+	}
+
+	return &RefreshResult{Changed: changed, StatusCode: resp.StatusCode}, false, nil
+}
+
+// robotsAllowed fetches pageURL's host robots.txt and reports whether a
+// "User-agent: *" block disallows pageURL's path. A missing or unreachable
+// robots.txt, or one with no matching Disallow rule, allows crawling.
+func (f *Fetcher) robotsAllowed(ctx context.Context, pageURL string) (bool, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false, fmt.Errorf("parse url: %w", err)
+	}
+
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// No robots.txt (or it's unreadable): treat as "allow", the
+		// conventional interpretation for a missing robots.txt.
+		return true, nil
+	}
+
+	disallowed := parseRobotsDisallow(resp.Body)
+	for _, prefix := range disallowed {
+		if prefix != "" && strings.HasPrefix(u.Path, prefix) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseRobotsDisallow extracts every Disallow path under the first
+// "User-agent: *" block. It's a minimal robots.txt reader: no wildcard or
+// $-anchor support, just prefix matching, which is sufficient to avoid
+// crawling paths a provider has explicitly fenced off.
+func parseRobotsDisallow(r io.Reader) []string {
+	scanner := bufio.NewScanner(r)
+	var disallowed []string
+	inWildcardBlock := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "user-agent:"):
+			agent := strings.TrimSpace(line[len("user-agent:"):])
+			inWildcardBlock = agent == "*"
+		case inWildcardBlock && strings.HasPrefix(lower, "disallow:"):
+			path := strings.TrimSpace(line[len("disallow:"):])
+			disallowed = append(disallowed, path)
+		}
+	}
+	return disallowed
+}
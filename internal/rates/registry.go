@@ -1,9 +1,11 @@
 package rates
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 )
 
 // ParserFunc is a function that parses a PDF file and returns rates.
@@ -12,6 +14,11 @@ type ParserFunc func(path string) (*RatesResponse, error)
 // TextParserFunc is a function that parses extracted PDF text and returns rates.
 type TextParserFunc func(text string) (*RatesResponse, error)
 
+// ParserCtxFunc is ParsePDF's context-aware counterpart, for parsers whose
+// work is worth bounding by a deadline (e.g. one that fetches its PDF over
+// HTTP rather than reading it off local disk).
+type ParserCtxFunc func(ctx context.Context, path string) (*RatesResponse, error)
+
 // ParserConfig holds the configuration for a provider's parser.
 type ParserConfig struct {
 	// Key is the unique identifier for this provider (e.g., "cemc", "nes").
@@ -23,8 +30,76 @@ type ParserConfig struct {
 	// ParsePDF parses a PDF file at the given path.
 	ParsePDF ParserFunc
 
+	// ParsePDFCtx is ParsePDF's context-aware counterpart. It's optional:
+	// providers that parse a local file have no I/O worth canceling and
+	// leave it nil, so FetchPDF falls back to racing ParsePDF against ctx on
+	// a best-effort basis (see softCancelPDF).
+	ParsePDFCtx ParserCtxFunc
+
 	// ParseText parses extracted text from a PDF (useful for testing).
 	ParseText TextParserFunc
+
+	// Extractor is the PDFExtractor this provider's PDF parser reads pages
+	// through. Nil means NewLedongthucTextExtractor(), the plain-text-only
+	// behavior every parser had before table extraction existed.
+	Extractor PDFExtractor
+}
+
+// FetchPDF runs cfg's parser against path, honoring ctx. When cfg.ParsePDFCtx
+// is set it's used directly; otherwise the legacy cfg.ParsePDF is raced
+// against ctx via softCancelPDF.
+func (cfg ParserConfig) FetchPDF(ctx context.Context, path string) (*RatesResponse, error) {
+	if cfg.ParsePDFCtx != nil {
+		return cfg.ParsePDFCtx(ctx, path)
+	}
+	return softCancelPDF(ctx, cfg.ParsePDF, path)
+}
+
+// softCancelPDF starts fn on its own goroutine and races it against ctx
+// using a deadlineTimer armed for the remainder of ctx's deadline (or
+// ctx.Done() directly when ctx has no deadline). If ctx wins, softCancelPDF
+// returns ctx.Err() immediately; fn's goroutine is left to finish on its own
+// and its result, if any, is discarded.
+func softCancelPDF(ctx context.Context, fn ParserFunc, path string) (*RatesResponse, error) {
+	type result struct {
+		resp *RatesResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := fn(path)
+		done <- result{resp, err}
+	}()
+
+	if dl, ok := ctx.Deadline(); ok {
+		timer := newDeadlineTimer(time.Until(dl))
+		defer timer.cancel()
+		select {
+		case r := <-done:
+			return r.resp, r.err
+		case <-timer.readCancel():
+			return nil, ctx.Err()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// extractorFor returns key's configured Extractor, defaulting to
+// NewLedongthucTextExtractor() when the provider hasn't registered one or
+// isn't registered at all.
+func extractorFor(key string) PDFExtractor {
+	if cfg, ok := GetParser(key); ok && cfg.Extractor != nil {
+		return cfg.Extractor
+	}
+	return NewLedongthucTextExtractor()
 }
 
 var (
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"time"
 
+	"github.com/bher20/eratemanager/internal/logging"
 	"github.com/bher20/eratemanager/internal/storage"
 )
 
@@ -17,12 +19,20 @@ type Config struct {
 	// PDFPaths allows overriding PDF paths per provider key.
 	// If empty, uses the provider's DefaultPDFPath from the registry.
 	PDFPaths map[string]string
+
+	// CacheDir is where FetchProviderPDF downloads provider PDFs to, keyed
+	// by provider key (e.g. "<CacheDir>/cemc.pdf"). Defaults to os.TempDir()
+	// when empty.
+	CacheDir string
 }
 
 // Service coordinates fetching and caching of rates.
 type Service struct {
-	cfg   Config
-	store storage.Storage // may be nil for PDF-only mode
+	cfg     Config
+	store   storage.Storage // may be nil for PDF-only mode
+	sink    Sink            // may be nil; set via SetSink
+	water   *WaterService   // lazily built by GetWater, shares store
+	fetcher *Fetcher        // lazily built by RefreshLandingPage, shares store
 }
 
 // NewService preserves the original API: PDF-only, no storage caching.
@@ -36,10 +46,31 @@ func NewServiceWithStorage(cfg Config, st storage.Storage) *Service {
 	return &Service{cfg: cfg, store: st}
 }
 
+// SetSink registers sink as the destination for snapshot publish events,
+// fired after each successful write to storage. Passing nil disables
+// publishing.
+func (s *Service) SetSink(sink Sink) {
+	s.sink = sink
+}
+
+// publish best-effort delivers snap to the configured sink, logging (never
+// returning) failures so a slow or dead sink can't affect the write path.
+func (s *Service) publish(ctx context.Context, snap storage.RatesSnapshot) {
+	if s.sink == nil {
+		return
+	}
+	if err := s.sink.Publish(ctx, snap); err != nil {
+		log.Printf("rates: sink publish for %s failed: %v", snap.Provider, err)
+	}
+}
+
 // GetResidential returns the residential rate structure based on provider key.
 // It consults persistent storage first; on cache miss it parses PDFs and
 // writes a new snapshot.
 func (s *Service) GetResidential(ctx context.Context, provider string) (*RatesResponse, error) {
+	l := logging.WithRequestID(logging.WithProvider(logging.Default(), provider), logging.RequestIDFromContext(ctx))
+	l.DebugContext(ctx, "get residential rates")
+
 	// Use the registry to find the parser
 	parser, ok := GetParser(provider)
 	if !ok {
@@ -47,10 +78,115 @@ func (s *Service) GetResidential(ctx context.Context, provider string) (*RatesRe
 	}
 
 	loader := func() (*RatesResponse, error) {
-		return s.parseProviderPDF(provider, parser)
+		return s.parseProviderPDF(ctx, provider, parser)
 	}
 
-	return s.getProviderRates(ctx, provider, loader)
+	resp, err := s.getProviderRates(ctx, provider, loader)
+	if err != nil {
+		l.ErrorContext(ctx, "get residential rates failed", "error", err)
+	}
+	return resp, err
+}
+
+// GetResidentialAsOf returns the residential rate structure in effect at
+// instant t, reconstructed from the stored snapshot history rather than the
+// latest cached snapshot. It requires a storage backend that implements
+// storage.SnapshotHistory (SQLiteStorage, PostgresStorage); other backends
+// only retain the latest snapshot and can't answer "as of" queries.
+func (s *Service) GetResidentialAsOf(ctx context.Context, provider string, t time.Time) (*RatesResponse, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("rates: no storage backend configured for historical queries")
+	}
+	hist, ok := s.store.(storage.SnapshotHistory)
+	if !ok {
+		return nil, fmt.Errorf("rates: storage backend does not retain snapshot history")
+	}
+
+	snap, err := hist.GetRatesSnapshotAt(ctx, provider, t)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot for %s as of %s: %w", provider, t, err)
+	}
+	if snap == nil {
+		return nil, nil
+	}
+
+	var resp RatesResponse
+	if err := json.Unmarshal(snap.Payload, &resp); err != nil {
+		return nil, fmt.Errorf("decode historical snapshot for %s: %w", provider, err)
+	}
+	return &resp, nil
+}
+
+// ListResidentialHistory returns provider's full snapshot history, newest
+// first, via the same storage.SnapshotHistory backend GetResidentialAsOf
+// uses, optionally bounded by since/until/limit (zero means unbounded).
+func (s *Service) ListResidentialHistory(ctx context.Context, provider string, since, until time.Time, limit int) ([]storage.RatesSnapshot, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("rates: no storage backend configured for historical queries")
+	}
+	hist, ok := s.store.(storage.SnapshotHistory)
+	if !ok {
+		return nil, fmt.Errorf("rates: storage backend does not retain snapshot history")
+	}
+	return hist.ListRatesSnapshots(ctx, provider, since, until, limit)
+}
+
+// GetWater returns water/sewer rates for a provider, consulting the
+// "water:"-prefixed storage snapshot before falling back to the provider's
+// registered WaterParser. It shares the Service's storage backend so water
+// snapshots live alongside electric ones, distinguished only by key prefix.
+func (s *Service) GetWater(ctx context.Context, providerKey string) (*WaterRatesResponse, error) {
+	l := logging.WithRequestID(logging.WithProvider(logging.Default(), providerKey), logging.RequestIDFromContext(ctx))
+	l.DebugContext(ctx, "get water rates")
+
+	if _, ok := GetWaterParser(providerKey); !ok {
+		return nil, fmt.Errorf("unknown water provider: %s (no parser registered)", providerKey)
+	}
+	if s.water == nil {
+		s.water = NewWaterServiceWithStorage(s.store)
+	}
+
+	resp, err := s.water.GetWaterRates(ctx, providerKey)
+	if err != nil {
+		l.ErrorContext(ctx, "get water rates failed", "error", err)
+	}
+	return resp, err
+}
+
+// GetWaterAsOf returns water/sewer rates for a provider as of instant t,
+// delegating to WaterService.GetWaterRatesAsOf the same way GetWater
+// delegates to GetWaterRates.
+func (s *Service) GetWaterAsOf(ctx context.Context, providerKey string, t time.Time) (*WaterRatesResponse, error) {
+	if _, ok := GetWaterParser(providerKey); !ok {
+		return nil, fmt.Errorf("unknown water provider: %s (no parser registered)", providerKey)
+	}
+	if s.water == nil {
+		s.water = NewWaterServiceWithStorage(s.store)
+	}
+	return s.water.GetWaterRatesAsOf(ctx, providerKey, t)
+}
+
+// RefreshLandingPage conditionally fetches provider's landing page (honoring
+// cached ETag/Last-Modified so an unchanged page costs a 304), reporting
+// whether its content actually changed since the last refresh. Callers (the
+// cron batch job, the /rates/{provider}/refresh endpoint) use Changed to
+// decide whether a re-parse is worthwhile.
+func (s *Service) RefreshLandingPage(ctx context.Context, providerKey string) (*RefreshResult, error) {
+	p, ok := GetProvider(providerKey)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %s", providerKey)
+	}
+
+	l := logging.WithRequestID(logging.WithProvider(logging.Default(), providerKey), logging.RequestIDFromContext(ctx))
+	if s.fetcher == nil {
+		s.fetcher = NewFetcher(nil, s.store)
+	}
+
+	result, err := s.fetcher.RefreshProvider(ctx, p)
+	if err != nil {
+		l.ErrorContext(ctx, "refresh landing page failed", "error", err)
+	}
+	return result, err
 }
 
 // getProviderRates is a small helper that tries storage first, then falls back
@@ -87,19 +223,24 @@ func (s *Service) getProviderRates(
 	// Best-effort write-back to storage.
 	if s.store != nil {
 		if payload, err := json.Marshal(resp); err == nil {
-			_ = s.store.SaveRatesSnapshot(ctx, storage.RatesSnapshot{
+			snap := storage.RatesSnapshot{
 				Provider:  key,
 				Payload:   payload,
 				FetchedAt: resp.FetchedAt,
-			})
+			}
+			_ = s.store.SaveRatesSnapshot(ctx, snap)
+			s.publish(ctx, snap)
 		}
 	}
 
 	return resp, nil
 }
 
-// parseProviderPDF is a generic PDF loader that uses the registry.
-func (s *Service) parseProviderPDF(providerKey string, parser ParserConfig) (*RatesResponse, error) {
+// parseProviderPDF is a generic PDF loader that uses the registry. The
+// parse itself is bounded by defaultFetchTimeout so a provider's ParsePDFCtx
+// (or the softCancelPDF fallback for providers that only implement the
+// legacy ParsePDF) can't stall a refresh indefinitely.
+func (s *Service) parseProviderPDF(ctx context.Context, providerKey string, parser ParserConfig) (*RatesResponse, error) {
 	// Check for override in config
 	path := ""
 	if s.cfg.PDFPaths != nil {
@@ -121,7 +262,9 @@ func (s *Service) parseProviderPDF(providerKey string, parser ParserConfig) (*Ra
 		return nil, fmt.Errorf("%s PDF not found at %s: %w", providerKey, path, err)
 	}
 
-	return parser.ParsePDF(path)
+	fetchCtx, cancel := context.WithTimeout(ctx, defaultFetchTimeout)
+	defer cancel()
+	return parser.FetchPDF(fetchCtx, path)
 }
 
 // ForceRefresh bypasses the cache and forces a fresh PDF parse for a provider.
@@ -133,7 +276,7 @@ func (s *Service) ForceRefresh(ctx context.Context, provider string) (*RatesResp
 		return nil, fmt.Errorf("unknown provider: %s (no parser registered)", provider)
 	}
 
-	resp, err := s.parseProviderPDF(provider, parser)
+	resp, err := s.parseProviderPDF(ctx, provider, parser)
 	if err != nil {
 		return nil, err
 	}
@@ -147,11 +290,13 @@ func (s *Service) ForceRefresh(ctx context.Context, provider string) (*RatesResp
 	// Write-back to storage (best-effort)
 	if s.store != nil {
 		if payload, err := json.Marshal(resp); err == nil {
-			_ = s.store.SaveRatesSnapshot(ctx, storage.RatesSnapshot{
+			snap := storage.RatesSnapshot{
 				Provider:  provider,
 				Payload:   payload,
 				FetchedAt: resp.FetchedAt,
-			})
+			}
+			_ = s.store.SaveRatesSnapshot(ctx, snap)
+			s.publish(ctx, snap)
 		}
 	}
 
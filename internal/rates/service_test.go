@@ -3,6 +3,7 @@ package rates
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 // TestParseCEMCRatesFromText_Basic tests the CEMC parser with sample text.
@@ -27,7 +28,7 @@ TVA Fuel Charge: 0.02 per kWh
 	if !rs.IsPresent {
 		t.Errorf("expected residential_standard to be present")
 	}
-	if rs.CustomerChargeMonthlyUSD != 39.0 {
+	if floatOrZero(rs.CustomerChargeMonthlyUSD) != 39.0 {
 		t.Errorf("unexpected customer charge: %v", rs.CustomerChargeMonthlyUSD)
 	}
 }
@@ -69,3 +70,15 @@ func TestGetResidential_UnknownProvider(t *testing.T) {
 		t.Fatalf("expected error for unknown provider")
 	}
 }
+
+// TestGetResidentialAsOf_NoStorage ensures historical queries fail clearly
+// when the service wasn't built with a storage backend, since "as of"
+// lookups have nothing to replay history from.
+func TestGetResidentialAsOf_NoStorage(t *testing.T) {
+	svc := NewService(Config{})
+	ctx := context.Background()
+
+	if _, err := svc.GetResidentialAsOf(ctx, "cemc", time.Now()); err == nil {
+		t.Fatalf("expected error when no storage backend is configured")
+	}
+}
@@ -0,0 +1,36 @@
+package rates
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// FileSink writes each snapshot's payload to
+// "<BaseDir>/<provider>/<timestamp>.json" using writeFileAtomically, for
+// local/dev use or simple file-based consumers.
+type FileSink struct {
+	baseDir string
+}
+
+// NewFileSink returns a FileSink rooted at baseDir.
+func NewFileSink(baseDir string) *FileSink {
+	return &FileSink{baseDir: baseDir}
+}
+
+// Publish atomically writes snap's payload to disk.
+func (f *FileSink) Publish(ctx context.Context, snap storage.RatesSnapshot) error {
+	fetched := snap.FetchedAt
+	if fetched.IsZero() {
+		fetched = time.Now()
+	}
+	path := filepath.Join(f.baseDir, snap.Provider, fetched.Format("20060102-150405")+".json")
+	if err := writeFileAtomically(path, bytes.NewReader(snap.Payload)); err != nil {
+		return fmt.Errorf("file sink: write %s: %w", path, err)
+	}
+	return nil
+}
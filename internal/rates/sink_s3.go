@@ -0,0 +1,41 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// S3Sink writes each snapshot's payload as a JSON object to an S3-compatible
+// object store (AWS S3, MinIO), keyed by provider and fetch time so
+// downstream consumers can browse or replay history without touching the DB.
+type S3Sink struct {
+	blob storage.BlobStore
+}
+
+// NewS3Sink connects to the S3-compatible endpoint described by cfg.
+func NewS3Sink(cfg storage.BlobConfig) (*S3Sink, error) {
+	cfg.Driver = "s3"
+	blob, err := storage.OpenBlobStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Sink{blob: blob}, nil
+}
+
+// Publish uploads snap's payload under "<provider>/YYYY/MM/DD/HHMMSS.json".
+func (s *S3Sink) Publish(ctx context.Context, snap storage.RatesSnapshot) error {
+	fetched := snap.FetchedAt
+	if fetched.IsZero() {
+		fetched = time.Now()
+	}
+	key := fmt.Sprintf("%s/%04d/%02d/%02d/%02d%02d%02d.json",
+		snap.Provider, fetched.Year(), fetched.Month(), fetched.Day(),
+		fetched.Hour(), fetched.Minute(), fetched.Second())
+	if _, err := s.blob.Put(ctx, key, snap.Payload); err != nil {
+		return fmt.Errorf("s3 sink: put %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package rates
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+	"github.com/bher20/eratemanager/internal/webhooks"
+)
+
+// WebhookSink POSTs each snapshot's payload to a configured endpoint,
+// signing the body with HMAC-SHA256 (X-ERM-Signature, same scheme as
+// internal/webhooks) so subscribers can verify authenticity, and retrying
+// transient failures with exponential backoff.
+type WebhookSink struct {
+	client      *http.Client
+	url         string
+	secret      string
+	maxAttempts int
+	baseBackoff time.Duration
+}
+
+// NewWebhookSink returns a WebhookSink that POSTs to url, signing each
+// request body with secret. maxAttempts defaults to 4 and baseBackoff to
+// 500ms when zero.
+func NewWebhookSink(url, secret string, maxAttempts int, baseBackoff time.Duration) *WebhookSink {
+	if maxAttempts <= 0 {
+		maxAttempts = 4
+	}
+	if baseBackoff <= 0 {
+		baseBackoff = 500 * time.Millisecond
+	}
+	return &WebhookSink{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		url:         url,
+		secret:      secret,
+		maxAttempts: maxAttempts,
+		baseBackoff: baseBackoff,
+	}
+}
+
+// Publish POSTs snap's payload to the configured endpoint, retrying with
+// exponential backoff until maxAttempts is reached.
+func (w *WebhookSink) Publish(ctx context.Context, snap storage.RatesSnapshot) error {
+	var lastErr error
+	for attempt := 1; attempt <= w.maxAttempts; attempt++ {
+		if err := w.deliverOnce(ctx, snap.Payload); err != nil {
+			lastErr = err
+			if attempt == w.maxAttempts {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(webhooks.Backoff(attempt, w.baseBackoff, 0)):
+			}
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("webhook sink: giving up after %d attempts: %w", w.maxAttempts, lastErr)
+}
+
+func (w *WebhookSink) deliverOnce(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-ERM-Signature", webhooks.Sign(w.secret, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook sink returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package rates
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// Sink publishes a rates snapshot to an external system after it's written
+// to storage, letting downstream consumers react to rate changes without
+// polling the DB. Implementations must be safe to ignore context
+// cancellation gracefully: Publish may be called from the request path.
+type Sink interface {
+	Publish(ctx context.Context, snap storage.RatesSnapshot) error
+}
+
+// MultiSink fans a snapshot out to every registered Sink.
+type MultiSink struct {
+	sinks []Sink
+}
+
+// NewMultiSink returns a MultiSink that publishes to each non-nil sink.
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	m := &MultiSink{}
+	for _, s := range sinks {
+		if s != nil {
+			m.sinks = append(m.sinks, s)
+		}
+	}
+	return m
+}
+
+// Publish fans out to every registered sink, continuing past individual
+// failures and returning a combined error describing all of them (nil if
+// every sink succeeded).
+func (m *MultiSink) Publish(ctx context.Context, snap storage.RatesSnapshot) error {
+	var errs []error
+	for _, s := range m.sinks {
+		if err := s.Publish(ctx, snap); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package rates
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// SinksFromEnv builds the set of output sinks configured via
+// ERATEMANAGER_SINK_* environment variables, returning (nil, nil) when none
+// are configured. It's called once at startup by api.NewMux and cron.Run so
+// the HTTP server and the cron worker publish snapshots to the same sinks.
+func SinksFromEnv() (Sink, error) {
+	var sinks []Sink
+
+	if url := os.Getenv("ERATEMANAGER_SINK_WEBHOOK_URL"); url != "" {
+		maxAttempts := 0
+		if v := os.Getenv("ERATEMANAGER_SINK_WEBHOOK_MAX_ATTEMPTS"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				maxAttempts = n
+			}
+		}
+		sinks = append(sinks, NewWebhookSink(url, os.Getenv("ERATEMANAGER_SINK_WEBHOOK_SECRET"), maxAttempts, 0))
+	}
+
+	if bucket := os.Getenv("ERATEMANAGER_SINK_S3_BUCKET"); bucket != "" {
+		s3Sink, err := NewS3Sink(storage.BlobConfig{
+			Endpoint:  os.Getenv("ERATEMANAGER_SINK_S3_ENDPOINT"),
+			Bucket:    bucket,
+			AccessKey: os.Getenv("ERATEMANAGER_SINK_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("ERATEMANAGER_SINK_S3_SECRET_KEY"),
+			UseSSL:    strings.ToLower(os.Getenv("ERATEMANAGER_SINK_S3_USE_SSL")) == "true",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("sinks: open s3 sink: %w", err)
+		}
+		sinks = append(sinks, s3Sink)
+	}
+
+	if dir := os.Getenv("ERATEMANAGER_SINK_FILE_DIR"); dir != "" {
+		sinks = append(sinks, NewFileSink(dir))
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return NewMultiSink(sinks...), nil
+}
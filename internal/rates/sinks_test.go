@@ -0,0 +1,91 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+	"github.com/bher20/eratemanager/internal/webhooks"
+)
+
+type fakeSink struct {
+	published []storage.RatesSnapshot
+	err       error
+}
+
+func (f *fakeSink) Publish(ctx context.Context, snap storage.RatesSnapshot) error {
+	f.published = append(f.published, snap)
+	return f.err
+}
+
+func TestMultiSink_FansOutAndJoinsErrors(t *testing.T) {
+	ok1 := &fakeSink{}
+	failing := &fakeSink{err: errors.New("boom")}
+	ok2 := &fakeSink{}
+
+	m := NewMultiSink(ok1, failing, ok2, nil)
+	snap := storage.RatesSnapshot{Provider: "cemc", Payload: []byte(`{}`)}
+
+	err := m.Publish(context.Background(), snap)
+	if err == nil {
+		t.Fatalf("expected combined error from failing sink")
+	}
+	for _, s := range []*fakeSink{ok1, failing, ok2} {
+		if len(s.published) != 1 || s.published[0].Provider != "cemc" {
+			t.Errorf("expected sink to receive snapshot, got %+v", s.published)
+		}
+	}
+}
+
+func TestFileSink_Publish(t *testing.T) {
+	dir := t.TempDir()
+	sink := NewFileSink(dir)
+
+	fetched := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	snap := storage.RatesSnapshot{
+		Provider:  "cemc",
+		Payload:   []byte(`{"utility":"CEMC"}`),
+		FetchedAt: fetched,
+	}
+
+	if err := sink.Publish(context.Background(), snap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := filepath.Join(dir, "cemc", "20260102-030405.json")
+	data, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("expected written file at %s: %v", want, err)
+	}
+	if string(data) != string(snap.Payload) {
+		t.Errorf("unexpected file contents: %s", data)
+	}
+}
+
+func TestWebhookSink_SignsAndDelivers(t *testing.T) {
+	var gotSig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-ERM-Signature")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, "s3cr3t", 1, 0)
+	snap := storage.RatesSnapshot{Provider: "cemc", Payload: []byte(`{"utility":"CEMC"}`)}
+
+	if err := sink.Publish(context.Background(), snap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotSig == "" {
+		t.Fatalf("expected X-ERM-Signature header to be set")
+	}
+	if want := webhooks.Sign("s3cr3t", snap.Payload); gotSig != want {
+		t.Errorf("unexpected signature: got %q want %q", gotSig, want)
+	}
+}
@@ -0,0 +1,71 @@
+package rates
+
+// TextSpan is a single piece of text extracted from a PDF page along with
+// its position, used by layout-aware extraction strategies.
+type TextSpan struct {
+	X, Y     float64
+	FontSize float64
+	S        string
+}
+
+// PageText holds the text spans extracted from one PDF page.
+type PageText struct {
+	Page  int
+	Spans []TextSpan
+}
+
+// Extracted holds the residential-rate fields a Strategy was able to read
+// from a provider's PDF.
+type Extracted struct {
+	CustomerChargeMonthlyUSD float64
+	EnergyRateUSDPerKWh      float64
+	TVAFuelRateUSDPerKWh     float64
+	RawSection               string
+}
+
+// IsZero reports whether none of the rate fields were populated, i.e. the
+// strategy found nothing worth keeping.
+func (e Extracted) IsZero() bool {
+	return e.CustomerChargeMonthlyUSD == 0 && e.EnergyRateUSDPerKWh == 0 && e.TVAFuelRateUSDPerKWh == 0
+}
+
+// Strategy extracts residential rate fields from a PDF's pages, reporting a
+// confidence in [0,1] so callers can pick among several competing attempts
+// when a provider's layout doesn't match what the parser expects.
+type Strategy interface {
+	Extract(pages []PageText) (Extracted, float64, error)
+}
+
+// runStrategies runs strategies in order against pages and returns the
+// highest-confidence non-zero result. A strategy that errors or returns a
+// zero-valued Extracted is skipped rather than failing the whole pipeline,
+// since a layout change should degrade gracefully instead of crashing.
+func runStrategies(pages []PageText, strategies []Strategy) (Extracted, float64) {
+	var best Extracted
+	var bestConfidence float64
+	for _, strat := range strategies {
+		extracted, confidence, err := strat.Extract(pages)
+		if err != nil || extracted.IsZero() {
+			continue
+		}
+		if confidence > bestConfidence {
+			best = extracted
+			bestConfidence = confidence
+		}
+	}
+	return best, bestConfidence
+}
+
+// joinPageText concatenates every span's text across pages, in order, to
+// reconstruct a whole-document plain-text view for strategies that work on
+// raw text rather than layout.
+func joinPageText(pages []PageText) string {
+	var out []byte
+	for _, p := range pages {
+		for _, span := range p.Spans {
+			out = append(out, span.S...)
+			out = append(out, '\n')
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,343 @@
+package rates
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/storage"
+)
+
+// CSVTariffImporter ingests tariff schedules from CSV files following the
+// structure used by public tariff datasets (OpenEI URDB-style): one row per
+// tariff, with a header naming each column. Recognized columns:
+//
+//	utility, sector, effective_date, end_date          (strings; dates as YYYY-MM-DD)
+//	fixed_monthly_charge, demand_charge_usd_per_kw,
+//	fuel_adjustment_usd_per_kwh                        (floats)
+//	rate1..rateN, max_kwh1..max_kwhN                   (tiered energy rate; rateN's upper bound is max_kwhN)
+//	weekday_schedule, weekend_schedule                 (24-char strings, one TOU period digit per hour)
+//	period_rate_0..period_rate_9                       ($/kWh for each TOU period referenced by the schedules)
+//
+// Every row becomes both a RatesResponse (returned, and saved to storage if
+// configured) and a ProviderDescriptor registered via
+// RegisterImportedProvider, so the row is immediately servable at
+// /rates/{provider}/residential without a per-provider Go parser.
+type CSVTariffImporter struct {
+	store storage.Storage // may be nil: rows are parsed and registered, not persisted
+}
+
+// NewCSVTariffImporter returns an importer that doesn't persist rows to storage.
+func NewCSVTariffImporter() *CSVTariffImporter {
+	return &CSVTariffImporter{}
+}
+
+// NewCSVTariffImporterWithStorage returns an importer that, after parsing
+// each row, also upserts a storage.Provider and saves an initial
+// RatesSnapshot through st.
+func NewCSVTariffImporterWithStorage(st storage.Storage) *CSVTariffImporter {
+	return &CSVTariffImporter{store: st}
+}
+
+// ImportFile opens path and imports it; see ImportReader.
+func (imp *CSVTariffImporter) ImportFile(ctx context.Context, path string) ([]RatesResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open tariff csv %s: %w", path, err)
+	}
+	defer f.Close()
+	return imp.ImportReader(ctx, f)
+}
+
+// ImportReader parses every row of r as one tariff, registers a provider for
+// it, and (when the importer has a storage backend) persists it. It returns
+// the parsed RatesResponse for every row, in file order, even if persistence
+// of an individual row fails (errors for those rows are joined and returned
+// alongside the otherwise-successful results).
+func (imp *CSVTariffImporter) ImportReader(ctx context.Context, r io.Reader) ([]RatesResponse, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read tariff csv header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := col["utility"]; !ok {
+		return nil, fmt.Errorf("tariff csv missing required %q column", "utility")
+	}
+
+	var (
+		results []RatesResponse
+		errs    []string
+		rowNum  = 1 // header was row 1
+	)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNum++
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("row %d: %v", rowNum, err))
+			continue
+		}
+
+		resp, provider, err := parseTariffRow(header, col, row)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("row %d: %v", rowNum, err))
+			continue
+		}
+
+		RegisterImportedProvider(provider)
+		results = append(results, *resp)
+
+		if imp.store == nil {
+			continue
+		}
+		if err := imp.store.UpsertProvider(ctx, storage.Provider{
+			Key:  provider.Key,
+			Name: provider.Name,
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("row %d: upsert provider %s: %v", rowNum, provider.Key, err))
+			continue
+		}
+		if err := saveTariffSnapshot(ctx, imp.store, provider.Key, resp); err != nil {
+			errs = append(errs, fmt.Sprintf("row %d: save snapshot for %s: %v", rowNum, provider.Key, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return results, fmt.Errorf("tariff csv import: %s", strings.Join(errs, "; "))
+	}
+	return results, nil
+}
+
+func saveTariffSnapshot(ctx context.Context, st storage.Storage, providerKey string, resp *RatesResponse) error {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return st.SaveRatesSnapshot(ctx, storage.RatesSnapshot{
+		Provider:  providerKey,
+		Payload:   payload,
+		FetchedAt: resp.FetchedAt,
+	})
+}
+
+var tierColRe = regexp.MustCompile(`^rate(\d+)$`)
+var periodRateColRe = regexp.MustCompile(`^period_rate_(\d+)$`)
+
+// parseTariffRow builds a RatesResponse and its matching ProviderDescriptor
+// from one CSV row. col maps lowercased header names to their column index.
+func parseTariffRow(header []string, col map[string]int, row []string) (*RatesResponse, ProviderDescriptor, error) {
+	get := func(name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	utility := get("utility")
+	if utility == "" {
+		return nil, ProviderDescriptor{}, fmt.Errorf("empty %q value", "utility")
+	}
+	key := slugifyProviderKey(utility)
+
+	resp := &RatesResponse{
+		Utility:   utility,
+		Source:    "csv_tariff_import",
+		FetchedAt: time.Now(),
+		Sector:    get("sector"),
+	}
+
+	var parseErr error
+	resp.FixedMonthlyChargeUSD = parseFloatField(get("fixed_monthly_charge"), &parseErr)
+	resp.DemandChargeUSDPerKW = parseFloatField(get("demand_charge_usd_per_kw"), &parseErr)
+	resp.FuelAdjustmentUSDPerKWh = parseFloatField(get("fuel_adjustment_usd_per_kwh"), &parseErr)
+	resp.EffectiveDate = parseDateField(get("effective_date"), &parseErr)
+	resp.EndDate = parseDateField(get("end_date"), &parseErr)
+	if parseErr != nil {
+		return nil, ProviderDescriptor{}, parseErr
+	}
+
+	tiers, err := parseTieredRates(col, row)
+	if err != nil {
+		return nil, ProviderDescriptor{}, err
+	}
+	resp.TieredRates = tiers
+	if len(tiers) > 0 {
+		resp.Rates.ResidentialStandard.IsPresent = true
+		firstTierRate := tiers[0].RateUSDPerKWh
+		resp.Rates.ResidentialStandard.EnergyRateUSDPerKWh = &firstTierRate
+	}
+
+	tou, err := parseTOUSchedule(col, row, get)
+	if err != nil {
+		return nil, ProviderDescriptor{}, err
+	}
+	resp.TOU = tou
+
+	provider := ProviderDescriptor{
+		Key:   key,
+		Type:  ProviderTypeElectric,
+		Name:  utility,
+		Notes: "Imported from CSV tariff row" + sectorSuffix(resp.Sector),
+	}
+	return resp, provider, nil
+}
+
+func sectorSuffix(sector string) string {
+	if sector == "" {
+		return ""
+	}
+	return " (" + sector + ")"
+}
+
+func parseFloatField(raw string, errOut *error) float64 {
+	if raw == "" || *errOut != nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		*errOut = fmt.Errorf("invalid float %q: %w", raw, err)
+		return 0
+	}
+	return v
+}
+
+func parseDateField(raw string, errOut *error) *time.Time {
+	if raw == "" || *errOut != nil {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		*errOut = fmt.Errorf("invalid date %q (want YYYY-MM-DD): %w", raw, err)
+		return nil
+	}
+	return &t
+}
+
+// parseTieredRates collects every rateN/max_kwhN column pair present in the
+// header, in ascending N order, skipping tiers whose rateN is blank.
+func parseTieredRates(col map[string]int, row []string) ([]TieredRate, error) {
+	type indexed struct {
+		n    int
+		tier TieredRate
+	}
+	var found []indexed
+
+	for name, i := range col {
+		m := tierColRe.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		if i >= len(row) || strings.TrimSpace(row[i]) == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, row[i], err)
+		}
+		var maxKWh float64
+		if mi, ok := col[fmt.Sprintf("max_kwh%d", n)]; ok && mi < len(row) && strings.TrimSpace(row[mi]) != "" {
+			maxKWh, err = strconv.ParseFloat(strings.TrimSpace(row[mi]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max_kwh%d %q: %w", n, row[mi], err)
+			}
+		}
+		found = append(found, indexed{n: n, tier: TieredRate{MaxKWh: maxKWh, RateUSDPerKWh: rate}})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].n < found[j].n })
+
+	tiers := make([]TieredRate, 0, len(found))
+	for _, f := range found {
+		tiers = append(tiers, f.tier)
+	}
+	return tiers, nil
+}
+
+// parseTOUSchedule builds a TOUSchedule from weekday_schedule/weekend_schedule
+// (24-char strings, one period digit per hour) and any period_rate_N
+// columns present. Returns nil, nil if neither schedule column is set.
+func parseTOUSchedule(col map[string]int, row []string, get func(string) string) (*TOUSchedule, error) {
+	weekday := get("weekday_schedule")
+	weekend := get("weekend_schedule")
+	if weekday == "" && weekend == "" {
+		return nil, nil
+	}
+
+	tou := &TOUSchedule{PeriodRates: make(map[int]float64)}
+	if err := parseHourlySchedule(weekday, &tou.WeekdaySchedule); err != nil {
+		return nil, fmt.Errorf("weekday_schedule: %w", err)
+	}
+	if err := parseHourlySchedule(weekend, &tou.WeekendSchedule); err != nil {
+		return nil, fmt.Errorf("weekend_schedule: %w", err)
+	}
+
+	for name, i := range col {
+		m := periodRateColRe.FindStringSubmatch(name)
+		if m == nil || i >= len(row) || strings.TrimSpace(row[i]) == "" {
+			continue
+		}
+		period, _ := strconv.Atoi(m[1])
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, row[i], err)
+		}
+		tou.PeriodRates[period] = rate
+	}
+	return tou, nil
+}
+
+func parseHourlySchedule(raw string, out *[24]int) error {
+	if raw == "" {
+		return nil
+	}
+	if len(raw) != 24 {
+		return fmt.Errorf("expected 24 characters, got %d (%q)", len(raw), raw)
+	}
+	for h, c := range raw {
+		period, err := strconv.Atoi(string(c))
+		if err != nil {
+			return fmt.Errorf("non-digit period %q at hour %d", string(c), h)
+		}
+		out[h] = period
+	}
+	return nil
+}
+
+// slugifyProviderKey turns a utility's display name into a stable provider
+// key (lowercase, non-alphanumerics collapsed to underscores), matching the
+// short lowercase keys (e.g. "cemc", "nes") used by the built-in providers.
+func slugifyProviderKey(name string) string {
+	var b strings.Builder
+	lastUnderscore := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastUnderscore = false
+		default:
+			if !lastUnderscore {
+				b.WriteByte('_')
+				lastUnderscore = true
+			}
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
@@ -0,0 +1,72 @@
+package rates
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const sampleTariffCSV = `utility,sector,effective_date,fixed_monthly_charge,rate1,max_kwh1,rate2,weekday_schedule,weekend_schedule,period_rate_0,period_rate_1
+Sample Power Co,Residential,2025-01-01,12.50,0.10,500,0.14,000000111111111110000000,000000000000000000000000,0.08,0.22
+`
+
+func TestCSVTariffImporter_ImportReader(t *testing.T) {
+	imp := NewCSVTariffImporter()
+	results, err := imp.ImportReader(context.Background(), strings.NewReader(sampleTariffCSV))
+	if err != nil {
+		t.Fatalf("ImportReader failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 parsed tariff, got %d", len(results))
+	}
+
+	r := results[0]
+	if r.Utility != "Sample Power Co" {
+		t.Errorf("expected utility %q, got %q", "Sample Power Co", r.Utility)
+	}
+	if r.Sector != "Residential" {
+		t.Errorf("expected sector %q, got %q", "Residential", r.Sector)
+	}
+	if r.FixedMonthlyChargeUSD != 12.50 {
+		t.Errorf("expected fixed_monthly_charge 12.50, got %v", r.FixedMonthlyChargeUSD)
+	}
+	if len(r.TieredRates) != 2 {
+		t.Fatalf("expected 2 tiers, got %d", len(r.TieredRates))
+	}
+	if r.TieredRates[0].MaxKWh != 500 || r.TieredRates[0].RateUSDPerKWh != 0.10 {
+		t.Errorf("unexpected tier 1: %+v", r.TieredRates[0])
+	}
+	if r.TOU == nil {
+		t.Fatalf("expected a TOU schedule to be parsed")
+	}
+	if r.TOU.WeekdaySchedule[6] != 1 {
+		t.Errorf("expected weekday hour 6 to be period 1, got %d", r.TOU.WeekdaySchedule[6])
+	}
+	if r.TOU.PeriodRates[1] != 0.22 {
+		t.Errorf("expected period_rate_1 0.22, got %v", r.TOU.PeriodRates[1])
+	}
+
+	if _, ok := GetProvider("sample_power_co"); !ok {
+		t.Errorf("expected ImportReader to register provider %q", "sample_power_co")
+	}
+}
+
+func TestCSVTariffImporter_MissingUtilityColumn(t *testing.T) {
+	imp := NewCSVTariffImporter()
+	_, err := imp.ImportReader(context.Background(), strings.NewReader("sector,fixed_monthly_charge\nResidential,10\n"))
+	if err == nil {
+		t.Fatalf("expected error for CSV missing required utility column")
+	}
+}
+
+func TestCSVTariffImporter_InvalidFloatField(t *testing.T) {
+	imp := NewCSVTariffImporter()
+	csv := "utility,fixed_monthly_charge\nBad Utility,not-a-number\n"
+	results, err := imp.ImportReader(context.Background(), strings.NewReader(csv))
+	if err == nil {
+		t.Fatalf("expected error for invalid fixed_monthly_charge")
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected no successful rows, got %d", len(results))
+	}
+}
@@ -0,0 +1,119 @@
+package rates
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TieredRate is one block of an inclining/declining block (tiered) energy
+// rate: usage up to MaxKWh (exclusive of any lower tier's MaxKWh) is billed
+// at RateUSDPerKWh. The last tier in a RatesResponse.TieredRates slice
+// should have MaxKWh <= 0, meaning "no upper bound".
+type TieredRate struct {
+	MaxKWh        float64 `json:"max_kwh"`
+	RateUSDPerKWh float64 `json:"rate_usd_per_kwh"`
+}
+
+// TOUSchedule describes a time-of-use rate structure: each hour of a
+// weekday/weekend falls into a numbered period, and each period has its own
+// $/kWh rate. WeekdaySchedule[h] and WeekendSchedule[h] hold the period
+// number in effect during hour h (0-23); PeriodRates maps period number to
+// its rate.
+type TOUSchedule struct {
+	WeekdaySchedule [24]int         `json:"weekday_schedule"`
+	WeekendSchedule [24]int         `json:"weekend_schedule"`
+	PeriodRates     map[int]float64 `json:"period_rates"`
+}
+
+// CalculateBill returns the estimated monthly bill for kwh of usage and
+// demandKW of peak demand. When TieredRates is set it's used instead of
+// Rates.ResidentialStandard's flat EnergyRateUSDPerKWh; FixedMonthlyChargeUSD,
+// DemandChargeUSDPerKW, and FuelAdjustmentUSDPerKWh always apply on top.
+func (r *RatesResponse) CalculateBill(kwh, demandKW float64) float64 {
+	var energyCost float64
+	if len(r.TieredRates) > 0 {
+		energyCost = r.CalculateTieredEnergyCost(kwh)
+	} else {
+		energyCost = kwh * floatOrZero(r.Rates.ResidentialStandard.EnergyRateUSDPerKWh)
+	}
+
+	fixed := r.FixedMonthlyChargeUSD
+	if fixed == 0 {
+		fixed = floatOrZero(r.Rates.ResidentialStandard.CustomerChargeMonthlyUSD)
+	}
+
+	return fixed + energyCost + demandKW*r.DemandChargeUSDPerKW + kwh*r.FuelAdjustmentUSDPerKWh
+}
+
+// CalculateTieredEnergyCost applies TieredRates to kwh of usage, billing
+// each block up to its MaxKWh at that block's rate and the remainder at the
+// last (unbounded) block's rate. TieredRates is assumed sorted ascending by
+// MaxKWh with the final entry's MaxKWh <= 0 meaning unbounded.
+func (r *RatesResponse) CalculateTieredEnergyCost(kwh float64) float64 {
+	var cost float64
+	var consumed float64
+	for i, tier := range r.TieredRates {
+		remaining := kwh - consumed
+		if remaining <= 0 {
+			break
+		}
+
+		unbounded := tier.MaxKWh <= 0 || i == len(r.TieredRates)-1
+		if unbounded {
+			cost += remaining * tier.RateUSDPerKWh
+			consumed = kwh
+			break
+		}
+
+		blockSize := tier.MaxKWh - consumed
+		if blockSize > remaining {
+			blockSize = remaining
+		}
+		cost += blockSize * tier.RateUSDPerKWh
+		consumed += blockSize
+	}
+	return cost
+}
+
+// EffectiveRatePerKWh returns the blended $/kWh a customer using kwh per
+// month actually pays, i.e. CalculateBill(kwh, 0) amortized over kwh. It
+// accounts for tier crossings (a customer using 1500 kWh against a tiered
+// schedule pays a different marginal rate than one using 300), unlike
+// Rates.ResidentialStandard.EnergyRateUSDPerKWh, which is only the flat
+// rate and ignores tiers entirely. Returns 0 when kwh <= 0.
+func (r *RatesResponse) EffectiveRatePerKWh(kwh float64) float64 {
+	if kwh <= 0 {
+		return 0
+	}
+	return r.CalculateBill(kwh, 0) / kwh
+}
+
+// CalculateTOUBill sums PeriodRates against periodKWh, a caller-supplied
+// breakdown of usage by TOU period number (e.g. from an interval meter),
+// plus FixedMonthlyChargeUSD. It returns an error if TOU is nil or
+// periodKWh references a period not present in TOU.PeriodRates.
+func (r *RatesResponse) CalculateTOUBill(periodKWh map[int]float64) (float64, error) {
+	if r.TOU == nil {
+		return 0, fmt.Errorf("rates: CalculateTOUBill called on a response with no TOU schedule")
+	}
+
+	periods := make([]int, 0, len(periodKWh))
+	for period := range periodKWh {
+		periods = append(periods, period)
+	}
+	sort.Ints(periods)
+
+	fixed := r.FixedMonthlyChargeUSD
+	if fixed == 0 {
+		fixed = floatOrZero(r.Rates.ResidentialStandard.CustomerChargeMonthlyUSD)
+	}
+	total := fixed
+	for _, period := range periods {
+		rate, ok := r.TOU.PeriodRates[period]
+		if !ok {
+			return 0, fmt.Errorf("rates: CalculateTOUBill: no rate configured for TOU period %d", period)
+		}
+		total += periodKWh[period] * rate
+	}
+	return total, nil
+}
@@ -0,0 +1,133 @@
+package rates
+
+import (
+	"testing"
+)
+
+// ptrFloat is a test convenience for populating the pointer-typed rate
+// fields (e.g. ResidentialStandard.EnergyRateUSDPerKWh) with a literal.
+func ptrFloat(v float64) *float64 { return &v }
+
+func TestCalculateBill_FlatRate(t *testing.T) {
+	r := &RatesResponse{
+		FixedMonthlyChargeUSD:   10,
+		DemandChargeUSDPerKW:    2,
+		FuelAdjustmentUSDPerKWh: 0.01,
+	}
+	r.Rates.ResidentialStandard.EnergyRateUSDPerKWh = ptrFloat(0.1)
+
+	got := r.CalculateBill(100, 5)
+	want := 10 + 100*0.1 + 5*2 + 100*0.01
+	if got != want {
+		t.Fatalf("CalculateBill: got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateBill_FixedChargeFallsBackToCustomerCharge(t *testing.T) {
+	r := &RatesResponse{}
+	r.Rates.ResidentialStandard.CustomerChargeMonthlyUSD = ptrFloat(15)
+	r.Rates.ResidentialStandard.EnergyRateUSDPerKWh = ptrFloat(0.05)
+
+	got := r.CalculateBill(40, 0)
+	want := 15 + 40*0.05
+	if got != want {
+		t.Fatalf("CalculateBill: got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateTieredEnergyCost(t *testing.T) {
+	r := &RatesResponse{
+		TieredRates: []TieredRate{
+			{MaxKWh: 500, RateUSDPerKWh: 0.10},
+			{MaxKWh: 1000, RateUSDPerKWh: 0.12},
+			{MaxKWh: 0, RateUSDPerKWh: 0.15},
+		},
+	}
+
+	got := r.CalculateTieredEnergyCost(1200)
+	want := 500*0.10 + 500*0.12 + 200*0.15
+	if got != want {
+		t.Fatalf("CalculateTieredEnergyCost: got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateTieredEnergyCost_WithinFirstTier(t *testing.T) {
+	r := &RatesResponse{
+		TieredRates: []TieredRate{
+			{MaxKWh: 500, RateUSDPerKWh: 0.10},
+			{MaxKWh: 0, RateUSDPerKWh: 0.15},
+		},
+	}
+
+	got := r.CalculateTieredEnergyCost(300)
+	want := 300 * 0.10
+	if got != want {
+		t.Fatalf("CalculateTieredEnergyCost: got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateTOUBill(t *testing.T) {
+	r := &RatesResponse{
+		FixedMonthlyChargeUSD: 8,
+		TOU: &TOUSchedule{
+			PeriodRates: map[int]float64{0: 0.08, 1: 0.20},
+		},
+	}
+
+	got, err := r.CalculateTOUBill(map[int]float64{0: 400, 1: 100})
+	if err != nil {
+		t.Fatalf("CalculateTOUBill: unexpected error: %v", err)
+	}
+	want := 8 + 400*0.08 + 100*0.20
+	if got != want {
+		t.Fatalf("CalculateTOUBill: got %v, want %v", got, want)
+	}
+}
+
+func TestCalculateTOUBill_NoScheduleErrors(t *testing.T) {
+	r := &RatesResponse{}
+	if _, err := r.CalculateTOUBill(map[int]float64{0: 10}); err == nil {
+		t.Fatalf("expected error when TOU is nil")
+	}
+}
+
+func TestCalculateTOUBill_MissingPeriodRateErrors(t *testing.T) {
+	r := &RatesResponse{TOU: &TOUSchedule{PeriodRates: map[int]float64{0: 0.10}}}
+	if _, err := r.CalculateTOUBill(map[int]float64{1: 10}); err == nil {
+		t.Fatalf("expected error for period with no configured rate")
+	}
+}
+
+func TestEffectiveRatePerKWh_Flat(t *testing.T) {
+	r := &RatesResponse{}
+	r.Rates.ResidentialStandard.CustomerChargeMonthlyUSD = ptrFloat(10)
+	r.Rates.ResidentialStandard.EnergyRateUSDPerKWh = ptrFloat(0.10)
+
+	got := r.EffectiveRatePerKWh(100)
+	want := (10 + 100*0.10) / 100
+	if got != want {
+		t.Fatalf("EffectiveRatePerKWh: got %v, want %v", got, want)
+	}
+}
+
+func TestEffectiveRatePerKWh_TieredCrossing(t *testing.T) {
+	r := &RatesResponse{
+		TieredRates: []TieredRate{
+			{MaxKWh: 500, RateUSDPerKWh: 0.10},
+			{MaxKWh: 0, RateUSDPerKWh: 0.15},
+		},
+	}
+
+	low := r.EffectiveRatePerKWh(400)
+	high := r.EffectiveRatePerKWh(800)
+	if !(low < high) {
+		t.Fatalf("expected crossing into the second tier to raise the blended rate: low=%v high=%v", low, high)
+	}
+}
+
+func TestEffectiveRatePerKWh_ZeroUsage(t *testing.T) {
+	r := &RatesResponse{}
+	if got := r.EffectiveRatePerKWh(0); got != 0 {
+		t.Fatalf("EffectiveRatePerKWh(0): got %v, want 0", got)
+	}
+}
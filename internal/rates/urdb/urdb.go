@@ -0,0 +1,437 @@
+// Package urdb converts between RatesResponse and the subset of NREL's
+// Utility Rate Database JSON schema (https://openei.org/wiki/Utility_Rate_Database)
+// that downstream tools (SAM, ReOpt, pvlib) actually read: energyratestructure,
+// energyweekdayschedule/energyweekendschedule, flatdemandstructure, and the
+// seasonal month arrays. It's a separate subpackage rather than methods on
+// rates.RatesResponse because URDBTariff's field names and shapes belong to
+// NREL's schema, not ours, the same reason tariff_csv_import.go's CSV column
+// names live next to the importer instead of on the struct.
+package urdb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/rates"
+)
+
+// URDBTier is one block of a URDB tiered rate structure: usage up to Max
+// (0 meaning unbounded, matching rates.TieredRate's convention) billed at
+// Rate.
+type URDBTier struct {
+	Max  float64 `json:"max,omitempty"`
+	Rate float64 `json:"rate"`
+}
+
+// URDBTariff is the subset of a URDB tariff record ToURDB/FromURDB round-trip.
+// EnergyWeekdaySchedule/EnergyWeekendSchedule are [month 0-11][hour 0-23]
+// indexes into EnergyRateStructure, matching URDB's own matrix layout.
+type URDBTariff struct {
+	Utility   string `json:"utility"`
+	Name      string `json:"name,omitempty"`
+	Sector    string `json:"sector,omitempty"`
+	StartDate string `json:"startdate,omitempty"`
+	EndDate   string `json:"enddate,omitempty"`
+
+	FixedChargeUSD float64 `json:"fixedchargefirstmeter,omitempty"`
+
+	EnergyRateStructure   [][]URDBTier `json:"energyratestructure,omitempty"`
+	EnergyWeekdaySchedule [12][24]int  `json:"energyweekdayschedule,omitempty"`
+	EnergyWeekendSchedule [12][24]int  `json:"energyweekendschedule,omitempty"`
+
+	FlatDemandStructure [][]URDBTier `json:"flatdemandstructure,omitempty"`
+	FlatDemandMonths    [12]int      `json:"flatdemandmonths,omitempty"`
+}
+
+var monthNames = [12]string{
+	"january", "february", "march", "april", "may", "june",
+	"july", "august", "september", "october", "november", "december",
+}
+
+// ToURDB maps r onto the URDB schema. Energy rate structure is built from
+// whichever of r.TOU, r.Rates.ResidentialTOU, r.TieredRates,
+// r.Rates.ResidentialSeasonal, or r.Rates.ResidentialStandard is populated,
+// in that order - r.TOU (an already-parsed TOUSchedule) is preferred over
+// Rates.ResidentialTOU's raw "HH:MM-HH:MM" strings when both are set, since
+// no text parsing is needed to trust it.
+func ToURDB(r rates.RatesResponse) (URDBTariff, error) {
+	t := URDBTariff{
+		Utility: r.Utility,
+		Name:    r.Source,
+		Sector:  r.Sector,
+	}
+	if t.Sector == "" {
+		t.Sector = "Residential"
+	}
+	if r.EffectiveDate != nil {
+		t.StartDate = r.EffectiveDate.Format("2006-01-02")
+	}
+	if r.EndDate != nil {
+		t.EndDate = r.EndDate.Format("2006-01-02")
+	}
+
+	t.FixedChargeUSD = r.FixedMonthlyChargeUSD
+	if t.FixedChargeUSD == 0 && r.Rates.ResidentialStandard.CustomerChargeMonthlyUSD != nil {
+		t.FixedChargeUSD = *r.Rates.ResidentialStandard.CustomerChargeMonthlyUSD
+	}
+
+	switch {
+	case r.TOU != nil:
+		structure, weekday, weekend := touScheduleToURDB(*r.TOU)
+		t.EnergyRateStructure = structure
+		fillAllMonths(&t.EnergyWeekdaySchedule, weekday)
+		fillAllMonths(&t.EnergyWeekendSchedule, weekend)
+
+	case r.Rates.ResidentialTOU.IsPresent:
+		structure, weekday, weekend, err := residentialTOUToURDB(r.Rates.ResidentialTOU)
+		if err != nil {
+			return URDBTariff{}, err
+		}
+		t.EnergyRateStructure = structure
+		fillAllMonths(&t.EnergyWeekdaySchedule, weekday)
+		fillAllMonths(&t.EnergyWeekendSchedule, weekend)
+
+	case len(r.TieredRates) > 0:
+		t.EnergyRateStructure = [][]URDBTier{tieredRatesToURDB(r.TieredRates)}
+		fillAllMonths(&t.EnergyWeekdaySchedule, [24]int{})
+		fillAllMonths(&t.EnergyWeekendSchedule, [24]int{})
+
+	case r.Rates.ResidentialSeasonal.IsPresent:
+		structure, weekdayByMonth, err := seasonalToURDB(r.Rates.ResidentialSeasonal)
+		if err != nil {
+			return URDBTariff{}, err
+		}
+		t.EnergyRateStructure = structure
+		t.EnergyWeekdaySchedule = weekdayByMonth
+		t.EnergyWeekendSchedule = weekdayByMonth
+
+	case r.Rates.ResidentialStandard.IsPresent:
+		rate := 0.0
+		if r.Rates.ResidentialStandard.EnergyRateUSDPerKWh != nil {
+			rate = *r.Rates.ResidentialStandard.EnergyRateUSDPerKWh
+		}
+		t.EnergyRateStructure = [][]URDBTier{{{Rate: rate}}}
+		fillAllMonths(&t.EnergyWeekdaySchedule, [24]int{})
+		fillAllMonths(&t.EnergyWeekendSchedule, [24]int{})
+	}
+
+	if r.DemandChargeUSDPerKW > 0 {
+		t.FlatDemandStructure = [][]URDBTier{{{Rate: r.DemandChargeUSDPerKW}}}
+	}
+
+	return t, nil
+}
+
+func fillAllMonths(matrix *[12][24]int, schedule [24]int) {
+	for m := range matrix {
+		matrix[m] = schedule
+	}
+}
+
+func touScheduleToURDB(tou rates.TOUSchedule) (structure [][]URDBTier, weekday, weekend [24]int) {
+	periods := make([]int, 0, len(tou.PeriodRates))
+	for p := range tou.PeriodRates {
+		periods = append(periods, p)
+	}
+	sort.Ints(periods)
+
+	indexOf := make(map[int]int, len(periods))
+	for i, p := range periods {
+		indexOf[p] = i
+		structure = append(structure, []URDBTier{{Rate: tou.PeriodRates[p]}})
+	}
+	for h := 0; h < 24; h++ {
+		weekday[h] = indexOf[tou.WeekdaySchedule[h]]
+		weekend[h] = indexOf[tou.WeekendSchedule[h]]
+	}
+	return structure, weekday, weekend
+}
+
+// residentialTOUToURDB parses tou's OnPeakHours/OffPeakHours/ShoulderHours
+// "HH:MM-HH:MM" range strings into a weekday/weekend schedule (identical for
+// both, since ResidentialTOU doesn't distinguish them) and an
+// EnergyRateStructure with one period per distinct rate actually present.
+func residentialTOUToURDB(tou rates.ResidentialTOU) (structure [][]URDBTier, weekday, weekend [24]int, err error) {
+	type band struct {
+		hours []string
+		rate  *float64
+	}
+	bands := []band{
+		{tou.OnPeakHours, tou.OnPeakRateUSDPerKWh},
+		{tou.OffPeakHours, tou.OffPeakRateUSDPerKWh},
+		{tou.ShoulderHours, tou.ShoulderRateUSDPerKWh},
+	}
+
+	periodForRate := make(map[float64]int)
+	var schedule [24]int
+	covered := false
+	for _, b := range bands {
+		if b.rate == nil || len(b.hours) == 0 {
+			continue
+		}
+		period, ok := periodForRate[*b.rate]
+		if !ok {
+			period = len(structure)
+			periodForRate[*b.rate] = period
+			structure = append(structure, []URDBTier{{Rate: *b.rate}})
+		}
+		for _, hr := range b.hours {
+			start, hours, err := parseHourRange(hr)
+			if err != nil {
+				return nil, [24]int{}, [24]int{}, fmt.Errorf("parse hour range %q: %w", hr, err)
+			}
+			for i := 0; i < hours; i++ {
+				schedule[(start+i)%24] = period
+				covered = true
+			}
+		}
+	}
+	if !covered {
+		return nil, [24]int{}, [24]int{}, fmt.Errorf("residential TOU has no usable on/off/shoulder hour ranges")
+	}
+	return structure, schedule, schedule, nil
+}
+
+// parseHourRange parses "HH:MM-HH:MM" into a starting hour-of-day and a
+// count of hours it covers, wrapping past midnight when the end is not
+// after the start (e.g. "22:00-06:00" covers 22,23,0,1,2,3,4,5 - 8 hours).
+// An identical start and end (e.g. "00:00-00:00") means the full day.
+// Minutes are accepted but dropped, since URDB schedules (and
+// rates.TOUSchedule) are hourly-granularity.
+func parseHourRange(s string) (start, hours int, err error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected \"HH:MM-HH:MM\"")
+	}
+	start, err = parseHourOfDay(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseHourOfDay(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	hours = end - start
+	if hours <= 0 {
+		hours += 24
+	}
+	return start, hours, nil
+}
+
+func parseHourOfDay(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	hh := s
+	if i := strings.Index(s, ":"); i >= 0 {
+		hh = s[:i]
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid hour in %q", s)
+	}
+	return h % 24, nil
+}
+
+func tieredRatesToURDB(tiers []rates.TieredRate) []URDBTier {
+	out := make([]URDBTier, len(tiers))
+	for i, tier := range tiers {
+		out[i] = URDBTier{Max: tier.MaxKWh, Rate: tier.RateUSDPerKWh}
+	}
+	return out
+}
+
+// seasonalToURDB maps seasonal.SummerMonths/WinterMonths (case-insensitive
+// month names) onto a two-period EnergyRateStructure (period 0 summer,
+// period 1 winter) and the per-month weekday schedule those months resolve
+// to (all 24 hours of a month share one period, since seasonal rates carry
+// no intraday schedule).
+func seasonalToURDB(seasonal rates.ResidentialSeasonal) (structure [][]URDBTier, byMonth [12][24]int, err error) {
+	summerRate, winterRate := 0.0, 0.0
+	if seasonal.SummerRateUSDPerKWh != nil {
+		summerRate = *seasonal.SummerRateUSDPerKWh
+	}
+	if seasonal.WinterRateUSDPerKWh != nil {
+		winterRate = *seasonal.WinterRateUSDPerKWh
+	}
+	structure = [][]URDBTier{{{Rate: summerRate}}, {{Rate: winterRate}}}
+
+	for m := range byMonth {
+		for h := 0; h < 24; h++ {
+			byMonth[m][h] = 1 // default to winter; summer months overridden below
+		}
+	}
+	for _, name := range seasonal.WinterMonths {
+		idx, ok := monthIndex(name)
+		if !ok {
+			return nil, [12][24]int{}, fmt.Errorf("unrecognized winter month %q", name)
+		}
+		for h := 0; h < 24; h++ {
+			byMonth[idx][h] = 1
+		}
+	}
+	for _, name := range seasonal.SummerMonths {
+		idx, ok := monthIndex(name)
+		if !ok {
+			return nil, [12][24]int{}, fmt.Errorf("unrecognized summer month %q", name)
+		}
+		for h := 0; h < 24; h++ {
+			byMonth[idx][h] = 0
+		}
+	}
+	return structure, byMonth, nil
+}
+
+func monthIndex(name string) (int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, m := range monthNames {
+		if m == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// FromURDB reconstructs a best-effort RatesResponse from t. It's lossy in
+// the other direction from ToURDB: a URDB tariff with a demand charge that
+// varies by tier, or a schedule that varies by both month and hour at once,
+// has no equivalent rates.RatesResponse field and is dropped rather than
+// guessed at.
+func FromURDB(t URDBTariff) (rates.RatesResponse, error) {
+	r := rates.RatesResponse{
+		Utility:               t.Utility,
+		Source:                t.Name,
+		Sector:                t.Sector,
+		FixedMonthlyChargeUSD: t.FixedChargeUSD,
+	}
+	if len(t.FlatDemandStructure) > 0 && len(t.FlatDemandStructure[0]) > 0 {
+		r.DemandChargeUSDPerKW = t.FlatDemandStructure[0][0].Rate
+	}
+
+	if len(t.EnergyRateStructure) == 0 {
+		return r, nil
+	}
+
+	switch {
+	case len(t.EnergyRateStructure) == 1 && len(t.EnergyRateStructure[0]) > 1:
+		r.TieredRates = urdbTiersToRates(t.EnergyRateStructure[0])
+
+	case len(t.EnergyRateStructure) == 1:
+		rate := t.EnergyRateStructure[0][0].Rate
+		r.Rates.ResidentialStandard = rates.ResidentialStandard{
+			IsPresent:           true,
+			EnergyRateUSDPerKWh: &rate,
+		}
+
+	case scheduleVariesByHour(t.EnergyWeekdaySchedule):
+		tou, err := urdbToTOUSchedule(t)
+		if err != nil {
+			return rates.RatesResponse{}, err
+		}
+		r.TOU = tou
+
+	default:
+		seasonal, err := urdbToSeasonal(t)
+		if err != nil {
+			return rates.RatesResponse{}, err
+		}
+		r.Rates.ResidentialSeasonal = seasonal
+	}
+
+	return r, nil
+}
+
+func urdbTiersToRates(tiers []URDBTier) []rates.TieredRate {
+	out := make([]rates.TieredRate, len(tiers))
+	for i, tier := range tiers {
+		out[i] = rates.TieredRate{MaxKWh: tier.Max, RateUSDPerKWh: tier.Rate}
+	}
+	return out
+}
+
+// scheduleVariesByHour reports whether any month's 24-hour schedule uses
+// more than one period, i.e. the tariff has an intraday (TOU) structure
+// rather than one that only varies month to month (seasonal).
+func scheduleVariesByHour(weekday [12][24]int) bool {
+	for _, month := range weekday {
+		for h := 1; h < 24; h++ {
+			if month[h] != month[0] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func urdbToTOUSchedule(t URDBTariff) (*rates.TOUSchedule, error) {
+	tou := &rates.TOUSchedule{PeriodRates: make(map[int]float64)}
+	for period, tiers := range t.EnergyRateStructure {
+		if len(tiers) == 0 {
+			return nil, fmt.Errorf("energyratestructure period %d has no tiers", period)
+		}
+		tou.PeriodRates[period] = tiers[0].Rate
+	}
+	tou.WeekdaySchedule = t.EnergyWeekdaySchedule[0]
+	tou.WeekendSchedule = t.EnergyWeekendSchedule[0]
+	return tou, nil
+}
+
+// urdbToSeasonal groups t's 12 per-month periods into (at most) two buckets
+// and labels whichever bucket contains more of June/July/August as summer,
+// the rest winter; a tariff with more than two distinct month-level periods
+// doesn't fit rates.ResidentialSeasonal and is an error.
+func urdbToSeasonal(t URDBTariff) (rates.ResidentialSeasonal, error) {
+	var periodOfMonth [12]int
+	distinct := make(map[int]bool)
+	for m := 0; m < 12; m++ {
+		periodOfMonth[m] = t.EnergyWeekdaySchedule[m][0]
+		distinct[periodOfMonth[m]] = true
+	}
+	if len(distinct) > 2 {
+		return rates.ResidentialSeasonal{}, fmt.Errorf("seasonal schedule has %d distinct periods, want at most 2", len(distinct))
+	}
+
+	periods := make([]int, 0, len(distinct))
+	for p := range distinct {
+		periods = append(periods, p)
+	}
+	sort.Ints(periods)
+
+	summerScore := func(p int) int {
+		score := 0
+		for _, m := range []int{5, 6, 7} {
+			if periodOfMonth[m] == p {
+				score++
+			}
+		}
+		return score
+	}
+	summerPeriod := periods[0]
+	if len(periods) == 2 && summerScore(periods[1]) > summerScore(periods[0]) {
+		summerPeriod = periods[1]
+	}
+
+	seasonal := rates.ResidentialSeasonal{IsPresent: true}
+	for m := 0; m < 12; m++ {
+		name := monthNames[m]
+		if periodOfMonth[m] == summerPeriod {
+			seasonal.SummerMonths = append(seasonal.SummerMonths, name)
+		} else {
+			seasonal.WinterMonths = append(seasonal.WinterMonths, name)
+		}
+	}
+	rateOf := func(p int) *float64 {
+		if p < len(t.EnergyRateStructure) && len(t.EnergyRateStructure[p]) > 0 {
+			rate := t.EnergyRateStructure[p][0].Rate
+			return &rate
+		}
+		return nil
+	}
+	seasonal.SummerRateUSDPerKWh = rateOf(summerPeriod)
+	for _, p := range periods {
+		if p != summerPeriod {
+			seasonal.WinterRateUSDPerKWh = rateOf(p)
+		}
+	}
+	return seasonal, nil
+}
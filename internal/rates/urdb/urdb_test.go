@@ -0,0 +1,145 @@
+package urdb
+
+import (
+	"testing"
+
+	"github.com/bher20/eratemanager/internal/rates"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+func TestToURDBFlatStandardRate(t *testing.T) {
+	r := rates.RatesResponse{
+		Utility: "Test Utility",
+		Rates: rates.Rates{
+			ResidentialStandard: rates.ResidentialStandard{
+				IsPresent:           true,
+				EnergyRateUSDPerKWh: ptr(0.12),
+			},
+		},
+	}
+
+	tariff, err := ToURDB(r)
+	if err != nil {
+		t.Fatalf("ToURDB failed: %v", err)
+	}
+	if len(tariff.EnergyRateStructure) != 1 || len(tariff.EnergyRateStructure[0]) != 1 {
+		t.Fatalf("expected a single flat period/tier, got %+v", tariff.EnergyRateStructure)
+	}
+	if tariff.EnergyRateStructure[0][0].Rate != 0.12 {
+		t.Errorf("expected rate 0.12, got %v", tariff.EnergyRateStructure[0][0].Rate)
+	}
+	if tariff.EnergyWeekdaySchedule[0] != tariff.EnergyWeekdaySchedule[11] {
+		t.Errorf("expected the flat rate to apply to every month identically")
+	}
+}
+
+func TestToURDBTieredRates(t *testing.T) {
+	r := rates.RatesResponse{
+		TieredRates: []rates.TieredRate{
+			{MaxKWh: 500, RateUSDPerKWh: 0.10},
+			{MaxKWh: 0, RateUSDPerKWh: 0.15},
+		},
+	}
+
+	tariff, err := ToURDB(r)
+	if err != nil {
+		t.Fatalf("ToURDB failed: %v", err)
+	}
+	if len(tariff.EnergyRateStructure) != 1 || len(tariff.EnergyRateStructure[0]) != 2 {
+		t.Fatalf("expected a single period with 2 tiers, got %+v", tariff.EnergyRateStructure)
+	}
+	if tariff.EnergyRateStructure[0][1].Rate != 0.15 {
+		t.Errorf("expected the unbounded tier's rate to be 0.15, got %v", tariff.EnergyRateStructure[0][1].Rate)
+	}
+}
+
+func TestToURDBResidentialTOUHourRanges(t *testing.T) {
+	r := rates.RatesResponse{
+		Rates: rates.Rates{
+			ResidentialTOU: rates.ResidentialTOU{
+				IsPresent:            true,
+				OnPeakRateUSDPerKWh:  ptr(0.20),
+				OffPeakRateUSDPerKWh: ptr(0.08),
+				OnPeakHours:          []string{"16:00-20:00"},
+				OffPeakHours:         []string{"20:00-16:00"},
+			},
+		},
+	}
+
+	tariff, err := ToURDB(r)
+	if err != nil {
+		t.Fatalf("ToURDB failed: %v", err)
+	}
+	if len(tariff.EnergyRateStructure) != 2 {
+		t.Fatalf("expected 2 periods (on-peak, off-peak), got %+v", tariff.EnergyRateStructure)
+	}
+	onPeakPeriod := tariff.EnergyWeekdaySchedule[0][17]
+	offPeakPeriod := tariff.EnergyWeekdaySchedule[0][2]
+	if tariff.EnergyRateStructure[onPeakPeriod][0].Rate != 0.20 {
+		t.Errorf("expected hour 17 to be on-peak (0.20), got period %d rate %v", onPeakPeriod, tariff.EnergyRateStructure[onPeakPeriod][0].Rate)
+	}
+	if tariff.EnergyRateStructure[offPeakPeriod][0].Rate != 0.08 {
+		t.Errorf("expected hour 2 to be off-peak (0.08), got period %d rate %v", offPeakPeriod, tariff.EnergyRateStructure[offPeakPeriod][0].Rate)
+	}
+}
+
+func TestToURDBSeasonal(t *testing.T) {
+	r := rates.RatesResponse{
+		Rates: rates.Rates{
+			ResidentialSeasonal: rates.ResidentialSeasonal{
+				IsPresent:           true,
+				SummerRateUSDPerKWh: ptr(0.18),
+				WinterRateUSDPerKWh: ptr(0.09),
+				SummerMonths:        []string{"june", "july", "august", "september"},
+				WinterMonths:        []string{"january", "february", "march", "april", "may", "october", "november", "december"},
+			},
+		},
+	}
+
+	tariff, err := ToURDB(r)
+	if err != nil {
+		t.Fatalf("ToURDB failed: %v", err)
+	}
+	julyPeriod := tariff.EnergyWeekdaySchedule[6][12]
+	januaryPeriod := tariff.EnergyWeekdaySchedule[0][12]
+	if tariff.EnergyRateStructure[julyPeriod][0].Rate != 0.18 {
+		t.Errorf("expected July to use the summer rate, got %v", tariff.EnergyRateStructure[julyPeriod][0].Rate)
+	}
+	if tariff.EnergyRateStructure[januaryPeriod][0].Rate != 0.09 {
+		t.Errorf("expected January to use the winter rate, got %v", tariff.EnergyRateStructure[januaryPeriod][0].Rate)
+	}
+}
+
+func TestRoundTripTOUSchedule(t *testing.T) {
+	r := rates.RatesResponse{
+		TOU: &rates.TOUSchedule{
+			WeekdaySchedule: [24]int{0: 1, 17: 0, 18: 0, 19: 0, 20: 1},
+			WeekendSchedule: [24]int{},
+			PeriodRates:     map[int]float64{0: 0.25, 1: 0.07},
+		},
+	}
+	for h := 0; h < 24; h++ {
+		if h < 17 || h >= 20 {
+			r.TOU.WeekdaySchedule[h] = 1
+		} else {
+			r.TOU.WeekdaySchedule[h] = 0
+		}
+	}
+
+	tariff, err := ToURDB(r)
+	if err != nil {
+		t.Fatalf("ToURDB failed: %v", err)
+	}
+
+	back, err := FromURDB(tariff)
+	if err != nil {
+		t.Fatalf("FromURDB failed: %v", err)
+	}
+	if back.TOU == nil {
+		t.Fatal("expected round-tripped response to carry a TOU schedule")
+	}
+	if back.TOU.WeekdaySchedule != r.TOU.WeekdaySchedule {
+		t.Errorf("weekday schedule did not round-trip: got %v, want %v", back.TOU.WeekdaySchedule, r.TOU.WeekdaySchedule)
+	}
+}
@@ -1,13 +1,20 @@
 package rates
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // WaterParserFunc is a function that fetches and parses water rates from a URL.
 type WaterParserFunc func(url string) (*WaterRatesResponse, error)
 
+// WaterParserCtxFunc is WaterParserFunc's context-aware counterpart: it must
+// honor ctx cancellation/deadline for the fetch it performs, typically by
+// building its request with http.NewRequestWithContext.
+type WaterParserCtxFunc func(ctx context.Context, url string) (*WaterRatesResponse, error)
+
 // WaterParserConfig holds the configuration for a water provider's parser.
 type WaterParserConfig struct {
 	// Key is the unique identifier for this provider (e.g., "whud").
@@ -18,6 +25,61 @@ type WaterParserConfig struct {
 
 	// ParseHTML fetches and parses an HTML page at the given URL.
 	ParseHTML WaterParserFunc
+
+	// ParseHTMLCtx is ParseHTML's context-aware counterpart. It's optional:
+	// providers that haven't been updated to thread a context through their
+	// transport leave it nil, and FetchHTML falls back to racing ParseHTML
+	// against ctx on a best-effort basis (see softCancelHTML).
+	ParseHTMLCtx WaterParserCtxFunc
+}
+
+// FetchHTML runs cfg's parser against url, honoring ctx. When cfg.ParseHTMLCtx
+// is set it's used directly; otherwise the legacy cfg.ParseHTML is raced
+// against ctx via softCancelHTML so a canceled or expired ctx is still
+// observed promptly even though the underlying call can't be aborted
+// mid-flight.
+func (cfg WaterParserConfig) FetchHTML(ctx context.Context, url string) (*WaterRatesResponse, error) {
+	if cfg.ParseHTMLCtx != nil {
+		return cfg.ParseHTMLCtx(ctx, url)
+	}
+	return softCancelHTML(ctx, cfg.ParseHTML, url)
+}
+
+// softCancelHTML starts fn on its own goroutine and races it against ctx
+// using a deadlineTimer armed for the remainder of ctx's deadline (or
+// ctx.Done() directly when ctx has no deadline). If ctx wins, softCancelHTML
+// returns ctx.Err() immediately; fn's goroutine is left to finish on its own
+// and its result, if any, is discarded.
+func softCancelHTML(ctx context.Context, fn WaterParserFunc, url string) (*WaterRatesResponse, error) {
+	type result struct {
+		resp *WaterRatesResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := fn(url)
+		done <- result{resp, err}
+	}()
+
+	if dl, ok := ctx.Deadline(); ok {
+		timer := newDeadlineTimer(time.Until(dl))
+		defer timer.cancel()
+		select {
+		case r := <-done:
+			return r.resp, r.err
+		case <-timer.readCancel():
+			return nil, ctx.Err()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 var (
@@ -3,13 +3,21 @@ package rates
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/bher20/eratemanager/internal/providerhealth"
 	"github.com/bher20/eratemanager/internal/storage"
 )
 
 // WaterService coordinates fetching and caching of water rates.
 type WaterService struct {
 	store storage.Storage // may be nil for direct fetch mode
+
+	// monitor, when set, lets GetWaterRates avoid a live fetch against a
+	// provider providerhealth.Monitor has marked Down, serving the last
+	// cached snapshot instead (flagged Stale).
+	monitor *providerhealth.Monitor
 }
 
 // NewWaterService creates a water service without storage.
@@ -22,6 +30,13 @@ func NewWaterServiceWithStorage(st storage.Storage) *WaterService {
 	return &WaterService{store: st}
 }
 
+// NewWaterServiceWithStorageAndMonitor creates a water service backed by
+// both st and mon, so GetWaterRates can consult mon before spending a
+// request on a provider it already knows is down.
+func NewWaterServiceWithStorageAndMonitor(st storage.Storage, mon *providerhealth.Monitor) *WaterService {
+	return &WaterService{store: st, monitor: mon}
+}
+
 // GetWaterRates returns water rates for a provider.
 func (s *WaterService) GetWaterRates(ctx context.Context, providerKey string) (*WaterRatesResponse, error) {
 	parser, ok := GetWaterParser(providerKey)
@@ -30,19 +45,39 @@ func (s *WaterService) GetWaterRates(ctx context.Context, providerKey string) (*
 	}
 
 	// Try cache first if we have storage
+	var cached *WaterRatesResponse
 	if s.store != nil {
 		snap, err := s.store.GetRatesSnapshot(ctx, "water:"+providerKey)
 		if err == nil && snap != nil && len(snap.Payload) > 0 {
 			var resp WaterRatesResponse
 			if err := json.Unmarshal(snap.Payload, &resp); err == nil {
-				return &resp, nil
+				cached = &resp
 			}
 		}
 	}
 
-	// Fetch from source
+	// A provider the health monitor has marked Down is known to be
+	// failing, so serve the last cached snapshot (flagged Stale) instead of
+	// spending a request on a live fetch we already expect to fail.
+	if s.monitor != nil && s.monitor.State(providerKey) == providerhealth.Down {
+		if cached == nil {
+			return nil, fmt.Errorf("rates: water provider %q is down and no cached snapshot is available", providerKey)
+		}
+		stale := *cached
+		stale.Stale = true
+		return &stale, nil
+	}
+
+	if cached != nil {
+		return cached, nil
+	}
+
+	// Fetch from source, bounded by a per-provider deadline so a slow or
+	// hanging provider can't block the caller indefinitely.
 	provider, _ := GetProvider(providerKey)
-	resp, err := parser.ParseHTML(provider.LandingURL)
+	fetchCtx, cancel := context.WithTimeout(ctx, defaultFetchTimeout)
+	defer cancel()
+	resp, err := parser.FetchHTML(fetchCtx, provider.LandingURL)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +96,33 @@ func (s *WaterService) GetWaterRates(ctx context.Context, providerKey string) (*
 	return resp, nil
 }
 
+// GetWaterRatesAsOf returns water/sewer rates in effect at instant t,
+// reconstructed from the stored snapshot history the same way
+// Service.GetResidentialAsOf does for electric rates.
+func (s *WaterService) GetWaterRatesAsOf(ctx context.Context, providerKey string, t time.Time) (*WaterRatesResponse, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("rates: no storage backend configured for historical queries")
+	}
+	hist, ok := s.store.(storage.SnapshotHistory)
+	if !ok {
+		return nil, fmt.Errorf("rates: storage backend does not retain snapshot history")
+	}
+
+	snap, err := hist.GetRatesSnapshotAt(ctx, "water:"+providerKey, t)
+	if err != nil {
+		return nil, fmt.Errorf("get water snapshot for %s as of %s: %w", providerKey, t, err)
+	}
+	if snap == nil {
+		return nil, nil
+	}
+
+	var resp WaterRatesResponse
+	if err := json.Unmarshal(snap.Payload, &resp); err != nil {
+		return nil, fmt.Errorf("decode historical water snapshot for %s: %w", providerKey, err)
+	}
+	return &resp, nil
+}
+
 // ForceRefresh bypasses the cache and fetches fresh rates.
 func (s *WaterService) ForceRefresh(ctx context.Context, providerKey string) (*WaterRatesResponse, error) {
 	parser, ok := GetWaterParser(providerKey)
@@ -69,7 +131,9 @@ func (s *WaterService) ForceRefresh(ctx context.Context, providerKey string) (*W
 	}
 
 	provider, _ := GetProvider(providerKey)
-	resp, err := parser.ParseHTML(provider.LandingURL)
+	fetchCtx, cancel := context.WithTimeout(ctx, defaultFetchTimeout)
+	defer cancel()
+	resp, err := parser.FetchHTML(fetchCtx, provider.LandingURL)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,9 @@
 package rates
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // WaterRatesResponse contains parsed water utility rate information
 type WaterRatesResponse struct {
@@ -13,9 +16,21 @@ type WaterRatesResponse struct {
 
 	// Sewer rates (optional, some providers bundle sewer)
 	Sewer *SewerRateDetails `json:"sewer,omitempty"`
+
+	// Warnings names water/sewer rate fields the parser's regexes failed to
+	// match, the water-response equivalent of RatesResponse.Warnings.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Stale is true when GetWaterRates served this response from cache
+	// instead of attempting a live fetch because providerhealth.Monitor
+	// reported the provider Down. The v2 HTTP handler mirrors it as an
+	// X-Rates-Stale response header.
+	Stale bool `json:"stale,omitempty"`
 }
 
-// WaterRateDetails contains the water-specific rate components
+// WaterRateDetails contains the water-specific rate components. BaseCharge
+// and UseRate are pointers so a page whose layout defeated the parser's
+// regexes leaves them nil (JSON null) rather than a bogus $0.
 type WaterRateDetails struct {
 	// MeterSizes maps meter size descriptions to their base charges
 	// e.g., {"5/8 x 3/4 inch": 9.85, "1 inch": 13.37}
@@ -25,10 +40,10 @@ type WaterRateDetails struct {
 	DefaultMeterSize string `json:"default_meter_size"`
 
 	// BaseCharge is the monthly base/service charge for the default meter
-	BaseCharge float64 `json:"base_charge"`
+	BaseCharge *float64 `json:"base_charge"`
 
 	// UseRate is the per-unit usage charge
-	UseRate float64 `json:"use_rate"`
+	UseRate *float64 `json:"use_rate"`
 
 	// UseRateUnit is the unit for the use rate (e.g., "gallon", "ccf", "1000 gallons")
 	UseRateUnit string `json:"use_rate_unit"`
@@ -37,13 +52,14 @@ type WaterRateDetails struct {
 	EffectiveDate string `json:"effective_date,omitempty"`
 }
 
-// SewerRateDetails contains sewer-specific rate components
+// SewerRateDetails contains sewer-specific rate components. BaseCharge and
+// UseRate are pointers for the same reason as WaterRateDetails'.
 type SewerRateDetails struct {
 	// BaseCharge is the monthly base/service charge
-	BaseCharge float64 `json:"base_charge"`
+	BaseCharge *float64 `json:"base_charge"`
 
 	// UseRate is the per-unit usage charge
-	UseRate float64 `json:"use_rate"`
+	UseRate *float64 `json:"use_rate"`
 
 	// UseRateUnit is the unit for the use rate
 	UseRateUnit string `json:"use_rate_unit"`
@@ -54,19 +70,64 @@ type SewerRateDetails struct {
 
 // CalculateWaterBill calculates the monthly water bill based on usage
 func (w *WaterRatesResponse) CalculateWaterBill(gallons float64) float64 {
-	waterCost := w.Water.BaseCharge + (gallons * w.Water.UseRate)
+	waterCost := floatOrZero(w.Water.BaseCharge) + (gallons * floatOrZero(w.Water.UseRate))
 
 	sewerCost := 0.0
 	if w.Sewer != nil {
-		sewerCost = w.Sewer.BaseCharge + (gallons * w.Sewer.UseRate)
+		sewerCost = floatOrZero(w.Sewer.BaseCharge) + (gallons * floatOrZero(w.Sewer.UseRate))
 	}
 
 	return waterCost + sewerCost
 }
 
+// gallonsPerCCF converts CCF (hundred cubic feet), the unit many water
+// utilities bill by, to gallons.
+const gallonsPerCCF = 748.052
+
+// NormalizedRatePerGallon returns UseRate converted to $/gallon regardless
+// of UseRateUnit, so callers (e.g. Comparator) can compare providers that
+// bill in gallons, CCF, or 1000-gallon units on a common basis. An
+// unrecognized unit is assumed to already be per-gallon.
+func (w WaterRateDetails) NormalizedRatePerGallon() float64 {
+	return normalizedRatePerGallon(floatOrZero(w.UseRate), w.UseRateUnit)
+}
+
+// NormalizedRatePerGallon is SewerRateDetails' equivalent of
+// WaterRateDetails.NormalizedRatePerGallon; sewer rates use the same set of
+// units as water rates.
+func (s SewerRateDetails) NormalizedRatePerGallon() float64 {
+	return normalizedRatePerGallon(floatOrZero(s.UseRate), s.UseRateUnit)
+}
+
+func normalizedRatePerGallon(useRate float64, unit string) float64 {
+	switch normalizeUnit(unit) {
+	case "ccf", "hundredcubicfeet":
+		return useRate / gallonsPerCCF
+	case "1000gallons", "1000gallon", "kgal", "thousandgallons":
+		return useRate / 1000
+	default:
+		return useRate
+	}
+}
+
+// normalizeUnit lowercases s and strips spaces/hyphens so unit strings like
+// "1,000 Gallons", "1000-gallon", and "1000gallons" all compare equal.
+func normalizeUnit(s string) string {
+	var out []rune
+	for _, r := range strings.ToLower(s) {
+		switch r {
+		case ' ', '-', ',':
+			continue
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
 // CalculateWaterOnlyCost calculates just the water portion (no sewer)
 func (w *WaterRatesResponse) CalculateWaterOnlyCost(gallons float64) float64 {
-	return w.Water.BaseCharge + (gallons * w.Water.UseRate)
+	return floatOrZero(w.Water.BaseCharge) + (gallons * floatOrZero(w.Water.UseRate))
 }
 
 // CalculateSewerOnlyCost calculates just the sewer portion
@@ -74,5 +135,5 @@ func (w *WaterRatesResponse) CalculateSewerOnlyCost(gallons float64) float64 {
 	if w.Sewer == nil {
 		return 0
 	}
-	return w.Sewer.BaseCharge + (gallons * w.Sewer.UseRate)
+	return floatOrZero(w.Sewer.BaseCharge) + (gallons * floatOrZero(w.Sewer.UseRate))
 }
@@ -0,0 +1,17 @@
+package storage
+
+import "time"
+
+// Alert represents a detected issue with a provider's rate data, such as a
+// parse regression or an unusually large rate swing between snapshots.
+type Alert struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Type        string     `json:"type"`
+	Severity    string     `json:"severity"`
+	Message     string     `json:"message"`
+	Data        []byte     `json:"data,omitempty"`
+	FirstSeen   time.Time  `json:"first_seen"`
+	LastSeen    time.Time  `json:"last_seen"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty"`
+}
@@ -0,0 +1,7 @@
+package storage
+
+import "time"
+
+// defaultLeaseRenewal is how far RefreshBatchProviderLease pushes a lease's
+// expiry out on each call, when the caller doesn't track its own ttl.
+const defaultLeaseRenewal = 5 * time.Minute
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// BlobStore persists large snapshot payloads outside the SQL row they're
+// associated with, keyed by content hash, so SaveRatesSnapshot can store a
+// {provider, blob_ref, sha256, size, fetched_at} row instead of the raw
+// bytes. Put is expected to be idempotent: writing the same key twice is a
+// no-op on the second call.
+type BlobStore interface {
+	// Put uploads data under key and returns a reference GetRatesSnapshot's
+	// caller can later pass to Get.
+	Put(ctx context.Context, key string, data []byte) (ref string, err error)
+	// Get fetches the blob previously stored under ref.
+	Get(ctx context.Context, ref string) ([]byte, error)
+}
+
+// BlobConfig controls which BlobStore OpenBlobStore constructs.
+type BlobConfig struct {
+	// Driver selects the backend: "s3" or "fs". Empty disables blob storage
+	// (snapshots keep their payload inline in the SQL row, the prior
+	// behavior).
+	Driver string
+
+	// S3 / MinIO settings, used when Driver == "s3".
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+
+	// BaseDir is the root directory for blobs, used when Driver == "fs".
+	BaseDir string
+}
+
+// OpenBlobStore constructs the BlobStore described by cfg, or (nil, nil) if
+// cfg.Driver is empty.
+func OpenBlobStore(cfg BlobConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "fs":
+		return NewFSBlobStore(cfg.BaseDir)
+	case "s3":
+		return NewS3BlobStore(cfg)
+	default:
+		return nil, fmt.Errorf("storage: unsupported blob driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FSBlobStore is a filesystem-backed BlobStore, used as a zero-dependency
+// fallback when no S3-compatible object store is configured.
+type FSBlobStore struct {
+	baseDir string
+}
+
+// NewFSBlobStore returns an FSBlobStore rooted at baseDir, creating it if
+// it doesn't exist.
+func NewFSBlobStore(baseDir string) (*FSBlobStore, error) {
+	if baseDir == "" {
+		baseDir = "blobs"
+	}
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	return &FSBlobStore{baseDir: baseDir}, nil
+}
+
+func (s *FSBlobStore) path(key string) string {
+	return filepath.Join(s.baseDir, filepath.FromSlash(key))
+}
+
+func (s *FSBlobStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", fmt.Errorf("create blob subdir: %w", err)
+	}
+	if _, err := os.Stat(p); err == nil {
+		// Already written (content-addressed key), nothing to do.
+		return key, nil
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	return key, nil
+}
+
+func (s *FSBlobStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(ref))
+	if err != nil {
+		return nil, fmt.Errorf("read blob: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3BlobStore is a BlobStore backed by an S3-compatible object store
+// (AWS S3, MinIO, etc.), used to keep large snapshot payloads out of the
+// SQL row and shareable across HA replicas.
+type S3BlobStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3BlobStore connects to the S3-compatible endpoint described by cfg
+// and ensures cfg.Bucket exists.
+func NewS3BlobStore(cfg BlobConfig) (*S3BlobStore, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect to s3 endpoint %s: %w", cfg.Endpoint, err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("create bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	return &S3BlobStore{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("put object %s: %w", key, err)
+	}
+	return key, nil
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, ref, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get object %s: %w", ref, err)
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("read object %s: %w", ref, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ProviderDeleter is implemented by backends that support removing a
+// provider row outright (SQLiteStorage, PostgresStorage, MemoryStorage).
+// It's exported, unlike blobStoreSetter, because eratemanager-cli type-
+// asserts a Storage opened via storage.Open against it to implement
+// `providers delete`.
+type ProviderDeleter interface {
+	DeleteProvider(ctx context.Context, key string) error
+}
+
+// CircuitBreakerStore is implemented by backends that persist per-provider
+// circuit breaker state across batch runs. cron.RunBatchOnce's circuit
+// breaker enforcement type-asserts a Storage against this rather than
+// requiring it of every backend, the same way batchLeaser does for the
+// leader-election lease: a backend without it simply runs with the circuit
+// breaker disabled instead of failing the batch.
+type CircuitBreakerStore interface {
+	GetCircuitState(ctx context.Context, provider string) (*CircuitState, error)
+	SaveCircuitState(ctx context.Context, cs CircuitState) error
+}
+
+// BatchProgressStore is implemented by backends that persist per-provider
+// batch progress (MemoryStorage, SQLiteStorage, GormStorage, EtcdStorage,
+// ValkeyStorage). PostgresStorage and PostgresPoolStorage do not implement
+// it. cron.RunBatchOnce's ResumeFromProgress tracking type-asserts a Storage
+// against this the same way it does for CircuitBreakerStore: a backend
+// without it just runs without resume/progress tracking instead of failing
+// the batch.
+type BatchProgressStore interface {
+	GetBatchProgress(ctx context.Context, batchID, provider string) (*BatchProgress, error)
+	SaveBatchProgress(ctx context.Context, progress BatchProgress) error
+}
+
+// SnapshotHistory is implemented by backends that retain every
+// rates_snapshots row rather than just the latest one per provider
+// (SQLiteStorage, PostgresStorage, ValkeyStorage). MemoryStorage and
+// EtcdStorage do not implement it: they only ever cache the most recent
+// snapshot. eratemanager-cli type-asserts against this to implement
+// `snapshots list|show|prune`.
+type SnapshotHistory interface {
+	// ListRatesSnapshots returns provider's history, newest first, bounded
+	// by since/until (zero for no lower/upper bound) and limit (zero for no
+	// limit).
+	ListRatesSnapshots(ctx context.Context, provider string, since, until time.Time, limit int) ([]RatesSnapshot, error)
+	// GetRatesSnapshotAt returns the snapshot in effect at instant at: the
+	// highest fetched_at <= at, letting callers answer point-in-time
+	// questions like "what did this tariff look like on 2024-03-15?".
+	GetRatesSnapshotAt(ctx context.Context, provider string, at time.Time) (*RatesSnapshot, error)
+	// GetRatesSnapshotByID fetches a single snapshot row by primary key.
+	GetRatesSnapshotByID(ctx context.Context, id int64) (*RatesSnapshot, error)
+	// PruneRatesSnapshots deletes rows older than olderThan, keeping at
+	// least the keepLast most recent rows per provider, and returns the
+	// number of rows deleted.
+	PruneRatesSnapshots(ctx context.Context, keepLast int, olderThan time.Duration) (int64, error)
+}
+
+// CasbinFilter narrows LoadFilteredCasbinRules to rows matching ptype and,
+// for each non-empty slice, one of the given values in that V0..V5 column.
+// An empty slice means "no constraint on this column".
+type CasbinFilter struct {
+	PType []string
+	V0    []string
+	V1    []string
+	V2    []string
+	V3    []string
+	V4    []string
+	V5    []string
+}
+
+// CasbinStore is implemented by backends that persist Casbin policy rows
+// (currently only GormStorage). auth.NewAdapter type-asserts a Storage
+// against it, the same way eratemanager-cli type-asserts against
+// SnapshotHistory, so backends that don't back Casbin don't need to carry
+// dead methods.
+type CasbinStore interface {
+	// LoadCasbinRules returns every policy row, in no particular order.
+	LoadCasbinRules(ctx context.Context) ([]CasbinRule, error)
+	// LoadFilteredCasbinRules returns the rows matching filter, for
+	// persist.FilteredAdapter support.
+	LoadFilteredCasbinRules(ctx context.Context, filter CasbinFilter) ([]CasbinRule, error)
+	// AddCasbinRule inserts a single policy row.
+	AddCasbinRule(ctx context.Context, rule CasbinRule) error
+	// AddCasbinRules inserts rule in a single batch, for
+	// persist.BatchAdapter support.
+	AddCasbinRules(ctx context.Context, rules []CasbinRule) error
+	// RemoveCasbinRule deletes rows matching rule's non-zero fields.
+	RemoveCasbinRule(ctx context.Context, rule CasbinRule) error
+	// RemoveFilteredCasbinRule deletes rows of ptype whose V<fieldIndex...>
+	// columns equal fieldValues, following persist.Adapter's
+	// RemoveFilteredPolicy convention.
+	RemoveFilteredCasbinRule(ctx context.Context, ptype string, fieldIndex int, fieldValues ...string) error
+	// ClearCasbinRules deletes every policy row, for SavePolicy's
+	// clear-then-reinsert semantics.
+	ClearCasbinRules(ctx context.Context) error
+}
+
+// AuthStore is implemented by backends that persist users and tokens for
+// auth.Service (currently only GormStorage). NewService type-asserts a
+// Storage against it, the same way it does against CasbinStore.
+type AuthStore interface {
+	CreateUser(ctx context.Context, user User) error
+	GetUser(ctx context.Context, id string) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	UpdateUser(ctx context.Context, user User) error
+	DeleteUser(ctx context.Context, id string) error
+	ListUsers(ctx context.Context) ([]User, error)
+
+	CreateToken(ctx context.Context, token Token) error
+	GetToken(ctx context.Context, id string) (*Token, error)
+	GetTokenByHash(ctx context.Context, hash string) (*Token, error)
+	ListTokens(ctx context.Context, userID string) ([]Token, error)
+	DeleteToken(ctx context.Context, id string) error
+	// UpdateTokenLastUsed records id's last-used timestamp and the IP it
+	// was used from (ip may be "" when no RequestMeta was available).
+	UpdateTokenLastUsed(ctx context.Context, id, ip string) error
+	// RevokeToken marks a single token revoked without deleting it, so a
+	// later reuse of its raw value can still be looked up and reported
+	// instead of just failing lookup.
+	RevokeToken(ctx context.Context, id string) error
+	// RevokeTokenFamily revokes every token sharing a refresh rotation
+	// chain, used when RefreshSession detects a refresh token being reused
+	// after it was already rotated.
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	// RevokeTokensForUser revokes every outstanding token for userID, for
+	// RevokeAllForUser.
+	RevokeTokensForUser(ctx context.Context, userID string) error
+}
+
+// OIDCStore is implemented by backends that persist OIDCProvider config
+// (currently only GormStorage). auth.NewService type-asserts a Storage
+// against it, the same way it does against AuthStore; a backend without it
+// simply can't have SSO providers configured.
+type OIDCStore interface {
+	GetOIDCProvider(ctx context.Context, id string) (*OIDCProvider, error)
+	ListOIDCProviders(ctx context.Context) ([]OIDCProvider, error)
+	SaveOIDCProvider(ctx context.Context, p OIDCProvider) error
+	DeleteOIDCProvider(ctx context.Context, id string) error
+}
+
+// AuditFilter narrows ListAuditEvents. An empty Actor/Action means "no
+// constraint on this field"; a zero Since/Until means "no lower/upper
+// time bound"; a zero Limit means "no limit".
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// AuditStore is implemented by backends that persist audit.Logger's
+// hash-chained security event log (currently only GormStorage). auth.NewService
+// type-asserts a Storage against it the same way it does against
+// NotificationStore, so backends that don't back audit logging simply
+// don't have it.
+type AuditStore interface {
+	CreateAuditEvent(ctx context.Context, e AuditEvent) error
+	// ListAuditEvents returns events newest-first, narrowed by filter.
+	ListAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+	// GetLastAuditEvent returns the most recently recorded event, or nil if
+	// none has been recorded yet, so audit.Logger can chain the next one
+	// onto it.
+	GetLastAuditEvent(ctx context.Context) (*AuditEvent, error)
+}
+
+// LoginAttemptStore is implemented by backends that persist per-key login
+// throttle state (currently only GormStorage). auth.NewService type-asserts
+// a Storage against it the same way it does against AuditStore; a backend
+// without it simply applies no lockout or backoff.
+type LoginAttemptStore interface {
+	GetLoginAttempt(ctx context.Context, key string) (*LoginAttempt, error)
+	SaveLoginAttempt(ctx context.Context, attempt LoginAttempt) error
+	ClearLoginAttempt(ctx context.Context, key string) error
+	// ListLockedLoginAttempts returns every key currently locked out, for
+	// /api/v1/auth/lockouts.
+	ListLockedLoginAttempts(ctx context.Context) ([]LoginAttempt, error)
+}
+
+// DeviceStore is implemented by backends that persist known-device
+// fingerprints per user (currently only GormStorage). auth.NewService
+// type-asserts a Storage against it the same way it does against
+// LoginAttemptStore; a backend without it just can't tell a new device
+// from a repeat one, so no new-sign-in notification is ever sent.
+type DeviceStore interface {
+	GetKnownDevice(ctx context.Context, userID, fingerprint string) (*KnownDevice, error)
+	SaveKnownDevice(ctx context.Context, device KnownDevice) error
+}
+
+// PasswordPolicyStore is implemented by backends that persist the runtime-
+// configurable password policy (currently only GormStorage). auth.NewService
+// type-asserts a Storage against it the same way it does against
+// NotificationStore; a backend without it just falls back to
+// password.DefaultPolicy() and no HIBP check.
+type PasswordPolicyStore interface {
+	GetPasswordPolicyConfig(ctx context.Context) (*PasswordPolicyConfig, error)
+	SavePasswordPolicyConfig(ctx context.Context, config PasswordPolicyConfig) error
+}
+
+// AlertingStore is implemented by backends that persist alerting.Alerter's
+// webhook subscriptions and dead-letter log (currently only GormStorage).
+// alerting.NewAlerter type-asserts a Storage against it the same way
+// auth.NewService does against AuthStore; a backend without it just can't
+// have subscriptions outlive a restart, and failed deliveries are only
+// logged, not kept for later inspection.
+type AlertingStore interface {
+	SaveAlertWebhookSubscription(ctx context.Context, sub AlertWebhookSubscription) error
+	ListAlertWebhookSubscriptions(ctx context.Context) ([]AlertWebhookSubscription, error)
+	DeleteAlertWebhookSubscription(ctx context.Context, id string) error
+
+	SaveAlertWebhookDeadLetter(ctx context.Context, dl AlertWebhookDeadLetter) error
+	// ListAlertWebhookDeadLetters returns every recorded dead letter, newest
+	// first, for /internal/alerts/webhooks/dead.
+	ListAlertWebhookDeadLetters(ctx context.Context) ([]AlertWebhookDeadLetter, error)
+}
+
+// ProviderHealthStore is implemented by backends that persist
+// providerhealth.Monitor's keepalive samples (currently only GormStorage).
+// Monitor type-asserts a Storage against it the same way alerting.NewAlerter
+// does against AlertingStore; a backend without it still runs live checks,
+// it just can't answer /health/providers/{key}'s history query.
+type ProviderHealthStore interface {
+	AppendProviderHealthSample(ctx context.Context, sample ProviderHealthSample) error
+	// ListProviderHealthSamples returns up to limit of key's most recent
+	// samples, newest first.
+	ListProviderHealthSamples(ctx context.Context, key string, limit int) ([]ProviderHealthSample, error)
+}
+
+// NotificationStore is implemented by backends that persist
+// notification.Service's channel configs and its at-shutdown delivery
+// queue (currently only GormStorage). NewService type-asserts a Storage
+// against it, the same way auth.NewService does against AuthStore.
+type NotificationStore interface {
+	GetEmailConfig(ctx context.Context) (*EmailConfig, error)
+	SaveEmailConfig(ctx context.Context, config EmailConfig) error
+
+	// GetNotificationChannelConfig looks up a non-email channel's config by
+	// channel name (e.g. "webhook", "slack", "discord", "ntfy"), returning
+	// nil if the channel has never been configured.
+	GetNotificationChannelConfig(ctx context.Context, channel string) (*NotificationChannelConfig, error)
+	// ListNotificationChannelConfigs returns every configured non-email
+	// channel, for Service.dispatch to fan a NotificationEvent out to.
+	ListNotificationChannelConfigs(ctx context.Context) ([]NotificationChannelConfig, error)
+	SaveNotificationChannelConfig(ctx context.Context, config NotificationChannelConfig) error
+
+	// SavePendingNotification persists one NotificationEvent that Service's
+	// in-memory queue hadn't delivered yet at shutdown.
+	SavePendingNotification(ctx context.Context, pending PendingNotification) error
+	// ListPendingNotifications returns every event spilled by a prior
+	// shutdown, for Service to redeliver on startup.
+	ListPendingNotifications(ctx context.Context) ([]PendingNotification, error)
+	DeletePendingNotification(ctx context.Context, id string) error
+}
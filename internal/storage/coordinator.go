@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Coordinator tracks which instance, among a fleet of replicas sharing the
+// same Postgres database, is allowed to run singleton jobs (e.g. the rates
+// refresh cron). Callers register OnBecameLeader/OnLostLeadership callbacks
+// and consult IsLeader before doing leader-only work.
+type Coordinator interface {
+	// Start begins the leader-election loop in a background goroutine. It
+	// returns once the coordinator has made its first acquisition attempt.
+	Start(ctx context.Context) error
+	// Stop releases leadership (if held) and stops the background loop.
+	Stop() error
+	// IsLeader reports whether this instance currently holds leadership.
+	IsLeader() bool
+	// OnBecameLeader registers a callback fired when this instance becomes
+	// leader. fn is called with a context that is canceled when leadership
+	// is lost, so long-running work can react to a handoff.
+	OnBecameLeader(fn func(ctx context.Context))
+	// OnLostLeadership registers a callback fired when this instance gives
+	// up (or loses) leadership.
+	OnLostLeadership(fn func())
+}
+
+// defaultElectionPoll is how often a non-leader instance retries acquiring
+// the advisory lock.
+const defaultElectionPoll = 5 * time.Second
+
+// leaderNotifyChannel is the Postgres NOTIFY channel leadership changes are
+// published on, so other interested connections (e.g. an admin UI) can
+// observe handoffs without polling the lock themselves.
+const leaderNotifyChannel = "eratemanager_leader"
+
+// NewCoordinator returns the Coordinator appropriate for st: a
+// PostgresCoordinator when st is backed by pgxpool, or a no-op
+// single-instance Coordinator otherwise (SQLite and friends don't support
+// multi-instance deployments, so there's nothing to coordinate). Non-leaders
+// retry acquisition every defaultElectionPoll; use NewCoordinatorWithPoll to
+// override that cadence.
+func NewCoordinator(st Storage, lockKey int64) Coordinator {
+	return NewCoordinatorWithPoll(st, lockKey, defaultElectionPoll)
+}
+
+// NewCoordinatorWithPoll is NewCoordinator with a configurable retry
+// interval for non-leaders, e.g. a scheduler's lease duration.
+func NewCoordinatorWithPoll(st Storage, lockKey int64, poll time.Duration) Coordinator {
+	if pg, ok := st.(*PostgresPoolStorage); ok {
+		return NewPostgresCoordinator(pg.pool, lockKey, poll)
+	}
+	return NewNoopCoordinator()
+}
+
+// PostgresCoordinator implements Coordinator using a session-scoped
+// pg_try_advisory_lock held on a dedicated connection: if the process
+// crashes, Postgres releases the lock when the connection drops, so there's
+// no stale-lock cleanup to worry about. Unlike a TTL-leased row, a held
+// session lock needs no periodic renewal; electionPoll only paces how often
+// a non-leader retries acquiring it.
+type PostgresCoordinator struct {
+	pool    *pgxpool.Pool
+	lockKey int64
+
+	electionPoll time.Duration
+
+	conn   *pgxpool.Conn
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	isLeader bool
+
+	onBecameLeader   []func(ctx context.Context)
+	onLostLeadership []func()
+	leaderCancel     context.CancelFunc
+}
+
+// NewPostgresCoordinator returns a Coordinator that elects a leader using
+// pg_try_advisory_lock(lockKey) on pool, with non-leaders retrying every
+// poll (defaultElectionPoll if zero).
+func NewPostgresCoordinator(pool *pgxpool.Pool, lockKey int64, poll time.Duration) *PostgresCoordinator {
+	if poll <= 0 {
+		poll = defaultElectionPoll
+	}
+	return &PostgresCoordinator{pool: pool, lockKey: lockKey, electionPoll: poll}
+}
+
+func (c *PostgresCoordinator) OnBecameLeader(fn func(ctx context.Context)) {
+	c.onBecameLeader = append(c.onBecameLeader, fn)
+}
+
+func (c *PostgresCoordinator) OnLostLeadership(fn func()) {
+	c.onLostLeadership = append(c.onLostLeadership, fn)
+}
+
+func (c *PostgresCoordinator) IsLeader() bool { return c.isLeader }
+
+func (c *PostgresCoordinator) Start(ctx context.Context) error {
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	c.tryAcquire(loopCtx)
+	go c.electionLoop(loopCtx)
+	return nil
+}
+
+func (c *PostgresCoordinator) electionLoop(ctx context.Context) {
+	defer close(c.done)
+	ticker := time.NewTicker(c.electionPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.isLeader {
+				c.tryAcquire(ctx)
+			}
+		}
+	}
+}
+
+func (c *PostgresCoordinator) tryAcquire(ctx context.Context) {
+	var ok bool
+	if err := c.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", c.lockKey).Scan(&ok); err != nil {
+		log.Printf("coordinator: acquire advisory lock failed: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	c.isLeader = true
+	if _, err := c.conn.Exec(ctx, "NOTIFY "+leaderNotifyChannel); err != nil {
+		log.Printf("coordinator: notify %s failed: %v", leaderNotifyChannel, err)
+	}
+
+	leaderCtx, leaderCancel := context.WithCancel(ctx)
+	c.leaderCancel = leaderCancel
+	for _, fn := range c.onBecameLeader {
+		go fn(leaderCtx)
+	}
+}
+
+func (c *PostgresCoordinator) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.done != nil {
+		<-c.done
+	}
+	if c.isLeader {
+		c.isLeader = false
+		if c.leaderCancel != nil {
+			c.leaderCancel()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := c.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", c.lockKey); err != nil {
+			log.Printf("coordinator: release advisory lock failed: %v", err)
+		}
+		for _, fn := range c.onLostLeadership {
+			fn()
+		}
+	}
+	if c.conn != nil {
+		c.conn.Release()
+	}
+	return nil
+}
+
+// NoopCoordinator is the Coordinator used for single-instance backends
+// (SQLite): this instance is always leader, since there's no fleet to
+// coordinate with.
+type NoopCoordinator struct {
+	onBecameLeader []func(ctx context.Context)
+	cancel         context.CancelFunc
+}
+
+// NewNoopCoordinator returns a Coordinator that is always leader.
+func NewNoopCoordinator() *NoopCoordinator {
+	return &NoopCoordinator{}
+}
+
+func (c *NoopCoordinator) OnBecameLeader(fn func(ctx context.Context)) {
+	c.onBecameLeader = append(c.onBecameLeader, fn)
+}
+
+func (c *NoopCoordinator) OnLostLeadership(fn func()) {}
+
+func (c *NoopCoordinator) IsLeader() bool { return true }
+
+func (c *NoopCoordinator) Start(ctx context.Context) error {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	for _, fn := range c.onBecameLeader {
+		go fn(leaderCtx)
+	}
+	return nil
+}
+
+func (c *NoopCoordinator) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
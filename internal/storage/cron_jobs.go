@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// CronJobStatus is the persisted state of one scheduled job, as surfaced by
+// GET /cron/jobs.
+type CronJobStatus struct {
+	Name          string     `json:"name"`
+	CronExpr      string     `json:"cron_expr"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastSuccess   bool       `json:"last_success"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastCancelled bool       `json:"last_cancelled,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+}
+
+// CronJobStore is implemented by backends that persist cron_jobs rows
+// (currently PostgresPoolStorage, the only backend the distributed
+// scheduler runs against — SQLite deployments are single-instance and have
+// no need for cross-replica schedule visibility). The HTTP API type-asserts
+// against this to serve GET /cron/jobs.
+type CronJobStore interface {
+	// UpsertCronJobSchedule registers or updates a job's cron expression and
+	// next scheduled run, without touching its run history.
+	UpsertCronJobSchedule(ctx context.Context, name, cronExpr string, nextRun time.Time) error
+	// RecordCronJobRun updates a job's last-run outcome and next scheduled
+	// run. cancelled marks a run that was interrupted by scheduler shutdown
+	// (see cron.Scheduler's shutdownTimeout) rather than completing or
+	// failing on its own.
+	RecordCronJobRun(ctx context.Context, name string, ranAt time.Time, success bool, errMsg string, cancelled bool, nextRun time.Time) error
+	// ListCronJobs returns the status of every registered job, ordered by name.
+	ListCronJobs(ctx context.Context) ([]CronJobStatus, error)
+}
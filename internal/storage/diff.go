@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RateLineChange describes one flattened JSON field that differs between two
+// snapshots. Path is a dot-separated walk of the parsed rates JSON (array
+// indices included, e.g. "tiers.1.rate_per_kwh").
+type RateLineChange struct {
+	Path     string `json:"path"`
+	OldValue any    `json:"old_value,omitempty"`
+	NewValue any    `json:"new_value,omitempty"`
+	// PercentChange is (NewValue-OldValue)/OldValue*100, set only for Changed
+	// entries whose old and new values are both numbers and OldValue is
+	// non-zero (a zero baseline makes "percent change" undefined).
+	PercentChange *float64 `json:"percent_change,omitempty"`
+}
+
+// RatesDiff is a structured comparison of two rates_snapshots rows for the
+// same provider, suitable for answering "what changed between two filings?".
+type RatesDiff struct {
+	Provider string           `json:"provider"`
+	FromID   int64            `json:"from_id"`
+	ToID     int64            `json:"to_id"`
+	Added    []RateLineChange `json:"added,omitempty"`
+	Removed  []RateLineChange `json:"removed,omitempty"`
+	Changed  []RateLineChange `json:"changed,omitempty"`
+}
+
+// DiffRatesSnapshots fetches snapshots a and b of provider via hist and
+// returns a structured diff of their parsed rates JSON payloads. It's a
+// package-level helper rather than a SnapshotHistory method because diffing
+// is pure business logic over two already-fetched rows, independent of the
+// storage engine that served them.
+func DiffRatesSnapshots(ctx context.Context, hist SnapshotHistory, provider string, a, b int64) (*RatesDiff, error) {
+	from, err := hist.GetRatesSnapshotByID(ctx, a)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot %d: %w", a, err)
+	}
+	if from == nil {
+		return nil, fmt.Errorf("snapshot %d not found", a)
+	}
+	to, err := hist.GetRatesSnapshotByID(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot %d: %w", b, err)
+	}
+	if to == nil {
+		return nil, fmt.Errorf("snapshot %d not found", b)
+	}
+	if from.Provider != provider || to.Provider != provider {
+		return nil, fmt.Errorf("snapshots %d and %d do not both belong to provider %s", a, b, provider)
+	}
+
+	diff, err := DiffPayloads(provider, from.Payload, to.Payload)
+	if err != nil {
+		return nil, err
+	}
+	diff.FromID = a
+	diff.ToID = b
+	return diff, nil
+}
+
+// DiffRatesSnapshotsAt resolves the snapshots in effect for provider at
+// instants a and b (via hist.GetRatesSnapshotAt) and diffs them, letting
+// callers compare "what changed between these two dates" without first
+// looking up snapshot IDs themselves.
+func DiffRatesSnapshotsAt(ctx context.Context, hist SnapshotHistory, provider string, a, b time.Time) (*RatesDiff, error) {
+	from, err := hist.GetRatesSnapshotAt(ctx, provider, a)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot at %s: %w", a, err)
+	}
+	if from == nil {
+		return nil, fmt.Errorf("no snapshot for %s existed yet at %s", provider, a)
+	}
+	to, err := hist.GetRatesSnapshotAt(ctx, provider, b)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot at %s: %w", b, err)
+	}
+	if to == nil {
+		return nil, fmt.Errorf("no snapshot for %s existed yet at %s", provider, b)
+	}
+
+	diff, err := DiffPayloads(provider, from.Payload, to.Payload)
+	if err != nil {
+		return nil, err
+	}
+	diff.FromID = from.ID
+	diff.ToID = to.ID
+	return diff, nil
+}
+
+// DiffPayloads flattens and compares two rates_snapshots payloads directly,
+// without requiring either one to already be a stored *RatesSnapshot row.
+// DiffRatesSnapshots and DiffRatesSnapshotsAt both resolve their two rows and
+// delegate here.
+func DiffPayloads(provider string, fromPayload, toPayload []byte) (*RatesDiff, error) {
+	fromFields, err := flattenJSON(fromPayload)
+	if err != nil {
+		return nil, fmt.Errorf("parse from payload: %w", err)
+	}
+	toFields, err := flattenJSON(toPayload)
+	if err != nil {
+		return nil, fmt.Errorf("parse to payload: %w", err)
+	}
+
+	diff := &RatesDiff{Provider: provider}
+	for path, oldVal := range fromFields {
+		newVal, ok := toFields[path]
+		if !ok {
+			diff.Removed = append(diff.Removed, RateLineChange{Path: path, OldValue: oldVal})
+			continue
+		}
+		if !jsonEqual(oldVal, newVal) {
+			diff.Changed = append(diff.Changed, RateLineChange{
+				Path:          path,
+				OldValue:      oldVal,
+				NewValue:      newVal,
+				PercentChange: percentChange(oldVal, newVal),
+			})
+		}
+	}
+	for path, newVal := range toFields {
+		if _, ok := fromFields[path]; !ok {
+			diff.Added = append(diff.Added, RateLineChange{Path: path, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Path < diff.Added[j].Path })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Path < diff.Removed[j].Path })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Path < diff.Changed[j].Path })
+
+	return diff, nil
+}
+
+// percentChange returns (new-old)/old*100 when old and new are both JSON
+// numbers and old is non-zero, and nil otherwise (a non-numeric field or a
+// zero baseline has no meaningful percent change).
+func percentChange(oldVal, newVal any) *float64 {
+	o, ok := oldVal.(float64)
+	if !ok || o == 0 {
+		return nil
+	}
+	n, ok := newVal.(float64)
+	if !ok {
+		return nil
+	}
+	pct := (n - o) / o * 100
+	return &pct
+}
+
+// flattenJSON parses payload as arbitrary JSON and flattens it into
+// dot/index-path -> scalar-value pairs, so two differently-shaped-but-
+// related rates documents can be diffed field by field without this package
+// depending on the rates package's concrete response structs.
+func flattenJSON(payload []byte) (map[string]any, error) {
+	var v any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, err
+	}
+	out := make(map[string]any)
+	flattenInto(out, "", v)
+	return out, nil
+}
+
+func flattenInto(out map[string]any, prefix string, v any) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			out[prefix] = val
+			return
+		}
+		for k, child := range val {
+			flattenInto(out, joinPath(prefix, k), child)
+		}
+	case []any:
+		if len(val) == 0 {
+			out[prefix] = val
+			return
+		}
+		for i, child := range val {
+			flattenInto(out, fmt.Sprintf("%s.%d", prefix, i), child)
+		}
+	default:
+		out[prefix] = val
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func jsonEqual(a, b any) bool {
+	aBytes, errA := json.Marshal(a)
+	bBytes, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aBytes) == string(bBytes)
+}
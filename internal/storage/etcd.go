@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdStorage implements Storage on top of etcd, letting multiple
+// eratemanager instances share state behind a load balancer without a
+// shared SQL server. Providers live under /eratemanager/providers/<key>,
+// snapshots under /eratemanager/snapshots/<provider>/<revision> (the
+// revision is the snapshot's FetchedAt in UnixNano, zero-padded so lexical
+// and chronological order agree), and batch progress under
+// /eratemanager/batches/<batch_id>/<provider>.
+type EtcdStorage struct {
+	client *clientv3.Client
+}
+
+const etcdKeyPrefix = "/eratemanager/"
+
+// OpenEtcd connects to an etcd cluster and returns an EtcdStorage, symmetric
+// with OpenSQLite/OpenPostgresPool. opts is passed through to
+// clientv3.Config for dial timeout, TLS, auth, etc.
+func OpenEtcd(ctx context.Context, endpoints []string, opts clientv3.Config) (*EtcdStorage, error) {
+	cfg := opts
+	cfg.Endpoints = endpoints
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+
+	client, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect to etcd: %w", err)
+	}
+	return &EtcdStorage{client: client}, nil
+}
+
+func (s *EtcdStorage) Close() error { return s.client.Close() }
+
+func (s *EtcdStorage) Ping(ctx context.Context) error {
+	_, err := s.client.Get(ctx, etcdKeyPrefix+"ping")
+	return err
+}
+
+// Migrate is a no-op for etcd: there's no schema to create, only keys
+// written on demand. It seeds a schema-version key so `etcdctl get
+// --prefix /eratemanager/` shows something even before the first write.
+func (s *EtcdStorage) Migrate(ctx context.Context) error {
+	_, err := s.client.Put(ctx, etcdKeyPrefix+"schema_version", "1")
+	return err
+}
+
+func providerKey(key string) string { return etcdKeyPrefix + "providers/" + key }
+
+func (s *EtcdStorage) ListProviders(ctx context.Context) ([]Provider, error) {
+	resp, err := s.client.Get(ctx, etcdKeyPrefix+"providers/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Provider, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var p Provider
+		if err := json.Unmarshal(kv.Value, &p); err != nil {
+			return nil, fmt.Errorf("decode provider %s: %w", kv.Key, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (s *EtcdStorage) GetProvider(ctx context.Context, key string) (*Provider, error) {
+	resp, err := s.client.Get(ctx, providerKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	var p Provider
+	if err := json.Unmarshal(resp.Kvs[0].Value, &p); err != nil {
+		return nil, fmt.Errorf("decode provider %s: %w", key, err)
+	}
+	return &p, nil
+}
+
+// UpsertProvider writes p via a Compare-and-swap transaction on the key's
+// ModRevision so a concurrent writer's update can't be silently lost, the
+// same optimistic-concurrency guarantee the SQL backends get from
+// ON CONFLICT, but native to etcd instead of emulated.
+func (s *EtcdStorage) UpsertProvider(ctx context.Context, p Provider) error {
+	key := providerKey(p.Key)
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	for {
+		cur, err := s.client.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		var modRev int64
+		if len(cur.Kvs) > 0 {
+			modRev = cur.Kvs[0].ModRevision
+		}
+
+		txn := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", modRev)).
+			Then(clientv3.OpPut(key, string(data)))
+		resp, err := txn.Commit()
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+		// Another writer changed the row between our Get and Commit; retry.
+	}
+}
+
+func snapshotPrefix(provider string) string { return etcdKeyPrefix + "snapshots/" + provider + "/" }
+
+func snapshotRevisionKey(provider string, fetchedAt time.Time) string {
+	return fmt.Sprintf("%s%020d", snapshotPrefix(provider), fetchedAt.UnixNano())
+}
+
+func (s *EtcdStorage) GetRatesSnapshot(ctx context.Context, provider string) (*RatesSnapshot, error) {
+	resp, err := s.client.Get(ctx, snapshotPrefix(provider),
+		clientv3.WithPrefix(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+		clientv3.WithLimit(1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	var snap RatesSnapshot
+	if err := json.Unmarshal(resp.Kvs[0].Value, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot for %s: %w", provider, err)
+	}
+	return &snap, nil
+}
+
+func (s *EtcdStorage) SaveRatesSnapshot(ctx context.Context, snap RatesSnapshot) error {
+	if snap.FetchedAt.IsZero() {
+		snap.FetchedAt = time.Now()
+	}
+
+	prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+	if err != nil {
+		prev = nil
+	}
+
+	hash := contentHash(snap.Payload)
+	if prev != nil && prev.ContentHash == hash {
+		// Unchanged payload; skip the write so an idle provider doesn't
+		// grow the snapshot history every cycle.
+		return nil
+	}
+	snap.ContentHash = hash
+	snap.Version = 1
+	if prev != nil {
+		snap.Version = prev.Version + 1
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Put(ctx, snapshotRevisionKey(snap.Provider, snap.FetchedAt), string(data))
+	return err
+}
+
+// SaveRatesSnapshotIfVersion behaves like SaveRatesSnapshot but first checks
+// that the provider's current stored version matches expectedVersion,
+// returning ErrVersionConflict if another writer has already advanced it.
+func (s *EtcdStorage) SaveRatesSnapshotIfVersion(ctx context.Context, snap RatesSnapshot, expectedVersion int) error {
+	prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+	if err != nil {
+		return err
+	}
+	current := 0
+	if prev != nil {
+		current = prev.Version
+	}
+	if current != expectedVersion {
+		return ErrVersionConflict
+	}
+	return s.SaveRatesSnapshot(ctx, snap)
+}
+
+func batchProgressKey(batchID, provider string) string {
+	return fmt.Sprintf("%sbatches/%s/%s", etcdKeyPrefix, batchID, provider)
+}
+
+// batchProgressLeaseTTL bounds how long an in-progress batch row can
+// outlive the worker that wrote it; if the worker crashes without
+// completing, etcd expires the key and GetPendingBatchProviders sees the
+// provider as available again.
+const batchProgressLeaseTTL = 10 * time.Minute
+
+// SaveBatchProgress writes progress via a Compare-and-swap transaction on
+// the row's ModRevision, mirroring UpsertProvider's optimistic-concurrency
+// pattern. Non-terminal statuses are attached to a lease so a crashed
+// worker's row expires instead of blocking the provider forever.
+func (s *EtcdStorage) SaveBatchProgress(ctx context.Context, progress BatchProgress) error {
+	key := batchProgressKey(progress.BatchID, progress.Provider)
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	var putOpts []clientv3.OpOption
+	if progress.Status != "completed" {
+		lease, err := s.client.Grant(ctx, int64(batchProgressLeaseTTL.Seconds()))
+		if err != nil {
+			return fmt.Errorf("grant lease: %w", err)
+		}
+		putOpts = append(putOpts, clientv3.WithLease(lease.ID))
+	}
+
+	for {
+		cur, err := s.client.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		var modRev int64
+		if len(cur.Kvs) > 0 {
+			modRev = cur.Kvs[0].ModRevision
+		}
+
+		txn := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", modRev)).
+			Then(clientv3.OpPut(key, string(data), putOpts...))
+		resp, err := txn.Commit()
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+}
+
+func (s *EtcdStorage) GetBatchProgress(ctx context.Context, batchID, provider string) (*BatchProgress, error) {
+	resp, err := s.client.Get(ctx, batchProgressKey(batchID, provider))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	var bp BatchProgress
+	if err := json.Unmarshal(resp.Kvs[0].Value, &bp); err != nil {
+		return nil, fmt.Errorf("decode batch progress %s/%s: %w", batchID, provider, err)
+	}
+	return &bp, nil
+}
+
+func (s *EtcdStorage) GetPendingBatchProviders(ctx context.Context, batchID string) ([]string, error) {
+	resp, err := s.client.Get(ctx, fmt.Sprintf("%sbatches/%s/", etcdKeyPrefix, batchID), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	var providers []string
+	for _, kv := range resp.Kvs {
+		var bp BatchProgress
+		if err := json.Unmarshal(kv.Value, &bp); err != nil {
+			return nil, fmt.Errorf("decode batch progress %s: %w", kv.Key, err)
+		}
+		if bp.Status == "pending" || bp.Status == "failed" {
+			providers = append(providers, bp.Provider)
+		}
+	}
+	return providers, nil
+}
+
+func circuitStateKey(provider string) string { return etcdKeyPrefix + "circuits/" + provider }
+
+// SaveCircuitState upserts provider's circuit breaker state. Unlike
+// UpsertProvider/SaveBatchProgress it's a plain overwrite rather than a
+// CAS-retry loop, since a circuit breaker decision racing another replica's
+// is harmless - the next refresh cycle reconciles it either way.
+func (s *EtcdStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+	data, err := json.Marshal(cs)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Put(ctx, circuitStateKey(cs.Provider), string(data))
+	return err
+}
+
+// GetCircuitState returns provider's circuit breaker state, or nil if it has
+// never tripped.
+func (s *EtcdStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+	resp, err := s.client.Get(ctx, circuitStateKey(provider))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	var cs CircuitState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &cs); err != nil {
+		return nil, fmt.Errorf("decode circuit state %s: %w", provider, err)
+	}
+	return &cs, nil
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
+
+	"github.com/bher20/eratemanager/internal/metrics"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 // Config controls how the storage backend is opened.
@@ -11,6 +15,40 @@ type Config struct {
 	Driver    string
 	DSN       string
 	Providers []Provider
+
+	// Blob configures an optional BlobStore that SaveRatesSnapshot offloads
+	// large payloads to. Left zero-valued, snapshots are stored inline in
+	// the SQL row (the prior behavior).
+	Blob BlobConfig
+
+	// Valkey configures TLS for the "valkey" driver. Ignored by every other
+	// driver.
+	Valkey ValkeyConfig
+}
+
+// blobStoreSetter is implemented by the backends that support offloading
+// snapshot payloads to a BlobStore (SQLiteStorage, PostgresStorage).
+type blobStoreSetter interface {
+	SetBlobStore(BlobStore)
+}
+
+// wireBlobStore opens cfg's BlobStore, if configured, and wires it into st
+// when st supports it.
+func wireBlobStore(st Storage, cfg BlobConfig) error {
+	if cfg.Driver == "" {
+		return nil
+	}
+	bs, err := OpenBlobStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open blob store: %w", err)
+	}
+	if setter, ok := st.(blobStoreSetter); ok {
+		setter.SetBlobStore(bs)
+		metrics.UpdateBlobStoreMetrics(cfg.Driver)
+	} else {
+		log.Printf("storage: backend does not support blob offload, ignoring Blob config")
+	}
+	return nil
 }
 
 // Open constructs a Storage based on the given configuration.
@@ -37,6 +75,10 @@ func Open(ctx context.Context, cfg Config) (Storage, error) {
 			st.Close()
 			return nil, fmt.Errorf("sqlite migrate: %w", err)
 		}
+		if err := wireBlobStore(st, cfg.Blob); err != nil {
+			st.Close()
+			return nil, err
+		}
 		return st, nil
 
 	case "postgres":
@@ -49,6 +91,10 @@ func Open(ctx context.Context, cfg Config) (Storage, error) {
 			st.Close()
 			return nil, fmt.Errorf("postgres migrate: %w", err)
 		}
+		if err := wireBlobStore(st, cfg.Blob); err != nil {
+			st.Close()
+			return nil, err
+		}
 		return st, nil
 
 	case "postgrespool":
@@ -63,6 +109,32 @@ func Open(ctx context.Context, cfg Config) (Storage, error) {
 		}
 		return st, nil
 
+	case "etcd":
+		endpoints := strings.Split(cfg.DSN, ",")
+		log.Printf("storage: using etcd backend endpoints=%v", endpoints)
+		st, err := OpenEtcd(ctx, endpoints, clientv3.Config{})
+		if err != nil {
+			return nil, err
+		}
+		if err := st.Migrate(ctx); err != nil {
+			st.Close()
+			return nil, fmt.Errorf("etcd migrate: %w", err)
+		}
+		return st, nil
+
+	case "valkey":
+		addrs := strings.Split(cfg.DSN, ",")
+		log.Printf("storage: using valkey backend addrs=%v tls=%v", addrs, cfg.Valkey.TLS)
+		st, err := OpenValkey(ctx, addrs, cfg.Valkey)
+		if err != nil {
+			return nil, err
+		}
+		if err := st.Migrate(ctx); err != nil {
+			st.Close()
+			return nil, fmt.Errorf("valkey migrate: %w", err)
+		}
+		return st, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported storage driver %q", drv)
 	}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/bher20/eratemanager/internal/logging"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slogGormLogger adapts gorm's logger.Interface to internal/logging so SQL
+// warnings and errors share the same dedup/JSON-file/stderr pipeline as the
+// rest of storage, instead of GORM's own stdlib-log default.
+type slogGormLogger struct {
+	l             *slog.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// newSlogGormLogger builds a gorm logger.Interface backed by l, logging at
+// or below level (gormlogger.Warn matches the previous logger.Default.LogMode
+// default: skip per-row Info/trace noise, keep warnings and errors).
+func newSlogGormLogger(l *slog.Logger, level gormlogger.LogLevel) gormlogger.Interface {
+	return &slogGormLogger{l: l, level: level, slowThreshold: 200 * time.Millisecond}
+}
+
+func (g *slogGormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+func (g *slogGormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if g.level < gormlogger.Info {
+		return
+	}
+	g.l.InfoContext(ctx, "gorm: "+msg, "args", args)
+}
+
+func (g *slogGormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if g.level < gormlogger.Warn {
+		return
+	}
+	g.l.WarnContext(ctx, "gorm: "+msg, "args", args)
+}
+
+func (g *slogGormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if g.level < gormlogger.Error {
+		return
+	}
+	g.l.ErrorContext(ctx, "gorm: "+msg, "args", args)
+}
+
+func (g *slogGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+
+	switch {
+	case err != nil && g.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		g.l.ErrorContext(ctx, "gorm: query failed", "error", err, "sql", sql, "rows", rows, "duration", elapsed)
+	case g.slowThreshold > 0 && elapsed > g.slowThreshold && g.level >= gormlogger.Warn:
+		g.l.WarnContext(ctx, "gorm: slow query", "sql", sql, "rows", rows, "duration", elapsed)
+	case g.level >= gormlogger.Info:
+		g.l.InfoContext(ctx, "gorm: query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
+
+// defaultGormLogger returns the slog-backed logger used when NewGormStorage
+// isn't given a custom one, matching the prior logger.Default.LogMode(Warn)
+// verbosity.
+func defaultGormLogger() gormlogger.Interface {
+	return newSlogGormLogger(logging.Default(), gormlogger.Warn)
+}
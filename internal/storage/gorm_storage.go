@@ -2,39 +2,144 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/glebarez/sqlite"
+	"github.com/bher20/eratemanager/internal/metrics"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"gorm.io/gorm/logger"
 )
 
 type GormStorage struct {
-	db *gorm.DB
+	db         *gorm.DB
+	driver     string
+	stopPoolMu sync.Once
+	stopPool   chan struct{}
+}
+
+// DialectorFactory builds a gorm.Dialector for a DSN. Implementations are
+// registered with RegisterDriver so NewGormStorage can plug in drivers
+// (MySQL, CockroachDB, SQL Server, ...) without this package depending on
+// their import directly.
+type DialectorFactory func(dsn string) (gorm.Dialector, error)
+
+var (
+	driverRegistryMu sync.RWMutex
+	driverRegistry   = map[string]DialectorFactory{
+		"postgres":     func(dsn string) (gorm.Dialector, error) { return postgres.Open(dsn), nil },
+		"postgrespool": func(dsn string) (gorm.Dialector, error) { return postgres.Open(dsn), nil },
+	}
+)
+
+// RegisterDriver registers a dialector factory under name, so
+// NewGormStorage(name, dsn) can open it. Call from an init() in a file that
+// imports the driver's gorm package, e.g. a MySQL or CockroachDB adapter.
+func RegisterDriver(name string, factory DialectorFactory) {
+	driverRegistryMu.Lock()
+	defer driverRegistryMu.Unlock()
+	driverRegistry[name] = factory
+}
+
+// GormOption configures NewGormStorage beyond the driver/dsn pair.
+type GormOption func(*gormOptions)
+
+type gormOptions struct {
+	maxOpenConns     int
+	maxIdleConns     int
+	connMaxLifetime  time.Duration
+	connMaxIdleTime  time.Duration
+	poolPollInterval time.Duration
+}
+
+// WithPoolConfig tunes the underlying *sql.DB connection pool. A zero value
+// for any argument leaves Go's database/sql default for that setting.
+func WithPoolConfig(maxOpen, maxIdle int, connMaxLifetime, connMaxIdleTime time.Duration) GormOption {
+	return func(o *gormOptions) {
+		o.maxOpenConns = maxOpen
+		o.maxIdleConns = maxIdle
+		o.connMaxLifetime = connMaxLifetime
+		o.connMaxIdleTime = connMaxIdleTime
+	}
 }
 
-func NewGormStorage(driver, dsn string) (*GormStorage, error) {
-	var gormDialector gorm.Dialector
-	if driver == "postgres" || driver == "postgrespool" {
-		gormDialector = postgres.Open(dsn)
-	} else if driver == "sqlite" {
-		gormDialector = sqlite.Open(dsn)
-	} else {
+// defaultPoolPollInterval is how often the background goroutine samples
+// *sql.DB.Stats() and reports them to metrics.UpdateDBPoolMetrics.
+const defaultPoolPollInterval = 15 * time.Second
+
+func NewGormStorage(driver, dsn string, opts ...GormOption) (*GormStorage, error) {
+	driverRegistryMu.RLock()
+	factory, ok := driverRegistry[driver]
+	driverRegistryMu.RUnlock()
+	if !ok {
 		return nil, fmt.Errorf("unsupported driver: %s", driver)
 	}
 
+	gormDialector, err := factory(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("build dialector for %s: %w", driver, err)
+	}
+
 	db, err := gorm.Open(gormDialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Warn),
+		Logger: defaultGormLogger(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &GormStorage{db: db}, nil
+	cfg := gormOptions{poolPollInterval: defaultPoolPollInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.maxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.maxOpenConns)
+	}
+	if cfg.maxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.maxIdleConns)
+	}
+	if cfg.connMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.connMaxLifetime)
+	}
+	if cfg.connMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.connMaxIdleTime)
+	}
+
+	s := &GormStorage{db: db, driver: driver, stopPool: make(chan struct{})}
+	go s.pollPoolMetrics(sqlDB, cfg.poolPollInterval)
+
+	return s, nil
+}
+
+// pollPoolMetrics periodically reports *sql.DB.Stats() to
+// metrics.UpdateDBPoolMetrics under the storage's driver label, so the
+// eratemanager_db_pool_* gauges are populated for the GORM path the same
+// way they already are for PostgresPoolStorage.
+func (s *GormStorage) pollPoolMetrics(sqlDB *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopPool:
+			return
+		case <-ticker.C:
+			stats := sqlDB.Stats()
+			metrics.UpdateDBPoolMetrics(
+				s.driver,
+				float64(stats.OpenConnections),
+				float64(stats.Idle),
+				float64(stats.InUse),
+				uint64(stats.WaitCount),
+			)
+		}
+	}
 }
 
 func (s *GormStorage) Migrate(ctx context.Context) error {
@@ -42,7 +147,7 @@ func (s *GormStorage) Migrate(ctx context.Context) error {
 	// Note: We need to define structs that cover all tables.
 	// We use the structs from models.go mostly.
 	// For tables not in models.go like 'scheduled_jobs', we define them locally or add to models.
-	
+
 	err := s.db.AutoMigrate(
 		&Provider{},
 		&RatesSnapshot{},
@@ -52,7 +157,18 @@ func (s *GormStorage) Migrate(ctx context.Context) error {
 		&Token{},
 		&CasbinRule{},
 		&EmailConfig{},
+		&NotificationChannelConfig{},
+		&PendingNotification{},
 		&ScheduledJob{},
+		&OIDCProvider{},
+		&AuditEvent{},
+		&PasswordPolicyConfig{},
+		&LoginAttempt{},
+		&KnownDevice{},
+		&AlertWebhookSubscription{},
+		&AlertWebhookDeadLetter{},
+		&ProviderHealthSample{},
+		&CircuitState{},
 	)
 	return err
 }
@@ -149,6 +265,27 @@ func (s *GormStorage) GetPendingBatchProviders(ctx context.Context, batchID stri
 	return providers, result.Error
 }
 
+// CircuitState
+
+func (s *GormStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}},
+		UpdateAll: true,
+	}).Create(&cs).Error
+}
+
+func (s *GormStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+	var cs CircuitState
+	result := s.db.WithContext(ctx).First(&cs, "provider = ?", provider)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &cs, nil
+}
+
 // Settings
 
 func (s *GormStorage) GetSetting(ctx context.Context, key string) (string, error) {
@@ -271,9 +408,30 @@ func (s *GormStorage) DeleteToken(ctx context.Context, id string) error {
 	return s.db.WithContext(ctx).Delete(&Token{}, "id = ?", id).Error
 }
 
-func (s *GormStorage) UpdateTokenLastUsed(ctx context.Context, id string) error {
+func (s *GormStorage) UpdateTokenLastUsed(ctx context.Context, id, ip string) error {
+	return s.db.WithContext(ctx).Model(&Token{}).Where("id = ?", id).Updates(map[string]any{
+		"last_used_at": time.Now(),
+		"last_used_ip": ip,
+	}).Error
+}
+
+func (s *GormStorage) RevokeToken(ctx context.Context, id string) error {
 	now := time.Now()
-	return s.db.WithContext(ctx).Model(&Token{}).Where("id = ?", id).Update("last_used_at", now).Error
+	return s.db.WithContext(ctx).Model(&Token{}).Where("id = ?", id).Update("revoked_at", now).Error
+}
+
+func (s *GormStorage) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	return s.db.WithContext(ctx).Model(&Token{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+func (s *GormStorage) RevokeTokensForUser(ctx context.Context, userID string) error {
+	now := time.Now()
+	return s.db.WithContext(ctx).Model(&Token{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
 }
 
 // Casbin Rules
@@ -292,6 +450,74 @@ func (s *GormStorage) RemoveCasbinRule(ctx context.Context, rule CasbinRule) err
 	return s.db.WithContext(ctx).Where(&rule).Delete(&CasbinRule{}).Error
 }
 
+func (s *GormStorage) AddCasbinRules(ctx context.Context, rules []CasbinRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Create(&rules).Error
+}
+
+// RemoveFilteredCasbinRule deletes rows of ptype whose V0..V5 columns equal
+// fieldValues, where fieldIndex is the column fieldValues[0] starts at
+// (persist.Adapter's RemoveFilteredPolicy convention). A rule built with
+// Where(&rule) ignores the columns left as the zero value, exactly as
+// RemoveCasbinRule already relies on.
+func (s *GormStorage) RemoveFilteredCasbinRule(ctx context.Context, ptype string, fieldIndex int, fieldValues ...string) error {
+	rule := CasbinRule{PType: ptype}
+	last := fieldIndex + len(fieldValues)
+	if fieldIndex <= 0 && 0 < last {
+		rule.V0 = fieldValues[0-fieldIndex]
+	}
+	if fieldIndex <= 1 && 1 < last {
+		rule.V1 = fieldValues[1-fieldIndex]
+	}
+	if fieldIndex <= 2 && 2 < last {
+		rule.V2 = fieldValues[2-fieldIndex]
+	}
+	if fieldIndex <= 3 && 3 < last {
+		rule.V3 = fieldValues[3-fieldIndex]
+	}
+	if fieldIndex <= 4 && 4 < last {
+		rule.V4 = fieldValues[4-fieldIndex]
+	}
+	if fieldIndex <= 5 && 5 < last {
+		rule.V5 = fieldValues[5-fieldIndex]
+	}
+	return s.db.WithContext(ctx).Where(&rule).Delete(&CasbinRule{}).Error
+}
+
+func (s *GormStorage) LoadFilteredCasbinRules(ctx context.Context, filter CasbinFilter) ([]CasbinRule, error) {
+	q := s.db.WithContext(ctx)
+	if len(filter.PType) > 0 {
+		q = q.Where("ptype IN ?", filter.PType)
+	}
+	if len(filter.V0) > 0 {
+		q = q.Where("v0 IN ?", filter.V0)
+	}
+	if len(filter.V1) > 0 {
+		q = q.Where("v1 IN ?", filter.V1)
+	}
+	if len(filter.V2) > 0 {
+		q = q.Where("v2 IN ?", filter.V2)
+	}
+	if len(filter.V3) > 0 {
+		q = q.Where("v3 IN ?", filter.V3)
+	}
+	if len(filter.V4) > 0 {
+		q = q.Where("v4 IN ?", filter.V4)
+	}
+	if len(filter.V5) > 0 {
+		q = q.Where("v5 IN ?", filter.V5)
+	}
+	var rules []CasbinRule
+	result := q.Find(&rules)
+	return rules, result.Error
+}
+
+func (s *GormStorage) ClearCasbinRules(ctx context.Context) error {
+	return s.db.WithContext(ctx).Where("1 = 1").Delete(&CasbinRule{}).Error
+}
+
 // Email Config
 
 func (s *GormStorage) GetEmailConfig(ctx context.Context) (*EmailConfig, error) {
@@ -317,9 +543,244 @@ func (s *GormStorage) SaveEmailConfig(ctx context.Context, config EmailConfig) e
 	}).Create(&config).Error
 }
 
+func (s *GormStorage) GetPasswordPolicyConfig(ctx context.Context) (*PasswordPolicyConfig, error) {
+	var config PasswordPolicyConfig
+	result := s.db.WithContext(ctx).First(&config)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &config, nil
+}
+
+func (s *GormStorage) SavePasswordPolicyConfig(ctx context.Context, config PasswordPolicyConfig) error {
+	if config.ID == "" {
+		config.ID = "default" // Force single row if not specified
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(&config).Error
+}
+
+// Notification channel configs (webhook, Slack, Discord, ntfy)
+
+func (s *GormStorage) GetNotificationChannelConfig(ctx context.Context, channel string) (*NotificationChannelConfig, error) {
+	var config NotificationChannelConfig
+	result := s.db.WithContext(ctx).Where("id = ?", channel).First(&config)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &config, nil
+}
+
+func (s *GormStorage) ListNotificationChannelConfigs(ctx context.Context) ([]NotificationChannelConfig, error) {
+	var configs []NotificationChannelConfig
+	result := s.db.WithContext(ctx).Find(&configs)
+	return configs, result.Error
+}
+
+func (s *GormStorage) SaveNotificationChannelConfig(ctx context.Context, config NotificationChannelConfig) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(&config).Error
+}
+
+// OIDC providers (SSO config)
+
+func (s *GormStorage) GetOIDCProvider(ctx context.Context, id string) (*OIDCProvider, error) {
+	var p OIDCProvider
+	result := s.db.WithContext(ctx).Where("id = ?", id).First(&p)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &p, nil
+}
+
+func (s *GormStorage) ListOIDCProviders(ctx context.Context) ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	result := s.db.WithContext(ctx).Find(&providers)
+	return providers, result.Error
+}
+
+func (s *GormStorage) SaveOIDCProvider(ctx context.Context, p OIDCProvider) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(&p).Error
+}
+
+func (s *GormStorage) DeleteOIDCProvider(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&OIDCProvider{}).Error
+}
+
+// Audit log (hash-chained security events)
+
+func (s *GormStorage) CreateAuditEvent(ctx context.Context, e AuditEvent) error {
+	return s.db.WithContext(ctx).Create(&e).Error
+}
+
+func (s *GormStorage) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	q := s.db.WithContext(ctx).Model(&AuditEvent{})
+	if filter.Actor != "" {
+		q = q.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("timestamp >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("timestamp <= ?", filter.Until)
+	}
+	q = q.Order("timestamp DESC")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+
+	var events []AuditEvent
+	result := q.Find(&events)
+	return events, result.Error
+}
+
+func (s *GormStorage) GetLastAuditEvent(ctx context.Context) (*AuditEvent, error) {
+	var e AuditEvent
+	result := s.db.WithContext(ctx).Order("timestamp DESC").First(&e)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &e, nil
+}
+
+// Login throttling
+
+func (s *GormStorage) GetLoginAttempt(ctx context.Context, key string) (*LoginAttempt, error) {
+	var a LoginAttempt
+	result := s.db.WithContext(ctx).Where("key = ?", key).First(&a)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &a, nil
+}
+
+func (s *GormStorage) SaveLoginAttempt(ctx context.Context, attempt LoginAttempt) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		UpdateAll: true,
+	}).Create(&attempt).Error
+}
+
+func (s *GormStorage) ClearLoginAttempt(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&LoginAttempt{}).Error
+}
+
+func (s *GormStorage) ListLockedLoginAttempts(ctx context.Context) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	result := s.db.WithContext(ctx).Where("locked_until > ?", time.Now()).Find(&attempts)
+	return attempts, result.Error
+}
+
+// Known devices (for new-sign-in notifications)
+
+func (s *GormStorage) GetKnownDevice(ctx context.Context, userID, fingerprint string) (*KnownDevice, error) {
+	var d KnownDevice
+	result := s.db.WithContext(ctx).Where("user_id = ? AND fingerprint = ?", userID, fingerprint).First(&d)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &d, nil
+}
+
+func (s *GormStorage) SaveKnownDevice(ctx context.Context, device KnownDevice) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "fingerprint"}},
+		UpdateAll: true,
+	}).Create(&device).Error
+}
+
+// Webhook subscriptions and dead letters (alerting.Alerter)
+
+func (s *GormStorage) SaveAlertWebhookSubscription(ctx context.Context, sub AlertWebhookSubscription) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(&sub).Error
+}
+
+func (s *GormStorage) ListAlertWebhookSubscriptions(ctx context.Context) ([]AlertWebhookSubscription, error) {
+	var subs []AlertWebhookSubscription
+	result := s.db.WithContext(ctx).Find(&subs)
+	return subs, result.Error
+}
+
+func (s *GormStorage) DeleteAlertWebhookSubscription(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&AlertWebhookSubscription{}).Error
+}
+
+func (s *GormStorage) SaveAlertWebhookDeadLetter(ctx context.Context, dl AlertWebhookDeadLetter) error {
+	return s.db.WithContext(ctx).Create(&dl).Error
+}
+
+func (s *GormStorage) ListAlertWebhookDeadLetters(ctx context.Context) ([]AlertWebhookDeadLetter, error) {
+	var letters []AlertWebhookDeadLetter
+	result := s.db.WithContext(ctx).Order("created_at DESC").Find(&letters)
+	return letters, result.Error
+}
+
+// Provider health samples (providerhealth.Monitor)
+
+func (s *GormStorage) AppendProviderHealthSample(ctx context.Context, sample ProviderHealthSample) error {
+	return s.db.WithContext(ctx).Create(&sample).Error
+}
+
+func (s *GormStorage) ListProviderHealthSamples(ctx context.Context, key string, limit int) ([]ProviderHealthSample, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	var samples []ProviderHealthSample
+	result := s.db.WithContext(ctx).Where("key = ?", key).Order("checked_at DESC").Limit(limit).Find(&samples)
+	return samples, result.Error
+}
+
+// Pending notifications (in-memory queue spill on shutdown)
+
+func (s *GormStorage) SavePendingNotification(ctx context.Context, pending PendingNotification) error {
+	return s.db.WithContext(ctx).Create(&pending).Error
+}
+
+func (s *GormStorage) ListPendingNotifications(ctx context.Context) ([]PendingNotification, error) {
+	var pending []PendingNotification
+	result := s.db.WithContext(ctx).Order("queued_at").Find(&pending)
+	return pending, result.Error
+}
+
+func (s *GormStorage) DeletePendingNotification(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&PendingNotification{}).Error
+}
+
 // Close & Ping
 
 func (s *GormStorage) Close() error {
+	s.stopPoolMu.Do(func() { close(s.stopPool) })
 	sqlDB, err := s.db.DB()
 	if err != nil {
 		return err
@@ -5,9 +5,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bher20/eratemanager/internal/rates"
 	"github.com/bher20/eratemanager/internal/storage"
 	"github.com/bher20/eratemanager/pkg/providers/electricproviders"
-	"github.com/bher20/eratemanager/pkg/providers/waterproviders"
 )
 
 // MemoryStorage is an in-memory Storage implementation, useful for tests and
@@ -34,12 +34,12 @@ func New() *MemoryStorage {
 			DefaultPDFPath: p.DefaultPDFPath(),
 		}
 	}
-	for _, p := range waterproviders.GetAll() {
-		m.providers[p.Key()] = storage.Provider{
-			Key:            p.Key(),
-			Name:           p.Name(),
-			LandingURL:     p.LandingURL(),
-			DefaultPDFPath: p.DefaultPDFPath(),
+	for _, p := range rates.WaterProviders() {
+		m.providers[p.Key] = storage.Provider{
+			Key:            p.Key,
+			Name:           p.Name,
+			LandingURL:     p.LandingURL,
+			DefaultPDFPath: p.DefaultPDFPath,
 		}
 	}
 
@@ -2,25 +2,78 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"log"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/bher20/eratemanager/internal/alerts"
+	"github.com/bher20/eratemanager/internal/logging"
+	"github.com/bher20/eratemanager/internal/webhooks"
+	"github.com/google/uuid"
 )
 
 // MemoryStorage is an in-memory Storage implementation, useful for tests and
 // simple single-process deployments.
 type MemoryStorage struct {
-	mu            sync.RWMutex
-	providers     map[string]Provider
-	snaps         map[string]RatesSnapshot
-	batchProgress map[string]BatchProgress
+	mu                  sync.RWMutex
+	providers           map[string]Provider
+	snaps               map[string]RatesSnapshot
+	batchProgress       map[string]BatchProgress
+	webhookSubs         map[string]WebhookSubscription
+	webhookDeliveries   []WebhookDelivery
+	webhookDispatcher   *webhooks.Dispatcher
+	alertThresholds     *alerts.Thresholds
+	alertsByKey         map[string]Alert
+	leaseKeyByID        map[string]string
+	ratesChangeNotifier RatesChangeNotifier
+	rateHistory         map[string][]RateHistoryPoint
+	rateHistoryLatest   map[string]map[string]float64
+	circuitStates       map[string]CircuitState
+	batchLeases         map[string]BatchLease
+}
+
+// EnableAlerts turns on rates-change alert evaluation on SaveRatesSnapshot
+// using the given thresholds. Passing nil disables it (the default).
+func (m *MemoryStorage) EnableAlerts(th *alerts.Thresholds) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alertThresholds = th
+}
+
+// SetWebhookDispatcher wires a webhook dispatcher into the storage layer so
+// that SaveRatesSnapshot can fire events when parsed rates change. Passing
+// nil disables dispatch (the default).
+func (m *MemoryStorage) SetWebhookDispatcher(d *webhooks.Dispatcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.webhookDispatcher = d
+}
+
+// SetRatesChangeNotifier wires a RatesChangeNotifier into the storage layer
+// so that SaveRatesSnapshot fires an EventRatesChanged notification through
+// it whenever a new snapshot's payload differs from the one it replaced.
+// Passing nil disables it (the default).
+func (m *MemoryStorage) SetRatesChangeNotifier(n RatesChangeNotifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ratesChangeNotifier = n
 }
 
 // NewMemory returns a MemoryStorage initialized with default providers.
 func NewMemory() *MemoryStorage {
 	m := &MemoryStorage{
-		providers:     make(map[string]Provider),
-		snaps:         make(map[string]RatesSnapshot),
-		batchProgress: make(map[string]BatchProgress),
+		providers:         make(map[string]Provider),
+		snaps:             make(map[string]RatesSnapshot),
+		batchProgress:     make(map[string]BatchProgress),
+		webhookSubs:       make(map[string]WebhookSubscription),
+		alertsByKey:       make(map[string]Alert),
+		leaseKeyByID:      make(map[string]string),
+		rateHistory:       make(map[string][]RateHistoryPoint),
+		rateHistoryLatest: make(map[string]map[string]float64),
+		circuitStates:     make(map[string]CircuitState),
+		batchLeases:       make(map[string]BatchLease),
 	}
 	return m
 }
@@ -30,9 +83,16 @@ func NewMemory() *MemoryStorage {
 // and thus prevents import cycles; conversion should be done by callers.
 func NewMemoryWithProviders(list []Provider) *MemoryStorage {
 	m := &MemoryStorage{
-		providers:     make(map[string]Provider),
-		snaps:         make(map[string]RatesSnapshot),
-		batchProgress: make(map[string]BatchProgress),
+		providers:         make(map[string]Provider),
+		snaps:             make(map[string]RatesSnapshot),
+		batchProgress:     make(map[string]BatchProgress),
+		webhookSubs:       make(map[string]WebhookSubscription),
+		alertsByKey:       make(map[string]Alert),
+		leaseKeyByID:      make(map[string]string),
+		rateHistory:       make(map[string][]RateHistoryPoint),
+		rateHistoryLatest: make(map[string]map[string]float64),
+		circuitStates:     make(map[string]CircuitState),
+		batchLeases:       make(map[string]BatchLease),
 	}
 	for _, p := range list {
 		m.providers[p.Key] = p
@@ -73,6 +133,15 @@ func (m *MemoryStorage) UpsertProvider(ctx context.Context, p Provider) error {
 	return nil
 }
 
+// DeleteProvider removes a provider entry. It does not remove that
+// provider's cached snapshot, matching SQLiteStorage/PostgresStorage.
+func (m *MemoryStorage) DeleteProvider(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.providers, key)
+	return nil
+}
+
 func (m *MemoryStorage) GetRatesSnapshot(ctx context.Context, provider string) (*RatesSnapshot, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -86,14 +155,320 @@ func (m *MemoryStorage) GetRatesSnapshot(ctx context.Context, provider string) (
 
 func (m *MemoryStorage) SaveRatesSnapshot(ctx context.Context, snap RatesSnapshot) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	if snap.FetchedAt.IsZero() {
 		snap.FetchedAt = time.Now()
 	}
+	prev, hadPrev := m.snaps[snap.Provider]
+
+	hash := contentHash(snap.Payload)
+	if hadPrev && prev.ContentHash == hash {
+		// Payload is byte-identical to the last snapshot; skip the write so
+		// an unchanged provider doesn't grow the history every cycle.
+		m.mu.Unlock()
+		return nil
+	}
+	snap.ContentHash = hash
+	snap.Version = 1
+	if hadPrev {
+		snap.Version = prev.Version + 1
+	}
 	m.snaps[snap.Provider] = snap
+	dispatcher := m.webhookDispatcher
+	th := m.alertThresholds
+	notifier := m.ratesChangeNotifier
+	m.mu.Unlock()
+
+	if dispatcher != nil && hadPrev {
+		if event := classifyRatesChange(prev.Payload, snap.Payload); event != "" {
+			dispatcher.Dispatch(ctx, webhooks.Envelope{
+				Event:     event,
+				Provider:  snap.Provider,
+				Old:       prev.Payload,
+				New:       snap.Payload,
+				FetchedAt: snap.FetchedAt,
+			})
+		}
+	}
+
+	if th != nil && hadPrev {
+		for _, f := range alerts.Evaluate(snap.Provider, prev.Payload, snap.Payload, *th) {
+			if err := m.UpsertAlert(ctx, f); err != nil {
+				log.Printf("storage: upsert alert for %s failed: %v", snap.Provider, err)
+			}
+		}
+	}
+
+	if notifier != nil && hadPrev {
+		if diff, err := DiffPayloads(snap.Provider, prev.Payload, snap.Payload); err != nil {
+			log.Printf("storage: diff rates snapshot for %s failed: %v", snap.Provider, err)
+		} else if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+			if err := notifier.NotifyRatesChanged(ctx, diff); err != nil {
+				log.Printf("storage: rates-changed notify for %s failed: %v", snap.Provider, err)
+			}
+		}
+	}
+
+	logSnapshotSaved(ctx, snap, prev.Payload, hadPrev)
+	return nil
+}
+
+// logSnapshotSaved emits the snapshot.saved event every SaveRatesSnapshot
+// write produces, separately from the dispatcher/alerts/notifier fan-out
+// above so a backend with none of those configured (the common case in
+// tests) still gets a record of what changed. changed_fields is the field
+// paths DiffPayloads found different; a failed diff or a provider's
+// first-ever snapshot logs without one rather than blocking the save.
+func logSnapshotSaved(ctx context.Context, snap RatesSnapshot, prevPayload []byte, hadPrev bool) {
+	l := logging.WithProvider(logging.Default(), snap.Provider)
+	if !hadPrev {
+		l.InfoContext(ctx, "snapshot.saved", "version", snap.Version)
+		return
+	}
+	diff, err := DiffPayloads(snap.Provider, prevPayload, snap.Payload)
+	if err != nil {
+		l.InfoContext(ctx, "snapshot.saved", "version", snap.Version)
+		return
+	}
+	changed := make([]string, 0, len(diff.Added)+len(diff.Removed)+len(diff.Changed))
+	for _, c := range diff.Added {
+		changed = append(changed, c.Path)
+	}
+	for _, c := range diff.Removed {
+		changed = append(changed, c.Path)
+	}
+	for _, c := range diff.Changed {
+		changed = append(changed, c.Path)
+	}
+	l.InfoContext(ctx, "snapshot.saved", "version", snap.Version, "changed_fields", changed)
+}
+
+// SaveRatesSnapshotIfVersion behaves like SaveRatesSnapshot but first checks
+// that the provider's current stored version matches expectedVersion,
+// returning ErrVersionConflict if another writer has already advanced it.
+// A expectedVersion of 0 requires that no snapshot exists yet for provider.
+func (m *MemoryStorage) SaveRatesSnapshotIfVersion(ctx context.Context, snap RatesSnapshot, expectedVersion int) error {
+	m.mu.RLock()
+	prev, hadPrev := m.snaps[snap.Provider]
+	m.mu.RUnlock()
+	current := 0
+	if hadPrev {
+		current = prev.Version
+	}
+	if current != expectedVersion {
+		return ErrVersionConflict
+	}
+	return m.SaveRatesSnapshot(ctx, snap)
+}
+
+// UpsertAlert records a finding, updating it in place if an alert for the
+// same (provider, type) is already tracked, or adding a new one.
+func (m *MemoryStorage) UpsertAlert(ctx context.Context, f alerts.Finding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.Marshal(f.Data)
+	if err != nil {
+		return err
+	}
+	key := f.Provider + ":" + f.Type
+	now := time.Now()
+	existing, ok := m.alertsByKey[key]
+	a := Alert{
+		ID:        existing.ID,
+		Provider:  f.Provider,
+		Type:      f.Type,
+		Severity:  f.Severity,
+		Message:   f.Message,
+		Data:      data,
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	if ok {
+		a.ID = existing.ID
+		a.FirstSeen = existing.FirstSeen
+	} else {
+		a.ID = uuid.New().String()
+	}
+	m.alertsByKey[key] = a
 	return nil
 }
 
+// ListAlerts returns alerts for provider (all providers if empty), excluding
+// dismissed ones unless includeDismissed is set.
+func (m *MemoryStorage) ListAlerts(ctx context.Context, provider string, includeDismissed bool) ([]Alert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Alert
+	for _, a := range m.alertsByKey {
+		if provider != "" && a.Provider != provider {
+			continue
+		}
+		if !includeDismissed && a.DismissedAt != nil {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// DismissAlert marks an alert as dismissed.
+func (m *MemoryStorage) DismissAlert(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, a := range m.alertsByKey {
+		if a.ID == id {
+			now := time.Now()
+			a.DismissedAt = &now
+			m.alertsByKey[key] = a
+			return nil
+		}
+	}
+	return nil
+}
+
+// ListProvidersWithLastSnapshot returns each provider's most recent
+// snapshot timestamp, for use by alerts.CheckStaleness.
+func (m *MemoryStorage) ListProvidersWithLastSnapshot(ctx context.Context) (map[string]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]time.Time, len(m.snaps))
+	for provider, s := range m.snaps {
+		out[provider] = s.FetchedAt
+	}
+	return out, nil
+}
+
+// AppendSnapshot implements RateHistory by recording one RateHistoryPoint per
+// field in fields whose value differs from the last one recorded for that
+// (providerKey, field) pair.
+func (m *MemoryStorage) AppendSnapshot(ctx context.Context, providerKey string, fetchedAt time.Time, fields map[string]float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	latest, ok := m.rateHistoryLatest[providerKey]
+	if !ok {
+		latest = make(map[string]float64)
+		m.rateHistoryLatest[providerKey] = latest
+	}
+
+	for path, value := range fields {
+		if prev, seen := latest[path]; seen && prev == value {
+			continue
+		}
+		latest[path] = value
+		m.rateHistory[providerKey] = append(m.rateHistory[providerKey], RateHistoryPoint{
+			ProviderKey:   providerKey,
+			FieldPath:     path,
+			Value:         value,
+			EffectiveFrom: fetchedAt,
+		})
+	}
+	return nil
+}
+
+// Query implements RateHistory by filtering and sorting the in-memory
+// history recorded by AppendSnapshot.
+func (m *MemoryStorage) Query(ctx context.Context, providerKey string, from, to time.Time, fields ...string) ([]RateHistoryPoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	want := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		want[f] = true
+	}
+
+	var out []RateHistoryPoint
+	for _, p := range m.rateHistory[providerKey] {
+		if len(want) > 0 && !want[p.FieldPath] {
+			continue
+		}
+		if !from.IsZero() && p.EffectiveFrom.Before(from) {
+			continue
+		}
+		if !to.IsZero() && p.EffectiveFrom.After(to) {
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EffectiveFrom.Before(out[j].EffectiveFrom) })
+	return out, nil
+}
+
+// ListWebhookSubscriptions returns all registered webhook subscriptions.
+func (m *MemoryStorage) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]WebhookSubscription, 0, len(m.webhookSubs))
+	for _, sub := range m.webhookSubs {
+		out = append(out, sub)
+	}
+	return out, nil
+}
+
+// GetWebhookSubscription looks up a single subscription by id.
+func (m *MemoryStorage) GetWebhookSubscription(ctx context.Context, id string) (*WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sub, ok := m.webhookSubs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+// CreateWebhookSubscription persists a new webhook subscription, assigning
+// an id if one wasn't supplied.
+func (m *MemoryStorage) CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sub.ID == "" {
+		sub.ID = uuid.New().String()
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+	m.webhookSubs[sub.ID] = sub
+	return &sub, nil
+}
+
+// DeleteWebhookSubscription removes a subscription by id.
+func (m *MemoryStorage) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.webhookSubs, id)
+	return nil
+}
+
+// SaveWebhookDelivery records the outcome of a webhook delivery attempt.
+func (m *MemoryStorage) SaveWebhookDelivery(ctx context.Context, d WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	if d.DeliveredAt.IsZero() {
+		d.DeliveredAt = time.Now()
+	}
+	m.webhookDeliveries = append(m.webhookDeliveries, d)
+	return nil
+}
+
+// ListWebhookDeliveries returns recent delivery attempts for a subscription,
+// most recent first.
+func (m *MemoryStorage) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if limit <= 0 {
+		limit = 50
+	}
+	var out []WebhookDelivery
+	for i := len(m.webhookDeliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		if m.webhookDeliveries[i].SubscriptionID == subscriptionID {
+			out = append(out, m.webhookDeliveries[i])
+		}
+	}
+	return out, nil
+}
+
 func (m *MemoryStorage) SaveBatchProgress(ctx context.Context, progress BatchProgress) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -128,3 +503,129 @@ func (m *MemoryStorage) GetPendingBatchProviders(ctx context.Context, batchID st
 	}
 	return providers, nil
 }
+
+// AcquireBatchProviderLease attempts to claim (batchID, provider) for
+// workerID for ttl, succeeding only if the provider has no lease or an
+// expired one.
+func (m *MemoryStorage) AcquireBatchProviderLease(ctx context.Context, batchID, provider, workerID string, ttl time.Duration) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := batchID + ":" + provider
+	p, ok := m.batchProgress[key]
+	if !ok {
+		return "", false, nil
+	}
+	now := time.Now()
+	if p.LeaseID != "" && p.LeaseExpiresAt.After(now) {
+		return "", false, nil
+	}
+
+	leaseID := uuid.New().String()
+	p.LeaseID = leaseID
+	p.LeaseOwner = workerID
+	p.LeaseExpiresAt = now.Add(ttl)
+	m.batchProgress[key] = p
+	m.leaseKeyByID[leaseID] = key
+	return leaseID, true, nil
+}
+
+// RefreshBatchProviderLease extends a held lease's expiry by
+// defaultLeaseRenewal, keeping it alive while the worker is still
+// processing the provider.
+func (m *MemoryStorage) RefreshBatchProviderLease(ctx context.Context, leaseID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.leaseKeyByID[leaseID]
+	if !ok {
+		return nil
+	}
+	p := m.batchProgress[key]
+	p.LeaseExpiresAt = time.Now().Add(defaultLeaseRenewal)
+	m.batchProgress[key] = p
+	return nil
+}
+
+// ReleaseBatchProviderLease clears a held lease so another worker can
+// acquire the provider immediately.
+func (m *MemoryStorage) ReleaseBatchProviderLease(ctx context.Context, leaseID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.leaseKeyByID[leaseID]
+	if !ok {
+		return nil
+	}
+	p := m.batchProgress[key]
+	p.LeaseID = ""
+	p.LeaseOwner = ""
+	p.LeaseExpiresAt = time.Time{}
+	m.batchProgress[key] = p
+	delete(m.leaseKeyByID, leaseID)
+	return nil
+}
+
+// SaveCircuitState upserts provider's circuit breaker state.
+func (m *MemoryStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.circuitStates[cs.Provider] = cs
+	return nil
+}
+
+// GetCircuitState returns provider's circuit breaker state, or nil if it has
+// never tripped.
+func (m *MemoryStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cs, ok := m.circuitStates[provider]
+	if !ok {
+		return nil, nil
+	}
+	cp := cs
+	return &cp, nil
+}
+
+// AcquireBatchLease claims key for holder for ttl, succeeding if the lease
+// is unheld, expired, or already held by holder (so a renewal-by-reacquire
+// doesn't fail on itself).
+func (m *MemoryStorage) AcquireBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	existing, ok := m.batchLeases[key]
+	if ok && existing.Holder != holder && existing.ExpiresAt.After(now) {
+		return false, nil
+	}
+
+	m.batchLeases[key] = BatchLease{Key: key, Holder: holder, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// RenewBatchLease extends holder's lease on key by ttl, failing if holder no
+// longer holds it (another replica acquired it after this one's lease
+// expired).
+func (m *MemoryStorage) RenewBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.batchLeases[key]
+	if !ok || existing.Holder != holder {
+		return false, nil
+	}
+	existing.ExpiresAt = time.Now().Add(ttl)
+	m.batchLeases[key] = existing
+	return true, nil
+}
+
+// ReleaseBatchLease clears holder's lease on key so another replica can
+// acquire it immediately. A no-op if holder doesn't currently hold it.
+func (m *MemoryStorage) ReleaseBatchLease(ctx context.Context, key, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.batchLeases[key]; ok && existing.Holder == holder {
+		delete(m.batchLeases, key)
+	}
+	return nil
+}
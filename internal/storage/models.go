@@ -1,6 +1,9 @@
 package storage
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Provider holds metadata about a utility provider.
 type Provider struct {
@@ -13,7 +16,387 @@ type Provider struct {
 
 // RatesSnapshot stores a previously computed rates response payload for a provider.
 type RatesSnapshot struct {
+	// ID is the backing row's primary key. It is populated by backends that
+	// retain full snapshot history (SQLiteStorage, PostgresStorage) and left
+	// zero by backends that only track the latest snapshot per provider.
+	ID        int64     `json:"id,omitempty"`
 	Provider  string    `json:"provider"`
 	Payload   []byte    `json:"payload"`
 	FetchedAt time.Time `json:"fetched_at"`
+	// ContentHash is the hex-encoded SHA-256 of Payload, used to dedupe
+	// identical snapshots and skip redundant writes.
+	ContentHash string `json:"content_hash,omitempty"`
+	// Version increments each time a distinct payload is stored for the
+	// provider, enabling optimistic-concurrency writes via
+	// SaveRatesSnapshotIfVersion.
+	Version int `json:"version,omitempty"`
+	// BlobRef is set when Payload was offloaded to a BlobStore instead of
+	// being stored inline; GetRatesSnapshot resolves it back into Payload
+	// transparently. Empty when no BlobStore is configured.
+	BlobRef string `json:"blob_ref,omitempty"`
+	// Size is len(Payload), recorded even when the payload itself lives in
+	// a BlobStore so callers can report sizes without fetching the blob.
+	Size int64 `json:"size,omitempty"`
+	// ETag and LastModified are the conditional-request validators returned
+	// by the upstream server for the page or PDF this snapshot was built
+	// from, used by rates.Fetcher to send If-None-Match/If-Modified-Since on
+	// the next refresh and skip re-parsing on a 304 response.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// SupersededAt is when a newer snapshot for the same provider was saved,
+	// left zero while this row is still the latest. Only set by backends
+	// that implement SnapshotHistory (SQLiteStorage, PostgresStorage); a
+	// backend that only tracks the latest snapshot has nothing to mark.
+	SupersededAt time.Time `json:"superseded_at,omitempty"`
+}
+
+// User is an account that can authenticate against the auth.Service.
+type User struct {
+	ID                    string    `json:"id"`
+	Username              string    `json:"username"`
+	FirstName             string    `json:"first_name,omitempty"`
+	LastName              string    `json:"last_name,omitempty"`
+	Email                 string    `json:"email,omitempty"`
+	EmailVerified         bool      `json:"email_verified,omitempty"`
+	SkipEmailVerification bool      `json:"skip_email_verification,omitempty"`
+	OnboardingCompleted   bool      `json:"onboarding_completed,omitempty"`
+	PasswordHash          string    `json:"-"`
+	Role                  string    `json:"role,omitempty"`
+	CreatedAt             time.Time `json:"created_at,omitempty"`
+	UpdatedAt             time.Time `json:"updated_at,omitempty"`
+	// TOTPSecret is the AES-GCM-encrypted (see auth/mfa.EncryptSecret)
+	// base32 TOTP secret issued by auth.Service.EnrollMFA. Empty until the
+	// user enrolls.
+	TOTPSecret string `json:"-"`
+	// TOTPEnrolled is set once the user has confirmed their authenticator
+	// app with a valid code via auth.Service.VerifyMFAEnrollment, gating
+	// AuthenticateWithMFA's second-factor check.
+	TOTPEnrolled bool `json:"totp_enrolled,omitempty"`
+	// RecoveryCodes is a JSON-encoded array of bcrypt hashes of the
+	// single-use codes issued at enrollment. A code is removed from the
+	// array the first time it's consumed.
+	RecoveryCodes string `json:"-"`
+	// Domain scopes this user's role assignment to one organization/
+	// provider tenant for auth.Service.EnforceInDomain, e.g. "org-acme".
+	// Empty means the user is assigned in every domain (see auth's
+	// defaultDomain), matching pre-multi-tenant behavior.
+	Domain string `json:"domain,omitempty"`
+}
+
+// Token is a credential issued to a User: a one-shot verification/invite/
+// reset link (Name identifies its purpose, e.g. "email-verification"), a
+// long-lived API key, or one half of a session's access/refresh pair (Kind
+// "access"/"refresh"). It is identified to callers by its plaintext value
+// but persisted only as TokenHash.
+type Token struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name,omitempty"`
+	Role       string     `json:"role,omitempty"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt time.Time  `json:"last_used_at,omitempty"`
+	// Kind is "access" or "refresh" for one half of a session pair, and
+	// empty for one-shot verification/reset tokens and API keys, which
+	// aren't part of a rotating session.
+	Kind string `json:"kind,omitempty"`
+	// FamilyID ties a session's access token to its refresh token, and to
+	// every refresh token descended from it by rotation. RefreshSession
+	// revokes the whole family when a refresh token is reused after
+	// rotation, so a stolen token can only be replayed once before the
+	// session it belongs to is shut down entirely.
+	FamilyID string `json:"family_id,omitempty"`
+	// RevokedAt is set by RevokeToken/RevokeTokenFamily/RevokeTokensForUser
+	// and left zero while the token is live. Revoked rows are kept (not
+	// deleted) so a reused refresh token can still be looked up and its
+	// family revoked, rather than just failing lookup as "not found".
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Scopes is a JSON-encoded array of scope strings (e.g.
+	// ["rates:read","settings:*"]) narrowing what this token can do within
+	// its Role's grants; Service.EnforceToken consults it alongside Enforce.
+	// Empty means no additional narrowing - the full Role applies, matching
+	// behavior before Scopes existed.
+	Scopes string `json:"-"`
+	// RateLimitPerMinute caps requests/minute for this token specifically,
+	// enforced by Middleware's token-bucket limiter. 0 means no
+	// token-specific limit.
+	RateLimitPerMinute int `json:"rate_limit_per_minute,omitempty"`
+	// LastUsedIP is the remote IP UpdateTokenLastUsed last recorded this
+	// token being used from.
+	LastUsedIP string `json:"last_used_ip,omitempty"`
+	// Fingerprint identifies the device (hash of IP+User-Agent, see
+	// auth.RequestMeta.Fingerprint) a session token was issued to, letting
+	// /api/v1/auth/sessions show which device each active session belongs
+	// to. Empty for tokens that aren't part of a login session.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// CasbinRule is a single row of the Casbin policy table, shaped to match
+// persist.Adapter's (ptype, v0..v5) convention so auth.Adapter can translate
+// rows directly into policy lines.
+type CasbinRule struct {
+	ID    uint   `json:"id,omitempty" gorm:"column:id"`
+	PType string `json:"ptype" gorm:"column:ptype"`
+	V0    string `json:"v0,omitempty" gorm:"column:v0"`
+	V1    string `json:"v1,omitempty" gorm:"column:v1"`
+	V2    string `json:"v2,omitempty" gorm:"column:v2"`
+	V3    string `json:"v3,omitempty" gorm:"column:v3"`
+	V4    string `json:"v4,omitempty" gorm:"column:v4"`
+	V5    string `json:"v5,omitempty" gorm:"column:v5"`
+}
+
+// PasswordPolicyConfig holds the runtime-configurable rules
+// auth.Service.ValidatePassword enforces on Register, ResetPassword, and
+// SetupInvitedAccount. Only one row is ever stored, forced to ID "default"
+// by SavePasswordPolicyConfig, the same convention as EmailConfig.
+// HIBPAllowlist is a JSON-encoded array of SHA-1 hashes (uppercase hex)
+// that always pass the breach check regardless of HIBPThreshold, letting
+// an air-gapped install pre-vet a specific set of passwords instead of
+// disabling the check outright.
+type PasswordPolicyConfig struct {
+	ID                        string  `json:"id"`
+	MinLength                 int     `json:"min_length"`
+	MaxLength                 int     `json:"max_length,omitempty"`
+	RequireUpper              bool    `json:"require_upper"`
+	RequireLower              bool    `json:"require_lower"`
+	RequireDigit              bool    `json:"require_digit"`
+	RequireSymbol             bool    `json:"require_symbol"`
+	DisallowUsernameSubstring bool    `json:"disallow_username_substring"`
+	MinEntropyBits            float64 `json:"min_entropy_bits,omitempty"`
+	HIBPEnabled               bool    `json:"hibp_enabled"`
+	HIBPThreshold             int     `json:"hibp_threshold,omitempty"`
+	HIBPAllowlist             string  `json:"-"`
+}
+
+// EmailConfig holds the outbound email settings used by
+// notification.Service's email channel. Only one row is ever stored,
+// forced to ID "default" by SaveEmailConfig. Provider selects which of
+// Host/Port/Encryption (smtp, gmail) or APIKey (sendgrid, resend) apply.
+type EmailConfig struct {
+	ID          string `json:"id"`
+	Enabled     bool   `json:"enabled"`
+	Provider    string `json:"provider"`
+	Host        string `json:"host,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	Encryption  string `json:"encryption,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"-"`
+	FromAddress string `json:"from_address"`
+	FromName    string `json:"from_name,omitempty"`
+	APIKey      string `json:"-"`
+}
+
+// NotificationChannelConfig holds the per-channel settings for one of
+// notification.Service's non-email channels (webhook, Slack, Discord,
+// ntfy), keyed by ID = the channel name. Settings is the channel-specific
+// payload (e.g. a webhook URL and HMAC secret), left as raw JSON here so
+// this one table can back every channel without a migration per channel.
+type NotificationChannelConfig struct {
+	ID        string          `json:"id"`
+	Enabled   bool            `json:"enabled"`
+	Settings  json.RawMessage `json:"settings,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at,omitempty"`
+}
+
+// PendingNotification is a NotificationEvent that notification.Service
+// couldn't deliver before shutdown - its in-memory queue spills here so a
+// restart can pick delivery back up instead of silently dropping it.
+type PendingNotification struct {
+	ID       string          `json:"id"`
+	Channel  string          `json:"channel"`
+	Event    json.RawMessage `json:"event"`
+	QueuedAt time.Time       `json:"queued_at"`
+}
+
+// AlertWebhookSubscription is an administrator-registered endpoint that
+// alerting.Alerter.Broadcast delivers matching Event values to, persisted so
+// subscriptions survive a restart. It's distinct from WebhookSubscription
+// (rate-snapshot-change webhooks, see webhooks.go): this one backs the
+// alerting package's Event/Subscription model. EventFilter is a
+// JSON-encoded array of "module.event_id" keys (or "module.*"/"*"
+// wildcards); empty means every event matches.
+type AlertWebhookSubscription struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	HeaderKey   string `json:"header_key,omitempty"`
+	HeaderValue string `json:"-"`
+	EventFilter string `json:"-"`
+	// WebhookType selects the payload shape Alerter builds for this
+	// subscription: "slack", "discord", or "generic" (default).
+	WebhookType string `json:"webhook_type,omitempty"`
+	// Secret HMAC-SHA256-signs every delivery's JSON body (see the
+	// X-Webhook-Signature header), letting the receiving endpoint verify
+	// the request actually came from this Alerter.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// AlertWebhookDeadLetter is a single Event delivery that exhausted
+// Alerter's retry budget against one AlertWebhookSubscription, kept around
+// so an operator can inspect and (eventually) replay it via
+// /internal/alerts/webhooks/dead.
+type AlertWebhookDeadLetter struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventModule    string          `json:"event_module"`
+	EventID        string          `json:"event_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Error          string          `json:"error"`
+	Attempts       int             `json:"attempts"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// OIDCProvider is an administrator-configured SSO identity provider that
+// auth.Service.LoginWithOIDC exchanges authorization codes against, keyed
+// by ID (e.g. "google", "azure", or a slug for a generic provider).
+type OIDCProvider struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IssuerURL string `json:"issuer_url"`
+	ClientID  string `json:"client_id"`
+	// ClientSecret is AES-GCM-encrypted (see auth/mfa.EncryptSecret, reused
+	// here rather than duplicating the cipher) the same way
+	// storage.User.TOTPSecret is.
+	ClientSecret string `json:"-"`
+	// RedirectURI is the fixed /callback URL this provider's app
+	// registration is configured with. OIDC requires the token exchange's
+	// redirect_uri to exactly match the one used for the authorization
+	// request, so LoginWithOIDC uses this instead of taking one per call.
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	// Scopes is space-separated, e.g. "openid email profile".
+	Scopes string `json:"scopes,omitempty"`
+	// RoleClaim names the ID token claim LoginWithOIDC reads to map the
+	// user onto an eRateManager role (e.g. "groups" or "roles"). Empty
+	// means DefaultRole always applies.
+	RoleClaim string `json:"role_claim,omitempty"`
+	// RoleMap is a JSON-encoded map from a RoleClaim value to an
+	// eRateManager role, e.g. {"eng-team":"editor"}. The first of the
+	// claim's values found in RoleMap wins.
+	RoleMap string `json:"role_map,omitempty"`
+	// DefaultRole applies when RoleClaim is empty or none of its values
+	// match RoleMap, and seeds a newly JIT-provisioned user before RoleMap
+	// is consulted.
+	DefaultRole string `json:"default_role,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// BatchProgress tracks the status of a single provider's refresh within a
+// batch run, keyed by (BatchID, Provider), so a resumed batch can skip
+// providers that already completed.
+type BatchProgress struct {
+	BatchID      string    `json:"batch_id"`
+	Provider     string    `json:"provider"`
+	Status       string    `json:"status"`
+	StartedAt    time.Time `json:"started_at,omitempty"`
+	CompletedAt  time.Time `json:"completed_at,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	RetryCount   int       `json:"retry_count,omitempty"`
+	// LeaseID identifies the current worker's claim on this provider, set by
+	// AcquireBatchProviderLease and cleared by ReleaseBatchProviderLease.
+	LeaseID string `json:"lease_id,omitempty"`
+	// LeaseOwner is the workerID that holds LeaseID.
+	LeaseOwner string `json:"lease_owner,omitempty"`
+	// LeaseExpiresAt is when the lease becomes stale and eligible for
+	// another worker to acquire.
+	LeaseExpiresAt time.Time `json:"lease_expires_at,omitempty"`
+}
+
+// CircuitState tracks a provider's circuit breaker across batch runs, keyed
+// by Provider, so a provider that's currently failing consistently can be
+// short-circuited instead of eating its full ProviderTimeout * (RetryAttempts+1)
+// on every cycle.
+type CircuitState struct {
+	Provider string `json:"provider" gorm:"primaryKey;column:provider"`
+	// State is one of "closed" (normal), "open" (short-circuiting), or
+	// "half_open" (probing whether the provider has recovered).
+	State               string    `json:"state" gorm:"column:state"`
+	ConsecutiveFailures int       `json:"consecutive_failures" gorm:"column:consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty" gorm:"column:opened_at"`
+	NextAttemptAt       time.Time `json:"next_attempt_at,omitempty" gorm:"column:next_attempt_at"`
+	LastError           string    `json:"last_error,omitempty" gorm:"column:last_error"`
+}
+
+// LoginAttempt tracks consecutive authentication failures for a single
+// throttle key - "user:<username>" or "ip:<ip>" - so
+// Service.Authenticate can apply exponential backoff and lock the key out
+// after too many failures, the same closed/open bookkeeping CircuitState
+// applies to a failing provider.
+type LoginAttempt struct {
+	Key                 string    `json:"key" gorm:"primaryKey;column:key"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastFailureAt       time.Time `json:"last_failure_at,omitempty"`
+	// LockedUntil is set once ConsecutiveFailures crosses the configured
+	// threshold, and left zero otherwise. Authenticate refuses any attempt
+	// for this key while LockedUntil is in the future.
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+}
+
+// KnownDevice records that Authenticate has already seen a fingerprint
+// (see auth.RequestMeta.Fingerprint) succeed for a user, keyed by
+// (UserID, Fingerprint), so a later login can tell a genuinely new device
+// from a repeat one and only send a new-sign-in notification for the
+// former.
+type KnownDevice struct {
+	UserID      string    `json:"user_id" gorm:"primaryKey;column:user_id"`
+	Fingerprint string    `json:"fingerprint" gorm:"primaryKey;column:fingerprint"`
+	FirstSeenAt time.Time `json:"first_seen_at,omitempty"`
+	LastSeenAt  time.Time `json:"last_seen_at,omitempty"`
+}
+
+// AuditEvent is a single security-relevant action recorded by
+// audit.Logger: an authentication attempt, a policy change, a password
+// reset, and so on. Hash is the hex-encoded SHA-256 of PrevHash plus this
+// event's own JSON encoding (with Hash itself blank), chaining every event
+// to the one before it so an admin can tell whether the log was edited or
+// had rows deleted out from under it.
+type AuditEvent struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Actor is the acting user's ID, or "" for an unauthenticated attempt
+	// (e.g. a failed login for a username that doesn't map to a user yet).
+	Actor string `json:"actor,omitempty"`
+	// Action names what happened, e.g. "authenticate", "register",
+	// "add_policy", "reset_password".
+	Action string `json:"action"`
+	// Success is false for a failed authentication/validation attempt.
+	Success bool `json:"success"`
+	// Target identifies what Action was performed against - a user ID, a
+	// role name, a token ID - empty when Action has no single target.
+	Target string `json:"target,omitempty"`
+	// Resource categorizes Target, e.g. "user", "token", "policy".
+	Resource string `json:"resource,omitempty"`
+	// Detail is a JSON-encoded object with action-specific context, e.g. a
+	// {"before":...,"after":...} diff for a policy change.
+	Detail    string `json:"detail,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// PrevHash is the Hash of the event immediately before this one, or ""
+	// for the very first event ever recorded.
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash"`
+}
+
+// BatchLease is a cooperative leader lock, keyed by Key, that RunBatchOnce's
+// leader election uses to stop two CronJob replicas from refreshing the
+// same providers concurrently. Unlike BatchProgress's per-provider leases,
+// there is exactly one BatchLease row per Key, held by at most one Holder at
+// a time.
+type BatchLease struct {
+	Key       string    `json:"key"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ProviderHealthSample is one providerhealth.Monitor keepalive check
+// result, persisted so /health/providers/{key} can show history across
+// restarts and across the separate processes that run the checks (the
+// cron worker) versus serve the HTTP endpoints (the API server).
+type ProviderHealthSample struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Success   bool      `json:"success"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
 }
@@ -4,14 +4,53 @@ package storage
 import (
     "context"
     "database/sql"
+    "encoding/json"
     "errors"
+    "fmt"
+    "log"
+    "strings"
     "time"
 
+    "github.com/bher20/eratemanager/internal/alerts"
+    "github.com/bher20/eratemanager/internal/webhooks"
+    "github.com/google/uuid"
     _ "github.com/jackc/pgx/v5/stdlib"
 )
 
 type PostgresStorage struct {
-    db *sql.DB
+    db                  *sql.DB
+    webhookDispatcher   *webhooks.Dispatcher
+    alertThresholds     *alerts.Thresholds
+    blobStore           BlobStore
+    ratesChangeNotifier RatesChangeNotifier
+}
+
+// SetBlobStore wires a BlobStore into the storage layer so SaveRatesSnapshot
+// offloads the payload there instead of storing it inline. Passing nil
+// disables offloading (the default).
+func (s *PostgresStorage) SetBlobStore(bs BlobStore) {
+    s.blobStore = bs
+}
+
+// SetWebhookDispatcher wires a webhook dispatcher into the storage layer so
+// that SaveRatesSnapshot can fire events when parsed rates change. Passing
+// nil disables dispatch (the default).
+func (s *PostgresStorage) SetWebhookDispatcher(d *webhooks.Dispatcher) {
+    s.webhookDispatcher = d
+}
+
+// EnableAlerts turns on rates-change alert evaluation on SaveRatesSnapshot
+// using the given thresholds. Passing nil disables it (the default).
+func (s *PostgresStorage) EnableAlerts(th *alerts.Thresholds) {
+    s.alertThresholds = th
+}
+
+// SetRatesChangeNotifier wires a RatesChangeNotifier into the storage layer
+// so that SaveRatesSnapshot fires an EventRatesChanged notification through
+// it whenever a new snapshot's payload differs from the one it replaced.
+// Passing nil disables it (the default).
+func (s *PostgresStorage) SetRatesChangeNotifier(n RatesChangeNotifier) {
+    s.ratesChangeNotifier = n
 }
 
 func OpenPostgres(dsn string) (*PostgresStorage, error) {
@@ -43,7 +82,68 @@ func (s *PostgresStorage) Migrate(ctx context.Context) error {
             id SERIAL PRIMARY KEY,
             provider TEXT NOT NULL,
             payload BYTEA NOT NULL,
-            fetched_at TIMESTAMPTZ NOT NULL
+            fetched_at TIMESTAMPTZ NOT NULL,
+            content_hash TEXT,
+            version INTEGER NOT NULL DEFAULT 1,
+            blob_ref TEXT,
+            size BIGINT,
+            etag TEXT,
+            last_modified TEXT,
+            superseded_at TIMESTAMPTZ
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_rates_snapshots_provider_fetched ON rates_snapshots(provider, fetched_at);`,
+        `CREATE TABLE IF NOT EXISTS webhooks (
+            id TEXT PRIMARY KEY,
+            url TEXT NOT NULL,
+            event_types TEXT NOT NULL,
+            secret TEXT NOT NULL,
+            headers TEXT,
+            auth_token TEXT,
+            created_at TIMESTAMPTZ NOT NULL
+        );`,
+        `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+            id TEXT PRIMARY KEY,
+            subscription_id TEXT NOT NULL,
+            event TEXT NOT NULL,
+            payload BYTEA,
+            status_code INTEGER,
+            error TEXT,
+            attempt INTEGER DEFAULT 0,
+            delivered_at TIMESTAMPTZ NOT NULL
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, delivered_at);`,
+        `CREATE TABLE IF NOT EXISTS alerts (
+            id TEXT PRIMARY KEY,
+            provider TEXT NOT NULL,
+            type TEXT NOT NULL,
+            severity TEXT NOT NULL,
+            message TEXT NOT NULL,
+            data TEXT,
+            first_seen TIMESTAMPTZ NOT NULL,
+            last_seen TIMESTAMPTZ NOT NULL,
+            dismissed_at TIMESTAMPTZ,
+            UNIQUE(provider, type)
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_alerts_provider ON alerts(provider, dismissed_at);`,
+        // rate_snapshots is a finer-grained companion to rates_snapshots: it
+        // tracks one row per (provider, field) change point instead of one
+        // row per whole-payload refresh, so RateHistory.Query can chart a
+        // single field over time without replaying every snapshot.
+        `CREATE TABLE IF NOT EXISTS rate_snapshots (
+            id SERIAL PRIMARY KEY,
+            provider_key TEXT NOT NULL,
+            field_path TEXT NOT NULL,
+            value DOUBLE PRECISION NOT NULL,
+            effective_from TIMESTAMPTZ NOT NULL
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_rate_snapshots_lookup ON rate_snapshots(provider_key, field_path, effective_from);`,
+        `CREATE TABLE IF NOT EXISTS circuit_states (
+            provider TEXT PRIMARY KEY,
+            state TEXT NOT NULL,
+            consecutive_failures INTEGER DEFAULT 0,
+            opened_at TIMESTAMPTZ,
+            next_attempt_at TIMESTAMPTZ,
+            last_error TEXT
         );`,
     }
     for _, stmt := range stmts {
@@ -97,28 +197,59 @@ func (s *PostgresStorage) UpsertProvider(ctx context.Context, p Provider) error
     return err
 }
 
+// DeleteProvider removes a provider row. It does not cascade to that
+// provider's rate snapshots, which are left in place for historical lookups.
+func (s *PostgresStorage) DeleteProvider(ctx context.Context, key string) error {
+    _, err := s.db.ExecContext(ctx, `DELETE FROM providers WHERE key=$1`, key)
+    return err
+}
+
 func (s *PostgresStorage) GetRatesSnapshot(ctx context.Context, provider string) (*RatesSnapshot, error) {
     row := s.db.QueryRowContext(ctx, `
-        SELECT payload, fetched_at
+        SELECT id, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
         FROM rates_snapshots
         WHERE provider=$1
         ORDER BY id DESC
         LIMIT 1
     `, provider)
 
+    var id int64
     var payload []byte
     var fetched time.Time
-    if err := row.Scan(&payload, &fetched); err != nil {
+    var hash, blobRef, etag, lastModified sql.NullString
+    var supersededAt sql.NullTime
+    var version int
+    var size sql.NullInt64
+    if err := row.Scan(&id, &payload, &fetched, &hash, &version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             return nil, nil
         }
         return nil, err
     }
 
+    if blobRef.Valid && blobRef.String != "" {
+        if s.blobStore == nil {
+            return nil, fmt.Errorf("storage: snapshot for %s has blob_ref %q but no BlobStore is configured", provider, blobRef.String)
+        }
+        var err error
+        payload, err = s.blobStore.Get(ctx, blobRef.String)
+        if err != nil {
+            return nil, fmt.Errorf("fetch blob %s: %w", blobRef.String, err)
+        }
+    }
+
     return &RatesSnapshot{
-        Provider:  provider,
-        Payload:   payload,
-        FetchedAt: fetched,
+        ID:           id,
+        Provider:     provider,
+        Payload:      payload,
+        FetchedAt:    fetched,
+        ContentHash:  hash.String,
+        Version:      version,
+        BlobRef:      blobRef.String,
+        Size:         size.Int64,
+        ETag:         etag.String,
+        LastModified: lastModified.String,
+        SupersededAt: supersededAt.Time,
     }, nil
 }
 
@@ -126,9 +257,596 @@ func (s *PostgresStorage) SaveRatesSnapshot(ctx context.Context, snap RatesSnaps
     if snap.FetchedAt.IsZero() {
         snap.FetchedAt = time.Now()
     }
+
+    prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+    if err != nil {
+        prev = nil
+    }
+    var prevPayload []byte
+    if prev != nil {
+        prevPayload = prev.Payload
+    }
+
+    hash := contentHash(snap.Payload)
+    if prev != nil && prev.ContentHash == hash {
+        // Payload is byte-identical to the last snapshot; skip the write so
+        // an unchanged provider doesn't grow the history table every cycle.
+        return nil
+    }
+    version := 1
+    if prev != nil {
+        version = prev.Version + 1
+    }
+
+    size := int64(len(snap.Payload))
+    row := snap.Payload
+    var blobRef sql.NullString
+    if s.blobStore != nil {
+        ref, err := s.blobStore.Put(ctx, "rates/"+snap.Provider+"/"+hash, snap.Payload)
+        if err != nil {
+            return fmt.Errorf("store blob: %w", err)
+        }
+        blobRef = sql.NullString{String: ref, Valid: true}
+        row = []byte{}
+    }
+
+    _, err = s.db.ExecContext(ctx, `
+        INSERT INTO rates_snapshots (provider, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `, snap.Provider, row, snap.FetchedAt, hash, version, blobRef, size, snap.ETag, snap.LastModified)
+    if err != nil {
+        return err
+    }
+
+    if prev != nil {
+        // Mark the row this one replaced as superseded, so ListRatesSnapshots
+        // and GetRatesSnapshotAt callers can tell how long each version was
+        // in effect.
+        if _, err := s.db.ExecContext(ctx, `
+            UPDATE rates_snapshots SET superseded_at = $1 WHERE id = $2
+        `, snap.FetchedAt, prev.ID); err != nil {
+            return fmt.Errorf("mark previous snapshot superseded: %w", err)
+        }
+    }
+
+    if s.webhookDispatcher != nil {
+        if event := classifyRatesChange(prevPayload, snap.Payload); event != "" {
+            s.webhookDispatcher.Dispatch(ctx, webhooks.Envelope{
+                Event:     event,
+                Provider:  snap.Provider,
+                Old:       json.RawMessage(prevPayload),
+                New:       json.RawMessage(snap.Payload),
+                FetchedAt: snap.FetchedAt,
+            })
+        }
+    }
+
+    if s.alertThresholds != nil {
+        for _, f := range alerts.Evaluate(snap.Provider, prevPayload, snap.Payload, *s.alertThresholds) {
+            if err := s.UpsertAlert(ctx, f); err != nil {
+                log.Printf("storage: upsert alert for %s failed: %v", snap.Provider, err)
+            }
+        }
+    }
+
+    if s.ratesChangeNotifier != nil && prev != nil {
+        if diff, err := DiffPayloads(snap.Provider, prevPayload, snap.Payload); err != nil {
+            log.Printf("storage: diff rates snapshot for %s failed: %v", snap.Provider, err)
+        } else if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+            if err := s.ratesChangeNotifier.NotifyRatesChanged(ctx, diff); err != nil {
+                log.Printf("storage: rates-changed notify for %s failed: %v", snap.Provider, err)
+            }
+        }
+    }
+
+    return nil
+}
+
+// SaveRatesSnapshotIfVersion behaves like SaveRatesSnapshot but first checks
+// that the provider's current stored version matches expectedVersion,
+// returning ErrVersionConflict if another writer has already advanced it.
+// A expectedVersion of 0 requires that no snapshot exists yet for provider.
+func (s *PostgresStorage) SaveRatesSnapshotIfVersion(ctx context.Context, snap RatesSnapshot, expectedVersion int) error {
+    prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+    if err != nil {
+        return err
+    }
+    current := 0
+    if prev != nil {
+        current = prev.Version
+    }
+    if current != expectedVersion {
+        return ErrVersionConflict
+    }
+    return s.SaveRatesSnapshot(ctx, snap)
+}
+
+// ListRatesSnapshots returns provider's snapshot history, newest first,
+// optionally bounded by since/until (zero means no lower/upper bound) and
+// limit (zero means no limit). Payload blobs are resolved the same way
+// GetRatesSnapshot resolves them.
+func (s *PostgresStorage) ListRatesSnapshots(ctx context.Context, provider string, since, until time.Time, limit int) ([]RatesSnapshot, error) {
+    query := `
+        SELECT id, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
+        FROM rates_snapshots
+        WHERE provider=$1 AND fetched_at >= $2
+    `
+    args := []any{provider, since}
+    if !until.IsZero() {
+        args = append(args, until)
+        query += fmt.Sprintf(" AND fetched_at <= $%d", len(args))
+    }
+    query += " ORDER BY id DESC"
+    if limit > 0 {
+        args = append(args, limit)
+        query += fmt.Sprintf(" LIMIT $%d", len(args))
+    }
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var out []RatesSnapshot
+    for rows.Next() {
+        var snap RatesSnapshot
+        var hash, blobRef, etag, lastModified sql.NullString
+        var supersededAt sql.NullTime
+        var size sql.NullInt64
+        if err := rows.Scan(&snap.ID, &snap.Payload, &snap.FetchedAt, &hash, &snap.Version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
+            return nil, err
+        }
+        snap.Provider = provider
+        snap.ContentHash = hash.String
+        snap.BlobRef = blobRef.String
+        snap.Size = size.Int64
+        snap.ETag = etag.String
+        snap.LastModified = lastModified.String
+        snap.SupersededAt = supersededAt.Time
+        out = append(out, snap)
+    }
+    return out, rows.Err()
+}
+
+// GetRatesSnapshotAt returns the snapshot in effect at instant at: the row
+// with the highest fetched_at <= at (ties broken by max id), so an E-Rate
+// auditor can ask "what did this provider's tariff look like on date X?".
+// Returns nil with no error if no snapshot existed yet at that time.
+func (s *PostgresStorage) GetRatesSnapshotAt(ctx context.Context, provider string, at time.Time) (*RatesSnapshot, error) {
+    row := s.db.QueryRowContext(ctx, `
+        SELECT id, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
+        FROM rates_snapshots
+        WHERE provider=$1 AND fetched_at <= $2
+        ORDER BY id DESC
+        LIMIT 1
+    `, provider, at)
+
+    var id int64
+    var payload []byte
+    var fetched time.Time
+    var hash, blobRef, etag, lastModified sql.NullString
+    var supersededAt sql.NullTime
+    var version int
+    var size sql.NullInt64
+    if err := row.Scan(&id, &payload, &fetched, &hash, &version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    if blobRef.Valid && blobRef.String != "" {
+        if s.blobStore == nil {
+            return nil, fmt.Errorf("storage: snapshot for %s has blob_ref %q but no BlobStore is configured", provider, blobRef.String)
+        }
+        var err error
+        payload, err = s.blobStore.Get(ctx, blobRef.String)
+        if err != nil {
+            return nil, fmt.Errorf("fetch blob %s: %w", blobRef.String, err)
+        }
+    }
+
+    return &RatesSnapshot{
+        ID:           id,
+        Provider:     provider,
+        Payload:      payload,
+        FetchedAt:    fetched,
+        ContentHash:  hash.String,
+        Version:      version,
+        BlobRef:      blobRef.String,
+        Size:         size.Int64,
+        ETag:         etag.String,
+        LastModified: lastModified.String,
+        SupersededAt: supersededAt.Time,
+    }, nil
+}
+
+// GetRatesSnapshotByID fetches a single snapshot row by its primary key,
+// resolving BlobRef the same way GetRatesSnapshot does.
+func (s *PostgresStorage) GetRatesSnapshotByID(ctx context.Context, id int64) (*RatesSnapshot, error) {
+    row := s.db.QueryRowContext(ctx, `
+        SELECT provider, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
+        FROM rates_snapshots
+        WHERE id=$1
+    `, id)
+
+    var snap RatesSnapshot
+    var hash, blobRef, etag, lastModified sql.NullString
+    var supersededAt sql.NullTime
+    var size sql.NullInt64
+    if err := row.Scan(&snap.Provider, &snap.Payload, &snap.FetchedAt, &hash, &snap.Version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    snap.ID = id
+    snap.ContentHash = hash.String
+    snap.BlobRef = blobRef.String
+    snap.Size = size.Int64
+    snap.ETag = etag.String
+    snap.LastModified = lastModified.String
+    snap.SupersededAt = supersededAt.Time
+
+    if snap.BlobRef != "" {
+        if s.blobStore == nil {
+            return nil, fmt.Errorf("storage: snapshot %d has blob_ref %q but no BlobStore is configured", id, snap.BlobRef)
+        }
+        payload, err := s.blobStore.Get(ctx, snap.BlobRef)
+        if err != nil {
+            return nil, fmt.Errorf("fetch blob %s: %w", snap.BlobRef, err)
+        }
+        snap.Payload = payload
+    }
+    return &snap, nil
+}
+
+// PruneRatesSnapshots deletes snapshot rows older than olderThan, keeping at
+// least the keepLast most recent rows per provider regardless of age. It
+// returns the number of rows deleted.
+func (s *PostgresStorage) PruneRatesSnapshots(ctx context.Context, keepLast int, olderThan time.Duration) (int64, error) {
+    cutoff := time.Now().Add(-olderThan)
+    result, err := s.db.ExecContext(ctx, `
+        DELETE FROM rates_snapshots
+        WHERE fetched_at < $1
+        AND id NOT IN (
+            SELECT id FROM rates_snapshots rs2
+            WHERE rs2.provider = rates_snapshots.provider
+            ORDER BY rs2.id DESC
+            LIMIT $2
+        )
+    `, cutoff, keepLast)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+// UpsertAlert records a finding, updating last_seen and data if an alert for
+// the same (provider, type) is already open, or inserting a new row.
+func (s *PostgresStorage) UpsertAlert(ctx context.Context, f alerts.Finding) error {
+    data, err := json.Marshal(f.Data)
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    _, err = s.db.ExecContext(ctx, `
+        INSERT INTO alerts (id, provider, type, severity, message, data, first_seen, last_seen, dismissed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULL)
+        ON CONFLICT (provider, type) DO UPDATE SET
+            severity = EXCLUDED.severity,
+            message = EXCLUDED.message,
+            data = EXCLUDED.data,
+            last_seen = EXCLUDED.last_seen,
+            dismissed_at = NULL
+    `, uuid.New().String(), f.Provider, f.Type, f.Severity, f.Message, string(data), now, now)
+    return err
+}
+
+// ListAlerts returns alerts for provider (all providers if empty), excluding
+// dismissed ones unless includeDismissed is set.
+func (s *PostgresStorage) ListAlerts(ctx context.Context, provider string, includeDismissed bool) ([]Alert, error) {
+    query := `SELECT id, provider, type, severity, message, data, first_seen, last_seen, dismissed_at FROM alerts WHERE true`
+    var args []interface{}
+    argN := 1
+    if provider != "" {
+        query += fmt.Sprintf(` AND provider = $%d`, argN)
+        args = append(args, provider)
+        argN++
+    }
+    if !includeDismissed {
+        query += ` AND dismissed_at IS NULL`
+    }
+    query += ` ORDER BY last_seen DESC`
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var out []Alert
+    for rows.Next() {
+        var a Alert
+        var data sql.NullString
+        var dismissedAt sql.NullTime
+        if err := rows.Scan(&a.ID, &a.Provider, &a.Type, &a.Severity, &a.Message, &data, &a.FirstSeen, &a.LastSeen, &dismissedAt); err != nil {
+            return nil, err
+        }
+        if data.Valid {
+            a.Data = []byte(data.String)
+        }
+        if dismissedAt.Valid {
+            a.DismissedAt = &dismissedAt.Time
+        }
+        out = append(out, a)
+    }
+    return out, rows.Err()
+}
+
+// DismissAlert marks an alert as dismissed.
+func (s *PostgresStorage) DismissAlert(ctx context.Context, id string) error {
+    _, err := s.db.ExecContext(ctx, `UPDATE alerts SET dismissed_at = $1 WHERE id = $2`, time.Now(), id)
+    return err
+}
+
+// ListProvidersWithLastSnapshot returns each provider's most recent
+// snapshot timestamp, for use by alerts.CheckStaleness.
+func (s *PostgresStorage) ListProvidersWithLastSnapshot(ctx context.Context) (map[string]time.Time, error) {
+    rows, err := s.db.QueryContext(ctx, `SELECT provider, MAX(fetched_at) FROM rates_snapshots GROUP BY provider`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    out := make(map[string]time.Time)
+    for rows.Next() {
+        var provider string
+        var fetched time.Time
+        if err := rows.Scan(&provider, &fetched); err != nil {
+            return nil, err
+        }
+        out[provider] = fetched
+    }
+    return out, rows.Err()
+}
+
+// ListWebhookSubscriptions returns all registered webhook subscriptions.
+func (s *PostgresStorage) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+    rows, err := s.db.QueryContext(ctx, `SELECT id, url, event_types, secret, headers, auth_token, created_at FROM webhooks`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var out []WebhookSubscription
+    for rows.Next() {
+        sub, err := scanWebhookRowPG(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, sub)
+    }
+    return out, rows.Err()
+}
+
+// GetWebhookSubscription looks up a single subscription by id.
+func (s *PostgresStorage) GetWebhookSubscription(ctx context.Context, id string) (*WebhookSubscription, error) {
+    row := s.db.QueryRowContext(ctx, `SELECT id, url, event_types, secret, headers, auth_token, created_at FROM webhooks WHERE id=$1`, id)
+    sub, err := scanWebhookRowPG(row)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return &sub, nil
+}
+
+// CreateWebhookSubscription persists a new webhook subscription, assigning
+// an id if one wasn't supplied.
+func (s *PostgresStorage) CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error) {
+    if sub.ID == "" {
+        sub.ID = uuid.New().String()
+    }
+    if sub.CreatedAt.IsZero() {
+        sub.CreatedAt = time.Now()
+    }
+    headers, err := json.Marshal(sub.Headers)
+    if err != nil {
+        return nil, err
+    }
+    _, err = s.db.ExecContext(ctx, `
+        INSERT INTO webhooks (id, url, event_types, secret, headers, auth_token, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, sub.ID, sub.URL, strings.Join(sub.EventTypes, ","), sub.Secret, string(headers), sub.AuthToken, sub.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &sub, nil
+}
+
+// DeleteWebhookSubscription removes a subscription by id.
+func (s *PostgresStorage) DeleteWebhookSubscription(ctx context.Context, id string) error {
+    _, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id=$1`, id)
+    return err
+}
+
+// SaveWebhookDelivery records the outcome of a webhook delivery attempt.
+func (s *PostgresStorage) SaveWebhookDelivery(ctx context.Context, d WebhookDelivery) error {
+    if d.ID == "" {
+        d.ID = uuid.New().String()
+    }
+    if d.DeliveredAt.IsZero() {
+        d.DeliveredAt = time.Now()
+    }
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (id, subscription_id, event, payload, status_code, error, attempt, delivered_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `, d.ID, d.SubscriptionID, d.Event, d.Payload, d.StatusCode, d.Error, d.Attempt, d.DeliveredAt)
+    return err
+}
+
+// ListWebhookDeliveries returns recent delivery attempts for a subscription,
+// most recent first.
+func (s *PostgresStorage) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]WebhookDelivery, error) {
+    if limit <= 0 {
+        limit = 50
+    }
+    rows, err := s.db.QueryContext(ctx, `
+        SELECT id, subscription_id, event, payload, status_code, error, attempt, delivered_at
+        FROM webhook_deliveries
+        WHERE subscription_id=$1
+        ORDER BY delivered_at DESC
+        LIMIT $2
+    `, subscriptionID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var out []WebhookDelivery
+    for rows.Next() {
+        var d WebhookDelivery
+        if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Event, &d.Payload, &d.StatusCode, &d.Error, &d.Attempt, &d.DeliveredAt); err != nil {
+            return nil, err
+        }
+        out = append(out, d)
+    }
+    return out, rows.Err()
+}
+
+// AppendSnapshot implements RateHistory by inserting one rate_snapshots row
+// per field in fields whose value differs from the last one recorded for
+// that (providerKey, field) pair.
+func (s *PostgresStorage) AppendSnapshot(ctx context.Context, providerKey string, fetchedAt time.Time, fields map[string]float64) error {
+    for path, value := range fields {
+        row := s.db.QueryRowContext(ctx, `
+            SELECT value FROM rate_snapshots
+            WHERE provider_key=$1 AND field_path=$2
+            ORDER BY id DESC
+            LIMIT 1
+        `, providerKey, path)
+        var prev float64
+        err := row.Scan(&prev)
+        if err != nil && !errors.Is(err, sql.ErrNoRows) {
+            return err
+        }
+        if err == nil && prev == value {
+            continue
+        }
+        if _, err := s.db.ExecContext(ctx, `
+            INSERT INTO rate_snapshots (provider_key, field_path, value, effective_from)
+            VALUES ($1, $2, $3, $4)
+        `, providerKey, path, value, fetchedAt); err != nil {
+            return fmt.Errorf("insert rate history for %s.%s: %w", providerKey, path, err)
+        }
+    }
+    return nil
+}
+
+// Query implements RateHistory, returning rate_snapshots rows for
+// providerKey bounded by from/to and restricted to fields, oldest first.
+func (s *PostgresStorage) Query(ctx context.Context, providerKey string, from, to time.Time, fields ...string) ([]RateHistoryPoint, error) {
+    query := `SELECT field_path, value, effective_from FROM rate_snapshots WHERE provider_key=$1`
+    args := []any{providerKey}
+    if !from.IsZero() {
+        args = append(args, from)
+        query += fmt.Sprintf(" AND effective_from >= $%d", len(args))
+    }
+    if !to.IsZero() {
+        args = append(args, to)
+        query += fmt.Sprintf(" AND effective_from <= $%d", len(args))
+    }
+    if len(fields) > 0 {
+        placeholders := make([]string, len(fields))
+        for i, f := range fields {
+            args = append(args, f)
+            placeholders[i] = fmt.Sprintf("$%d", len(args))
+        }
+        query += " AND field_path IN (" + strings.Join(placeholders, ",") + ")"
+    }
+    query += " ORDER BY id ASC"
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var out []RateHistoryPoint
+    for rows.Next() {
+        var p RateHistoryPoint
+        if err := rows.Scan(&p.FieldPath, &p.Value, &p.EffectiveFrom); err != nil {
+            return nil, err
+        }
+        p.ProviderKey = providerKey
+        out = append(out, p)
+    }
+    return out, rows.Err()
+}
+
+// SaveCircuitState upserts a provider's circuit breaker state.
+func (s *PostgresStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+    var openedAt, nextAttemptAt sql.NullTime
+    if !cs.OpenedAt.IsZero() {
+        openedAt = sql.NullTime{Time: cs.OpenedAt, Valid: true}
+    }
+    if !cs.NextAttemptAt.IsZero() {
+        nextAttemptAt = sql.NullTime{Time: cs.NextAttemptAt, Valid: true}
+    }
+
     _, err := s.db.ExecContext(ctx, `
-        INSERT INTO rates_snapshots (provider, payload, fetched_at)
-        VALUES ($1, $2, $3)
-    `, snap.Provider, snap.Payload, snap.FetchedAt)
+        INSERT INTO circuit_states (provider, state, consecutive_failures, opened_at, next_attempt_at, last_error)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (provider) DO UPDATE SET
+            state = EXCLUDED.state,
+            consecutive_failures = EXCLUDED.consecutive_failures,
+            opened_at = EXCLUDED.opened_at,
+            next_attempt_at = EXCLUDED.next_attempt_at,
+            last_error = EXCLUDED.last_error
+    `, cs.Provider, cs.State, cs.ConsecutiveFailures, openedAt, nextAttemptAt, cs.LastError)
     return err
 }
+
+// GetCircuitState returns the stored circuit breaker state for provider, or
+// nil if none has been recorded yet.
+func (s *PostgresStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+    row := s.db.QueryRowContext(ctx, `
+        SELECT provider, state, consecutive_failures, opened_at, next_attempt_at, last_error
+        FROM circuit_states
+        WHERE provider=$1
+    `, provider)
+
+    var cs CircuitState
+    var openedAt, nextAttemptAt sql.NullTime
+    if err := row.Scan(&cs.Provider, &cs.State, &cs.ConsecutiveFailures, &openedAt, &nextAttemptAt, &cs.LastError); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    cs.OpenedAt = openedAt.Time
+    cs.NextAttemptAt = nextAttemptAt.Time
+    return &cs, nil
+}
+
+type pgRowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanWebhookRowPG(row pgRowScanner) (WebhookSubscription, error) {
+    var sub WebhookSubscription
+    var eventTypes, headers string
+    if err := row.Scan(&sub.ID, &sub.URL, &eventTypes, &sub.Secret, &headers, &sub.AuthToken, &sub.CreatedAt); err != nil {
+        return sub, err
+    }
+    if eventTypes != "" {
+        sub.EventTypes = strings.Split(eventTypes, ",")
+    }
+    if headers != "" {
+        _ = json.Unmarshal([]byte(headers), &sub.Headers)
+    }
+    return sub, nil
+}
@@ -4,8 +4,11 @@ package storage
 import (
     "context"
     "errors"
+    "fmt"
+    "strings"
     "time"
 
+    "github.com/jackc/pgx/v5"
     "github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -47,6 +50,42 @@ func (s *PostgresPoolStorage) Migrate(ctx context.Context) error {
             payload BYTEA NOT NULL,
             fetched_at TIMESTAMPTZ NOT NULL
         );`,
+        `CREATE TABLE IF NOT EXISTS cron_jobs (
+            name TEXT PRIMARY KEY,
+            cron_expr TEXT NOT NULL,
+            last_run_at TIMESTAMPTZ,
+            last_success BOOLEAN NOT NULL DEFAULT FALSE,
+            last_error TEXT,
+            next_run_at TIMESTAMPTZ
+        );`,
+        // rate_snapshots is a finer-grained companion to rates_snapshots: one
+        // row per (provider, field) change point instead of one row per
+        // whole-payload refresh.
+        `CREATE TABLE IF NOT EXISTS rate_snapshots (
+            id SERIAL PRIMARY KEY,
+            provider_key TEXT NOT NULL,
+            field_path TEXT NOT NULL,
+            value DOUBLE PRECISION NOT NULL,
+            effective_from TIMESTAMPTZ NOT NULL
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_rate_snapshots_lookup ON rate_snapshots(provider_key, field_path, effective_from);`,
+        // batch_leases backs RunBatchOnce's leader election: at most one
+        // CronJob replica holds the row for a given key at a time.
+        `CREATE TABLE IF NOT EXISTS batch_leases (
+            key TEXT PRIMARY KEY,
+            holder TEXT NOT NULL,
+            expires_at TIMESTAMPTZ NOT NULL
+        );`,
+        // circuit_states backs RunBatchOnce's per-provider circuit breaker,
+        // the same bookkeeping sqlite_flat.go's circuit_states table holds.
+        `CREATE TABLE IF NOT EXISTS circuit_states (
+            provider TEXT PRIMARY KEY,
+            state TEXT NOT NULL,
+            consecutive_failures INTEGER DEFAULT 0,
+            opened_at TIMESTAMPTZ,
+            next_attempt_at TIMESTAMPTZ,
+            last_error TEXT
+        );`,
     }
     for _, stmt := range stmts {
         _, err := s.pool.Exec(ctx, stmt)
@@ -125,3 +164,194 @@ func (s *PostgresPoolStorage) SaveRatesSnapshot(ctx context.Context, snap RatesS
     `, snap.Provider, snap.Payload, snap.FetchedAt)
     return err
 }
+
+func (s *PostgresPoolStorage) AppendSnapshot(ctx context.Context, providerKey string, fetchedAt time.Time, fields map[string]float64) error {
+    for path, value := range fields {
+        row := s.pool.QueryRow(ctx, `
+            SELECT value FROM rate_snapshots
+            WHERE provider_key=$1 AND field_path=$2
+            ORDER BY id DESC
+            LIMIT 1
+        `, providerKey, path)
+        var prev float64
+        err := row.Scan(&prev)
+        if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+            return err
+        }
+        if err == nil && prev == value {
+            continue
+        }
+        if _, err := s.pool.Exec(ctx, `
+            INSERT INTO rate_snapshots (provider_key, field_path, value, effective_from)
+            VALUES ($1,$2,$3,$4)
+        `, providerKey, path, value, fetchedAt); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (s *PostgresPoolStorage) Query(ctx context.Context, providerKey string, from, to time.Time, fields ...string) ([]RateHistoryPoint, error) {
+    query := `SELECT field_path, value, effective_from FROM rate_snapshots WHERE provider_key=$1`
+    args := []any{providerKey}
+    if !from.IsZero() {
+        args = append(args, from)
+        query += fmt.Sprintf(" AND effective_from >= $%d", len(args))
+    }
+    if !to.IsZero() {
+        args = append(args, to)
+        query += fmt.Sprintf(" AND effective_from <= $%d", len(args))
+    }
+    if len(fields) > 0 {
+        placeholders := make([]string, len(fields))
+        for i, f := range fields {
+            args = append(args, f)
+            placeholders[i] = fmt.Sprintf("$%d", len(args))
+        }
+        query += " AND field_path IN (" + strings.Join(placeholders, ",") + ")"
+    }
+    query += " ORDER BY id ASC"
+
+    rows, err := s.pool.Query(ctx, query, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+
+    var out []RateHistoryPoint
+    for rows.Next() {
+        var p RateHistoryPoint
+        if err := rows.Scan(&p.FieldPath, &p.Value, &p.EffectiveFrom); err != nil {
+            return nil, err
+        }
+        p.ProviderKey = providerKey
+        out = append(out, p)
+    }
+    return out, rows.Err()
+}
+
+func (s *PostgresPoolStorage) UpsertCronJobSchedule(ctx context.Context, name, cronExpr string, nextRun time.Time) error {
+    _, err := s.pool.Exec(ctx, `
+        INSERT INTO cron_jobs (name, cron_expr, next_run_at)
+        VALUES ($1,$2,$3)
+        ON CONFLICT (name) DO UPDATE SET
+            cron_expr=EXCLUDED.cron_expr,
+            next_run_at=EXCLUDED.next_run_at
+    `, name, cronExpr, nextRun)
+    return err
+}
+
+func (s *PostgresPoolStorage) RecordCronJobRun(ctx context.Context, name string, ranAt time.Time, success bool, errMsg string, cancelled bool, nextRun time.Time) error {
+    _, err := s.pool.Exec(ctx, `
+        UPDATE cron_jobs
+        SET last_run_at=$2, last_success=$3, last_error=$4, last_cancelled=$5, next_run_at=$6
+        WHERE name=$1
+    `, name, ranAt, success, errMsg, cancelled, nextRun)
+    return err
+}
+
+func (s *PostgresPoolStorage) ListCronJobs(ctx context.Context) ([]CronJobStatus, error) {
+    rows, err := s.pool.Query(ctx, `
+        SELECT name, cron_expr, last_run_at, last_success, last_error, last_cancelled, next_run_at
+        FROM cron_jobs
+        ORDER BY name
+    `)
+    if err != nil { return nil, err }
+    defer rows.Close()
+
+    var out []CronJobStatus
+    for rows.Next() {
+        var st CronJobStatus
+        var lastErr *string
+        if err := rows.Scan(&st.Name, &st.CronExpr, &st.LastRunAt, &st.LastSuccess, &lastErr, &st.LastCancelled, &st.NextRunAt); err != nil {
+            return nil, err
+        }
+        if lastErr != nil {
+            st.LastError = *lastErr
+        }
+        out = append(out, st)
+    }
+    return out, rows.Err()
+}
+
+// AcquireBatchLease claims key for holder for ttl, succeeding if the lease
+// is unheld, expired, or already held by holder (so a renewal-by-reacquire
+// doesn't fail on itself).
+func (s *PostgresPoolStorage) AcquireBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+    now := time.Now()
+    tag, err := s.pool.Exec(ctx, `
+        INSERT INTO batch_leases (key, holder, expires_at)
+        VALUES ($1,$2,$3)
+        ON CONFLICT (key) DO UPDATE SET
+            holder=EXCLUDED.holder,
+            expires_at=EXCLUDED.expires_at
+        WHERE batch_leases.expires_at < $4 OR batch_leases.holder = $2
+    `, key, holder, now.Add(ttl), now)
+    if err != nil { return false, err }
+    return tag.RowsAffected() > 0, nil
+}
+
+// RenewBatchLease extends holder's lease on key by ttl, failing if holder no
+// longer holds it (another replica acquired it after this one's lease
+// expired).
+func (s *PostgresPoolStorage) RenewBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+    tag, err := s.pool.Exec(ctx, `
+        UPDATE batch_leases SET expires_at=$3 WHERE key=$1 AND holder=$2
+    `, key, holder, time.Now().Add(ttl))
+    if err != nil { return false, err }
+    return tag.RowsAffected() > 0, nil
+}
+
+// ReleaseBatchLease clears holder's lease on key so another replica can
+// acquire it immediately. A no-op if holder doesn't currently hold it.
+func (s *PostgresPoolStorage) ReleaseBatchLease(ctx context.Context, key, holder string) error {
+    _, err := s.pool.Exec(ctx, `DELETE FROM batch_leases WHERE key=$1 AND holder=$2`, key, holder)
+    return err
+}
+
+// SaveCircuitState upserts a provider's circuit breaker state.
+func (s *PostgresPoolStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+    var openedAt, nextAttemptAt *time.Time
+    if !cs.OpenedAt.IsZero() {
+        openedAt = &cs.OpenedAt
+    }
+    if !cs.NextAttemptAt.IsZero() {
+        nextAttemptAt = &cs.NextAttemptAt
+    }
+
+    _, err := s.pool.Exec(ctx, `
+        INSERT INTO circuit_states (provider, state, consecutive_failures, opened_at, next_attempt_at, last_error)
+        VALUES ($1,$2,$3,$4,$5,$6)
+        ON CONFLICT (provider) DO UPDATE SET
+            state=EXCLUDED.state,
+            consecutive_failures=EXCLUDED.consecutive_failures,
+            opened_at=EXCLUDED.opened_at,
+            next_attempt_at=EXCLUDED.next_attempt_at,
+            last_error=EXCLUDED.last_error
+    `, cs.Provider, cs.State, cs.ConsecutiveFailures, openedAt, nextAttemptAt, cs.LastError)
+    return err
+}
+
+// GetCircuitState returns the stored circuit breaker state for provider, or
+// nil if none has been recorded yet.
+func (s *PostgresPoolStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+    row := s.pool.QueryRow(ctx, `
+        SELECT provider, state, consecutive_failures, opened_at, next_attempt_at, last_error
+        FROM circuit_states
+        WHERE provider=$1
+    `, provider)
+
+    var cs CircuitState
+    var openedAt, nextAttemptAt *time.Time
+    if err := row.Scan(&cs.Provider, &cs.State, &cs.ConsecutiveFailures, &openedAt, &nextAttemptAt, &cs.LastError); err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    if openedAt != nil {
+        cs.OpenedAt = *openedAt
+    }
+    if nextAttemptAt != nil {
+        cs.NextAttemptAt = *nextAttemptAt
+    }
+    return &cs, nil
+}
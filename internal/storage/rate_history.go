@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// RateHistoryPoint is one change point in a provider field's value over
+// time: FieldPath held Value from EffectiveFrom until the next point for the
+// same (ProviderKey, FieldPath) pair (or indefinitely, for the last one).
+type RateHistoryPoint struct {
+	ProviderKey   string    `json:"provider_key"`
+	FieldPath     string    `json:"field_path"`
+	Value         float64   `json:"value"`
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// RateHistory is implemented by backends that track per-field rate history
+// as change points rather than whole-payload snapshots. It's a finer-grained
+// companion to SnapshotHistory: where SnapshotHistory retains every parsed
+// payload verbatim, RateHistory only grows when a field's value actually
+// moves, so a flat field can be charted over years at the cost of one row
+// instead of one per refresh.
+type RateHistory interface {
+	// AppendSnapshot records the fields observed for providerKey at
+	// fetchedAt, inserting a new row for each field whose value differs from
+	// its most recently recorded one (or has none yet). Fields already at
+	// their last-recorded value are skipped, so an unchanged refresh costs
+	// nothing.
+	AppendSnapshot(ctx context.Context, providerKey string, fetchedAt time.Time, fields map[string]float64) error
+	// Query returns the change points for providerKey, oldest first,
+	// bounded by from/to (zero for no lower/upper bound) and restricted to
+	// fields (all recorded fields when none are given).
+	Query(ctx context.Context, providerKey string, from, to time.Time, fields ...string) ([]RateHistoryPoint, error)
+}
+
+// FlattenRateFields extracts the numeric leaves of a rates response payload
+// into a field_path -> value map, the shape RateHistory.AppendSnapshot
+// expects. Non-numeric leaves (strings, booleans) are dropped since a
+// time series only makes sense for quantities that move.
+func FlattenRateFields(payload []byte) (map[string]float64, error) {
+	flat, err := flattenJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(flat))
+	for path, v := range flat {
+		if f, ok := v.(float64); ok {
+			out[path] = f
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,20 @@
+package storage
+
+import "context"
+
+// EventRatesChanged is the notification.NotificationEvent subject prefix
+// fired when SaveRatesSnapshot detects a non-empty diff against the
+// previous snapshot for a provider, mirroring webhooks.EventResidentialChanged
+// for the notification package's channels instead of the webhooks package's.
+const EventRatesChanged = "rates.changed"
+
+// RatesChangeNotifier is implemented by notification.RatesChangeNotifier,
+// which turns a RatesDiff into a NotificationEvent and fans it out through
+// every enabled channel. It's declared here (rather than storage importing
+// notification, which would cycle back through notification's own
+// storage.NotificationStore dependency) so SaveRatesSnapshot can call it
+// through SetRatesChangeNotifier without either package depending on the
+// other's concrete types.
+type RatesChangeNotifier interface {
+	NotifyRatesChanged(ctx context.Context, diff *RatesDiff) error
+}
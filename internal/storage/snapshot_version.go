@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrVersionConflict is returned by SaveRatesSnapshotIfVersion when the
+// stored snapshot's version has moved on since the caller last read it.
+var ErrVersionConflict = errors.New("storage: rates snapshot version conflict")
+
+// contentHash returns the hex-encoded SHA-256 of payload, used to dedupe
+// identical snapshots so a provider that returns unchanged rates doesn't
+// grow the history table on every refresh.
+func contentHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseNullTime parses a RFC3339Nano-formatted sql.NullString (the format
+// SQLiteStorage/PostgresStorage store snapshot timestamps in) into a
+// time.Time, returning the zero value when ns is NULL, empty, or
+// unparseable, the same "not superseded yet" meaning RatesSnapshot.SupersededAt
+// gives a zero value.
+func parseNullTime(ns sql.NullString) time.Time {
+	if !ns.Valid || ns.String == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, ns.String)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -3,15 +3,55 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/bher20/eratemanager/internal/alerts"
+	"github.com/bher20/eratemanager/internal/logging"
+	"github.com/bher20/eratemanager/internal/webhooks"
+	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 )
 
 // SQLiteStorage implements Storage using SQLite.
 type SQLiteStorage struct {
-	db *sql.DB
+	db                  *sql.DB
+	webhookDispatcher   *webhooks.Dispatcher
+	alertThresholds     *alerts.Thresholds
+	blobStore           BlobStore
+	ratesChangeNotifier RatesChangeNotifier
+}
+
+// SetBlobStore wires a BlobStore into the storage layer so SaveRatesSnapshot
+// offloads the payload there instead of storing it inline, keeping
+// rates_snapshots rows small. Passing nil disables offloading (the
+// default): payloads are stored inline as before.
+func (s *SQLiteStorage) SetBlobStore(bs BlobStore) {
+	s.blobStore = bs
+}
+
+// SetWebhookDispatcher wires a webhook dispatcher into the storage layer so
+// that SaveRatesSnapshot can fire events when parsed rates change. Passing
+// nil disables dispatch (the default).
+func (s *SQLiteStorage) SetWebhookDispatcher(d *webhooks.Dispatcher) {
+	s.webhookDispatcher = d
+}
+
+// EnableAlerts turns on rates-change alert evaluation on SaveRatesSnapshot
+// using the given thresholds. Passing nil disables it (the default).
+func (s *SQLiteStorage) EnableAlerts(th *alerts.Thresholds) {
+	s.alertThresholds = th
+}
+
+// SetRatesChangeNotifier wires a RatesChangeNotifier into the storage layer
+// so that SaveRatesSnapshot fires an EventRatesChanged notification through
+// it whenever a new snapshot's payload differs from the one it replaced.
+// Passing nil disables it (the default).
+func (s *SQLiteStorage) SetRatesChangeNotifier(n RatesChangeNotifier) {
+	s.ratesChangeNotifier = n
 }
 
 func OpenSQLite(dsn string) (*SQLiteStorage, error) {
@@ -46,8 +86,16 @@ func (s *SQLiteStorage) Migrate(ctx context.Context) error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			provider TEXT NOT NULL,
 			payload BLOB NOT NULL,
-			fetched_at TEXT NOT NULL
+			fetched_at TEXT NOT NULL,
+			content_hash TEXT,
+			version INTEGER NOT NULL DEFAULT 1,
+			blob_ref TEXT,
+			size INTEGER,
+			etag TEXT,
+			last_modified TEXT,
+			superseded_at TEXT
 		);`,
+		`CREATE INDEX IF NOT EXISTS idx_rates_snapshots_provider_fetched ON rates_snapshots(provider, fetched_at);`,
 		`CREATE TABLE IF NOT EXISTS batch_progress (
 			batch_id TEXT NOT NULL,
 			provider TEXT NOT NULL,
@@ -56,9 +104,70 @@ func (s *SQLiteStorage) Migrate(ctx context.Context) error {
 			completed_at TEXT,
 			error_message TEXT,
 			retry_count INTEGER DEFAULT 0,
+			lease_id TEXT,
+			lease_owner TEXT,
+			lease_expires_at TEXT,
 			PRIMARY KEY (batch_id, provider)
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_batch_progress_status ON batch_progress(batch_id, status);`,
+		`CREATE TABLE IF NOT EXISTS circuit_states (
+			provider TEXT PRIMARY KEY,
+			state TEXT NOT NULL,
+			consecutive_failures INTEGER DEFAULT 0,
+			opened_at TEXT,
+			next_attempt_at TEXT,
+			last_error TEXT
+		);`,
+		`CREATE TABLE IF NOT EXISTS batch_leases (
+			key TEXT PRIMARY KEY,
+			holder TEXT NOT NULL,
+			expires_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			event_types TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			headers TEXT,
+			auth_token TEXT,
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			subscription_id TEXT NOT NULL,
+			event TEXT NOT NULL,
+			payload BLOB,
+			status_code INTEGER,
+			error TEXT,
+			attempt INTEGER DEFAULT 0,
+			delivered_at TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, delivered_at);`,
+		`CREATE TABLE IF NOT EXISTS alerts (
+			id TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			type TEXT NOT NULL,
+			severity TEXT NOT NULL,
+			message TEXT NOT NULL,
+			data TEXT,
+			first_seen TEXT NOT NULL,
+			last_seen TEXT NOT NULL,
+			dismissed_at TEXT,
+			UNIQUE(provider, type)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_alerts_provider ON alerts(provider, dismissed_at);`,
+		// rate_snapshots is a finer-grained companion to rates_snapshots: it
+		// tracks one row per (provider, field) change point instead of one
+		// row per whole-payload refresh, so RateHistory.Query can chart a
+		// single field over time without replaying every snapshot.
+		`CREATE TABLE IF NOT EXISTS rate_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			provider_key TEXT NOT NULL,
+			field_path TEXT NOT NULL,
+			value REAL NOT NULL,
+			effective_from TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_rate_snapshots_lookup ON rate_snapshots(provider_key, field_path, effective_from);`,
 	}
 	for _, stmt := range stmts {
 		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
@@ -111,18 +220,29 @@ func (s *SQLiteStorage) UpsertProvider(ctx context.Context, p Provider) error {
 	return err
 }
 
+// DeleteProvider removes a provider row. It does not cascade to that
+// provider's rate snapshots, which are left in place for historical lookups.
+func (s *SQLiteStorage) DeleteProvider(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM providers WHERE key = ?`, key)
+	return err
+}
+
 func (s *SQLiteStorage) GetRatesSnapshot(ctx context.Context, provider string) (*RatesSnapshot, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT payload, fetched_at
+		SELECT id, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
 		FROM rates_snapshots
 		WHERE provider = ?
 		ORDER BY id DESC
 		LIMIT 1
 	`, provider)
 
+	var id int64
 	var payload []byte
 	var fetched string
-	if err := row.Scan(&payload, &fetched); err != nil {
+	var hash, blobRef, etag, lastModified, supersededAt sql.NullString
+	var version int
+	var size sql.NullInt64
+	if err := row.Scan(&id, &payload, &fetched, &hash, &version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -133,10 +253,29 @@ func (s *SQLiteStorage) GetRatesSnapshot(ctx context.Context, provider string) (
 		// Fall back to now if parsing fails.
 		t = time.Now()
 	}
+
+	if blobRef.Valid && blobRef.String != "" {
+		if s.blobStore == nil {
+			return nil, fmt.Errorf("storage: snapshot for %s has blob_ref %q but no BlobStore is configured", provider, blobRef.String)
+		}
+		payload, err = s.blobStore.Get(ctx, blobRef.String)
+		if err != nil {
+			return nil, fmt.Errorf("fetch blob %s: %w", blobRef.String, err)
+		}
+	}
+
 	return &RatesSnapshot{
-		Provider:  provider,
-		Payload:   payload,
-		FetchedAt: t,
+		ID:           id,
+		Provider:     provider,
+		Payload:      payload,
+		FetchedAt:    t,
+		ContentHash:  hash.String,
+		Version:      version,
+		BlobRef:      blobRef.String,
+		Size:         size.Int64,
+		ETag:         etag.String,
+		LastModified: lastModified.String,
+		SupersededAt: parseNullTime(supersededAt),
 	}, nil
 }
 
@@ -144,13 +283,587 @@ func (s *SQLiteStorage) SaveRatesSnapshot(ctx context.Context, snap RatesSnapsho
 	if snap.FetchedAt.IsZero() {
 		snap.FetchedAt = time.Now()
 	}
+
+	prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+	if err != nil {
+		prev = nil
+	}
+	var prevPayload []byte
+	if prev != nil {
+		prevPayload = prev.Payload
+	}
+
+	hash := contentHash(snap.Payload)
+	if prev != nil && prev.ContentHash == hash {
+		// Payload is byte-identical to the last snapshot; skip the write so
+		// an unchanged provider doesn't grow the history table every cycle.
+		return nil
+	}
+	version := 1
+	if prev != nil {
+		version = prev.Version + 1
+	}
+
+	size := int64(len(snap.Payload))
+	row := snap.Payload
+	var blobRef sql.NullString
+	if s.blobStore != nil {
+		ref, err := s.blobStore.Put(ctx, "rates/"+snap.Provider+"/"+hash, snap.Payload)
+		if err != nil {
+			return fmt.Errorf("store blob: %w", err)
+		}
+		blobRef = sql.NullString{String: ref, Valid: true}
+		row = []byte{}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO rates_snapshots (provider, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, snap.Provider, row, snap.FetchedAt.Format(time.RFC3339Nano), hash, version, blobRef, size, snap.ETag, snap.LastModified)
+	if err != nil {
+		return err
+	}
+
+	if prev != nil {
+		// Mark the row this one replaced as superseded, so ListRatesSnapshots
+		// and GetRatesSnapshotAt callers can tell how long each version was
+		// in effect.
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE rates_snapshots SET superseded_at = ? WHERE id = ?
+		`, snap.FetchedAt.Format(time.RFC3339Nano), prev.ID); err != nil {
+			return fmt.Errorf("mark previous snapshot superseded: %w", err)
+		}
+	}
+
+	if s.webhookDispatcher != nil {
+		if event := classifyRatesChange(prevPayload, snap.Payload); event != "" {
+			s.webhookDispatcher.Dispatch(ctx, webhooks.Envelope{
+				Event:     event,
+				Provider:  snap.Provider,
+				Old:       json.RawMessage(prevPayload),
+				New:       json.RawMessage(snap.Payload),
+				FetchedAt: snap.FetchedAt,
+			})
+		}
+	}
+
+	if s.alertThresholds != nil {
+		for _, f := range alerts.Evaluate(snap.Provider, prevPayload, snap.Payload, *s.alertThresholds) {
+			if err := s.UpsertAlert(ctx, f); err != nil {
+				logging.WithProvider(logging.Default(), snap.Provider).ErrorContext(ctx, "upsert alert failed", "error", err)
+			}
+		}
+	}
+
+	if s.ratesChangeNotifier != nil && prev != nil {
+		if diff, err := DiffPayloads(snap.Provider, prevPayload, snap.Payload); err != nil {
+			logging.WithProvider(logging.Default(), snap.Provider).ErrorContext(ctx, "diff rates snapshot failed", "error", err)
+		} else if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+			if err := s.ratesChangeNotifier.NotifyRatesChanged(ctx, diff); err != nil {
+				logging.WithProvider(logging.Default(), snap.Provider).ErrorContext(ctx, "rates-changed notify failed", "error", err)
+			}
+		}
+	}
+
+	snap.Version = version
+	logSnapshotSaved(ctx, snap, prevPayload, prev != nil)
+	return nil
+}
+
+// SaveRatesSnapshotIfVersion behaves like SaveRatesSnapshot but first checks
+// that the provider's current stored version matches expectedVersion,
+// returning ErrVersionConflict if another writer has already advanced it.
+// A expectedVersion of 0 requires that no snapshot exists yet for provider.
+func (s *SQLiteStorage) SaveRatesSnapshotIfVersion(ctx context.Context, snap RatesSnapshot, expectedVersion int) error {
+	prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+	if err != nil {
+		return err
+	}
+	current := 0
+	if prev != nil {
+		current = prev.Version
+	}
+	if current != expectedVersion {
+		return ErrVersionConflict
+	}
+	return s.SaveRatesSnapshot(ctx, snap)
+}
+
+// ListRatesSnapshots returns provider's snapshot history, newest first,
+// optionally bounded by since/until (zero means no lower/upper bound) and
+// limit (zero means no limit). Payload blobs are resolved the same way
+// GetRatesSnapshot resolves them.
+func (s *SQLiteStorage) ListRatesSnapshots(ctx context.Context, provider string, since, until time.Time, limit int) ([]RatesSnapshot, error) {
+	query := `
+		SELECT id, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
+		FROM rates_snapshots
+		WHERE provider = ? AND fetched_at >= ?
+	`
+	args := []any{provider, since.Format(time.RFC3339Nano)}
+	if !until.IsZero() {
+		query += ` AND fetched_at <= ?`
+		args = append(args, until.Format(time.RFC3339Nano))
+	}
+	query += ` ORDER BY id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RatesSnapshot
+	for rows.Next() {
+		var snap RatesSnapshot
+		var fetched string
+		var hash, blobRef, etag, lastModified, supersededAt sql.NullString
+		var size sql.NullInt64
+		if err := rows.Scan(&snap.ID, &snap.Payload, &fetched, &hash, &snap.Version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
+			return nil, err
+		}
+		snap.Provider = provider
+		snap.ContentHash = hash.String
+		snap.BlobRef = blobRef.String
+		snap.Size = size.Int64
+		snap.ETag = etag.String
+		snap.LastModified = lastModified.String
+		snap.SupersededAt = parseNullTime(supersededAt)
+		if t, err := time.Parse(time.RFC3339Nano, fetched); err == nil {
+			snap.FetchedAt = t
+		}
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
+// GetRatesSnapshotAt returns the snapshot in effect at instant at: the row
+// with the highest fetched_at <= at (ties broken by max id), so an E-Rate
+// auditor can ask "what did this provider's tariff look like on date X?".
+// Returns nil with no error if no snapshot existed yet at that time.
+func (s *SQLiteStorage) GetRatesSnapshotAt(ctx context.Context, provider string, at time.Time) (*RatesSnapshot, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
+		FROM rates_snapshots
+		WHERE provider = ? AND fetched_at <= ?
+		ORDER BY id DESC
+		LIMIT 1
+	`, provider, at.Format(time.RFC3339Nano))
+
+	var id int64
+	var payload []byte
+	var fetched string
+	var hash, blobRef, etag, lastModified, supersededAt sql.NullString
+	var version int
+	var size sql.NullInt64
+	if err := row.Scan(&id, &payload, &fetched, &hash, &version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	t, err := time.Parse(time.RFC3339Nano, fetched)
+	if err != nil {
+		t = at
+	}
+
+	if blobRef.Valid && blobRef.String != "" {
+		if s.blobStore == nil {
+			return nil, fmt.Errorf("storage: snapshot for %s has blob_ref %q but no BlobStore is configured", provider, blobRef.String)
+		}
+		payload, err = s.blobStore.Get(ctx, blobRef.String)
+		if err != nil {
+			return nil, fmt.Errorf("fetch blob %s: %w", blobRef.String, err)
+		}
+	}
+
+	return &RatesSnapshot{
+		ID:           id,
+		Provider:     provider,
+		Payload:      payload,
+		FetchedAt:    t,
+		ContentHash:  hash.String,
+		Version:      version,
+		BlobRef:      blobRef.String,
+		Size:         size.Int64,
+		ETag:         etag.String,
+		LastModified: lastModified.String,
+		SupersededAt: parseNullTime(supersededAt),
+	}, nil
+}
+
+// GetRatesSnapshotByID fetches a single snapshot row by its primary key,
+// resolving BlobRef the same way GetRatesSnapshot does.
+func (s *SQLiteStorage) GetRatesSnapshotByID(ctx context.Context, id int64) (*RatesSnapshot, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT provider, payload, fetched_at, content_hash, version, blob_ref, size, etag, last_modified, superseded_at
+		FROM rates_snapshots
+		WHERE id = ?
+	`, id)
+
+	var snap RatesSnapshot
+	var fetched string
+	var hash, blobRef, etag, lastModified, supersededAt sql.NullString
+	var size sql.NullInt64
+	if err := row.Scan(&snap.Provider, &snap.Payload, &fetched, &hash, &snap.Version, &blobRef, &size, &etag, &lastModified, &supersededAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	snap.ID = id
+	snap.ContentHash = hash.String
+	snap.BlobRef = blobRef.String
+	snap.Size = size.Int64
+	snap.ETag = etag.String
+	snap.LastModified = lastModified.String
+	snap.SupersededAt = parseNullTime(supersededAt)
+	if t, err := time.Parse(time.RFC3339Nano, fetched); err == nil {
+		snap.FetchedAt = t
+	}
+
+	if snap.BlobRef != "" {
+		if s.blobStore == nil {
+			return nil, fmt.Errorf("storage: snapshot %d has blob_ref %q but no BlobStore is configured", id, snap.BlobRef)
+		}
+		payload, err := s.blobStore.Get(ctx, snap.BlobRef)
+		if err != nil {
+			return nil, fmt.Errorf("fetch blob %s: %w", snap.BlobRef, err)
+		}
+		snap.Payload = payload
+	}
+	return &snap, nil
+}
+
+// PruneRatesSnapshots deletes snapshot rows older than olderThan, keeping at
+// least the keepLast most recent rows per provider regardless of age. It
+// returns the number of rows deleted.
+func (s *SQLiteStorage) PruneRatesSnapshots(ctx context.Context, keepLast int, olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-olderThan).Format(time.RFC3339Nano)
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM rates_snapshots
+		WHERE fetched_at < ?
+		AND id NOT IN (
+			SELECT id FROM rates_snapshots rs2
+			WHERE rs2.provider = rates_snapshots.provider
+			ORDER BY rs2.id DESC
+			LIMIT ?
+		)
+	`, cutoff, keepLast)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpsertAlert records a finding, updating last_seen and data if an alert for
+// the same (provider, type) is already open (not dismissed), or inserting a
+// new row otherwise. This keeps repeated detections idempotent.
+func (s *SQLiteStorage) UpsertAlert(ctx context.Context, f alerts.Finding) error {
+	data, err := json.Marshal(f.Data)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Format(time.RFC3339Nano)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO alerts (id, provider, type, severity, message, data, first_seen, last_seen, dismissed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULL)
+		ON CONFLICT(provider, type) DO UPDATE SET
+			severity = excluded.severity,
+			message = excluded.message,
+			data = excluded.data,
+			last_seen = excluded.last_seen,
+			dismissed_at = NULL
+	`, uuid.New().String(), f.Provider, f.Type, f.Severity, f.Message, string(data), now, now)
+	return err
+}
+
+// ListAlerts returns alerts for provider (all providers if empty), excluding
+// dismissed ones unless includeDismissed is set.
+func (s *SQLiteStorage) ListAlerts(ctx context.Context, provider string, includeDismissed bool) ([]Alert, error) {
+	query := `SELECT id, provider, type, severity, message, data, first_seen, last_seen, dismissed_at FROM alerts WHERE 1=1`
+	var args []interface{}
+	if provider != "" {
+		query += ` AND provider = ?`
+		args = append(args, provider)
+	}
+	if !includeDismissed {
+		query += ` AND dismissed_at IS NULL`
+	}
+	query += ` ORDER BY last_seen DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Alert
+	for rows.Next() {
+		a, err := scanAlertRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// DismissAlert marks an alert as dismissed.
+func (s *SQLiteStorage) DismissAlert(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE alerts SET dismissed_at = ? WHERE id = ?`, time.Now().Format(time.RFC3339Nano), id)
+	return err
+}
+
+// ListProvidersWithLastSnapshot returns each provider's most recent
+// snapshot timestamp, for use by alerts.CheckStaleness.
+func (s *SQLiteStorage) ListProvidersWithLastSnapshot(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT provider, MAX(fetched_at) FROM rates_snapshots GROUP BY provider`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]time.Time)
+	for rows.Next() {
+		var provider, fetched string
+		if err := rows.Scan(&provider, &fetched); err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, fetched)
+		if err != nil {
+			continue
+		}
+		out[provider] = t
+	}
+	return out, rows.Err()
+}
+
+// AppendSnapshot implements RateHistory by inserting one rate_snapshots row
+// per field in fields whose value differs from the last one recorded for
+// that (providerKey, field) pair, found via a per-field lookup of the most
+// recent row.
+func (s *SQLiteStorage) AppendSnapshot(ctx context.Context, providerKey string, fetchedAt time.Time, fields map[string]float64) error {
+	for path, value := range fields {
+		row := s.db.QueryRowContext(ctx, `
+			SELECT value FROM rate_snapshots
+			WHERE provider_key = ? AND field_path = ?
+			ORDER BY id DESC
+			LIMIT 1
+		`, providerKey, path)
+		var prev float64
+		err := row.Scan(&prev)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && prev == value {
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO rate_snapshots (provider_key, field_path, value, effective_from)
+			VALUES (?, ?, ?, ?)
+		`, providerKey, path, value, fetchedAt.Format(time.RFC3339Nano)); err != nil {
+			return fmt.Errorf("insert rate history for %s.%s: %w", providerKey, path, err)
+		}
+	}
+	return nil
+}
+
+// Query implements RateHistory, returning rate_snapshots rows for
+// providerKey bounded by from/to and restricted to fields, oldest first.
+func (s *SQLiteStorage) Query(ctx context.Context, providerKey string, from, to time.Time, fields ...string) ([]RateHistoryPoint, error) {
+	query := `SELECT field_path, value, effective_from FROM rate_snapshots WHERE provider_key = ?`
+	args := []any{providerKey}
+	if !from.IsZero() {
+		query += ` AND effective_from >= ?`
+		args = append(args, from.Format(time.RFC3339Nano))
+	}
+	if !to.IsZero() {
+		query += ` AND effective_from <= ?`
+		args = append(args, to.Format(time.RFC3339Nano))
+	}
+	if len(fields) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(fields)), ",")
+		query += ` AND field_path IN (` + placeholders + `)`
+		for _, f := range fields {
+			args = append(args, f)
+		}
+	}
+	query += ` ORDER BY id ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RateHistoryPoint
+	for rows.Next() {
+		var p RateHistoryPoint
+		var effectiveFrom string
+		if err := rows.Scan(&p.FieldPath, &p.Value, &effectiveFrom); err != nil {
+			return nil, err
+		}
+		p.ProviderKey = providerKey
+		p.EffectiveFrom, _ = time.Parse(time.RFC3339Nano, effectiveFrom)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func scanAlertRow(row rowScanner) (Alert, error) {
+	var a Alert
+	var data sql.NullString
+	var firstSeen, lastSeen string
+	var dismissedAt sql.NullString
+	if err := row.Scan(&a.ID, &a.Provider, &a.Type, &a.Severity, &a.Message, &data, &firstSeen, &lastSeen, &dismissedAt); err != nil {
+		return a, err
+	}
+	if data.Valid {
+		a.Data = []byte(data.String)
+	}
+	a.FirstSeen, _ = time.Parse(time.RFC3339Nano, firstSeen)
+	a.LastSeen, _ = time.Parse(time.RFC3339Nano, lastSeen)
+	if dismissedAt.Valid {
+		t, err := time.Parse(time.RFC3339Nano, dismissedAt.String)
+		if err == nil {
+			a.DismissedAt = &t
+		}
+	}
+	return a, nil
+}
+
+// ListWebhookSubscriptions returns all registered webhook subscriptions.
+func (s *SQLiteStorage) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, url, event_types, secret, headers, auth_token, created_at FROM webhooks`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhookRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// GetWebhookSubscription looks up a single subscription by id.
+func (s *SQLiteStorage) GetWebhookSubscription(ctx context.Context, id string) (*WebhookSubscription, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, url, event_types, secret, headers, auth_token, created_at FROM webhooks WHERE id = ?`, id)
+	sub, err := scanWebhookRow(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// CreateWebhookSubscription persists a new webhook subscription, assigning
+// an id if one wasn't supplied.
+func (s *SQLiteStorage) CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error) {
+	if sub.ID == "" {
+		sub.ID = uuid.New().String()
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+	headers, err := json.Marshal(sub.Headers)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO webhooks (id, url, event_types, secret, headers, auth_token, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, sub.ID, sub.URL, strings.Join(sub.EventTypes, ","), sub.Secret, string(headers), sub.AuthToken, sub.CreatedAt.Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// DeleteWebhookSubscription removes a subscription by id.
+func (s *SQLiteStorage) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	return err
+}
+
+// SaveWebhookDelivery records the outcome of a webhook delivery attempt.
+func (s *SQLiteStorage) SaveWebhookDelivery(ctx context.Context, d WebhookDelivery) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	if d.DeliveredAt.IsZero() {
+		d.DeliveredAt = time.Now()
+	}
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO rates_snapshots (provider, payload, fetched_at)
-		VALUES (?, ?, ?)
-	`, snap.Provider, snap.Payload, snap.FetchedAt.Format(time.RFC3339Nano))
+		INSERT INTO webhook_deliveries (id, subscription_id, event, payload, status_code, error, attempt, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.SubscriptionID, d.Event, d.Payload, d.StatusCode, d.Error, d.Attempt, d.DeliveredAt.Format(time.RFC3339Nano))
 	return err
 }
 
+// ListWebhookDeliveries returns recent delivery attempts for a subscription,
+// most recent first.
+func (s *SQLiteStorage) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, subscription_id, event, payload, status_code, error, attempt, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY delivered_at DESC
+		LIMIT ?
+	`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var delivered string
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Event, &d.Payload, &d.StatusCode, &d.Error, &d.Attempt, &delivered); err != nil {
+			return nil, err
+		}
+		d.DeliveredAt, _ = time.Parse(time.RFC3339Nano, delivered)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// rowScanner abstracts *sql.Row and *sql.Rows for scanWebhookRow.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookRow(row rowScanner) (WebhookSubscription, error) {
+	var sub WebhookSubscription
+	var eventTypes, headers, created string
+	if err := row.Scan(&sub.ID, &sub.URL, &eventTypes, &sub.Secret, &headers, &sub.AuthToken, &created); err != nil {
+		return sub, err
+	}
+	if eventTypes != "" {
+		sub.EventTypes = strings.Split(eventTypes, ",")
+	}
+	if headers != "" {
+		_ = json.Unmarshal([]byte(headers), &sub.Headers)
+	}
+	sub.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+	return sub, nil
+}
+
 func (s *SQLiteStorage) SaveBatchProgress(ctx context.Context, progress BatchProgress) error {
 	var startedAt, completedAt sql.NullString
 	if !progress.StartedAt.IsZero() {
@@ -220,3 +933,150 @@ func (s *SQLiteStorage) GetPendingBatchProviders(ctx context.Context, batchID st
 	}
 	return providers, rows.Err()
 }
+
+// AcquireBatchProviderLease attempts to claim (batchID, provider) for
+// workerID for ttl. The UPDATE's WHERE clause only matches rows with no
+// lease or an expired one, so concurrent workers racing on the same
+// provider see at most one row affected.
+func (s *SQLiteStorage) AcquireBatchProviderLease(ctx context.Context, batchID, provider, workerID string, ttl time.Duration) (string, bool, error) {
+	leaseID := uuid.New().String()
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE batch_progress
+		SET lease_id = ?, lease_owner = ?, lease_expires_at = ?
+		WHERE batch_id = ? AND provider = ?
+			AND (lease_id IS NULL OR lease_expires_at < ?)
+	`, leaseID, workerID, expiresAt.Format(time.RFC3339Nano), batchID, provider, now.Format(time.RFC3339Nano))
+	if err != nil {
+		return "", false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", false, err
+	}
+	if n == 0 {
+		return "", false, nil
+	}
+	return leaseID, true, nil
+}
+
+// RefreshBatchProviderLease extends a held lease's expiry by
+// defaultLeaseRenewal, keeping it alive while the worker is still
+// processing the provider.
+func (s *SQLiteStorage) RefreshBatchProviderLease(ctx context.Context, leaseID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE batch_progress SET lease_expires_at = ? WHERE lease_id = ?
+	`, time.Now().Add(defaultLeaseRenewal).Format(time.RFC3339Nano), leaseID)
+	return err
+}
+
+// ReleaseBatchProviderLease clears a held lease so another worker (or the
+// same one, on a later batch) can acquire the provider immediately.
+func (s *SQLiteStorage) ReleaseBatchProviderLease(ctx context.Context, leaseID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE batch_progress SET lease_id = NULL, lease_owner = NULL, lease_expires_at = NULL WHERE lease_id = ?
+	`, leaseID)
+	return err
+}
+
+// SaveCircuitState upserts a provider's circuit breaker state.
+func (s *SQLiteStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+	var openedAt, nextAttemptAt sql.NullString
+	if !cs.OpenedAt.IsZero() {
+		openedAt = sql.NullString{String: cs.OpenedAt.Format(time.RFC3339Nano), Valid: true}
+	}
+	if !cs.NextAttemptAt.IsZero() {
+		nextAttemptAt = sql.NullString{String: cs.NextAttemptAt.Format(time.RFC3339Nano), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO circuit_states (provider, state, consecutive_failures, opened_at, next_attempt_at, last_error)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(provider) DO UPDATE SET
+			state = excluded.state,
+			consecutive_failures = excluded.consecutive_failures,
+			opened_at = excluded.opened_at,
+			next_attempt_at = excluded.next_attempt_at,
+			last_error = excluded.last_error
+	`, cs.Provider, cs.State, cs.ConsecutiveFailures, openedAt, nextAttemptAt, cs.LastError)
+	return err
+}
+
+// GetCircuitState returns the stored circuit breaker state for provider, or
+// nil if none has been recorded yet.
+func (s *SQLiteStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT provider, state, consecutive_failures, opened_at, next_attempt_at, last_error
+		FROM circuit_states
+		WHERE provider = ?
+	`, provider)
+
+	var cs CircuitState
+	var openedAt, nextAttemptAt sql.NullString
+	if err := row.Scan(&cs.Provider, &cs.State, &cs.ConsecutiveFailures, &openedAt, &nextAttemptAt, &cs.LastError); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if openedAt.Valid {
+		cs.OpenedAt, _ = time.Parse(time.RFC3339Nano, openedAt.String)
+	}
+	if nextAttemptAt.Valid {
+		cs.NextAttemptAt, _ = time.Parse(time.RFC3339Nano, nextAttemptAt.String)
+	}
+
+	return &cs, nil
+}
+
+// AcquireBatchLease claims key for holder for ttl, succeeding if the lease
+// is unheld, expired, or already held by holder.
+func (s *SQLiteStorage) AcquireBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO batch_leases (key, holder, expires_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			holder = excluded.holder,
+			expires_at = excluded.expires_at
+		WHERE batch_leases.expires_at < ? OR batch_leases.holder = ?
+	`, key, holder, expiresAt.Format(time.RFC3339Nano), now.Format(time.RFC3339Nano), holder)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RenewBatchLease extends holder's lease on key by ttl, failing if holder no
+// longer holds it.
+func (s *SQLiteStorage) RenewBatchLease(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE batch_leases SET expires_at = ? WHERE key = ? AND holder = ?
+	`, time.Now().Add(ttl).Format(time.RFC3339Nano), key, holder)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ReleaseBatchLease clears holder's lease on key so another replica can
+// acquire it immediately. A no-op if holder doesn't currently hold it.
+func (s *SQLiteStorage) ReleaseBatchLease(ctx context.Context, key, holder string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM batch_leases WHERE key = ? AND holder = ?
+	`, key, holder)
+	return err
+}
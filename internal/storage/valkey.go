@@ -0,0 +1,449 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// ValkeyStorage implements Storage (and SnapshotHistory) on top of a
+// Valkey/Redis server, letting multiple eratemanager instances share
+// provider, snapshot, and batch progress state the same way EtcdStorage
+// does for deployments that already run a Valkey cluster instead of etcd.
+// Unlike EtcdStorage, it keeps every snapshot version queryable (not just
+// the latest), since a sorted set is the natural index for that in Valkey.
+//
+// Providers live under provider:<key> as JSON. Snapshots are stored twice:
+// the full row under rates:id:<id> (id assigned from the rates:next_id
+// counter), and as a member of the sorted set rates:<provider>:history,
+// scored by FetchedAt's Unix timestamp, so ListRatesSnapshots and
+// GetRatesSnapshotAt can range-query by time instead of scanning the whole
+// keyspace. Batch progress lives under batch:<batch_id>:<provider>, with a
+// TTL on non-terminal rows so a crashed worker's claim expires instead of
+// blocking the provider forever.
+type ValkeyStorage struct {
+	client valkey.Client
+}
+
+const (
+	valkeyProviderPrefix = "provider:"
+	valkeySnapshotPrefix = "rates:id:"
+	valkeyNextIDKey      = "rates:next_id"
+	valkeyBatchPrefix    = "batch:"
+	valkeyCircuitPrefix  = "circuit:"
+)
+
+// ValkeyConfig controls TLS for OpenValkey, fed by config.FromEnv the same
+// way BlobConfig feeds OpenBlobStore.
+type ValkeyConfig struct {
+	// TLS enables a TLS connection to the Valkey server.
+	TLS bool
+	// InsecureSkipVerify disables server certificate verification; only
+	// meaningful when TLS is true. Intended for local/dev clusters running
+	// self-signed certs.
+	InsecureSkipVerify bool
+}
+
+// OpenValkey connects to a Valkey/Redis server given its seed addresses
+// (host:port), symmetric with OpenEtcd/OpenSQLite/OpenPostgresPool.
+func OpenValkey(ctx context.Context, addrs []string, cfg ValkeyConfig) (*ValkeyStorage, error) {
+	opt := valkey.ClientOption{InitAddress: addrs}
+	if cfg.TLS {
+		opt.TLSConfig = &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	}
+
+	client, err := valkey.NewClient(opt)
+	if err != nil {
+		return nil, fmt.Errorf("connect to valkey: %w", err)
+	}
+	return &ValkeyStorage{client: client}, nil
+}
+
+func (s *ValkeyStorage) Close() error {
+	s.client.Close()
+	return nil
+}
+
+func (s *ValkeyStorage) Ping(ctx context.Context) error {
+	return s.client.Do(ctx, s.client.B().Ping().Build()).Error()
+}
+
+// Migrate is a no-op for Valkey: there's no schema, only keys written on
+// demand, mirroring EtcdStorage.Migrate.
+func (s *ValkeyStorage) Migrate(ctx context.Context) error { return nil }
+
+func providerValkeyKey(key string) string { return valkeyProviderPrefix + key }
+
+func (s *ValkeyStorage) ListProviders(ctx context.Context) ([]Provider, error) {
+	keys, err := s.client.Do(ctx, s.client.B().Keys().Pattern(valkeyProviderPrefix+"*").Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Provider, 0, len(keys))
+	for _, k := range keys {
+		raw, err := s.client.Do(ctx, s.client.B().Get().Key(k).Build()).AsBytes()
+		if err != nil {
+			continue
+		}
+		var p Provider
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("decode provider %s: %w", k, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (s *ValkeyStorage) GetProvider(ctx context.Context, key string) (*Provider, error) {
+	raw, err := s.client.Do(ctx, s.client.B().Get().Key(providerValkeyKey(key)).Build()).AsBytes()
+	if valkey.IsValkeyNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p Provider
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("decode provider %s: %w", key, err)
+	}
+	return &p, nil
+}
+
+func (s *ValkeyStorage) UpsertProvider(ctx context.Context, p Provider) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return s.client.Do(ctx, s.client.B().Set().Key(providerValkeyKey(p.Key)).Value(string(data)).Build()).Error()
+}
+
+// DeleteProvider removes a provider row. It does not cascade to that
+// provider's rate snapshots, which are left in place for historical
+// lookups, matching SQLiteStorage/PostgresStorage.
+func (s *ValkeyStorage) DeleteProvider(ctx context.Context, key string) error {
+	return s.client.Do(ctx, s.client.B().Del().Key(providerValkeyKey(key)).Build()).Error()
+}
+
+func valkeyHistoryKey(provider string) string { return "rates:" + provider + ":history" }
+
+func valkeySnapshotKey(id int64) string { return valkeySnapshotPrefix + strconv.FormatInt(id, 10) }
+
+// nextSnapshotID atomically increments the shared rates:next_id counter,
+// giving every snapshot (across every provider) a unique, monotonically
+// increasing ID the same way SQLiteStorage/PostgresStorage's SERIAL/
+// AUTOINCREMENT primary key does.
+func (s *ValkeyStorage) nextSnapshotID(ctx context.Context) (int64, error) {
+	return s.client.Do(ctx, s.client.B().Incr().Key(valkeyNextIDKey).Build()).AsInt64()
+}
+
+func (s *ValkeyStorage) getSnapshotByID(ctx context.Context, id int64) (*RatesSnapshot, error) {
+	raw, err := s.client.Do(ctx, s.client.B().Get().Key(valkeySnapshotKey(id)).Build()).AsBytes()
+	if valkey.IsValkeyNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap RatesSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot %d: %w", id, err)
+	}
+	return &snap, nil
+}
+
+// GetRatesSnapshot returns the latest snapshot for provider: the history
+// sorted set's highest-scored (most recent) member.
+func (s *ValkeyStorage) GetRatesSnapshot(ctx context.Context, provider string) (*RatesSnapshot, error) {
+	ids, err := s.client.Do(ctx, s.client.B().Zrevrange().Key(valkeyHistoryKey(provider)).Start(0).Stop(0).Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(ids[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse snapshot id %q: %w", ids[0], err)
+	}
+	return s.getSnapshotByID(ctx, id)
+}
+
+// SaveRatesSnapshot writes a new snapshot version and marks the row it
+// replaces as superseded, mirroring SQLiteStorage/PostgresStorage. Unlike
+// those backends it skips the content-hash dedupe check's SQL round trip by
+// reusing GetRatesSnapshot, which is already a single ZREVRANGE + GET here.
+func (s *ValkeyStorage) SaveRatesSnapshot(ctx context.Context, snap RatesSnapshot) error {
+	if snap.FetchedAt.IsZero() {
+		snap.FetchedAt = time.Now()
+	}
+
+	prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+	if err != nil {
+		prev = nil
+	}
+
+	hash := contentHash(snap.Payload)
+	if prev != nil && prev.ContentHash == hash {
+		// Unchanged payload; skip the write so an idle provider doesn't grow
+		// the history sorted set every cycle.
+		return nil
+	}
+
+	snap.ContentHash = hash
+	snap.Version = 1
+	if prev != nil {
+		snap.Version = prev.Version + 1
+	}
+
+	id, err := s.nextSnapshotID(ctx)
+	if err != nil {
+		return fmt.Errorf("assign snapshot id: %w", err)
+	}
+	snap.ID = id
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Do(ctx, s.client.B().Set().Key(valkeySnapshotKey(id)).Value(string(data)).Build()).Error(); err != nil {
+		return err
+	}
+	score := float64(snap.FetchedAt.UnixNano())
+	if err := s.client.Do(ctx, s.client.B().Zadd().Key(valkeyHistoryKey(snap.Provider)).ScoreMember().ScoreMember(score, strconv.FormatInt(id, 10)).Build()).Error(); err != nil {
+		return fmt.Errorf("index snapshot %d: %w", id, err)
+	}
+
+	if prev != nil {
+		prev.SupersededAt = snap.FetchedAt
+		prevData, err := json.Marshal(prev)
+		if err != nil {
+			return err
+		}
+		if err := s.client.Do(ctx, s.client.B().Set().Key(valkeySnapshotKey(prev.ID)).Value(string(prevData)).Build()).Error(); err != nil {
+			return fmt.Errorf("mark previous snapshot superseded: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveRatesSnapshotIfVersion behaves like SaveRatesSnapshot but first checks
+// that the provider's current stored version matches expectedVersion,
+// returning ErrVersionConflict if another writer has already advanced it.
+func (s *ValkeyStorage) SaveRatesSnapshotIfVersion(ctx context.Context, snap RatesSnapshot, expectedVersion int) error {
+	prev, err := s.GetRatesSnapshot(ctx, snap.Provider)
+	if err != nil {
+		return err
+	}
+	current := 0
+	if prev != nil {
+		current = prev.Version
+	}
+	if current != expectedVersion {
+		return ErrVersionConflict
+	}
+	return s.SaveRatesSnapshot(ctx, snap)
+}
+
+// ListRatesSnapshots returns provider's snapshot history, newest first,
+// optionally bounded by since/until (zero means no lower/upper bound) and
+// limit (zero means no limit).
+func (s *ValkeyStorage) ListRatesSnapshots(ctx context.Context, provider string, since, until time.Time, limit int) ([]RatesSnapshot, error) {
+	min := "-inf"
+	if !since.IsZero() {
+		min = strconv.FormatInt(since.UnixNano(), 10)
+	}
+	max := "+inf"
+	if !until.IsZero() {
+		max = strconv.FormatInt(until.UnixNano(), 10)
+	}
+
+	ids, err := s.client.Do(ctx, s.client.B().Zrevrangebyscore().Key(valkeyHistoryKey(provider)).Max(max).Min(min).Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	out := make([]RatesSnapshot, 0, len(ids))
+	for _, idStr := range ids {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse snapshot id %q: %w", idStr, err)
+		}
+		snap, err := s.getSnapshotByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if snap != nil {
+			out = append(out, *snap)
+		}
+	}
+	return out, nil
+}
+
+// GetRatesSnapshotAt returns the snapshot in effect at instant at: the
+// history member with the highest score <= at, so an E-Rate auditor can ask
+// "what did this provider's tariff look like on date X?". Returns nil with
+// no error if no snapshot existed yet at that time.
+func (s *ValkeyStorage) GetRatesSnapshotAt(ctx context.Context, provider string, at time.Time) (*RatesSnapshot, error) {
+	ids, err := s.client.Do(ctx, s.client.B().Zrevrangebyscore().
+		Key(valkeyHistoryKey(provider)).
+		Max(strconv.FormatInt(at.UnixNano(), 10)).
+		Min("-inf").
+		Limit(0, 1).
+		Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(ids[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse snapshot id %q: %w", ids[0], err)
+	}
+	return s.getSnapshotByID(ctx, id)
+}
+
+// GetRatesSnapshotByID fetches a single snapshot row by its assigned ID.
+func (s *ValkeyStorage) GetRatesSnapshotByID(ctx context.Context, id int64) (*RatesSnapshot, error) {
+	return s.getSnapshotByID(ctx, id)
+}
+
+// PruneRatesSnapshots deletes snapshot rows older than olderThan, keeping at
+// least the keepLast most recent rows per provider regardless of age. It
+// returns the number of rows deleted.
+func (s *ValkeyStorage) PruneRatesSnapshots(ctx context.Context, keepLast int, olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	historyKeys, err := s.client.Do(ctx, s.client.B().Keys().Pattern("rates:*:history").Build()).AsStrSlice()
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, hkey := range historyKeys {
+		ids, err := s.client.Do(ctx, s.client.B().Zrevrange().Key(hkey).Start(0).Stop(-1).Build()).AsStrSlice()
+		if err != nil {
+			return deleted, err
+		}
+		if len(ids) <= keepLast {
+			continue
+		}
+		for _, idStr := range ids[keepLast:] {
+			snap, err := func() (*RatesSnapshot, error) {
+				id, err := strconv.ParseInt(idStr, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				return s.getSnapshotByID(ctx, id)
+			}()
+			if err != nil || snap == nil || snap.FetchedAt.After(cutoff) {
+				continue
+			}
+			if err := s.client.Do(ctx, s.client.B().Del().Key(valkeySnapshotKey(snap.ID)).Build()).Error(); err != nil {
+				return deleted, fmt.Errorf("delete snapshot %d: %w", snap.ID, err)
+			}
+			if err := s.client.Do(ctx, s.client.B().Zrem().Key(hkey).Member(idStr).Build()).Error(); err != nil {
+				return deleted, fmt.Errorf("unindex snapshot %d: %w", snap.ID, err)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func batchValkeyKey(batchID, provider string) string {
+	return valkeyBatchPrefix + batchID + ":" + provider
+}
+
+// batchProgressTTL bounds how long an in-progress batch row can outlive the
+// worker that wrote it; if the worker crashes without completing, the key
+// expires and GetPendingBatchProviders sees the provider as available
+// again, mirroring EtcdStorage's lease-backed batchProgressLeaseTTL.
+const batchProgressTTL = 10 * time.Minute
+
+func (s *ValkeyStorage) SaveBatchProgress(ctx context.Context, progress BatchProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	key := batchValkeyKey(progress.BatchID, progress.Provider)
+	if progress.Status == "completed" {
+		return s.client.Do(ctx, s.client.B().Set().Key(key).Value(string(data)).Build()).Error()
+	}
+	return s.client.Do(ctx, s.client.B().Set().Key(key).Value(string(data)).Px(batchProgressTTL).Build()).Error()
+}
+
+func (s *ValkeyStorage) GetBatchProgress(ctx context.Context, batchID, provider string) (*BatchProgress, error) {
+	raw, err := s.client.Do(ctx, s.client.B().Get().Key(batchValkeyKey(batchID, provider)).Build()).AsBytes()
+	if valkey.IsValkeyNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bp BatchProgress
+	if err := json.Unmarshal(raw, &bp); err != nil {
+		return nil, fmt.Errorf("decode batch progress %s/%s: %w", batchID, provider, err)
+	}
+	return &bp, nil
+}
+
+func (s *ValkeyStorage) GetPendingBatchProviders(ctx context.Context, batchID string) ([]string, error) {
+	keys, err := s.client.Do(ctx, s.client.B().Keys().Pattern(valkeyBatchPrefix+batchID+":*").Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+	var providers []string
+	for _, k := range keys {
+		raw, err := s.client.Do(ctx, s.client.B().Get().Key(k).Build()).AsBytes()
+		if err != nil {
+			continue
+		}
+		var bp BatchProgress
+		if err := json.Unmarshal(raw, &bp); err != nil {
+			return nil, fmt.Errorf("decode batch progress %s: %w", k, err)
+		}
+		if bp.Status == "pending" || bp.Status == "failed" {
+			providers = append(providers, bp.Provider)
+		}
+	}
+	return providers, nil
+}
+
+// SaveCircuitState upserts provider's circuit breaker state under
+// circuit:<provider>, the same JSON-blob-per-key approach provider:<key>
+// uses.
+func (s *ValkeyStorage) SaveCircuitState(ctx context.Context, cs CircuitState) error {
+	data, err := json.Marshal(cs)
+	if err != nil {
+		return err
+	}
+	key := valkeyCircuitPrefix + cs.Provider
+	return s.client.Do(ctx, s.client.B().Set().Key(key).Value(string(data)).Build()).Error()
+}
+
+// GetCircuitState returns provider's circuit breaker state, or nil if it has
+// never tripped.
+func (s *ValkeyStorage) GetCircuitState(ctx context.Context, provider string) (*CircuitState, error) {
+	raw, err := s.client.Do(ctx, s.client.B().Get().Key(valkeyCircuitPrefix+provider).Build()).AsBytes()
+	if valkey.IsValkeyNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cs CircuitState
+	if err := json.Unmarshal(raw, &cs); err != nil {
+		return nil, fmt.Errorf("decode circuit state %s: %w", provider, err)
+	}
+	return &cs, nil
+}
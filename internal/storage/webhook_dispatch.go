@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/bher20/eratemanager/internal/webhooks"
+)
+
+// residentialFields mirrors the subset of rates.RatesResponse that webhook
+// subscribers care about. It's duplicated here (rather than imported from
+// package rates) because rates already imports storage.
+type residentialFields struct {
+	Rates struct {
+		ResidentialStandard struct {
+			IsPresent                bool    `json:"is_present"`
+			CustomerChargeMonthlyUSD float64 `json:"customer_charge_monthly_usd"`
+			EnergyRateUSDPerKWh      float64 `json:"energy_rate_usd_per_kwh"`
+			TVAFuelRateUSDPerKWh     float64 `json:"tva_fuel_rate_usd_per_kwh"`
+		} `json:"residential_standard"`
+	} `json:"rates"`
+}
+
+// classifyRatesChange compares the previous and new snapshot payloads and
+// returns the webhook event type to dispatch, or "" if nothing relevant
+// changed. A malformed or missing previous payload is treated as "no prior
+// data" rather than an error, so the first snapshot for a provider never
+// triggers a spurious event.
+func classifyRatesChange(prev, next []byte) string {
+	if len(prev) == 0 {
+		return ""
+	}
+	var oldR, newR residentialFields
+	if err := json.Unmarshal(prev, &oldR); err != nil {
+		return ""
+	}
+	if err := json.Unmarshal(next, &newR); err != nil {
+		return ""
+	}
+
+	if oldR.Rates.ResidentialStandard.IsPresent != newR.Rates.ResidentialStandard.IsPresent {
+		return webhooks.EventParseRegression
+	}
+	o, n := oldR.Rates.ResidentialStandard, newR.Rates.ResidentialStandard
+	if o.CustomerChargeMonthlyUSD != n.CustomerChargeMonthlyUSD ||
+		o.EnergyRateUSDPerKWh != n.EnergyRateUSDPerKWh ||
+		o.TVAFuelRateUSDPerKWh != n.TVAFuelRateUSDPerKWh {
+		return webhooks.EventResidentialChanged
+	}
+	return ""
+}
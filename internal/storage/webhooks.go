@@ -0,0 +1,28 @@
+package storage
+
+import "time"
+
+// WebhookSubscription represents an endpoint registered to receive rate
+// change events.
+type WebhookSubscription struct {
+	ID         string            `json:"id"`
+	URL        string            `json:"url"`
+	EventTypes []string          `json:"event_types"`
+	Secret     string            `json:"secret"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	AuthToken  string            `json:"auth_token,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// WebhookDelivery records the outcome of one attempt to deliver an event to
+// a subscription, for observability and retry bookkeeping.
+type WebhookDelivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Event          string    `json:"event"`
+	Payload        []byte    `json:"payload"`
+	StatusCode     int       `json:"status_code"`
+	Error          string    `json:"error,omitempty"`
+	Attempt        int       `json:"attempt"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+}
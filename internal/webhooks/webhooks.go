@@ -0,0 +1,231 @@
+// Package webhooks dispatches rate-change events to subscriber endpoints.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// EventResidentialChanged fires when a provider's parsed residential rates
+// differ from the previously stored snapshot.
+const EventResidentialChanged = "residential_rates.changed"
+
+// EventParseRegression fires when a provider's rates flip from present to
+// not-present (or vice versa) between snapshots.
+const EventParseRegression = "residential_rates.presence_changed"
+
+// Subscription is an endpoint registered to receive webhook deliveries.
+type Subscription struct {
+	ID         string
+	URL        string
+	EventTypes []string
+	Secret     string
+	Headers    map[string]string
+	AuthToken  string
+}
+
+// Delivery records the outcome of a single delivery attempt.
+type Delivery struct {
+	SubscriptionID string
+	Event          string
+	Payload        []byte
+	StatusCode     int
+	Error          string
+	Attempt        int
+	DeliveredAt    time.Time
+}
+
+// Envelope is the JSON body POSTed to subscriber endpoints.
+type Envelope struct {
+	Event     string          `json:"event"`
+	Provider  string          `json:"provider"`
+	Old       json.RawMessage `json:"old,omitempty"`
+	New       json.RawMessage `json:"new,omitempty"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// Lister returns the currently registered subscriptions.
+type Lister func(ctx context.Context) ([]Subscription, error)
+
+// DeliveryRecorder persists the outcome of a delivery attempt.
+type DeliveryRecorder func(ctx context.Context, d Delivery) error
+
+// Backoff returns how long to wait before the attempt after the given
+// (1-indexed) completed attempt count, doubling base each time, capped at
+// cap (cap <= 0 means uncapped). Shared by every package that retries
+// webhook-style deliveries (this package's Dispatcher, internal/alerting,
+// rates.WebhookSink) so the doubling behavior isn't reimplemented per
+// caller.
+func Backoff(attempt int, base, cap time.Duration) time.Duration {
+	if attempt <= 0 {
+		return 0
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		if cap > 0 && d >= cap {
+			break
+		}
+		d *= 2
+	}
+	if cap > 0 && d > cap {
+		d = cap
+	}
+	return d
+}
+
+// Dispatcher delivers events to subscribed endpoints, signing each payload
+// and retrying failed deliveries with exponential backoff.
+type Dispatcher struct {
+	client      *http.Client
+	list        Lister
+	record      DeliveryRecorder
+	maxAttempts int
+	baseBackoff time.Duration
+}
+
+// NewDispatcher builds a Dispatcher backed by the given subscription lister
+// and delivery recorder.
+func NewDispatcher(list Lister, record DeliveryRecorder) *Dispatcher {
+	return &Dispatcher{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		list:        list,
+		record:      record,
+		maxAttempts: 4,
+		baseBackoff: 500 * time.Millisecond,
+	}
+}
+
+// Dispatch delivers ev to every subscription registered for its event type.
+// Delivery happens synchronously but best-effort: failures are logged and
+// recorded, never returned to the caller, so a slow or dead subscriber can't
+// block the snapshot write path.
+func (d *Dispatcher) Dispatch(ctx context.Context, ev Envelope) {
+	subs, err := d.list(ctx)
+	if err != nil {
+		log.Printf("webhooks: list subscriptions failed: %v", err)
+		return
+	}
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("webhooks: marshal envelope failed: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		if !subscribed(sub, ev.Event) {
+			continue
+		}
+		go d.deliverWithRetry(ctx, sub, ev.Event, body)
+	}
+}
+
+func subscribed(sub Subscription, event string) bool {
+	for _, t := range sub.EventTypes {
+		if t == event || t == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, sub Subscription, event string, body []byte) {
+	var lastErr string
+	var lastStatus int
+
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		status, err := d.deliverOnce(ctx, sub, body)
+		lastStatus = status
+		if err != nil {
+			lastErr = err.Error()
+		} else {
+			lastErr = ""
+		}
+
+		d.recordDelivery(ctx, sub.ID, event, body, status, lastErr, attempt)
+
+		if err == nil && status >= 200 && status < 300 {
+			return
+		}
+		if attempt == d.maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(Backoff(attempt, d.baseBackoff, 0)):
+		}
+	}
+
+	log.Printf("webhooks: giving up delivering %s to %s after %d attempts (status=%d err=%s)",
+		event, sub.URL, d.maxAttempts, lastStatus, lastErr)
+}
+
+func (d *Dispatcher) deliverOnce(ctx context.Context, sub Subscription, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-ERM-Signature", Sign(sub.Secret, body))
+	if sub.AuthToken != "" {
+		req.Header.Set("Authorization", sub.AuthToken)
+	}
+	for k, v := range sub.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) recordDelivery(ctx context.Context, subID, event string, body []byte, status int, errMsg string, attempt int) {
+	if d.record == nil {
+		return
+	}
+	if err := d.record(ctx, Delivery{
+		SubscriptionID: subID,
+		Event:          event,
+		Payload:        body,
+		StatusCode:     status,
+		Error:          errMsg,
+		Attempt:        attempt,
+		DeliveredAt:    time.Now(),
+	}); err != nil {
+		log.Printf("webhooks: record delivery failed: %v", err)
+	}
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body using secret, in the form
+// expected in the X-ERM-Signature header ("sha256=<hex>"). Exported so other
+// delivery mechanisms that sign payloads the same way (rates.WebhookSink,
+// internal/alerting via SignHex) don't reimplement HMAC signing from
+// scratch.
+func Sign(secret string, body []byte) string {
+	return "sha256=" + SignHex(secret, body)
+}
+
+// SignHex returns the raw hex-encoded HMAC-SHA256 of body using secret,
+// without the "sha256=" prefix Sign adds - the form internal/alerting's
+// X-Webhook-Signature header expects.
+func SignHex(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
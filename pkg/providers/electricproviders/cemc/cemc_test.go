@@ -23,10 +23,10 @@ TVA Fuel Charge: 0.02 per kWh
 	}
 
 	rs := res.ElectricRates.ResidentialStandard
-	if rs.CustomerChargeMonthlyUSD != 39.0 {
+	if rs.CustomerChargeMonthlyUSD == nil || *rs.CustomerChargeMonthlyUSD != 39.0 {
 		t.Errorf("unexpected customer charge: %v", rs.CustomerChargeMonthlyUSD)
 	}
-	if rs.EnergyRateUSDPerKWh != 0.09 {
+	if rs.EnergyRateUSDPerKWh == nil || *rs.EnergyRateUSDPerKWh != 0.09 {
 		t.Errorf("unexpected energy rate: %v", rs.EnergyRateUSDPerKWh)
 	}
 }
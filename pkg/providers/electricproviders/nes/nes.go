@@ -1,18 +1,31 @@
+// Package nes is the Nashville Electric Service electric provider: a thin
+// wrapper around its schema.json extraction schema (see
+// pkg/providers/shared/extractor), kept as a compiled-in provider rather
+// than a dropped-in schema file so it registers automatically.
 package nes
 
 import (
-	"bytes"
-	"fmt"
-	"io"
-	"regexp"
-	"time"
+	_ "embed"
 
 	"github.com/bher20/eratemanager/pkg/providers"
 	"github.com/bher20/eratemanager/pkg/providers/electricproviders"
 	"github.com/bher20/eratemanager/pkg/providers/shared"
-	"github.com/ledongthuc/pdf"
+	"github.com/bher20/eratemanager/pkg/providers/shared/extractor"
 )
 
+//go:embed schema.json
+var schemaJSON []byte
+
+var schema = mustParseSchema(schemaJSON)
+
+func mustParseSchema(data []byte) *extractor.Schema {
+	s, err := extractor.ParseSchema(data)
+	if err != nil {
+		panic("nes: " + err.Error())
+	}
+	return s
+}
+
 func init() {
 	electricproviders.Register(&Provider{})
 }
@@ -40,89 +53,14 @@ func (p *Provider) DefaultPDFPath() string {
 }
 
 func (p *Provider) ParsePDF(path string) (*electricproviders.ElectricRatesResponse, error) {
-	f, r, err := pdf.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("open pdf: %w", err)
-	}
-	defer f.Close()
-
-	rc, err := r.GetPlainText()
+	text, err := shared.ExtractPDFText(path)
 	if err != nil {
-		return nil, fmt.Errorf("extract pdf text: %w", err)
-	}
-
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, rc); err != nil {
-		return nil, fmt.Errorf("read pdf text: %w", err)
+		return nil, err
 	}
-
-	return p.ParseText(buf.String())
+	return p.ParseText(text)
 }
 
 func (p *Provider) ParseText(text string) (*electricproviders.ElectricRatesResponse, error) {
-	// NES uses "Service Charge" instead of "Customer Charge"
-	custRe := regexp.MustCompile(`(?:Customer|Service)\s+Charge[:\s]*\$?([0-9]+(?:\.[0-9]+)?)\s*(?:per month)?`)
-
-	// NES Energy Charge format
-	energyCentsRe := regexp.MustCompile(`Energy Charge[:\s]*(?:Summer Period\s+)?([0-9]+(?:\.[0-9]+)?)\s*[¢c]`)
-	energyUSDRe := regexp.MustCompile(`Energy Charge[:\s]*\$?([0-9]+(?:\.[0-9]+)?)\s*per kWh`)
-	energyCentsAltRe := regexp.MustCompile(`Energy Charge[:\s]*([0-9]+(?:\.[0-9]+)?)\s*cents?\s*per kWh`)
-
-	// Fuel adjustment (TVA)
-	fuelRe := regexp.MustCompile(`Fuel(?: Cost)? Adjustment[:\s]*([0-9]+(?:\.[0-9]+)?)\s*[¢c]?(?:ents?)?\s*per kWh`)
-
-	// TVA Grid Access Charge
-	gridAccessRe := regexp.MustCompile(`(?:TVA )?Grid Access Charge[:\s]*\$?([0-9]+(?:\.[0-9]+)?)\s*per month`)
-
-	customerCharge := shared.ParseFirstFloat(custRe, text)
-	gridAccessCharge := shared.ParseFirstFloat(gridAccessRe, text)
-
-	totalCustomerCharge := customerCharge
-	if gridAccessCharge > 0 {
-		totalCustomerCharge += gridAccessCharge
-	}
-
-	energyRate := 0.0
-	if cents := shared.ParseFirstFloat(energyCentsRe, text); cents > 0 {
-		energyRate = cents / 100.0
-	} else if usd := shared.ParseFirstFloat(energyUSDRe, text); usd > 0 {
-		energyRate = usd
-	} else if cents := shared.ParseFirstFloat(energyCentsAltRe, text); cents > 0 {
-		energyRate = cents / 100.0
-	}
-
-	fuelRate := 0.0
-	if v := shared.ParseFirstFloat(fuelRe, text); v > 0 {
-		if v < 1 {
-			fuelRate = v
-		} else {
-			fuelRate = v / 100.0
-		}
-	}
-
-	energyCents := energyRate * 100
-	fuelCents := fuelRate * 100
-
-	now := time.Now().UTC()
-	rawCopy := text
-
-	resp := &electricproviders.ElectricRatesResponse{
-		Utility:   "Nashville Electric Service",
-		Source:    "NES Rates PDF",
-		SourceURL: "https://www.nespower.com/rates/",
-		FetchedAt: now,
-		ElectricRates: electricproviders.ElectricRates{
-			ResidentialStandard: electricproviders.ResidentialStandard{
-				IsPresent:                true,
-				CustomerChargeMonthlyUSD: totalCustomerCharge,
-				EnergyRateUSDPerKWh:      energyRate,
-				EnergyRateCentsPerKWh:    energyCents,
-				TVAFuelRateUSDPerKWh:     fuelRate,
-				TVAFuelRateCentsPerKWh:   fuelCents,
-				RawSection:               &rawCopy,
-			},
-		},
-	}
-
+	resp, _ := extractor.BuildResponse(schema, text)
 	return resp, nil
 }
@@ -0,0 +1,37 @@
+package electricproviders
+
+import "time"
+
+// ElectricRatesResponse is the rate schedule an ElectricProvider extracts
+// from a utility's published tariff PDF.
+type ElectricRatesResponse struct {
+	Utility       string        `json:"utility"`
+	Source        string        `json:"source"`
+	SourceURL     string        `json:"source_url"`
+	FetchedAt     time.Time     `json:"fetched_at"`
+	ElectricRates ElectricRates `json:"rates"`
+}
+
+// ElectricRates holds the rate schedules an ElectricProvider can populate.
+// Only ResidentialStandard is populated today; supplemental, seasonal, and
+// time-of-use schedules are left for a provider that needs them.
+type ElectricRates struct {
+	ResidentialStandard ResidentialStandard `json:"residential_standard"`
+}
+
+// ResidentialStandard is a utility's flat-rate residential tariff: a fixed
+// monthly customer charge plus a per-kWh energy rate and TVA fuel
+// adjustment. The rate fields are pointers because a schema field can fail
+// to match a reflowed PDF; leaving it nil (serializing as JSON null) keeps
+// that distinct from a utility that genuinely charges $0.
+type ResidentialStandard struct {
+	IsPresent                bool     `json:"is_present"`
+	CustomerChargeMonthlyUSD *float64 `json:"customer_charge_monthly_usd"`
+	EnergyRateUSDPerKWh      *float64 `json:"energy_rate_usd_per_kwh"`
+	EnergyRateCentsPerKWh    *float64 `json:"energy_rate_cents_per_kwh"`
+	TVAFuelRateUSDPerKWh     *float64 `json:"tva_fuel_rate_usd_per_kwh"`
+	TVAFuelRateCentsPerKWh   *float64 `json:"tva_fuel_rate_cents_per_kwh"`
+	// RawSection is the slice of PDF text the rates above were extracted
+	// from, kept around so a caller can eyeball what the parser saw.
+	RawSection *string `json:"raw_section"`
+}
@@ -0,0 +1,303 @@
+// Package extractor runs a declarative Schema against a provider's PDF text
+// to populate an electricproviders.ElectricRatesResponse, replacing the
+// hand-rolled regex cascades each provider package used to carry directly.
+// A Schema can ship embedded with its provider package (kub, cemc, nes) or
+// be dropped into a config directory at runtime via LoadSchemaDir and
+// wrapped in a DynamicProvider, letting a new utility be onboarded without a
+// recompile.
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bher20/eratemanager/pkg/providers"
+	"github.com/bher20/eratemanager/pkg/providers/electricproviders"
+	"github.com/bher20/eratemanager/pkg/providers/shared"
+)
+
+// Unit is the physical unit a Field's matched value is expressed in, used
+// to normalize it to ResidentialStandard's USD-per-kWh/USD-per-month
+// convention.
+type Unit string
+
+const (
+	UnitUSDPerKWh   Unit = "$/kWh"
+	UnitCentsPerKWh Unit = "¢/kWh"
+	UnitUSDPerMonth Unit = "$/month"
+	// UnitAuto treats a matched value below 1 as already USD/kWh and
+	// anything else as cents/kWh, for providers whose PDF doesn't
+	// consistently label which (e.g. NES's fuel adjustment).
+	UnitAuto Unit = "auto"
+)
+
+// Pattern is one candidate regex for a Field, tried in order until one
+// matches (a matched 0 counts as a match). Unit overrides the Field's Unit for this
+// pattern alone, for fields whose candidate patterns capture the same
+// quantity in different units (e.g. an energy rate quoted in dollars in one
+// place and cents in another).
+type Pattern struct {
+	Regex string `json:"regex"`
+	Unit  Unit   `json:"unit,omitempty"`
+}
+
+// Field is one named value a Schema pulls out of a provider's PDF text.
+type Field struct {
+	Name string `json:"name"`
+	// Patterns are tried in order against the schema's (possibly
+	// section-narrowed) text; the first to match wins, even if its value is 0.
+	Patterns []Pattern `json:"patterns"`
+	// Unit is used for any Pattern that doesn't set its own.
+	Unit Unit `json:"unit,omitempty"`
+	// SumWith names other fields whose resolved values are added into this
+	// one once every field has been matched (e.g. folding a grid access
+	// charge into the customer charge).
+	SumWith []string `json:"sum_with,omitempty"`
+	// FallbackTo names other fields to use, in order, if every Pattern
+	// above fails to match.
+	FallbackTo []string `json:"fallback_to,omitempty"`
+}
+
+// Schema is a provider's declarative extraction recipe: a list of named
+// fields, each with candidate patterns, a unit, and post-processing,
+// together with enough metadata to populate an ElectricRatesResponse.
+type Schema struct {
+	ProviderKey string `json:"provider_key"`
+	Utility     string `json:"utility"`
+	Source      string `json:"source"`
+	SourceURL   string `json:"source_url"`
+	// SectionPattern, when set, narrows the text every Field's patterns
+	// run against to the first regexp match, the way a provider might
+	// narrow to its residential rate schedule before a supplemental one.
+	// Text is left unmodified when it doesn't match.
+	SectionPattern string  `json:"section_pattern,omitempty"`
+	Fields         []Field `json:"fields"`
+}
+
+// LoadSchema reads and parses a provider's extraction schema from path.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema %s: %w", path, err)
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema parses a Schema from its JSON encoding, validating that every
+// field can actually resolve to a value.
+func ParseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	for _, f := range schema.Fields {
+		if len(f.Patterns) == 0 && len(f.FallbackTo) == 0 {
+			return nil, fmt.Errorf("field %q has neither patterns nor a fallback", f.Name)
+		}
+	}
+	return &schema, nil
+}
+
+// LoadSchemaDir loads every *.json file in dir as a Schema, keyed by
+// ProviderKey, so a deployment can add a new utility by dropping a file in
+// without recompiling.
+func LoadSchemaDir(dir string) (map[string]*Schema, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read schema dir %s: %w", dir, err)
+	}
+	schemas := make(map[string]*Schema)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		schema, err := LoadSchema(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		schemas[schema.ProviderKey] = schema
+	}
+	return schemas, nil
+}
+
+// Result is the set of resolved field values from running a Schema against
+// PDF text, keyed by Field.Name, plus the section of text they were
+// extracted from. Values holds every field, matched or not (unmatched
+// fields are left at 0); Matched records which ones actually resolved to a
+// value, so callers can tell a legitimate zero from a field nothing
+// matched.
+type Result struct {
+	Values  map[string]float64
+	Matched map[string]bool
+	Section string
+}
+
+// Run extracts every field in schema from text: narrowing to
+// schema.SectionPattern if set, matching each field's patterns, then
+// resolving FallbackTo and SumWith in that order.
+func Run(schema *Schema, text string) Result {
+	section := text
+	if schema.SectionPattern != "" {
+		if re, err := regexp.Compile(schema.SectionPattern); err == nil {
+			if m := re.FindString(text); m != "" {
+				section = m
+			}
+		}
+	}
+
+	result := Result{
+		Values:  make(map[string]float64, len(schema.Fields)),
+		Matched: make(map[string]bool, len(schema.Fields)),
+		Section: section,
+	}
+	for _, f := range schema.Fields {
+		v, ok := matchField(f, section)
+		result.Values[f.Name] = v
+		result.Matched[f.Name] = ok
+	}
+	for _, f := range schema.Fields {
+		if result.Matched[f.Name] {
+			continue
+		}
+		for _, fb := range f.FallbackTo {
+			if result.Matched[fb] {
+				result.Values[f.Name] = result.Values[fb]
+				result.Matched[f.Name] = true
+				break
+			}
+		}
+	}
+	for _, f := range schema.Fields {
+		for _, other := range f.SumWith {
+			result.Values[f.Name] += result.Values[other]
+		}
+	}
+	return result
+}
+
+func matchField(f Field, text string) (float64, bool) {
+	for _, pat := range f.Patterns {
+		re, err := regexp.Compile(pat.Regex)
+		if err != nil {
+			continue
+		}
+		if v, ok := shared.ParseFirstFloat(re, text); ok {
+			unit := pat.Unit
+			if unit == "" {
+				unit = f.Unit
+			}
+			return convert(unit, v), true
+		}
+	}
+	return 0, false
+}
+
+func convert(unit Unit, v float64) float64 {
+	switch unit {
+	case UnitCentsPerKWh:
+		return v / 100.0
+	case UnitAuto:
+		if v < 1 {
+			return v
+		}
+		return v / 100.0
+	default:
+		return v
+	}
+}
+
+// BuildResponse runs schema against text and maps its resolved
+// "customer_charge", "energy_rate", and "fuel_rate" fields onto a
+// ResidentialStandard - the three quantities a Schema is expected to supply.
+// Warnings lists the name of each of those three fields that no pattern (or
+// fallback) matched, so a caller can surface a parse regression instead of
+// silently reporting $0.
+func BuildResponse(schema *Schema, text string) (resp *electricproviders.ElectricRatesResponse, warnings []string) {
+	result := Run(schema, text)
+	rawCopy := result.Section
+
+	energyRate := result.Values["energy_rate"]
+	fuelRate := result.Values["fuel_rate"]
+
+	rs := electricproviders.ResidentialStandard{
+		IsPresent:  true,
+		RawSection: &rawCopy,
+	}
+	if result.Matched["customer_charge"] {
+		v := result.Values["customer_charge"]
+		rs.CustomerChargeMonthlyUSD = &v
+	} else {
+		warnings = append(warnings, "customer_charge_monthly_usd")
+	}
+	if result.Matched["energy_rate"] {
+		v, cents := energyRate, energyRate*100
+		rs.EnergyRateUSDPerKWh = &v
+		rs.EnergyRateCentsPerKWh = &cents
+	} else {
+		warnings = append(warnings, "energy_rate_usd_per_kwh")
+	}
+	if result.Matched["fuel_rate"] {
+		v, cents := fuelRate, fuelRate*100
+		rs.TVAFuelRateUSDPerKWh = &v
+		rs.TVAFuelRateCentsPerKWh = &cents
+	} else {
+		warnings = append(warnings, "tva_fuel_rate_usd_per_kwh")
+	}
+
+	return &electricproviders.ElectricRatesResponse{
+		Utility:       schema.Utility,
+		Source:        schema.Source,
+		SourceURL:     schema.SourceURL,
+		FetchedAt:     time.Now().UTC(),
+		ElectricRates: electricproviders.ElectricRates{ResidentialStandard: rs},
+	}, warnings
+}
+
+// DynamicProvider is an electricproviders.ElectricProvider driven entirely
+// by a Schema, with no provider-specific Go code - how a deployment onboards
+// a utility loaded via LoadSchemaDir without a recompile.
+type DynamicProvider struct {
+	schema *Schema
+}
+
+// NewDynamicProvider wraps schema as an ElectricProvider.
+func NewDynamicProvider(schema *Schema) *DynamicProvider {
+	return &DynamicProvider{schema: schema}
+}
+
+func (p *DynamicProvider) Key() string  { return p.schema.ProviderKey }
+func (p *DynamicProvider) Name() string { return p.schema.Utility }
+
+func (p *DynamicProvider) Type() providers.ProviderType {
+	return providers.ProviderTypeElectric
+}
+
+func (p *DynamicProvider) LandingURL() string {
+	return p.schema.SourceURL
+}
+
+func (p *DynamicProvider) DefaultPDFPath() string {
+	return "rates_" + p.schema.ProviderKey + ".pdf"
+}
+
+func (p *DynamicProvider) ParsePDF(path string) (*electricproviders.ElectricRatesResponse, error) {
+	text, err := shared.ExtractPDFText(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseText(text)
+}
+
+func (p *DynamicProvider) ParseText(text string) (*electricproviders.ElectricRatesResponse, error) {
+	resp, _ := BuildResponse(p.schema, text)
+	return resp, nil
+}
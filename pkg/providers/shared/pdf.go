@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ExtractPDFText opens the PDF at path and returns its concatenated plain
+// text - the common first step every PDF-based provider parser performs
+// before running its own extraction logic on the result.
+func ExtractPDFText(path string) (string, error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open pdf: %w", err)
+	}
+	defer f.Close()
+
+	rc, err := r.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("extract pdf text: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, rc); err != nil {
+		return "", fmt.Errorf("read pdf text: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -5,14 +5,17 @@ import (
 	"regexp"
 )
 
-// ParseFirstFloat finds the first float match in the string using the provided regex.
-// The regex must have at least one capture group.
-func ParseFirstFloat(re *regexp.Regexp, s string) float64 {
+// ParseFirstFloat finds the first float match in the string using the
+// provided regex. The regex must have at least one capture group. The
+// second return value reports whether the regex matched at all, so a
+// caller can distinguish "matched zero" from "didn't match" instead of
+// treating both as a bare 0.
+func ParseFirstFloat(re *regexp.Regexp, s string) (float64, bool) {
 	m := re.FindStringSubmatch(s)
 	if len(m) < 2 {
-		return 0
+		return 0, false
 	}
 	var v float64
 	fmt.Sscanf(m[1], "%f", &v)
-	return v
+	return v, true
 }